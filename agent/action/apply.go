@@ -18,22 +18,25 @@ const (
 )
 
 type ApplyAction struct {
-	applier         boshappl.Applier
-	specService     boshas.V1Service
-	settingsService boshsettings.Service
-	instanceDir     string
-	fs              boshsys.FileSystem
+	applier             boshappl.Applier
+	specService         boshas.V1Service
+	previousSpecService boshas.V1Service
+	settingsService     boshsettings.Service
+	instanceDir         string
+	fs                  boshsys.FileSystem
 }
 
 func NewApply(
 	applier boshappl.Applier,
 	specService boshas.V1Service,
+	previousSpecService boshas.V1Service,
 	settingsService boshsettings.Service,
 	instanceDir string,
 	fs boshsys.FileSystem,
 ) (action ApplyAction) {
 	action.applier = applier
 	action.specService = specService
+	action.previousSpecService = previousSpecService
 	action.settingsService = settingsService
 	action.instanceDir = instanceDir
 	action.fs = fs
@@ -70,6 +73,14 @@ func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
 		if err != nil {
 			return "", bosherr.WrapError(err, "Applying")
 		}
+
+		// Remembered so that rollback action can revert to last good spec
+		if currentSpec.ConfigurationHash != "" && currentSpec.ConfigurationHash != resolvedDesiredSpec.ConfigurationHash {
+			err = a.previousSpecService.Set(currentSpec)
+			if err != nil {
+				return "", bosherr.WrapError(err, "Persisting previous apply spec")
+			}
+		}
 	}
 
 	err = a.specService.Set(resolvedDesiredSpec)
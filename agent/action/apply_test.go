@@ -21,21 +21,23 @@ import (
 func init() {
 	Describe("ApplyAction", func() {
 		var (
-			applier         *fakeappl.FakeApplier
-			specService     *fakeas.FakeV1Service
-			settingsService *fakesettings.FakeSettingsService
-			dirProvider     boshdir.Provider
-			action          ApplyAction
-			fs              boshsys.FileSystem
+			applier             *fakeappl.FakeApplier
+			specService         *fakeas.FakeV1Service
+			previousSpecService *fakeas.FakeV1Service
+			settingsService     *fakesettings.FakeSettingsService
+			dirProvider         boshdir.Provider
+			action              ApplyAction
+			fs                  boshsys.FileSystem
 		)
 
 		BeforeEach(func() {
 			applier = fakeappl.NewFakeApplier()
 			specService = fakeas.NewFakeV1Service()
+			previousSpecService = fakeas.NewFakeV1Service()
 			settingsService = &fakesettings.FakeSettingsService{}
 			dirProvider = boshdir.NewProvider("/var/vcap")
 			fs = fakesys.NewFakeFileSystem()
-			action = NewApply(applier, specService, settingsService, dirProvider.InstanceDir(), fs)
+			action = NewApply(applier, specService, previousSpecService, settingsService, dirProvider.InstanceDir(), fs)
 		})
 
 		AssertActionIsAsynchronous(action)
@@ -94,6 +96,28 @@ func init() {
 									Expect(specService.Spec).To(Equal(populatedDesiredApplySpec))
 								})
 
+								It("remembers current spec as previous spec", func() {
+									_, err := action.Run(desiredApplySpec)
+									Expect(err).ToNot(HaveOccurred())
+									Expect(previousSpecService.Spec).To(Equal(currentApplySpec))
+								})
+
+								It("does not remember current spec when it is reapplied", func() {
+									specService.PopulateDHCPNetworksResultSpec = currentApplySpec
+
+									_, err := action.Run(desiredApplySpec)
+									Expect(err).ToNot(HaveOccurred())
+									Expect(previousSpecService.ActionsCalled).To(BeEmpty())
+								})
+
+								It("returns error when previous spec cannot be saved", func() {
+									previousSpecService.SetErr = errors.New("fake-set-previous-error")
+
+									_, err := action.Run(desiredApplySpec)
+									Expect(err).To(HaveOccurred())
+									Expect(err.Error()).To(ContainSubstring("fake-set-previous-error"))
+								})
+
 								Context("desired spec has id, instance name, deployment name, and az", func() {
 
 									BeforeEach(func() {
@@ -154,6 +178,12 @@ func init() {
 								Expect(err).To(HaveOccurred())
 								Expect(specService.Spec).To(Equal(currentApplySpec))
 							})
+
+							It("does not change previous spec", func() {
+								_, err := action.Run(desiredApplySpec)
+								Expect(err).To(HaveOccurred())
+								Expect(previousSpecService.ActionsCalled).To(BeEmpty())
+							})
 						})
 					})
 
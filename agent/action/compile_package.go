@@ -51,15 +51,30 @@ func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.Multiple
 		})
 	}
 
-	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
+	compiledPkg, err := a.compiler.Compile(pkg, modelsDeps)
 	if err != nil {
 		err = bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
 		return
 	}
 
-	result := map[string]string{
-		"blobstore_id": uploadedBlobID,
-		"sha1":         uploadedDigest.String(),
+	packaging := map[string]interface{}{
+		"duration_seconds": compiledPkg.Packaging.Duration.Seconds(),
+	}
+
+	// Resource usage is only known when packaging script ran in its own cgroup
+	if compiledPkg.Packaging.PeakMemoryBytes > 0 {
+		packaging["peak_memory_bytes"] = compiledPkg.Packaging.PeakMemoryBytes
+		packaging["cpu_seconds"] = compiledPkg.Packaging.CPUTime.Seconds()
+	}
+
+	result := map[string]interface{}{
+		"blobstore_id": compiledPkg.BlobstoreID,
+		"sha1":         compiledPkg.Digest.String(),
+		"packaging":    packaging,
+	}
+
+	if compiledPkg.LogsBlobstoreID != "" {
+		result["logs_blobstore_id"] = compiledPkg.LogsBlobstoreID
 	}
 
 	val = map[string]interface{}{
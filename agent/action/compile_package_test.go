@@ -3,6 +3,7 @@ package action_test
 import (
 	"encoding/json"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -75,8 +76,11 @@ var _ = Describe("CompilePackageAction", func() {
 		})
 
 		It("compile package compiles the package and returns blob id", func() {
-			compiler.CompileBlobID = "my-blob-id"
-			compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+			compiler.CompileCompiledPkg = boshcomp.CompiledPackage{
+				BlobstoreID: "my-blob-id",
+				Digest:      boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "somechecksum")),
+				Packaging:   boshcomp.PackagingStats{Duration: 90 * time.Second},
+			}
 
 			expectedPkg := boshcomp.Package{
 				BlobstoreID: "fake-blobstore-id",
@@ -86,9 +90,12 @@ var _ = Describe("CompilePackageAction", func() {
 			}
 
 			expectedValue := map[string]interface{}{
-				"result": map[string]string{
+				"result": map[string]interface{}{
 					"blobstore_id": "my-blob-id",
-					"sha1":         "some checksum",
+					"sha1":         "somechecksum",
+					"packaging": map[string]interface{}{
+						"duration_seconds": float64(90),
+					},
 				},
 			}
 
@@ -121,6 +128,34 @@ var _ = Describe("CompilePackageAction", func() {
 			Expect(compiler.CompileDeps).To(ConsistOf(expectedDeps))
 		})
 
+		It("returns packaging logs blob id and resource usage when they are known", func() {
+			compiler.CompileCompiledPkg = boshcomp.CompiledPackage{
+				BlobstoreID:     "my-blob-id",
+				Digest:          boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "somechecksum")),
+				LogsBlobstoreID: "my-logs-blob-id",
+				Packaging: boshcomp.PackagingStats{
+					Duration:        90 * time.Second,
+					PeakMemoryBytes: 4096,
+					CPUTime:         80 * time.Second,
+				},
+			}
+
+			value, err := action.Run(getCompileActionArguments())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"result": map[string]interface{}{
+					"blobstore_id":      "my-blob-id",
+					"sha1":              "somechecksum",
+					"logs_blobstore_id": "my-logs-blob-id",
+					"packaging": map[string]interface{}{
+						"duration_seconds":  float64(90),
+						"peak_memory_bytes": uint64(4096),
+						"cpu_seconds":       float64(80),
+					},
+				},
+			}))
+		})
+
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
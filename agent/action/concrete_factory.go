@@ -1,19 +1,24 @@
 package action
 
 import (
+	"path/filepath"
+
 	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
-	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
 )
 
 type concreteFactory struct {
@@ -32,6 +37,8 @@ func NewFactory(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
+	scriptRunner boshsys.CmdRunner,
+	bundleVerifier boshbc.Verifier,
 	logger boshlog.Logger,
 ) (factory Factory) {
 	compressor := platform.GetCompressor()
@@ -39,7 +46,10 @@ func NewFactory(
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
-	ntpService := boshntp.NewConcreteService(platform.GetFs(), dirProvider)
+	ntpService := platform.GetNTPService()
+	jobCertManager := boshcert.NewJobCertManager(platform.GetFs(), dirProvider, logger)
+	previousSpecService := boshas.NewConcreteV1Service(platform.GetFs(), filepath.Join(dirProvider.BoshDir(), "previous_spec.json"))
+	snapshotGuard := NewSnapshotGuard(platform, dirProvider.StoreDir(), clock.NewClock(), logger)
 
 	factory = concreteFactory{
 		availableActions: map[string]Action{
@@ -55,13 +65,18 @@ func NewFactory(
 
 			// Job management
 			"prepare":    NewPrepare(applier),
-			"apply":      NewApply(applier, specService, settingsService, dirProvider.InstanceDir(), platform.GetFs()),
+			"apply":      NewApply(applier, specService, previousSpecService, settingsService, dirProvider.InstanceDir(), platform.GetFs()),
+			"rollback":   NewRollback(applier, specService, previousSpecService),
 			"start":      NewStart(jobSupervisor, applier, specService),
-			"stop":       NewStop(jobSupervisor),
+			"stop":       NewStop(jobSupervisor, specService),
 			"drain":      NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService),
-			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
-			"run_script": NewRunScript(jobScriptProvider, specService, logger),
+			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService, platform, certManager),
+			"run_errand": NewRunErrand(specService, dirProvider, scriptRunner, platform.GetFs(), compressor, copier, blobstore, logger),
+			"run_script": NewRunScript(jobScriptProvider, specService, clock.NewClock(), logger),
+
+			"job_certificates": NewJobCertificates(jobCertManager, jobScriptProvider, logger),
+
+			"verify_bundles": NewVerifyBundles(bundleVerifier),
 
 			// Compilation
 			"compile_package":    NewCompilePackage(compiler),
@@ -76,6 +91,10 @@ func NewFactory(
 			"mount_disk":   NewMountDisk(settingsService, platform, dirProvider, logger),
 			"unmount_disk": NewUnmountDisk(settingsService, platform),
 
+			// Disk snapshots
+			"prepare_snapshot": NewPrepareSnapshot(jobScriptProvider, specService, snapshotGuard, logger),
+			"finish_snapshot":  NewFinishSnapshot(jobScriptProvider, specService, snapshotGuard, logger),
+
 			// ARP cache management
 			"delete_arp_entries": NewDeleteARPEntries(platform),
 
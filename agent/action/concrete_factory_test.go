@@ -1,17 +1,21 @@
 package action_test
 
 import (
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
-	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
 	fakecomp "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
 	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
@@ -21,6 +25,8 @@ import (
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock"
 )
 
 //go:generate counterfeiter -o fakes/fake_clock.go ../../vendor/github.com/pivotal-golang/clock Clock
@@ -38,6 +44,8 @@ var _ = Describe("concreteFactory", func() {
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
 		specService       *fakeas.FakeV1Service
 		jobScriptProvider boshscript.JobScriptProvider
+		scriptRunner      *fakesys.FakeCmdRunner
+		bundleVerifier    *fakebc.FakeVerifier
 		factory           Factory
 		logger            boshlog.Logger
 	)
@@ -54,6 +62,8 @@ var _ = Describe("concreteFactory", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		scriptRunner = fakesys.NewFakeCmdRunner()
+		bundleVerifier = &fakebc.FakeVerifier{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
 		factory = NewFactory(
@@ -68,6 +78,8 @@ var _ = Describe("concreteFactory", func() {
 			jobSupervisor,
 			specService,
 			jobScriptProvider,
+			scriptRunner,
+			bundleVerifier,
 			logger,
 		)
 	})
@@ -81,7 +93,21 @@ var _ = Describe("concreteFactory", func() {
 	It("apply", func() {
 		action, err := factory.Create("apply")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewApply(applier, specService, settingsService, boshdir.NewProvider("/var/vcap").InstanceDir(), platform.GetFs())))
+		previousSpecService := boshas.NewConcreteV1Service(platform.GetFs(), filepath.Join(boshdir.NewProvider("/var/vcap").BoshDir(), "previous_spec.json"))
+		Expect(action).To(Equal(NewApply(applier, specService, previousSpecService, settingsService, boshdir.NewProvider("/var/vcap").InstanceDir(), platform.GetFs())))
+	})
+
+	It("rollback", func() {
+		action, err := factory.Create("rollback")
+		Expect(err).ToNot(HaveOccurred())
+		previousSpecService := boshas.NewConcreteV1Service(platform.GetFs(), filepath.Join(boshdir.NewProvider("/var/vcap").BoshDir(), "previous_spec.json"))
+		Expect(action).To(Equal(NewRollback(applier, specService, previousSpecService)))
+	})
+
+	It("verify_bundles", func() {
+		action, err := factory.Create("verify_bundles")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewVerifyBundles(bundleVerifier)))
 	})
 
 	It("drain", func() {
@@ -110,10 +136,10 @@ var _ = Describe("concreteFactory", func() {
 	})
 
 	It("get_state", func() {
-		ntpService := boshntp.NewConcreteService(platform.GetFs(), platform.GetDirProvider())
+		ntpService := platform.GetNTPService()
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), ntpService)))
+		Expect(action).To(Equal(NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), ntpService, platform, platform.GetCertManager())))
 	})
 
 	It("list_disk", func() {
@@ -173,7 +199,7 @@ var _ = Describe("concreteFactory", func() {
 	It("stop", func() {
 		action, err := factory.Create("stop")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewStop(jobSupervisor)))
+		Expect(action).To(Equal(NewStop(jobSupervisor, specService)))
 	})
 
 	It("unmount_disk", func() {
@@ -196,10 +222,36 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(BeAssignableToTypeOf(RunErrandAction{}))
 	})
 
+	It("job_certificates", func() {
+		action, err := factory.Create("job_certificates")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewJobCertificates(
+			boshcert.NewJobCertManager(platform.GetFs(), platform.GetDirProvider(), logger),
+			jobScriptProvider,
+			logger,
+		)))
+	})
+
 	It("run_script", func() {
 		action, err := factory.Create("run_script")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewRunScript(jobScriptProvider, specService, logger)))
+
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(RunScriptAction{}))
+	})
+
+	It("prepare_snapshot", func() {
+		action, err := factory.Create("prepare_snapshot")
+		Expect(err).ToNot(HaveOccurred())
+		guard := NewSnapshotGuard(platform, boshdir.NewProvider("/var/vcap").StoreDir(), clock.NewClock(), logger)
+		Expect(action).To(Equal(NewPrepareSnapshot(jobScriptProvider, specService, guard, logger)))
+	})
+
+	It("finish_snapshot", func() {
+		action, err := factory.Create("finish_snapshot")
+		Expect(err).ToNot(HaveOccurred())
+		guard := NewSnapshotGuard(platform, boshdir.NewProvider("/var/vcap").StoreDir(), clock.NewClock(), logger)
+		Expect(action).To(Equal(NewFinishSnapshot(jobScriptProvider, specService, guard, logger)))
 	})
 
 	It("prepare", func() {
@@ -0,0 +1,79 @@
+package action
+
+import (
+	"errors"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type FinishSnapshotAction struct {
+	jobScriptProvider boshscript.JobScriptProvider
+	specService       boshas.V1Service
+	snapshotGuard     SnapshotGuard
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewFinishSnapshot(
+	jobScriptProvider boshscript.JobScriptProvider,
+	specService boshas.V1Service,
+	snapshotGuard SnapshotGuard,
+	logger boshlog.Logger,
+) FinishSnapshotAction {
+	return FinishSnapshotAction{
+		jobScriptProvider: jobScriptProvider,
+		specService:       specService,
+		snapshotGuard:     snapshotGuard,
+
+		logTag: "FinishSnapshot Action",
+		logger: logger,
+	}
+}
+
+func (a FinishSnapshotAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a FinishSnapshotAction) IsPersistent() bool {
+	return false
+}
+
+func (a FinishSnapshotAction) IsLoggable() bool {
+	return true
+}
+
+func (a FinishSnapshotAction) Run() (map[string]string, error) {
+	// Thaw before anything else so that jobs are not blocked on writes
+	thawErr := a.snapshotGuard.Thaw()
+	if thawErr != nil {
+		a.logger.Error(a.logTag, "Failed to thaw persistent disk: %s", thawErr.Error())
+	}
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	err = runJobsScripts(a.jobScriptProvider, currentSpec, "post-snapshot")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Running post-snapshot scripts")
+	}
+
+	if thawErr != nil {
+		return nil, thawErr
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a FinishSnapshotAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a FinishSnapshotAction) Cancel() error {
+	return errors.New("not supported")
+}
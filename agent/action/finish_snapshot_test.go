@@ -0,0 +1,104 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("FinishSnapshot", func() {
+	var (
+		jobScriptProvider *fakescript.FakeJobScriptProvider
+		specService       *fakeapplyspec.FakeV1Service
+		platform          *fakeplatform.FakePlatform
+		guard             SnapshotGuard
+		parallelScript    *fakescript.FakeCancellableScript
+		action            FinishSnapshotAction
+	)
+
+	BeforeEach(func() {
+		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		specService = fakeapplyspec.NewFakeV1Service()
+		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		platform = fakeplatform.NewFakePlatform()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		guard = NewSnapshotGuard(platform, "/var/vcap/store", fakeclock.NewFakeClock(time.Now()), logger)
+		action = NewFinishSnapshot(jobScriptProvider, specService, guard, logger)
+
+		specService.Spec.JobSpec.JobTemplateSpecs = []applyspec.JobTemplateSpec{{Name: "fake-job-1"}}
+
+		jobScriptProvider.NewScriptReturns(&fakescript.FakeScript{})
+
+		parallelScript = &fakescript.FakeCancellableScript{}
+		jobScriptProvider.NewParallelScriptReturns(parallelScript)
+
+		err := guard.Freeze(time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("thaws persistent disk and then runs post-snapshot scripts", func() {
+			parallelScript.RunStub = func() error {
+				Expect(guard.IsFrozen()).To(BeFalse())
+				return nil
+			}
+
+			results, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(Equal(map[string]string{}))
+
+			Expect(platform.ThawPersistentDiskMountPoints).To(Equal([]string{"/var/vcap/store"}))
+
+			jobName, scriptName := jobScriptProvider.NewScriptArgsForCall(0)
+			Expect(jobName).To(Equal("fake-job-1"))
+			Expect(scriptName).To(Equal("post-snapshot"))
+
+			parallelScriptName, _ := jobScriptProvider.NewParallelScriptArgsForCall(0)
+			Expect(parallelScriptName).To(Equal("post-snapshot"))
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+		})
+
+		It("runs post-snapshot scripts even if thawing fails", func() {
+			platform.ThawPersistentDiskErr = errors.New("fake-thaw-err")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+		})
+
+		It("returns error if post-snapshot scripts fail", func() {
+			parallelScript.RunReturns(errors.New("fake-script-err"))
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-script-err"))
+		})
+
+		It("returns error if current spec cannot be retrieved", func() {
+			specService.GetErr = errors.New("fake-spec-err")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-spec-err"))
+			Expect(guard.IsFrozen()).To(BeFalse())
+		})
+	})
+})
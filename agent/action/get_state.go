@@ -5,6 +5,8 @@ import (
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -17,6 +19,12 @@ type GetStateAction struct {
 	jobSupervisor   boshjobsuper.JobSupervisor
 	vitalsService   boshvitals.Service
 	ntpService      boshntp.Service
+	diskHealth      diskHealthProvider
+	certManager     boshcert.Manager
+}
+
+type diskHealthProvider interface {
+	GetDiskHealthState() (*boshdisk.HealthState, error)
 }
 
 func NewGetState(
@@ -25,12 +33,16 @@ func NewGetState(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
 	ntpService boshntp.Service,
+	diskHealth diskHealthProvider,
+	certManager boshcert.Manager,
 ) (action GetStateAction) {
 	action.settingsService = settingsService
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
 	action.ntpService = ntpService
+	action.diskHealth = diskHealth
+	action.certManager = certManager
 	return
 }
 
@@ -56,6 +68,10 @@ type GetStateV1ApplySpec struct {
 	Processes    []boshjobsuper.Process `json:"processes,omitempty"`
 	VM           boshsettings.VM        `json:"vm"`
 	Ntp          boshntp.Info           `json:"ntp"`
+
+	DiskHealth map[string]boshdisk.FilesystemCheckResult `json:"disk_health,omitempty"`
+
+	Certificates []boshcert.CertificateInfo `json:"certificates,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -80,6 +96,16 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting processes status")
 	}
 
+	diskHealthState, err := a.diskHealth.GetDiskHealthState()
+	if err != nil {
+		return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting disk health")
+	}
+
+	certificates, err := a.certManager.Certificates()
+	if err != nil {
+		return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting certificates")
+	}
+
 	settings := a.settingsService.GetSettings()
 
 	value := GetStateV1ApplySpec{
@@ -91,6 +117,8 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		processes,
 		settings.VM,
 		a.ntpService.GetInfo(),
+		diskHealthState.Disks,
+		certificates,
 	}
 
 	if value.NetworkSpecs == nil {
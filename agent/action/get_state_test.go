@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -11,6 +12,10 @@ import (
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	fakentp "github.com/cloudfoundry/bosh-agent/platform/ntp/fakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -26,6 +31,8 @@ var _ = Describe("GetState", func() {
 		specService     *fakeas.FakeV1Service
 		jobSupervisor   *fakejobsuper.FakeJobSupervisor
 		vitalsService   *fakevitals.FakeService
+		platform        *fakeplatform.FakePlatform
+		certManager     *fakecert.FakeManager
 		action          GetStateAction
 	)
 
@@ -40,7 +47,9 @@ var _ = Describe("GetState", func() {
 				Timestamp: "12 Oct 17:37:58",
 			},
 		}
-		action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService)
+		platform = fakeplatform.NewFakePlatform()
+		certManager = new(fakecert.FakeManager)
+		action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService, platform, certManager)
 	})
 
 	AssertActionIsNotAsynchronous(action)
@@ -77,6 +86,7 @@ var _ = Describe("GetState", func() {
 							Offset:    "0.34958",
 							Timestamp: "12 Oct 17:37:58",
 						},
+						DiskHealth: map[string]boshdisk.FilesystemCheckResult{},
 					}
 					expectedSpec.Deployment = "fake-deployment"
 
@@ -140,6 +150,56 @@ var _ = Describe("GetState", func() {
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
 				})
 
+				It("returns last filesystem check result for each persistent disk", func() {
+					err := platform.Fs.WriteFileString(
+						"/var/vcap/bosh/disk_health.json",
+						`{"disks":{"fake-disk-cid":{"partition_path":"/dev/sdb1","file_system_type":"ext4","status":"repaired","exit_status":1}}}`,
+					)
+					Expect(err).ToNot(HaveOccurred())
+
+					state, err := action.Run()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.DiskHealth).To(Equal(map[string]boshdisk.FilesystemCheckResult{
+						"fake-disk-cid": {
+							PartitionPath:  "/dev/sdb1",
+							FileSystemType: boshdisk.FileSystemExt4,
+							Status:         boshdisk.FilesystemCheckRepaired,
+							ExitStatus:     1,
+						},
+					}))
+				})
+
+				It("returns error if disk health cannot be retrieved", func() {
+					platform.GetDiskHealthStateErr = errors.New("fake-disk-health-error")
+
+					_, err := action.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-disk-health-error"))
+				})
+
+				It("returns subject and expiry of installed certificates", func() {
+					notAfter := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+					certManager.CertificatesReturns([]boshcert.CertificateInfo{
+						{Subject: "CN=fake-ca", NotAfter: notAfter},
+						{Bundle: "uaa", Subject: "CN=fake-uaa-ca", NotAfter: notAfter},
+					}, nil)
+
+					state, err := action.Run()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.Certificates).To(Equal([]boshcert.CertificateInfo{
+						{Subject: "CN=fake-ca", NotAfter: notAfter},
+						{Bundle: "uaa", Subject: "CN=fake-uaa-ca", NotAfter: notAfter},
+					}))
+				})
+
+				It("returns error if certificates cannot be retrieved", func() {
+					certManager.CertificatesReturns(nil, errors.New("fake-certificates-error"))
+
+					_, err := action.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-certificates-error"))
+				})
+
 				Describe("non-populated field formatting", func() {
 					It("returns network as empty hash if not set", func() {
 						specService.Spec = boshas.V1ApplySpec{NetworkSpecs: nil}
@@ -0,0 +1,170 @@
+package action
+
+import (
+	"errors"
+
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	jobCertificatesRequestCmd = "request"
+	jobCertificatesInstallCmd = "install"
+)
+
+// JobCertificatesAction rotates job certificates without redeploying.
+// "request" generates private keys on the VM and returns CSRs to be signed by director;
+// "install" installs signed certificates and runs reload scripts of affected jobs.
+type JobCertificatesAction struct {
+	jobCertManager boshcert.JobCertManager
+	scriptProvider boshscript.JobScriptProvider
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewJobCertificates(
+	jobCertManager boshcert.JobCertManager,
+	scriptProvider boshscript.JobScriptProvider,
+	logger boshlog.Logger,
+) JobCertificatesAction {
+	return JobCertificatesAction{
+		jobCertManager: jobCertManager,
+		scriptProvider: scriptProvider,
+
+		logTag: "JobCertificates Action",
+		logger: logger,
+	}
+}
+
+func (a JobCertificatesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a JobCertificatesAction) IsPersistent() bool {
+	return false
+}
+
+func (a JobCertificatesAction) IsLoggable() bool {
+	return true
+}
+
+type JobCertificatesParams struct {
+	Policies     []boshcert.CertificatePolicy `json:"policies,omitempty"`
+	Certificates []boshcert.SignedCertificate `json:"certificates,omitempty"`
+}
+
+type JobCertificateRequest struct {
+	Job  string `json:"job"`
+	Name string `json:"name"`
+	CSR  string `json:"csr"`
+}
+
+type JobCertificatesResult struct {
+	Command string `json:"command"`
+	Status  string `json:"status"`
+
+	Requests []JobCertificateRequest    `json:"requests,omitempty"`
+	Reloads  map[string]JobScriptResult `json:"reloads,omitempty"`
+}
+
+func (a JobCertificatesAction) Run(cmd string, params JobCertificatesParams) (JobCertificatesResult, error) {
+	switch cmd {
+	case jobCertificatesRequestCmd:
+		return a.request(params.Policies)
+	case jobCertificatesInstallCmd:
+		return a.install(params.Certificates)
+	}
+
+	return JobCertificatesResult{}, bosherr.Errorf("Unknown command for job certificates method '%s'", cmd)
+}
+
+func (a JobCertificatesAction) request(policies []boshcert.CertificatePolicy) (JobCertificatesResult, error) {
+	result := JobCertificatesResult{Command: jobCertificatesRequestCmd}
+
+	for _, policy := range policies {
+		csr, err := a.jobCertManager.RequestCertificate(policy)
+		if err != nil {
+			return JobCertificatesResult{}, bosherr.WrapError(err, "Requesting job certificate")
+		}
+
+		result.Requests = append(result.Requests, JobCertificateRequest{
+			Job:  policy.Job,
+			Name: policy.Name,
+			CSR:  csr,
+		})
+	}
+
+	result.Status = "requested"
+
+	return result, nil
+}
+
+func (a JobCertificatesAction) install(certificates []boshcert.SignedCertificate) (JobCertificatesResult, error) {
+	result := JobCertificatesResult{Command: jobCertificatesInstallCmd}
+
+	// Each job is reloaded once even if multiple of its certificates were installed
+	var jobNames []string
+	reloadScripts := map[string]string{}
+
+	for _, certificate := range certificates {
+		policy, err := a.jobCertManager.InstallCertificate(certificate)
+		if err != nil {
+			return JobCertificatesResult{}, bosherr.WrapError(err, "Installing job certificate")
+		}
+
+		if policy.ReloadScript == "" {
+			continue
+		}
+
+		if _, found := reloadScripts[policy.Job]; !found {
+			jobNames = append(jobNames, policy.Job)
+			reloadScripts[policy.Job] = policy.ReloadScript
+		}
+	}
+
+	result.Status = "installed"
+
+	if len(jobNames) == 0 {
+		return result, nil
+	}
+
+	var scripts []boshscript.Script
+
+	for _, jobName := range jobNames {
+		scripts = append(scripts, a.scriptProvider.NewScript(jobName, reloadScripts[jobName]))
+	}
+
+	parallelScript := a.scriptProvider.NewParallelScript("reload certificates", scripts)
+
+	// Certificates are already installed so reload failures are only reported
+	if reportingScript, ok := parallelScript.(boshscript.ParallelReportingScript); ok {
+		results, err := reportingScript.RunWithResults()
+		if err != nil {
+			a.logger.Error(a.logTag, "Failed to reload job certificates: %s", err.Error())
+		}
+
+		result.Reloads = map[string]JobScriptResult{}
+
+		for jobName, r := range results {
+			result.Reloads[jobName] = newJobScriptResult(r)
+		}
+	} else {
+		err := parallelScript.Run()
+		if err != nil {
+			a.logger.Error(a.logTag, "Failed to reload job certificates: %s", err.Error())
+		}
+	}
+
+	return result, nil
+}
+
+func (a JobCertificatesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a JobCertificatesAction) Cancel() error {
+	return errors.New("not supported")
+}
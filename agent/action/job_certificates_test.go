@@ -0,0 +1,163 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("JobCertificates", func() {
+	var (
+		jobCertManager        *fakecert.FakeJobCertManager
+		fakeJobScriptProvider *fakescript.FakeJobScriptProvider
+		action                JobCertificatesAction
+	)
+
+	BeforeEach(func() {
+		jobCertManager = &fakecert.FakeJobCertManager{}
+		fakeJobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		action = NewJobCertificates(jobCertManager, fakeJobScriptProvider, logger)
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("returns error for unknown commands", func() {
+		_, err := action.Run("fake-cmd", JobCertificatesParams{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown command for job certificates method 'fake-cmd'"))
+	})
+
+	Describe("request", func() {
+		It("returns certificate signing request for each policy", func() {
+			jobCertManager.RequestCertificateStub = func(policy boshcert.CertificatePolicy) (string, error) {
+				return "fake-csr-" + policy.Name, nil
+			}
+
+			policies := []boshcert.CertificatePolicy{
+				{Job: "fake-job-1", Name: "server", CommonName: "fake-job-1.internal"},
+				{Job: "fake-job-2", Name: "client", CommonName: "fake-job-2.internal"},
+			}
+
+			result, err := action.Run("request", JobCertificatesParams{Policies: policies})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(JobCertificatesResult{
+				Command: "request",
+				Status:  "requested",
+				Requests: []JobCertificateRequest{
+					{Job: "fake-job-1", Name: "server", CSR: "fake-csr-server"},
+					{Job: "fake-job-2", Name: "client", CSR: "fake-csr-client"},
+				},
+			}))
+
+			Expect(jobCertManager.RequestCertificateCallCount()).To(Equal(2))
+			Expect(jobCertManager.RequestCertificateArgsForCall(0)).To(Equal(policies[0]))
+			Expect(jobCertManager.RequestCertificateArgsForCall(1)).To(Equal(policies[1]))
+		})
+
+		It("returns error when certificate cannot be requested", func() {
+			jobCertManager.RequestCertificateReturns("", errors.New("fake-request-err"))
+
+			_, err := action.Run("request", JobCertificatesParams{
+				Policies: []boshcert.CertificatePolicy{{Job: "fake-job", Name: "server"}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-request-err"))
+		})
+	})
+
+	Describe("install", func() {
+		var (
+			certificates   []boshcert.SignedCertificate
+			parallelScript *fakescript.FakeParallelReportingScript
+		)
+
+		BeforeEach(func() {
+			certificates = []boshcert.SignedCertificate{
+				{Job: "fake-job-1", Name: "server", Certificate: "fake-cert-1"},
+				{Job: "fake-job-1", Name: "client", Certificate: "fake-cert-2"},
+				{Job: "fake-job-2", Name: "server", Certificate: "fake-cert-3"},
+			}
+
+			parallelScript = &fakescript.FakeParallelReportingScript{}
+			fakeJobScriptProvider.NewParallelScriptReturns(parallelScript)
+
+			fakeJobScriptProvider.NewScriptStub = func(jobName, scriptName string) boshscript.Script {
+				script := &fakescript.FakeScript{}
+				script.TagReturns(jobName)
+				return script
+			}
+		})
+
+		It("installs certificates and runs reload script of each affected job once", func() {
+			jobCertManager.InstallCertificateStub = func(signed boshcert.SignedCertificate) (boshcert.CertificatePolicy, error) {
+				return boshcert.CertificatePolicy{Job: signed.Job, Name: signed.Name, ReloadScript: "reload-certs"}, nil
+			}
+
+			parallelScript.RunWithResultsReturns(map[string]boshscript.ScriptResult{
+				"fake-job-1": {Tag: "fake-job-1", Status: boshscript.ScriptStatusSucceeded, Duration: 2 * time.Second},
+				"fake-job-2": {Tag: "fake-job-2", Status: boshscript.ScriptStatusFailed, ExitCode: 1, Stderr: "fake-stderr"},
+			}, errors.New("fake-reload-err"))
+
+			result, err := action.Run("install", JobCertificatesParams{Certificates: certificates})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(JobCertificatesResult{
+				Command: "install",
+				Status:  "installed",
+				Reloads: map[string]JobScriptResult{
+					"fake-job-1": {Status: "succeeded", Duration: 2},
+					"fake-job-2": {Status: "failed", ExitCode: 1, Stderr: "fake-stderr"},
+				},
+			}))
+
+			Expect(jobCertManager.InstallCertificateCallCount()).To(Equal(3))
+			Expect(jobCertManager.InstallCertificateArgsForCall(2)).To(Equal(certificates[2]))
+
+			Expect(fakeJobScriptProvider.NewScriptCallCount()).To(Equal(2))
+
+			jobName, scriptName := fakeJobScriptProvider.NewScriptArgsForCall(0)
+			Expect(jobName).To(Equal("fake-job-1"))
+			Expect(scriptName).To(Equal("reload-certs"))
+
+			jobName, scriptName = fakeJobScriptProvider.NewScriptArgsForCall(1)
+			Expect(jobName).To(Equal("fake-job-2"))
+			Expect(scriptName).To(Equal("reload-certs"))
+
+			Expect(parallelScript.RunWithResultsCallCount()).To(Equal(1))
+		})
+
+		It("does not run reload scripts when policies do not specify them", func() {
+			jobCertManager.InstallCertificateReturns(boshcert.CertificatePolicy{Job: "fake-job-1"}, nil)
+
+			result, err := action.Run("install", JobCertificatesParams{Certificates: certificates})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(JobCertificatesResult{Command: "install", Status: "installed"}))
+
+			Expect(fakeJobScriptProvider.NewParallelScriptCallCount()).To(Equal(0))
+		})
+
+		It("returns error when certificate cannot be installed", func() {
+			jobCertManager.InstallCertificateReturns(boshcert.CertificatePolicy{}, errors.New("fake-install-err"))
+
+			_, err := action.Run("install", JobCertificatesParams{Certificates: certificates})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-install-err"))
+
+			Expect(fakeJobScriptProvider.NewParallelScriptCallCount()).To(Equal(0))
+		})
+	})
+})
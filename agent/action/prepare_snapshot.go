@@ -0,0 +1,110 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	// Persistent disk is thawed after this period
+	// if finish_snapshot was not received
+	defaultSnapshotTimeout = 2 * time.Minute
+)
+
+type PrepareSnapshotAction struct {
+	jobScriptProvider boshscript.JobScriptProvider
+	specService       boshas.V1Service
+	snapshotGuard     SnapshotGuard
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewPrepareSnapshot(
+	jobScriptProvider boshscript.JobScriptProvider,
+	specService boshas.V1Service,
+	snapshotGuard SnapshotGuard,
+	logger boshlog.Logger,
+) PrepareSnapshotAction {
+	return PrepareSnapshotAction{
+		jobScriptProvider: jobScriptProvider,
+		specService:       specService,
+		snapshotGuard:     snapshotGuard,
+
+		logTag: "PrepareSnapshot Action",
+		logger: logger,
+	}
+}
+
+func (a PrepareSnapshotAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a PrepareSnapshotAction) IsPersistent() bool {
+	return false
+}
+
+func (a PrepareSnapshotAction) IsLoggable() bool {
+	return true
+}
+
+// Run accepts optional 'timeout' (in seconds) after which
+// persistent disk is thawed even if snapshot was not finished
+func (a PrepareSnapshotAction) Run(options map[string]interface{}) (map[string]string, error) {
+	timeout := defaultSnapshotTimeout
+
+	if timeoutOpt, found := options["timeout"]; found {
+		timeoutSecs, ok := timeoutOpt.(float64)
+		if !ok || timeoutSecs <= 0 {
+			return nil, bosherr.Errorf("Expected timeout to be a positive number of seconds, got '%v'", timeoutOpt)
+		}
+		timeout = time.Duration(timeoutSecs * float64(time.Second))
+	}
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	err = runJobsScripts(a.jobScriptProvider, currentSpec, "pre-snapshot")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Running pre-snapshot scripts")
+	}
+
+	err = a.snapshotGuard.Freeze(timeout)
+	if err != nil {
+		a.logger.Error(a.logTag, "Resuming jobs after failing to freeze persistent disk: %s", err.Error())
+
+		postErr := runJobsScripts(a.jobScriptProvider, currentSpec, "post-snapshot")
+		if postErr != nil {
+			a.logger.Error(a.logTag, "Failed running post-snapshot scripts: %s", postErr.Error())
+		}
+
+		return nil, err
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a PrepareSnapshotAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a PrepareSnapshotAction) Cancel() error {
+	return errors.New("not supported")
+}
+
+func runJobsScripts(jobScriptProvider boshscript.JobScriptProvider, spec boshas.V1ApplySpec, scriptName string) error {
+	var scripts []boshscript.Script
+
+	for _, job := range spec.Jobs() {
+		scripts = append(scripts, jobScriptProvider.NewScript(job.BundleName(), scriptName))
+	}
+
+	return jobScriptProvider.NewParallelScript(scriptName, scripts).Run()
+}
@@ -0,0 +1,148 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeapplyspec "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("PrepareSnapshot", func() {
+	var (
+		jobScriptProvider *fakescript.FakeJobScriptProvider
+		specService       *fakeapplyspec.FakeV1Service
+		platform          *fakeplatform.FakePlatform
+		timeService       *fakeclock.FakeClock
+		guard             SnapshotGuard
+		parallelScripts   map[string]*fakescript.FakeCancellableScript
+		action            PrepareSnapshotAction
+	)
+
+	BeforeEach(func() {
+		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		specService = fakeapplyspec.NewFakeV1Service()
+		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		platform = fakeplatform.NewFakePlatform()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		guard = NewSnapshotGuard(platform, "/var/vcap/store", timeService, logger)
+		action = NewPrepareSnapshot(jobScriptProvider, specService, guard, logger)
+
+		specService.Spec.JobSpec.JobTemplateSpecs = []applyspec.JobTemplateSpec{
+			{Name: "fake-job-1"},
+			{Name: "fake-job-2"},
+		}
+
+		jobScriptProvider.NewScriptStub = func(jobName, scriptName string) boshscript.Script {
+			script := &fakescript.FakeScript{}
+			script.TagReturns(jobName + "/" + scriptName)
+			return script
+		}
+
+		parallelScripts = map[string]*fakescript.FakeCancellableScript{
+			"pre-snapshot":  &fakescript.FakeCancellableScript{},
+			"post-snapshot": &fakescript.FakeCancellableScript{},
+		}
+
+		jobScriptProvider.NewParallelScriptStub = func(scriptName string, scripts []boshscript.Script) boshscript.CancellableScript {
+			return parallelScripts[scriptName]
+		}
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("runs pre-snapshot scripts of all jobs and then freezes persistent disk", func() {
+			parallelScripts["pre-snapshot"].RunStub = func() error {
+				Expect(platform.FreezePersistentDiskMountPoints).To(BeEmpty())
+				return nil
+			}
+
+			results, err := action.Run(map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(Equal(map[string]string{}))
+
+			Expect(jobScriptProvider.NewParallelScriptCallCount()).To(Equal(1))
+			scriptName, scripts := jobScriptProvider.NewParallelScriptArgsForCall(0)
+			Expect(scriptName).To(Equal("pre-snapshot"))
+			Expect(scripts).To(HaveLen(2))
+			Expect(scripts[0].Tag()).To(Equal("fake-job-1/pre-snapshot"))
+			Expect(scripts[1].Tag()).To(Equal("fake-job-2/pre-snapshot"))
+
+			Expect(parallelScripts["pre-snapshot"].RunCallCount()).To(Equal(1))
+			Expect(platform.FreezePersistentDiskMountPoints).To(Equal([]string{"/var/vcap/store"}))
+			Expect(guard.IsFrozen()).To(BeTrue())
+		})
+
+		It("thaws persistent disk after default timeout", func() {
+			_, err := action.Run(map[string]interface{}{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+
+			timeService.Increment(2*time.Minute - time.Second)
+			Consistently(guard.IsFrozen).Should(BeTrue())
+
+			timeService.Increment(time.Second)
+			Eventually(guard.IsFrozen).Should(BeFalse())
+		})
+
+		It("thaws persistent disk after given timeout", func() {
+			_, err := action.Run(map[string]interface{}{"timeout": float64(10)})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(10 * time.Second)
+
+			Eventually(guard.IsFrozen).Should(BeFalse())
+		})
+
+		It("returns error if timeout is not a positive number", func() {
+			_, err := action.Run(map[string]interface{}{"timeout": "fake-timeout"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected timeout to be a positive number"))
+			Expect(platform.FreezePersistentDiskMountPoints).To(BeEmpty())
+		})
+
+		It("does not freeze persistent disk if pre-snapshot scripts fail", func() {
+			parallelScripts["pre-snapshot"].RunReturns(errors.New("fake-script-err"))
+
+			_, err := action.Run(map[string]interface{}{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-script-err"))
+			Expect(platform.FreezePersistentDiskMountPoints).To(BeEmpty())
+		})
+
+		It("runs post-snapshot scripts if freezing fails", func() {
+			platform.FreezePersistentDiskErr = errors.New("fake-freeze-err")
+
+			_, err := action.Run(map[string]interface{}{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-freeze-err"))
+			Expect(parallelScripts["post-snapshot"].RunCallCount()).To(Equal(1))
+		})
+
+		It("returns error if current spec cannot be retrieved", func() {
+			specService.GetErr = errors.New("fake-spec-err")
+
+			_, err := action.Run(map[string]interface{}{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-spec-err"))
+		})
+	})
+})
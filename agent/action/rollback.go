@@ -0,0 +1,88 @@
+package action
+
+import (
+	"errors"
+
+	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// RollbackAction reverts jobs and packages to the spec
+// that was successfully applied before the current one.
+type RollbackAction struct {
+	applier             boshappl.Applier
+	specService         boshas.V1Service
+	previousSpecService boshas.V1Service
+}
+
+func NewRollback(
+	applier boshappl.Applier,
+	specService boshas.V1Service,
+	previousSpecService boshas.V1Service,
+) (action RollbackAction) {
+	action.applier = applier
+	action.specService = specService
+	action.previousSpecService = previousSpecService
+	return
+}
+
+func (a RollbackAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RollbackAction) IsPersistent() bool {
+	return false
+}
+
+func (a RollbackAction) IsLoggable() bool {
+	return true
+}
+
+func (a RollbackAction) Run() (string, error) {
+	previousSpec, err := a.previousSpecService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting previous spec")
+	}
+
+	if previousSpec.ConfigurationHash == "" {
+		return "", bosherr.Error("No previous spec to roll back to")
+	}
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	err = a.applier.Apply(currentSpec, previousSpec)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Applying previous spec")
+	}
+
+	// Unlike apply, rollback is not followed by start from director
+	err = a.applier.ConfigureJobs(previousSpec)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Configuring jobs of previous spec")
+	}
+
+	err = a.specService.Set(previousSpec)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Persisting apply spec")
+	}
+
+	// Spec that was rolled back from is not considered good
+	err = a.previousSpecService.Set(boshas.V1ApplySpec{})
+	if err != nil {
+		return "", bosherr.WrapError(err, "Clearing previous apply spec")
+	}
+
+	return "rolled_back", nil
+}
+
+func (a RollbackAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RollbackAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,108 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
+)
+
+var _ = Describe("RollbackAction", func() {
+	var (
+		applier             *fakeappl.FakeApplier
+		specService         *fakeas.FakeV1Service
+		previousSpecService *fakeas.FakeV1Service
+		action              RollbackAction
+	)
+
+	currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+	previousApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-previous-config-hash"}
+
+	BeforeEach(func() {
+		applier = fakeappl.NewFakeApplier()
+		specService = fakeas.NewFakeV1Service()
+		previousSpecService = fakeas.NewFakeV1Service()
+		action = NewRollback(applier, specService, previousSpecService)
+
+		specService.Spec = currentApplySpec
+		previousSpecService.Spec = previousApplySpec
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("applies previous spec and saves it as current spec", func() {
+			value, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("rolled_back"))
+
+			Expect(applier.ApplyCurrentApplySpec).To(Equal(currentApplySpec))
+			Expect(applier.ApplyDesiredApplySpec).To(Equal(previousApplySpec))
+			Expect(specService.Spec).To(Equal(previousApplySpec))
+		})
+
+		It("configures jobs of previous spec", func() {
+			_, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(applier.Configured).To(BeTrue())
+			Expect(applier.ConfiguredDesiredApplySpec).To(Equal(previousApplySpec))
+		})
+
+		It("keeps current spec when jobs of previous spec cannot be configured", func() {
+			applier.ConfiguredError = errors.New("fake-configure-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-configure-error"))
+			Expect(specService.Spec).To(Equal(currentApplySpec))
+		})
+
+		It("forgets previous spec so that it is not rolled back to twice", func() {
+			_, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(previousSpecService.Spec).To(Equal(boshas.V1ApplySpec{}))
+
+			_, err = action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No previous spec to roll back to"))
+		})
+
+		It("returns error when there is no previous spec", func() {
+			previousSpecService.Spec = boshas.V1ApplySpec{}
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No previous spec to roll back to"))
+			Expect(applier.Applied).To(BeFalse())
+		})
+
+		It("returns error when previous spec cannot be retrieved", func() {
+			previousSpecService.GetErr = errors.New("fake-get-previous-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-previous-error"))
+		})
+
+		It("keeps current spec when previous spec cannot be applied", func() {
+			applier.ApplyError = errors.New("fake-apply-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-apply-error"))
+			Expect(specService.Spec).To(Equal(currentApplySpec))
+			Expect(previousSpecService.Spec).To(Equal(previousApplySpec))
+		})
+	})
+})
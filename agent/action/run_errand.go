@@ -2,21 +2,40 @@ package action
 
 import (
 	"errors"
+	"io"
+	"os"
 	"path"
 	"time"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-const runErrandActionLogTag = "runErrandAction"
+const (
+	runErrandActionLogTag = "runErrandAction"
+
+	errandStdoutLog = "errand.stdout.log"
+	errandStderrLog = "errand.stderr.log"
+
+	// Full output is available via logs_blobstore_id
+	errandOutputTailBytes = 10 * 1024
+)
 
 type RunErrandAction struct {
 	specService boshas.V1Service
-	jobsDir     string
+	dirProvider boshdirs.Provider
 	cmdRunner   boshsys.CmdRunner
+	fs          boshsys.FileSystem
+	compressor  boshcmd.Compressor
+	copier      boshcmd.Copier
+	blobstore   boshblob.DigestBlobstore
 	logger      boshlog.Logger
 
 	cancelCh chan struct{}
@@ -24,14 +43,22 @@ type RunErrandAction struct {
 
 func NewRunErrand(
 	specService boshas.V1Service,
-	jobsDir string,
+	dirProvider boshdirs.Provider,
 	cmdRunner boshsys.CmdRunner,
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	copier boshcmd.Copier,
+	blobstore boshblob.DigestBlobstore,
 	logger boshlog.Logger,
 ) RunErrandAction {
 	return RunErrandAction{
 		specService: specService,
-		jobsDir:     jobsDir,
+		dirProvider: dirProvider,
 		cmdRunner:   cmdRunner,
+		fs:          fs,
+		compressor:  compressor,
+		copier:      copier,
+		blobstore:   blobstore,
 		logger:      logger,
 
 		// Initialize channel in a constructor to avoid race
@@ -52,27 +79,86 @@ func (a RunErrandAction) IsLoggable() bool {
 	return true
 }
 
+// ErrandRequest selects errand job and how its bin/run is invoked.
+// Errand of the first job template is run when name is not specified.
+type ErrandRequest struct {
+	Name string            `json:"name"`
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env"`
+}
+
 type ErrandResult struct {
-	Stdout     string `json:"stdout"`
-	Stderr     string `json:"stderr"`
-	ExitStatus int    `json:"exit_code"`
+	// Stdout and Stderr only include last part of errand output
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	OutputTruncated bool   `json:"output_truncated,omitempty"`
+
+	ExitStatus int `json:"exit_code"`
+
+	// Blob includes full errand stdout and stderr logs
+	LogsBlobstoreID string `json:"logs_blobstore_id,omitempty"`
+
+	// Only reported when errands run in their own cgroups
+	PeakMemoryBytes uint64  `json:"peak_memory_bytes,omitempty"`
+	CPUTime         float64 `json:"cpu_time,omitempty"`
 }
 
-func (a RunErrandAction) Run() (ErrandResult, error) {
+// Run accepts optional request for compatibility with directors
+// that run errand without any arguments.
+func (a RunErrandAction) Run(requests ...ErrandRequest) (ErrandResult, error) {
+	var request ErrandRequest
+	if len(requests) > 0 {
+		request = requests[0]
+	}
+
 	currentSpec, err := a.specService.Get()
 	if err != nil {
 		return ErrandResult{}, bosherr.WrapError(err, "Getting current spec")
 	}
 
-	if len(currentSpec.JobSpec.Template) == 0 {
-		return ErrandResult{}, bosherr.Error("At least one job template is required to run an errand")
+	jobName, err := a.errandJobName(currentSpec, request.Name)
+	if err != nil {
+		return ErrandResult{}, err
+	}
+
+	logsDir := path.Join(a.dirProvider.LogsDir(), jobName)
+
+	err = a.fs.MkdirAll(logsDir, os.FileMode(0750))
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Creating errand logs dir")
+	}
+
+	stdoutFile, err := a.openLogFile(path.Join(logsDir, errandStdoutLog))
+	if err != nil {
+		return ErrandResult{}, err
+	}
+
+	defer stdoutFile.Close()
+
+	stderrFile, err := a.openLogFile(path.Join(logsDir, errandStderrLog))
+	if err != nil {
+		return ErrandResult{}, err
+	}
+
+	defer stderrFile.Close()
+
+	stdoutTail := boshscript.NewOutputTail(errandOutputTailBytes)
+	stderrTail := boshscript.NewOutputTail(errandOutputTailBytes)
+
+	env := map[string]string{
+		"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
+	}
+
+	for name, value := range request.Env {
+		env[name] = value
 	}
 
 	command := boshsys.Command{
-		Name: path.Join(a.jobsDir, currentSpec.JobSpec.Template, "bin", "run"),
-		Env: map[string]string{
-			"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
-		},
+		Name:   path.Join(a.dirProvider.JobsDir(), jobName, "bin", "run"),
+		Args:   request.Args,
+		Env:    env,
+		Stdout: io.MultiWriter(stdoutFile, stdoutTail),
+		Stderr: io.MultiWriter(stderrFile, stderrTail),
 	}
 
 	process, err := a.cmdRunner.RunComplexCommandAsync(command)
@@ -100,11 +186,88 @@ func (a RunErrandAction) Run() (ErrandResult, error) {
 		return ErrandResult{}, bosherr.WrapError(result.Error, "Running errand script")
 	}
 
-	return ErrandResult{
-		Stdout:     result.Stdout,
-		Stderr:     result.Stderr,
-		ExitStatus: result.ExitStatus,
-	}, nil
+	// Make sure all output is flushed before logs are uploaded
+	_ = stdoutFile.Close()
+	_ = stderrFile.Close()
+
+	errandResult := ErrandResult{
+		Stdout:          stdoutTail.String(),
+		Stderr:          stderrTail.String(),
+		OutputTruncated: stdoutTail.Truncated() || stderrTail.Truncated(),
+		ExitStatus:      result.ExitStatus,
+	}
+
+	if reporter, ok := process.(boshrunner.ResourceUsageReporter); ok {
+		usage, err := reporter.ResourceUsage()
+		if err == nil {
+			errandResult.PeakMemoryBytes = usage.PeakMemoryBytes
+			errandResult.CPUTime = usage.CPUTime.Seconds()
+		}
+	}
+
+	// Errand has already finished so its result is returned even if logs cannot be uploaded
+	errandResult.LogsBlobstoreID, err = a.uploadLogs(logsDir)
+	if err != nil {
+		a.logger.Error(runErrandActionLogTag, "Failed to upload errand logs: %s", err.Error())
+	}
+
+	return errandResult, nil
+}
+
+func (a RunErrandAction) errandJobName(spec boshas.V1ApplySpec, name string) (string, error) {
+	if len(name) == 0 {
+		if len(spec.JobSpec.Template) == 0 {
+			return "", bosherr.Error("At least one job template is required to run an errand")
+		}
+
+		return spec.JobSpec.Template, nil
+	}
+
+	if name == spec.JobSpec.Template {
+		return name, nil
+	}
+
+	for _, template := range spec.JobSpec.JobTemplateSpecs {
+		if template.Name == name {
+			return name, nil
+		}
+	}
+
+	return "", bosherr.Errorf("Job '%s' is not part of current spec", name)
+}
+
+func (a RunErrandAction) openLogFile(logPath string) (boshsys.File, error) {
+	file, err := a.fs.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0640))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening errand log file %s", logPath)
+	}
+
+	return file, nil
+}
+
+func (a RunErrandAction) uploadLogs(logsDir string) (string, error) {
+	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, []string{errandStdoutLog, errandStderrLog})
+	if err != nil {
+		return "", bosherr.WrapError(err, "Copying errand logs to temp directory")
+	}
+
+	defer a.copier.CleanUp(tmpDir)
+
+	tarball, err := a.compressor.CompressFilesInDir(tmpDir)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Making errand logs tarball")
+	}
+
+	defer func() {
+		_ = a.compressor.CleanUp(tarball)
+	}()
+
+	blobID, _, err := a.blobstore.Create(tarball)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Create file on blobstore")
+	}
+
+	return blobID, nil
 }
 
 func (a RunErrandAction) Resume() (interface{}, error) {
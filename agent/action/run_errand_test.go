@@ -2,6 +2,8 @@ package action_test
 
 import (
 	"errors"
+	"os"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -10,23 +12,57 @@ import (
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
+// outputCmdRunner writes output to command's writers as real runner does
+// when custom Stdout and Stderr are specified
+type outputCmdRunner struct {
+	*fakesys.FakeCmdRunner
+
+	Stdout string
+	Stderr string
+}
+
+func (r *outputCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	process, err := r.FakeCmdRunner.RunComplexCommandAsync(cmd)
+	if err == nil {
+		_, _ = cmd.Stdout.Write([]byte(r.Stdout))
+		_, _ = cmd.Stderr.Write([]byte(r.Stderr))
+	}
+
+	return process, err
+}
+
 var _ = Describe("RunErrand", func() {
 	var (
 		specService *fakeas.FakeV1Service
-		cmdRunner   *fakesys.FakeCmdRunner
+		cmdRunner   *outputCmdRunner
+		fs          *fakesys.FakeFileSystem
+		compressor  *fakecmd.FakeCompressor
+		copier      *fakecmd.FakeCopier
+		blobstore   *fakeblobstore.FakeDigestBlobstore
 		action      RunErrandAction
 	)
 
 	BeforeEach(func() {
 		specService = fakeas.NewFakeV1Service()
-		cmdRunner = fakesys.NewFakeCmdRunner()
+		cmdRunner = &outputCmdRunner{FakeCmdRunner: fakesys.NewFakeCmdRunner()}
+		fs = fakesys.NewFakeFileSystem()
+		compressor = fakecmd.NewFakeCompressor()
+		copier = fakecmd.NewFakeCopier()
+		blobstore = &fakeblobstore.FakeDigestBlobstore{}
+		blobstore.CreateReturns("fake-logs-blob-id", boshcrypto.MultipleDigest{}, nil)
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		action = NewRunErrand(specService, "/fake-jobs-dir", cmdRunner, logger)
+		action = NewRunErrand(specService, boshdirs.NewProvider("/fake-base-dir"), cmdRunner, fs, compressor, copier, blobstore, logger)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -41,15 +77,20 @@ var _ = Describe("RunErrand", func() {
 				BeforeEach(func() {
 					currentSpec := boshas.V1ApplySpec{}
 					currentSpec.JobSpec.Template = "fake-job-name"
+					currentSpec.JobSpec.JobTemplateSpecs = []boshas.JobTemplateSpec{
+						{Name: "fake-job-name"},
+						{Name: "other-job-name"},
+					}
 					specService.Spec = currentSpec
+
+					cmdRunner.Stdout = "fake-stdout"
+					cmdRunner.Stderr = "fake-stderr"
 				})
 
 				Context("when errand script exits with non-0 exit code (execution of script is ok)", func() {
 					BeforeEach(func() {
-						cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+						cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 							WaitResult: boshsys.Result{
-								Stdout:     "fake-stdout",
-								Stderr:     "fake-stderr",
 								ExitStatus: 0,
 							},
 						})
@@ -60,13 +101,34 @@ var _ = Describe("RunErrand", func() {
 						Expect(err).ToNot(HaveOccurred())
 						Expect(result).To(Equal(
 							ErrandResult{
-								Stdout:     "fake-stdout",
-								Stderr:     "fake-stderr",
-								ExitStatus: 0,
+								Stdout:          "fake-stdout",
+								Stderr:          "fake-stderr",
+								ExitStatus:      0,
+								LogsBlobstoreID: "fake-logs-blob-id",
 							},
 						))
 					})
 
+					It("returns resource usage when errand runs in its own cgroup", func() {
+						cgroupProvider := fakecmdrunner.NewFakeCgroupProvider()
+						cgroupProvider.UsageResult = boshrunner.CgroupUsage{PeakMemoryBytes: 4096, CPUTime: 2 * time.Second}
+
+						logger := boshlog.NewLogger(boshlog.LevelNone)
+						cgroupRunner := boshrunner.NewCgroupCmdRunner(cmdRunner, cgroupProvider, boshrunner.CgroupLimits{}, logger)
+						action = NewRunErrand(specService, boshdirs.NewProvider("/fake-base-dir"), cgroupRunner, fs, compressor, copier, blobstore, logger)
+
+						cmdRunner.AddProcess("fake-cgroup-wrapper run-1 /fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
+							WaitResult: boshsys.Result{ExitStatus: 0},
+						})
+
+						result, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.Stdout).To(Equal("fake-stdout"))
+						Expect(result.PeakMemoryBytes).To(Equal(uint64(4096)))
+						Expect(result.CPUTime).To(Equal(float64(2)))
+						Expect(cgroupProvider.Cgroups[0].Removed).To(BeTrue())
+					})
+
 					It("runs errand script with properly configured environment", func() {
 						_, err := action.Run()
 						Expect(err).ToNot(HaveOccurred())
@@ -74,14 +136,101 @@ var _ = Describe("RunErrand", func() {
 						env := map[string]string{"PATH": "/usr/sbin:/usr/bin:/sbin:/bin"}
 						Expect(cmd.Env).To(Equal(env))
 					})
+
+					It("writes full output to log files in job's log dir", func() {
+						_, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fs.FileExists("/fake-base-dir/sys/log/fake-job-name")).To(BeTrue())
+						Expect(fs.ReadFileString("/fake-base-dir/sys/log/fake-job-name/errand.stdout.log")).To(Equal("fake-stdout"))
+						Expect(fs.ReadFileString("/fake-base-dir/sys/log/fake-job-name/errand.stderr.log")).To(Equal("fake-stderr"))
+
+						stats := fs.GetFileTestStat("/fake-base-dir/sys/log/fake-job-name/errand.stdout.log")
+						Expect(stats.Flags).To(Equal(os.O_CREATE | os.O_WRONLY | os.O_TRUNC))
+					})
+
+					It("uploads log files to blobstore", func() {
+						copier.FilteredCopyToTempTempDir = "/fake-temp-dir"
+						compressor.CompressFilesInDirTarballPath = "/fake-logs.tgz"
+
+						_, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(copier.FilteredCopyToTempDir).To(Equal("/fake-base-dir/sys/log/fake-job-name"))
+						Expect(copier.FilteredCopyToTempFilters).To(Equal([]string{"errand.stdout.log", "errand.stderr.log"}))
+						Expect(compressor.CompressFilesInDirDir).To(Equal("/fake-temp-dir"))
+						Expect(copier.CleanUpTempDir).To(Equal("/fake-temp-dir"))
+						Expect(blobstore.CreateArgsForCall(0)).To(Equal("/fake-logs.tgz"))
+						Expect(compressor.CleanUpTarballPath).To(Equal("/fake-logs.tgz"))
+					})
+
+					It("returns errand result without logs blob when logs cannot be uploaded", func() {
+						blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-error"))
+
+						result, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.LogsBlobstoreID).To(BeEmpty())
+						Expect(result.Stdout).To(Equal("fake-stdout"))
+					})
+
+					It("only returns last part of large output", func() {
+						cmdRunner.Stdout = strings.Repeat("a", 20*1024) + strings.Repeat("b", 10*1024)
+
+						result, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.Stdout).To(Equal(strings.Repeat("b", 10*1024)))
+						Expect(result.Stderr).To(Equal("fake-stderr"))
+						Expect(result.OutputTruncated).To(BeTrue())
+
+						Expect(fs.ReadFileString("/fake-base-dir/sys/log/fake-job-name/errand.stdout.log")).To(HaveLen(30 * 1024))
+					})
+
+					It("returns error when log file cannot be opened", func() {
+						fs.OpenFileErr = errors.New("fake-open-error")
+
+						_, err := action.Run()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-open-error"))
+						Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+					})
+				})
+
+				Context("when errand request names a job", func() {
+					BeforeEach(func() {
+						cmdRunner.AddProcess("/fake-base-dir/jobs/other-job-name/bin/run --fake-arg", &fakesys.FakeProcess{})
+					})
+
+					It("runs errand of the named job with args and env", func() {
+						_, err := action.Run(ErrandRequest{
+							Name: "other-job-name",
+							Args: []string{"--fake-arg"},
+							Env:  map[string]string{"FAKE_ENV": "fake-value"},
+						})
+						Expect(err).ToNot(HaveOccurred())
+
+						cmd := cmdRunner.RunComplexCommands[0]
+						Expect(cmd.Name).To(Equal("/fake-base-dir/jobs/other-job-name/bin/run"))
+						Expect(cmd.Args).To(Equal([]string{"--fake-arg"}))
+						Expect(cmd.Env).To(Equal(map[string]string{
+							"PATH":     "/usr/sbin:/usr/bin:/sbin:/bin",
+							"FAKE_ENV": "fake-value",
+						}))
+
+						Expect(fs.ReadFileString("/fake-base-dir/sys/log/other-job-name/errand.stdout.log")).To(Equal("fake-stdout"))
+					})
+
+					It("returns error when job is not part of current spec", func() {
+						_, err := action.Run(ErrandRequest{Name: "../unknown-job"})
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Job '../unknown-job' is not part of current spec"))
+						Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+					})
 				})
 
 				Context("when errand script fails with non-0 exit code (execution of script is ok)", func() {
 					BeforeEach(func() {
-						cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+						cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 							WaitResult: boshsys.Result{
-								Stdout:     "fake-stdout",
-								Stderr:     "fake-stderr",
 								ExitStatus: 123,
 								Error:      errors.New("fake-bosh-error"), // not used
 							},
@@ -93,9 +242,10 @@ var _ = Describe("RunErrand", func() {
 						Expect(err).ToNot(HaveOccurred())
 						Expect(result).To(Equal(
 							ErrandResult{
-								Stdout:     "fake-stdout",
-								Stderr:     "fake-stderr",
-								ExitStatus: 123,
+								Stdout:          "fake-stdout",
+								Stderr:          "fake-stderr",
+								ExitStatus:      123,
+								LogsBlobstoreID: "fake-logs-blob-id",
 							},
 						))
 					})
@@ -103,7 +253,7 @@ var _ = Describe("RunErrand", func() {
 
 				Context("when errand script fails to execute", func() {
 					BeforeEach(func() {
-						cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+						cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 							WaitResult: boshsys.Result{
 								ExitStatus: -1,
 								Error:      errors.New("fake-bosh-error"),
@@ -164,6 +314,9 @@ var _ = Describe("RunErrand", func() {
 				JobSpec: boshas.JobSpec{Template: "fake-job-name"},
 			}
 			specService.Spec = currentSpec
+
+			cmdRunner.Stdout = "fake-stdout"
+			cmdRunner.Stderr = "fake-stderr"
 		})
 
 		Context("when action was not cancelled yet", func() {
@@ -171,14 +324,12 @@ var _ = Describe("RunErrand", func() {
 				process := &fakesys.FakeProcess{
 					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
 						p.WaitCh <- boshsys.Result{
-							Stdout:     "fake-stdout",
-							Stderr:     "fake-stderr",
 							ExitStatus: 0,
 						}
 					},
 				}
 
-				cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", process)
+				cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", process)
 
 				err := action.Cancel()
 				Expect(err).ToNot(HaveOccurred())
@@ -191,11 +342,9 @@ var _ = Describe("RunErrand", func() {
 
 			Context("when errand script exits with non-0 exit code (execution of script is ok)", func() {
 				BeforeEach(func() {
-					cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+					cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 						TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
 							p.WaitCh <- boshsys.Result{
-								Stdout:     "fake-stdout",
-								Stderr:     "fake-stderr",
 								ExitStatus: 0,
 							}
 						},
@@ -210,9 +359,10 @@ var _ = Describe("RunErrand", func() {
 					Expect(err).ToNot(HaveOccurred())
 					Expect(result).To(Equal(
 						ErrandResult{
-							Stdout:     "fake-stdout",
-							Stderr:     "fake-stderr",
-							ExitStatus: 0,
+							Stdout:          "fake-stdout",
+							Stderr:          "fake-stderr",
+							ExitStatus:      0,
+							LogsBlobstoreID: "fake-logs-blob-id",
 						},
 					))
 				})
@@ -220,11 +370,9 @@ var _ = Describe("RunErrand", func() {
 
 			Context("when errand script fails with non-0 exit code (execution of script is ok)", func() {
 				BeforeEach(func() {
-					cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+					cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 						TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
 							p.WaitCh <- boshsys.Result{
-								Stdout:     "fake-stdout",
-								Stderr:     "fake-stderr",
 								ExitStatus: 123,
 								Error:      errors.New("fake-bosh-error"), // not used
 							}
@@ -240,9 +388,10 @@ var _ = Describe("RunErrand", func() {
 					Expect(err).ToNot(HaveOccurred())
 					Expect(result).To(Equal(
 						ErrandResult{
-							Stdout:     "fake-stdout",
-							Stderr:     "fake-stderr",
-							ExitStatus: 123,
+							Stdout:          "fake-stdout",
+							Stderr:          "fake-stderr",
+							ExitStatus:      123,
+							LogsBlobstoreID: "fake-logs-blob-id",
 						},
 					))
 				})
@@ -250,7 +399,7 @@ var _ = Describe("RunErrand", func() {
 
 			Context("when errand script fails to execute", func() {
 				BeforeEach(func() {
-					cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+					cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 						TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
 							p.WaitCh <- boshsys.Result{
 								ExitStatus: -1,
@@ -274,7 +423,7 @@ var _ = Describe("RunErrand", func() {
 
 		Context("when action was cancelled already", func() {
 			BeforeEach(func() {
-				cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+				cmdRunner.AddProcess("/fake-base-dir/jobs/fake-job-name/bin/run", &fakesys.FakeProcess{
 					TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
 						p.WaitCh <- boshsys.Result{
 							ExitStatus: -1,
@@ -2,6 +2,9 @@ package action
 
 import (
 	"errors"
+	"time"
+
+	"github.com/pivotal-golang/clock"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
@@ -9,25 +12,36 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+const scriptStatusTimedOut = "timed_out"
+
 type RunScriptAction struct {
 	scriptProvider boshscript.JobScriptProvider
 	specService    boshas.V1Service
+	timeService    clock.Clock
 
 	logTag string
 	logger boshlog.Logger
+
+	cancelCh chan struct{}
 }
 
 func NewRunScript(
 	scriptProvider boshscript.JobScriptProvider,
 	specService boshas.V1Service,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) RunScriptAction {
 	return RunScriptAction{
 		scriptProvider: scriptProvider,
 		specService:    specService,
+		timeService:    timeService,
 
 		logTag: "RunScript Action",
 		logger: logger,
+
+		// Initialize channel in a constructor to avoid race
+		// between initializing in Run()/Cancel()
+		cancelCh: make(chan struct{}, 1),
 	}
 }
 
@@ -43,13 +57,51 @@ func (a RunScriptAction) IsLoggable() bool {
 	return true
 }
 
-func (a RunScriptAction) Run(scriptName string, options map[string]interface{}) (map[string]string, error) {
-	// May be used in future to return more information
-	emptyResults := map[string]string{}
+// JobScriptResult describes how script of a single job ran.
+// Stdout and Stderr only include last part of script output.
+type JobScriptResult struct {
+	Status   string  `json:"status"`
+	ExitCode int     `json:"exit_code"`
+	Duration float64 `json:"duration"`
+	Stdout   string  `json:"stdout"`
+	Stderr   string  `json:"stderr"`
+
+	// Only reported when scripts run in their own cgroups
+	PeakMemoryBytes uint64  `json:"peak_memory_bytes,omitempty"`
+	CPUTime         float64 `json:"cpu_time,omitempty"`
+}
+
+func newJobScriptResult(r boshscript.ScriptResult) JobScriptResult {
+	return JobScriptResult{
+		Status:   r.Status,
+		ExitCode: r.ExitCode,
+		Duration: r.Duration.Seconds(),
+		Stdout:   r.Stdout,
+		Stderr:   r.Stderr,
+
+		PeakMemoryBytes: r.PeakMemoryBytes,
+		CPUTime:         r.CPUTime.Seconds(),
+	}
+}
+
+type parallelScriptResult struct {
+	results map[string]boshscript.ScriptResult
+	err     error
+}
+
+// Run returns results keyed by job name. Scripts that are still running
+// after optional "timeout" option (in seconds) are cancelled.
+func (a RunScriptAction) Run(scriptName string, options map[string]interface{}) (map[string]JobScriptResult, error) {
+	jobResults := map[string]JobScriptResult{}
+
+	timeout, err := a.timeout(options)
+	if err != nil {
+		return jobResults, err
+	}
 
 	currentSpec, err := a.specService.Get()
 	if err != nil {
-		return emptyResults, bosherr.WrapError(err, "Getting current spec")
+		return jobResults, bosherr.WrapError(err, "Getting current spec")
 	}
 
 	var scripts []boshscript.Script
@@ -61,13 +113,94 @@ func (a RunScriptAction) Run(scriptName string, options map[string]interface{})
 
 	parallelScript := a.scriptProvider.NewParallelScript(scriptName, scripts)
 
-	return emptyResults, parallelScript.Run()
+	resultCh := make(chan parallelScriptResult, 1)
+
+	go func() {
+		if reportingScript, ok := parallelScript.(boshscript.ParallelReportingScript); ok {
+			results, err := reportingScript.RunWithResults()
+			resultCh <- parallelScriptResult{results: results, err: err}
+		} else {
+			resultCh <- parallelScriptResult{err: parallelScript.Run()}
+		}
+	}()
+
+	var timeoutCh <-chan time.Time
+
+	if timeout > 0 {
+		timer := a.timeService.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C()
+	}
+
+	var result parallelScriptResult
+
+	timedOut := false
+
+	// Scripts are cancelled at most once on timeout but user may cancel multiple times
+	for resultCh != nil {
+		select {
+		case result = <-resultCh:
+			resultCh = nil
+		case <-timeoutCh:
+			a.logger.Error(a.logTag, "'%s' scripts did not finish after %s, cancelling them", scriptName, timeout)
+			timedOut = true
+			timeoutCh = nil
+			a.cancel(parallelScript)
+		case <-a.cancelCh:
+			a.cancel(parallelScript)
+		}
+	}
+
+	for jobName, r := range result.results {
+		jobResult := newJobScriptResult(r)
+
+		if timedOut && r.Status == boshscript.ScriptStatusCancelled {
+			jobResult.Status = scriptStatusTimedOut
+		}
+
+		jobResults[jobName] = jobResult
+	}
+
+	if result.err != nil && timedOut {
+		return jobResults, bosherr.WrapErrorf(result.err, "Running '%s' scripts timed out after %s", scriptName, timeout)
+	}
+
+	return jobResults, result.err
+}
+
+func (a RunScriptAction) timeout(options map[string]interface{}) (time.Duration, error) {
+	value, found := options["timeout"]
+	if !found {
+		return 0, nil
+	}
+
+	seconds, ok := value.(float64)
+	if !ok || seconds < 0 {
+		return 0, bosherr.Errorf("Expected timeout to be a non-negative number of seconds, got '%v'", value)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (a RunScriptAction) cancel(parallelScript boshscript.CancellableScript) {
+	// Ignore possible Cancel error since we cannot return it
+	err := parallelScript.Cancel()
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed to cancel scripts: %s", err.Error())
+	}
 }
 
 func (a RunScriptAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
+// Cancel follows the same rules as cancelling errands:
+// it takes constant time and cancels scripts that are running or about to run.
 func (a RunScriptAction) Cancel() error {
-	return errors.New("not supported")
+	select {
+	case a.cancelCh <- struct{}{}:
+	default:
+		// Cancel action is already queued up
+	}
+	return nil
 }
@@ -2,6 +2,9 @@ package action_test
 
 import (
 	"errors"
+	"time"
+
+	"github.com/pivotal-golang/clock/fakeclock"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -18,6 +21,7 @@ var _ = Describe("RunScript", func() {
 	var (
 		fakeJobScriptProvider *fakescript.FakeJobScriptProvider
 		specService           *fakeapplyspec.FakeV1Service
+		timeService           *fakeclock.FakeClock
 		action                RunScriptAction
 	)
 
@@ -25,8 +29,9 @@ var _ = Describe("RunScript", func() {
 		fakeJobScriptProvider = &fakescript.FakeJobScriptProvider{}
 		specService = fakeapplyspec.NewFakeV1Service()
 		specService.Spec.RenderedTemplatesArchiveSpec = &applyspec.RenderedTemplatesArchiveSpec{}
+		timeService = fakeclock.NewFakeClock(time.Now())
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		action = NewRunScript(fakeJobScriptProvider, specService, logger)
+		action = NewRunScript(fakeJobScriptProvider, specService, timeService, logger)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -34,16 +39,15 @@ var _ = Describe("RunScript", func() {
 	AssertActionIsLoggable(action)
 
 	AssertActionIsNotResumable(action)
-	AssertActionIsNotCancelable(action)
 
 	Describe("Run", func() {
-		act := func() (map[string]string, error) { return action.Run("run-me", map[string]interface{}{}) }
+		act := func() (map[string]JobScriptResult, error) { return action.Run("run-me", map[string]interface{}{}) }
 
 		Context("when current spec can be retrieved", func() {
-			var parallelScript *fakescript.FakeCancellableScript
+			var parallelScript *fakescript.FakeParallelReportingScript
 
 			BeforeEach(func() {
-				parallelScript = &fakescript.FakeCancellableScript{}
+				parallelScript = &fakescript.FakeParallelReportingScript{}
 				fakeJobScriptProvider.NewParallelScriptReturns(parallelScript)
 			})
 
@@ -73,26 +77,124 @@ var _ = Describe("RunScript", func() {
 					}
 				}
 
-				parallelScript.RunReturns(nil)
+				parallelScript.RunWithResultsReturns(map[string]boshscript.ScriptResult{}, nil)
 
 				results, err := act()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(results).To(Equal(map[string]string{}))
+				Expect(results).To(Equal(map[string]JobScriptResult{}))
 
-				Expect(parallelScript.RunCallCount()).To(Equal(1))
+				Expect(parallelScript.RunWithResultsCallCount()).To(Equal(1))
 
 				scriptName, scripts := fakeJobScriptProvider.NewParallelScriptArgsForCall(0)
 				Expect(scriptName).To(Equal("run-me"))
 				Expect(scripts).To(Equal([]boshscript.Script{script1, script2}))
 			})
 
-			It("returns an error when parallel script fails", func() {
-				parallelScript.RunReturns(errors.New("fake-error"))
+			It("returns result of each job script", func() {
+				parallelScript.RunWithResultsReturns(map[string]boshscript.ScriptResult{
+					"fake-job-1": {
+						Tag:      "fake-job-1",
+						Status:   boshscript.ScriptStatusSucceeded,
+						ExitCode: 0,
+						Duration: 1500 * time.Millisecond,
+						Stdout:   "fake-stdout",
+						Stderr:   "fake-stderr",
+
+						PeakMemoryBytes: 4096,
+						CPUTime:         250 * time.Millisecond,
+					},
+				}, nil)
 
 				results, err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(Equal(map[string]JobScriptResult{
+					"fake-job-1": {
+						Status:   "succeeded",
+						ExitCode: 0,
+						Duration: 1.5,
+						Stdout:   "fake-stdout",
+						Stderr:   "fake-stderr",
+
+						PeakMemoryBytes: 4096,
+						CPUTime:         0.25,
+					},
+				}))
+			})
+
+			It("returns an error when parallel script fails", func() {
+				parallelScript.RunWithResultsReturns(map[string]boshscript.ScriptResult{}, errors.New("fake-error"))
+
+				_, err := act()
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-error"))
-				Expect(results).To(Equal(map[string]string{}))
+			})
+
+			It("runs parallel scripts that do not report results", func() {
+				cancellableScript := &fakescript.FakeCancellableScript{}
+				cancellableScript.RunReturns(errors.New("fake-error"))
+				fakeJobScriptProvider.NewParallelScriptReturns(cancellableScript)
+
+				results, err := act()
+				Expect(err).To(MatchError("fake-error"))
+				Expect(results).To(Equal(map[string]JobScriptResult{}))
+				Expect(cancellableScript.RunCallCount()).To(Equal(1))
+			})
+
+			Context("when timeout is specified", func() {
+				var (
+					cancelledCh chan struct{}
+				)
+
+				BeforeEach(func() {
+					cancelledCh = make(chan struct{})
+
+					parallelScript.CancelStub = func() error {
+						close(cancelledCh)
+						return nil
+					}
+
+					parallelScript.RunWithResultsStub = func() (map[string]boshscript.ScriptResult, error) {
+						<-cancelledCh
+						return map[string]boshscript.ScriptResult{
+							"fake-job-1": {Tag: "fake-job-1", Status: boshscript.ScriptStatusCancelled, ExitCode: -1},
+						}, errors.New("fake-cancelled-error")
+					}
+				})
+
+				It("cancels scripts that are still running after timeout", func() {
+					type runResult struct {
+						results map[string]JobScriptResult
+						err     error
+					}
+
+					resultCh := make(chan runResult)
+					go func() {
+						results, err := action.Run("run-me", map[string]interface{}{"timeout": float64(30)})
+						resultCh <- runResult{results, err}
+					}()
+
+					Eventually(timeService.WatcherCount).Should(Equal(1))
+					timeService.Increment(29 * time.Second)
+					Consistently(resultCh).ShouldNot(Receive())
+
+					timeService.Increment(1 * time.Second)
+
+					var result runResult
+					Eventually(resultCh).Should(Receive(&result))
+					Expect(result.err).To(HaveOccurred())
+					Expect(result.err.Error()).To(Equal("Running 'run-me' scripts timed out after 30s: fake-cancelled-error"))
+					Expect(result.results).To(Equal(map[string]JobScriptResult{
+						"fake-job-1": {Status: "timed_out", ExitCode: -1},
+					}))
+					Expect(parallelScript.CancelCallCount()).To(Equal(1))
+				})
+			})
+
+			It("returns error when timeout is not a number", func() {
+				_, err := action.Run("run-me", map[string]interface{}{"timeout": "fake-timeout"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected timeout to be a non-negative number of seconds"))
+				Expect(fakeJobScriptProvider.NewParallelScriptCallCount()).To(Equal(0))
 			})
 		})
 
@@ -103,8 +205,42 @@ var _ = Describe("RunScript", func() {
 				results, err := act()
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-spec-get-error"))
-				Expect(results).To(Equal(map[string]string{}))
+				Expect(results).To(Equal(map[string]JobScriptResult{}))
 			})
 		})
 	})
+
+	Describe("Cancel", func() {
+		It("cancels running scripts", func() {
+			parallelScript := &fakescript.FakeParallelReportingScript{}
+			fakeJobScriptProvider.NewParallelScriptReturns(parallelScript)
+
+			cancelledCh := make(chan struct{})
+			parallelScript.CancelStub = func() error {
+				close(cancelledCh)
+				return nil
+			}
+
+			parallelScript.RunWithResultsStub = func() (map[string]boshscript.ScriptResult, error) {
+				<-cancelledCh
+				return map[string]boshscript.ScriptResult{}, errors.New("fake-cancelled-error")
+			}
+
+			errCh := make(chan error)
+			go func() {
+				_, err := action.Run("run-me", map[string]interface{}{})
+				errCh <- err
+			}()
+
+			Expect(action.Cancel()).ToNot(HaveOccurred())
+
+			Eventually(errCh).Should(Receive(MatchError("fake-cancelled-error")))
+			Expect(parallelScript.CancelCallCount()).To(Equal(1))
+		})
+
+		It("allows to cancel action second time without returning an error", func() {
+			Expect(action.Cancel()).ToNot(HaveOccurred())
+			Expect(action.Cancel()).ToNot(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,162 @@
+package action
+
+import (
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	snapshotGuardThawRetryDelay    = 1 * time.Second
+	snapshotGuardMaxThawRetryDelay = 1 * time.Minute
+)
+
+type diskFreezer interface {
+	FreezePersistentDisk(mountPoint string) error
+	ThawPersistentDisk(mountPoint string) error
+}
+
+// SnapshotGuard keeps persistent disk frozen between prepare_snapshot
+// and finish_snapshot actions and makes sure that it gets thawed
+// even if finish_snapshot is never received.
+type SnapshotGuard struct {
+	diskFreezer diskFreezer
+	mountPoint  string
+	timeService clock.Clock
+
+	lock     *sync.Mutex
+	thawChan *chan struct{}
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewSnapshotGuard(
+	diskFreezer diskFreezer,
+	mountPoint string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) SnapshotGuard {
+	var thawChan chan struct{}
+
+	return SnapshotGuard{
+		diskFreezer: diskFreezer,
+		mountPoint:  mountPoint,
+		timeService: timeService,
+
+		lock:     &sync.Mutex{},
+		thawChan: &thawChan,
+
+		logTag: "SnapshotGuard",
+		logger: logger,
+	}
+}
+
+// Freeze freezes persistent disk and schedules it to be thawed after timeout.
+func (g SnapshotGuard) Freeze(timeout time.Duration) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if *g.thawChan != nil {
+		return bosherr.Errorf("Persistent disk mounted at %s is already frozen", g.mountPoint)
+	}
+
+	err := g.diskFreezer.FreezePersistentDisk(g.mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Freezing persistent disk")
+	}
+
+	thawChan := make(chan struct{})
+	*g.thawChan = thawChan
+
+	timer := g.timeService.NewTimer(timeout)
+
+	go func() {
+		select {
+		case <-timer.C():
+			g.logger.Error(g.logTag, "Snapshot was not finished within %s, thawing persistent disk", timeout)
+			g.thawAfterTimeout(thawChan)
+		case <-thawChan:
+			timer.Stop()
+		}
+	}()
+
+	return nil
+}
+
+// Thaw thaws persistent disk if it was previously frozen.
+func (g SnapshotGuard) Thaw() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if *g.thawChan == nil {
+		g.logger.Debug(g.logTag, "Persistent disk is not frozen, nothing to thaw")
+		return nil
+	}
+
+	return g.thaw()
+}
+
+// thawAfterTimeout keeps trying to thaw persistent disk with backoff
+// since writes to it block for as long as it stays frozen.
+// It stops once disk is thawed, either here or by explicit Thaw.
+func (g SnapshotGuard) thawAfterTimeout(thawChan chan struct{}) {
+	delay := snapshotGuardThawRetryDelay
+
+	for {
+		g.lock.Lock()
+
+		if *g.thawChan != thawChan {
+			g.lock.Unlock()
+			return
+		}
+
+		err := g.thaw()
+
+		g.lock.Unlock()
+
+		if err == nil {
+			return
+		}
+
+		g.logger.Error(g.logTag, "Failed to thaw persistent disk, retrying in %s: %s", delay, err.Error())
+
+		timer := g.timeService.NewTimer(delay)
+
+		select {
+		case <-timer.C():
+		case <-thawChan:
+			timer.Stop()
+			return
+		}
+
+		delay *= 2
+		if delay > snapshotGuardMaxThawRetryDelay {
+			delay = snapshotGuardMaxThawRetryDelay
+		}
+	}
+}
+
+// thaw must be called with lock held
+func (g SnapshotGuard) thaw() error {
+	err := g.diskFreezer.ThawPersistentDisk(g.mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Thawing persistent disk")
+	}
+
+	close(*g.thawChan)
+	*g.thawChan = nil
+
+	return nil
+}
+
+// IsFrozen returns true until persistent disk gets thawed.
+func (g SnapshotGuard) IsFrozen() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return *g.thawChan != nil
+}
@@ -0,0 +1,155 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("SnapshotGuard", func() {
+	var (
+		platform    *fakeplatform.FakePlatform
+		timeService *fakeclock.FakeClock
+		guard       SnapshotGuard
+	)
+
+	BeforeEach(func() {
+		platform = fakeplatform.NewFakePlatform()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		guard = NewSnapshotGuard(platform, "/var/vcap/store", timeService, logger)
+	})
+
+	Describe("Freeze", func() {
+		It("freezes persistent disk", func() {
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.FreezePersistentDiskMountPoints).To(Equal([]string{"/var/vcap/store"}))
+			Expect(guard.IsFrozen()).To(BeTrue())
+		})
+
+		It("thaws persistent disk after timeout", func() {
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Minute)
+
+			Eventually(guard.IsFrozen).Should(BeFalse())
+			Expect(platform.ThawPersistentDiskMountPoints).To(Equal([]string{"/var/vcap/store"}))
+		})
+
+		It("keeps retrying to thaw persistent disk with backoff when thawing after timeout fails", func() {
+			platform.ThawPersistentDiskErr = errors.New("fake-thaw-err")
+
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Minute)
+
+			Eventually(func() []string { return platform.ThawPersistentDiskMountPoints }).Should(HaveLen(1))
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Second)
+
+			Eventually(func() []string { return platform.ThawPersistentDiskMountPoints }).Should(HaveLen(2))
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			Expect(guard.IsFrozen()).To(BeTrue())
+
+			// Backoff doubles delay between attempts
+			timeService.Increment(time.Second)
+			Consistently(func() []string { return platform.ThawPersistentDiskMountPoints }).Should(HaveLen(2))
+
+			platform.ThawPersistentDiskErr = nil
+			timeService.Increment(time.Second)
+
+			Eventually(guard.IsFrozen).Should(BeFalse())
+			Expect(platform.ThawPersistentDiskMountPoints).To(HaveLen(3))
+			Eventually(timeService.WatcherCount).Should(Equal(0))
+		})
+
+		It("stops retrying to thaw persistent disk when it is thawed explicitly", func() {
+			platform.ThawPersistentDiskErr = errors.New("fake-thaw-err")
+
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Minute)
+
+			Eventually(func() []string { return platform.ThawPersistentDiskMountPoints }).Should(HaveLen(1))
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+
+			platform.ThawPersistentDiskErr = nil
+
+			err = guard.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(guard.IsFrozen()).To(BeFalse())
+
+			Eventually(timeService.WatcherCount).Should(Equal(0))
+			timeService.Increment(time.Hour)
+			Consistently(func() []string { return platform.ThawPersistentDiskMountPoints }).Should(HaveLen(2))
+		})
+
+		It("returns error if persistent disk is already frozen", func() {
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = guard.Freeze(time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already frozen"))
+			Expect(platform.FreezePersistentDiskMountPoints).To(HaveLen(1))
+		})
+
+		It("returns error if freezing fails", func() {
+			platform.FreezePersistentDiskErr = errors.New("fake-freeze-err")
+
+			err := guard.Freeze(time.Minute)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-freeze-err"))
+			Expect(guard.IsFrozen()).To(BeFalse())
+		})
+	})
+
+	Describe("Thaw", func() {
+		It("thaws frozen persistent disk only once", func() {
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = guard.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(guard.IsFrozen()).To(BeFalse())
+
+			timeService.Increment(time.Minute)
+
+			err = guard.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Consistently(func() []string { return platform.ThawPersistentDiskMountPoints }).Should(HaveLen(1))
+		})
+
+		It("does nothing if persistent disk is not frozen", func() {
+			err := guard.Thaw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.ThawPersistentDiskMountPoints).To(BeEmpty())
+		})
+
+		It("keeps persistent disk frozen if thawing fails", func() {
+			err := guard.Freeze(time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			platform.ThawPersistentDiskErr = errors.New("fake-thaw-err")
+
+			err = guard.Thaw()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+			Expect(guard.IsFrozen()).To(BeTrue())
+		})
+	})
+})
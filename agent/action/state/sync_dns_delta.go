@@ -0,0 +1,155 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// DNSRecordsDelta lists entries added to and removed from list fields
+// of a records blob (records, record_infos, srv_records, ...) since base version.
+// Fields that are not mentioned are kept as they are in the base blob.
+type DNSRecordsDelta struct {
+	Version     uint64                       `json:"version"`
+	BaseVersion uint64                       `json:"base_version"`
+	Added       map[string][]json.RawMessage `json:"added"`
+	Removed     map[string][]json.RawMessage `json:"removed"`
+}
+
+// ParseDNSRecordsDelta returns false when contents is a full records blob
+func ParseDNSRecordsDelta(contents []byte) (DNSRecordsDelta, bool, error) {
+	var fields map[string]json.RawMessage
+
+	err := json.Unmarshal(contents, &fields)
+	if err != nil {
+		return DNSRecordsDelta{}, false, bosherr.WrapError(err, "unmarshalling DNS records")
+	}
+
+	if _, found := fields["base_version"]; !found {
+		return DNSRecordsDelta{}, false, nil
+	}
+
+	var delta DNSRecordsDelta
+
+	err = json.Unmarshal(contents, &delta)
+	if err != nil {
+		return DNSRecordsDelta{}, false, bosherr.WrapError(err, "unmarshalling DNS records delta")
+	}
+
+	return delta, true, nil
+}
+
+// Apply returns a full records blob at delta version.
+// Removing entries that are not present in the base blob is an error
+// since it means that the base blob is not the one the delta was computed from.
+func (d DNSRecordsDelta) Apply(base []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+
+	err := json.Unmarshal(base, &doc)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "unmarshalling base DNS records")
+	}
+
+	for key := range doc {
+		if strings.EqualFold(key, "version") {
+			delete(doc, key)
+		}
+	}
+
+	fields := map[string]bool{}
+	for field := range d.Added {
+		fields[field] = true
+	}
+	for field := range d.Removed {
+		fields[field] = true
+	}
+
+	for field := range fields {
+		entries := []json.RawMessage{}
+
+		if raw, found := doc[field]; found {
+			err = json.Unmarshal(raw, &entries)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "unmarshalling base DNS records field '%s'", field)
+			}
+		}
+
+		entries, err = removeEntries(entries, d.Removed[field])
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "removing entries from '%s'", field)
+		}
+
+		entries = append(entries, d.Added[field]...)
+
+		doc[field], err = json.Marshal(entries)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "marshalling DNS records field '%s'", field)
+		}
+	}
+
+	doc["version"], err = json.Marshal(d.Version)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "marshalling DNS records version")
+	}
+
+	return json.Marshal(doc)
+}
+
+// removeEntries removes one occurrence per removed entry so that
+// lists kept parallel to each other (records and record_infos) stay aligned
+func removeEntries(entries, removed []json.RawMessage) ([]json.RawMessage, error) {
+	pending := map[string]int{}
+
+	for _, entry := range removed {
+		key, err := canonicalJSON(entry)
+		if err != nil {
+			return nil, err
+		}
+		pending[key]++
+	}
+
+	kept := []json.RawMessage{}
+
+	for _, entry := range entries {
+		key, err := canonicalJSON(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if pending[key] > 0 {
+			pending[key]--
+			continue
+		}
+
+		kept = append(kept, entry)
+	}
+
+	for key, count := range pending {
+		if count > 0 {
+			return nil, bosherr.Errorf("entry %s is not present", key)
+		}
+	}
+
+	return kept, nil
+}
+
+func canonicalJSON(raw json.RawMessage) (string, error) {
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	err := decoder.Decode(&value)
+	if err != nil {
+		return "", bosherr.WrapError(err, "unmarshalling DNS records entry")
+	}
+
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return "", bosherr.WrapError(err, "marshalling DNS records entry")
+	}
+
+	return string(canonical), nil
+}
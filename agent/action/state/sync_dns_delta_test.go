@@ -0,0 +1,127 @@
+package state_test
+
+import (
+	. "github.com/cloudfoundry/bosh-agent/agent/action/state"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DNSRecordsDelta", func() {
+	var base []byte
+
+	BeforeEach(func() {
+		base = []byte(`{
+			"Version": 4,
+			"records": [
+				["10.0.0.1", "web-0.bosh"],
+				["10.0.0.2", "web-1.bosh"]
+			],
+			"record_keys": ["id", "ip"],
+			"record_infos": [
+				["id-0", "10.0.0.1"],
+				["id-1", "10.0.0.2"]
+			]
+		}`)
+	})
+
+	Describe("ParseDNSRecordsDelta", func() {
+		It("parses blob with base version as delta", func() {
+			delta, isDelta, err := ParseDNSRecordsDelta([]byte(`{
+				"version": 5,
+				"base_version": 4,
+				"added": {"records": [["10.0.0.3", "web-2.bosh"]]}
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDelta).To(BeTrue())
+			Expect(delta.Version).To(Equal(uint64(5)))
+			Expect(delta.BaseVersion).To(Equal(uint64(4)))
+			Expect(delta.Added).To(HaveKey("records"))
+		})
+
+		It("does not treat full blob as delta", func() {
+			_, isDelta, err := ParseDNSRecordsDelta(base)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDelta).To(BeFalse())
+		})
+
+		It("returns error when blob is not JSON", func() {
+			_, _, err := ParseDNSRecordsDelta([]byte("hot-trash"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unmarshalling DNS records"))
+		})
+	})
+
+	Describe("Apply", func() {
+		It("adds and removes entries keeping other fields", func() {
+			delta, _, err := ParseDNSRecordsDelta([]byte(`{
+				"version": 5,
+				"base_version": 4,
+				"added": {
+					"records": [["10.0.0.3", "web-2.bosh"]],
+					"record_infos": [["id-2", "10.0.0.3"]],
+					"srv_records": [{"name": "_http._tcp.web.bosh", "target": "web-2.bosh", "port": 80}]
+				},
+				"removed": {
+					"records": [["10.0.0.1", "web-0.bosh"]],
+					"record_infos": [["id-0", "10.0.0.1"]]
+				}
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := delta.Apply(base)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(MatchJSON(`{
+				"version": 5,
+				"records": [
+					["10.0.0.2", "web-1.bosh"],
+					["10.0.0.3", "web-2.bosh"]
+				],
+				"record_keys": ["id", "ip"],
+				"record_infos": [
+					["id-1", "10.0.0.2"],
+					["id-2", "10.0.0.3"]
+				],
+				"srv_records": [{"name": "_http._tcp.web.bosh", "target": "web-2.bosh", "port": 80}]
+			}`))
+		})
+
+		It("removes entries regardless of JSON formatting", func() {
+			delta, _, err := ParseDNSRecordsDelta([]byte(`{
+				"version": 5,
+				"base_version": 4,
+				"removed": {"records": [[ "10.0.0.1" ,"web-0.bosh" ]]}
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := delta.Apply(base)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(ContainSubstring(`"records":[["10.0.0.2","web-1.bosh"]]`))
+		})
+
+		It("returns error when removed entry is not present", func() {
+			delta, _, err := ParseDNSRecordsDelta([]byte(`{
+				"version": 5,
+				"base_version": 4,
+				"removed": {"records": [["10.0.0.9", "web-9.bosh"]]}
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = delta.Apply(base)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`removing entries from 'records': entry ["10.0.0.9","web-9.bosh"] is not present`))
+		})
+
+		It("returns error when changed field is not a list", func() {
+			delta, _, err := ParseDNSRecordsDelta([]byte(`{
+				"version": 5,
+				"base_version": 4,
+				"added": {"record_keys": ["az"]}
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = delta.Apply([]byte(`{"version": 4, "record_keys": "id"}`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unmarshalling base DNS records field 'record_keys'"))
+		})
+	})
+})
@@ -64,6 +64,25 @@ func (s SyncDNSState) NeedsUpdate(newVersion uint64) bool {
 	return version < newVersion
 }
 
+// LoadState returns the records blob saved by the last successful sync
+func (s SyncDNSState) LoadState() ([]byte, error) {
+	contents, err := s.fs.ReadFile(s.path)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "reading state file")
+	}
+
+	return contents, nil
+}
+
+// Version returns the version of the records blob saved by the last successful sync
+func (s SyncDNSState) Version() (uint64, error) {
+	if !s.fs.FileExists(s.path) {
+		return 0, bosherr.Error("state file does not exist")
+	}
+
+	return s.loadVersion()
+}
+
 func (s SyncDNSState) loadVersion() (uint64, error) {
 	contents, err := s.fs.ReadFile(s.path)
 	if err != nil {
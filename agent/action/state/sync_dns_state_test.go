@@ -137,6 +137,22 @@ var _ = Describe("SyncDNSState", func() {
 		})
 	})
 
+	Describe("#Version", func() {
+		It("returns version of the saved state", func() {
+			err = fakeFileSystem.WriteFile(path, localDNSState)
+			Expect(err).ToNot(HaveOccurred())
+
+			version, err := syncDNSState.Version()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(uint64(1234)))
+		})
+
+		It("returns error when state file does not exist", func() {
+			_, err = syncDNSState.Version()
+			Expect(err).To(MatchError("state file does not exist"))
+		})
+	})
+
 	Describe("#NeedsUpdate", func() {
 		It("returns true when state file does not exist", func() {
 			Expect(syncDNSState.NeedsUpdate(0)).To(BeTrue())
@@ -2,18 +2,22 @@ package action
 
 import (
 	"errors"
+	"time"
 
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type StopAction struct {
 	jobSupervisor boshjobsuper.JobSupervisor
+	specService   boshas.V1Service
 }
 
-func NewStop(jobSupervisor boshjobsuper.JobSupervisor) (stop StopAction) {
+func NewStop(jobSupervisor boshjobsuper.JobSupervisor, specService boshas.V1Service) (stop StopAction) {
 	stop = StopAction{
 		jobSupervisor: jobSupervisor,
+		specService:   specService,
 	}
 	return
 }
@@ -30,20 +34,69 @@ func (a StopAction) IsLoggable() bool {
 	return true
 }
 
-func (a StopAction) Run(protocolVersion ProtocolVersion) (value string, err error) {
-	if protocolVersion > 2 {
-		err = a.jobSupervisor.StopAndWait()
-	} else {
-		err = a.jobSupervisor.Stop()
+// StopRequest is optional so that directors that expect "stopped" keep working
+type StopRequest struct {
+	// When set, stop result names services that exited on their own
+	// and services that had to be killed instead of just "stopped"
+	DetailedResult bool `json:"detailed_result"`
+}
+
+func (a StopAction) Run(protocolVersion ProtocolVersion, requests ...StopRequest) (interface{}, error) {
+	if protocolVersion <= 2 {
+		err := a.jobSupervisor.Stop()
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Stopping Monitored Services")
+		}
+
+		return "stopped", nil
 	}
 
+	currentSpec, err := a.specService.Get()
 	if err != nil {
-		err = bosherr.WrapError(err, "Stopping Monitored Services")
-		return
+		return nil, bosherr.WrapError(err, "Getting current spec")
 	}
 
-	value = "stopped"
-	return
+	result, err := a.jobSupervisor.StopAndWait(a.stopOptions(currentSpec))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Stopping Monitored Services")
+	}
+
+	if len(requests) > 0 && requests[0].DetailedResult {
+		return result, nil
+	}
+
+	return "stopped", nil
+}
+
+// stopOptions stops jobs in order of their stop order. Jobs with the same
+// stop order are stopped in reverse order of templates so that jobs
+// are stopped before jobs listed ahead of them.
+func (a StopAction) stopOptions(spec boshas.V1ApplySpec) boshjobsuper.StopOptions {
+	options := boshjobsuper.StopOptions{}
+
+	templates := spec.JobSpec.JobTemplateSpecs
+	ordered := []boshas.JobTemplateSpec{}
+
+	for i := len(templates) - 1; i >= 0; i-- {
+		// Insert after all templates with lower or the same stop order
+		j := len(ordered)
+		for j > 0 && ordered[j-1].StopOrder > templates[i].StopOrder {
+			j--
+		}
+
+		ordered = append(ordered, boshas.JobTemplateSpec{})
+		copy(ordered[j+1:], ordered[j:])
+		ordered[j] = templates[i]
+	}
+
+	for _, template := range ordered {
+		options.Jobs = append(options.Jobs, boshjobsuper.JobStopOptions{
+			Name:    template.Name,
+			Timeout: time.Duration(template.StopTimeout) * time.Second,
+		})
+	}
+
+	return options
 }
 
 func (a StopAction) Resume() (interface{}, error) {
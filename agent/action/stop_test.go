@@ -1,10 +1,16 @@
 package action_test
 
 import (
+	"errors"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 )
 
@@ -12,12 +18,14 @@ func init() {
 	Describe("Stop", func() {
 		var (
 			jobSupervisor *fakejobsuper.FakeJobSupervisor
+			specService   *fakeas.FakeV1Service
 			action        StopAction
 		)
 
 		BeforeEach(func() {
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-			action = NewStop(jobSupervisor)
+			specService = fakeas.NewFakeV1Service()
+			action = NewStop(jobSupervisor, specService)
 		})
 
 		AssertActionIsAsynchronous(action)
@@ -45,10 +53,105 @@ func init() {
 			Expect(jobSupervisor.StoppedAndWaited).ToNot(BeTrue())
 		})
 
-		It("stops and waits when protocol version is greater than 2", func() {
-			_, err := action.Run(ProtocolVersion(3))
-			Expect(err).ToNot(HaveOccurred())
-			Expect(jobSupervisor.StoppedAndWaited).To(BeTrue())
+		It("returns error when stopping fails", func() {
+			jobSupervisor.StopErr = errors.New("fake-stop-error")
+
+			_, err := action.Run(ProtocolVersion(2))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-stop-error"))
+		})
+
+		Context("when protocol version is greater than 2", func() {
+			It("stops and waits", func() {
+				_, err := action.Run(ProtocolVersion(3))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(jobSupervisor.StoppedAndWaited).To(BeTrue())
+			})
+
+			It("stops jobs in reverse order of templates with their stop timeouts", func() {
+				specService.Spec = boshas.V1ApplySpec{
+					JobSpec: boshas.JobSpec{
+						JobTemplateSpecs: []boshas.JobTemplateSpec{
+							{Name: "fake-job-1"},
+							{Name: "fake-job-2", StopTimeout: 30},
+						},
+					},
+				}
+
+				_, err := action.Run(ProtocolVersion(3))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(jobSupervisor.StopAndWaitOptions).To(Equal(boshjobsuper.StopOptions{
+					Jobs: []boshjobsuper.JobStopOptions{
+						{Name: "fake-job-2", Timeout: 30 * time.Second},
+						{Name: "fake-job-1"},
+					},
+				}))
+			})
+
+			It("stops jobs in order of their stop order before falling back to reverse order of templates", func() {
+				specService.Spec = boshas.V1ApplySpec{
+					JobSpec: boshas.JobSpec{
+						JobTemplateSpecs: []boshas.JobTemplateSpec{
+							{Name: "fake-job-1", StopOrder: 2},
+							{Name: "fake-job-2"},
+							{Name: "fake-job-3", StopOrder: 1},
+							{Name: "fake-job-4"},
+							{Name: "fake-job-5", StopOrder: 1},
+						},
+					},
+				}
+
+				_, err := action.Run(ProtocolVersion(3))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(jobSupervisor.StopAndWaitOptions).To(Equal(boshjobsuper.StopOptions{
+					Jobs: []boshjobsuper.JobStopOptions{
+						{Name: "fake-job-4"},
+						{Name: "fake-job-2"},
+						{Name: "fake-job-5"},
+						{Name: "fake-job-3"},
+						{Name: "fake-job-1"},
+					},
+				}))
+			})
+
+			It("returns stopped", func() {
+				jobSupervisor.StopAndWaitResult = boshjobsuper.StopResult{
+					Stopped: []string{"fake-service-1"},
+					Killed:  []string{"fake-service-2"},
+				}
+
+				result, err := action.Run(ProtocolVersion(3))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal("stopped"))
+			})
+
+			It("returns stop result naming killed services when detailed result is requested", func() {
+				jobSupervisor.StopAndWaitResult = boshjobsuper.StopResult{
+					Stopped: []string{"fake-service-1"},
+					Killed:  []string{"fake-service-2"},
+				}
+
+				result, err := action.Run(ProtocolVersion(3), StopRequest{DetailedResult: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(jobSupervisor.StopAndWaitResult))
+			})
+
+			It("returns error when current spec cannot be loaded", func() {
+				specService.GetErr = errors.New("fake-get-error")
+
+				_, err := action.Run(ProtocolVersion(3))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+				Expect(jobSupervisor.StoppedAndWaited).To(BeFalse())
+			})
+
+			It("returns error when stopping fails", func() {
+				jobSupervisor.StopErr = errors.New("fake-stop-error")
+
+				_, err := action.Run(ProtocolVersion(3))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-stop-error"))
+			})
 		})
 	})
 }
@@ -60,28 +60,40 @@ func (a SyncDNS) Cancel() error {
 	return errors.New("not supported")
 }
 
-func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, version uint64) (string, error) {
-	if !a.needsUpdateWithLock(version) {
-		return "synced", nil
-	}
+// SyncDNSFullBlob is synced instead of a delta blob
+// that does not apply on top of the local DNS records
+type SyncDNSFullBlob struct {
+	BlobID      string                    `json:"blob_id"`
+	MultiDigest boshcrypto.MultipleDigest `json:"multi_digest"`
+}
 
-	filePath, err := a.blobstore.Get(blobID, multiDigest)
+type SyncDNSResult struct {
+	Status      string `json:"status"`
+	Version     uint64 `json:"version"`
+	RecordCount int    `json:"record_count"`
+}
+
+// Run syncs either a full records blob or a delta blob
+// containing changes since the local version.
+func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, version uint64, fullBlobs ...SyncDNSFullBlob) (SyncDNSResult, error) {
+	result, err := a.sync(blobID, multiDigest, version, fullBlobs)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "getting %s from blobstore", blobID)
+		return SyncDNSResult{}, err
 	}
 
-	fs := a.platform.GetFs()
+	a.logger.Info(a.logTag, "DNS records are at version %d with %d records", result.Version, result.RecordCount)
 
-	defer func() {
-		err = fs.RemoveAll(filePath)
-		if err != nil {
-			a.logger.Error(a.logTag, fmt.Sprintf("Failed to remove dns blob file at path '%s'", filePath))
-		}
-	}()
+	return result, nil
+}
 
-	contents, err := fs.ReadFile(filePath)
+func (a SyncDNS) sync(blobID string, multiDigest boshcrypto.MultipleDigest, version uint64, fullBlobs []SyncDNSFullBlob) (SyncDNSResult, error) {
+	if !a.needsUpdateWithLock(version) {
+		return a.currentResultWithLock()
+	}
+
+	contents, err := a.readBlob(blobID, multiDigest)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "reading %s from blobstore", filePath)
+		return SyncDNSResult{}, err
 	}
 
 	a.lock.Lock()
@@ -89,29 +101,139 @@ func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, versi
 
 	syncDNSState := a.createSyncDNSState()
 	if !syncDNSState.NeedsUpdate(version) {
-		return "synced", nil
+		return a.currentResult(syncDNSState)
+	}
+
+	delta, isDelta, err := state.ParseDNSRecordsDelta(contents)
+	if err != nil {
+		return SyncDNSResult{}, err
+	}
+
+	if isDelta {
+		contents, err = a.applyDelta(syncDNSState, delta)
+		if err != nil {
+			if len(fullBlobs) == 0 {
+				return SyncDNSResult{}, bosherr.WrapError(err, "applying DNS records delta")
+			}
+
+			a.logger.Info(a.logTag, "Falling back to full DNS records blob: %s", err.Error())
+
+			contents, err = a.readFullBlob(fullBlobs[0])
+			if err != nil {
+				return SyncDNSResult{}, err
+			}
+		}
 	}
 
 	dnsRecords := boshsettings.DNSRecords{}
 	if err := json.Unmarshal(contents, &dnsRecords); err != nil {
-		return "", bosherr.WrapError(err, "unmarshalling DNS records")
+		return SyncDNSResult{}, bosherr.WrapError(err, "unmarshalling DNS records")
 	}
 
 	if dnsRecords.Version != version {
-		return "", bosherr.Error("version from unpacked dns blob does not match version supplied by director")
+		return SyncDNSResult{}, bosherr.Error("version from unpacked dns blob does not match version supplied by director")
 	}
 
 	err = a.platform.SaveDNSRecords(dnsRecords, a.settingsService.GetSettings().AgentID)
 	if err != nil {
-		return "", bosherr.WrapError(err, "saving DNS records")
+		return SyncDNSResult{}, bosherr.WrapError(err, "saving DNS records")
 	}
 
 	err = syncDNSState.SaveState(contents)
 	if err != nil {
-		return "", bosherr.WrapError(err, "saving local DNS state")
+		return SyncDNSResult{}, bosherr.WrapError(err, "saving local DNS state")
+	}
+
+	return newSyncDNSResult(dnsRecords), nil
+}
+
+func (a SyncDNS) applyDelta(syncDNSState state.SyncDNSState, delta state.DNSRecordsDelta) ([]byte, error) {
+	localVersion, err := syncDNSState.Version()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "loading local DNS state version")
+	}
+
+	if localVersion != delta.BaseVersion {
+		return nil, bosherr.Errorf("delta base version %d does not match local version %d", delta.BaseVersion, localVersion)
+	}
+
+	base, err := syncDNSState.LoadState()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "loading local DNS state")
+	}
+
+	return delta.Apply(base)
+}
+
+func (a SyncDNS) readFullBlob(fullBlob SyncDNSFullBlob) ([]byte, error) {
+	contents, err := a.readBlob(fullBlob.BlobID, fullBlob.MultiDigest)
+	if err != nil {
+		return nil, err
 	}
 
-	return "synced", nil
+	_, isDelta, err := state.ParseDNSRecordsDelta(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDelta {
+		return nil, bosherr.Errorf("full dns blob %s contains a delta", fullBlob.BlobID)
+	}
+
+	return contents, nil
+}
+
+func (a SyncDNS) readBlob(blobID string, multiDigest boshcrypto.MultipleDigest) ([]byte, error) {
+	filePath, err := a.blobstore.Get(blobID, multiDigest)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "getting %s from blobstore", blobID)
+	}
+
+	fs := a.platform.GetFs()
+
+	defer func() {
+		err = fs.RemoveAll(filePath)
+		if err != nil {
+			a.logger.Error(a.logTag, fmt.Sprintf("Failed to remove dns blob file at path '%s'", filePath))
+		}
+	}()
+
+	contents, err := fs.ReadFile(filePath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "reading %s from blobstore", filePath)
+	}
+
+	return contents, nil
+}
+
+func (a SyncDNS) currentResultWithLock() (SyncDNSResult, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.currentResult(a.createSyncDNSState())
+}
+
+// currentResult describes records saved by the last successful sync
+func (a SyncDNS) currentResult(syncDNSState state.SyncDNSState) (SyncDNSResult, error) {
+	contents, err := syncDNSState.LoadState()
+	if err != nil {
+		return SyncDNSResult{}, bosherr.WrapError(err, "loading local DNS state")
+	}
+
+	dnsRecords := boshsettings.DNSRecords{}
+	if err := json.Unmarshal(contents, &dnsRecords); err != nil {
+		return SyncDNSResult{}, bosherr.WrapError(err, "unmarshalling local DNS state")
+	}
+
+	return newSyncDNSResult(dnsRecords), nil
+}
+
+func newSyncDNSResult(dnsRecords boshsettings.DNSRecords) SyncDNSResult {
+	return SyncDNSResult{
+		Status:      "synced",
+		Version:     dnsRecords.Version,
+		RecordCount: len(dnsRecords.Records) + len(dnsRecords.SRVRecords),
+	}
 }
 
 func (a SyncDNS) createSyncDNSState() state.SyncDNSState {
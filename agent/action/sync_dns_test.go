@@ -88,8 +88,9 @@ var _ = Describe("SyncDNS", func() {
 				})
 
 				It("returns with no error and does no writes and no gets to blobstore", func() {
-					_, err := action.Run("fake-blobstore-id", multiDigest, 2)
+					response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2}))
 				})
 			})
 
@@ -101,9 +102,10 @@ var _ = Describe("SyncDNS", func() {
 					fakeFileSystem.WriteFileError = errors.New("fake-write-error")
 				})
 
-				It("returns error", func() {
-					_, err := action.Run("fake-blobstore-id", multiDigest, 2)
+				It("reports the local version", func() {
+					response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 3}))
 				})
 			})
 		})
@@ -125,7 +127,7 @@ var _ = Describe("SyncDNS", func() {
 				It("accesses the blobstore and fetches DNS records", func() {
 					response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 					Expect(err).ToNot(HaveOccurred())
-					Expect(response).To(Equal("synced"))
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 					Expect(fakeBlobstore.GetCallCount()).To(Equal(1))
 					blobID, fingerPrint := fakeBlobstore.GetArgsForCall(0)
@@ -136,7 +138,7 @@ var _ = Describe("SyncDNS", func() {
 				It("reads the DNS records from the blobstore file", func() {
 					response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 					Expect(err).ToNot(HaveOccurred())
-					Expect(response).To(Equal("synced"))
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 					Expect(fakeFileSystem.ReadFileError).ToNot(HaveOccurred())
 				})
@@ -146,7 +148,7 @@ var _ = Describe("SyncDNS", func() {
 
 					response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 					Expect(err).To(HaveOccurred())
-					Expect(response).To(Equal(""))
+					Expect(response).To(Equal(SyncDNSResult{}))
 					Expect(err.Error()).To(ContainSubstring("reading fake-blobstore-file-path from blobstore"))
 					Expect(fakeFileSystem.FileExists("fake-blobstore-file-path")).To(BeFalse())
 				})
@@ -176,7 +178,7 @@ var _ = Describe("SyncDNS", func() {
 				It("saves DNS records to the platform", func() {
 					response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 					Expect(err).ToNot(HaveOccurred())
-					Expect(response).To(Equal("synced"))
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 					Expect(fakePlatform.SaveDNSRecordsError).To(BeNil())
 					Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
@@ -199,7 +201,7 @@ var _ = Describe("SyncDNS", func() {
 
 						response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 						Expect(err).ToNot(HaveOccurred())
-						Expect(response).To(Equal("synced"))
+						Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 						Expect(fakePlatform.SaveDNSRecordsError).To(BeNil())
 						Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
@@ -223,7 +225,7 @@ var _ = Describe("SyncDNS", func() {
 					It("saves DNS records to the platform", func() {
 						response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 						Expect(err).ToNot(HaveOccurred())
-						Expect(response).To(Equal("synced"))
+						Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 						Expect(fakePlatform.SaveDNSRecordsError).To(BeNil())
 						Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
@@ -245,7 +247,7 @@ var _ = Describe("SyncDNS", func() {
 					It("saves DNS records to the platform", func() {
 						response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 						Expect(err).ToNot(HaveOccurred())
-						Expect(response).To(Equal("synced"))
+						Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 						Expect(fakePlatform.SaveDNSRecordsError).To(BeNil())
 						Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
@@ -270,7 +272,7 @@ var _ = Describe("SyncDNS", func() {
 
 							response, err := action.Run("fake-blobstore-id", multiDigest, 2)
 							Expect(err).ToNot(HaveOccurred())
-							Expect(response).To(Equal("synced"))
+							Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
 
 							contents, err := fakeFileSystem.ReadFile(stateFilePath)
 							Expect(err).ToNot(HaveOccurred())
@@ -377,5 +379,145 @@ var _ = Describe("SyncDNS", func() {
 				})
 			})
 		})
+
+		Context("when blobstore contains a DNS records delta", func() {
+			var fullBlob SyncDNSFullBlob
+
+			BeforeEach(func() {
+				err := fakeFileSystem.WriteFileString(stateFilePath, `{
+					"version": 1,
+					"records": [["fake-ip0", "fake-name0"]],
+					"record_keys": ["id", "ip"],
+					"record_infos": [["id-0", "fake-ip0"]]
+				}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = fakeFileSystem.WriteFileString("fake-delta-file-path", `{
+					"version": 2,
+					"base_version": 1,
+					"added": {
+						"records": [["fake-ip1", "fake-name1"]],
+						"record_infos": [["id-1", "fake-ip1"]]
+					}
+				}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeBlobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+					if blobID == "fake-full-blobstore-id" {
+						return "fake-blobstore-file-path", nil
+					}
+					return "fake-delta-file-path", nil
+				}
+
+				fullBlob = SyncDNSFullBlob{BlobID: "fake-full-blobstore-id", MultiDigest: multiDigest}
+			})
+
+			It("applies the delta on top of the local DNS state", func() {
+				response, err := action.Run("fake-delta-blobstore-id", multiDigest, 2, fullBlob)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
+
+				Expect(fakeBlobstore.GetCallCount()).To(Equal(1))
+				Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
+					Version: 2,
+					Records: [][2]string{
+						{"fake-ip0", "fake-name0"},
+						{"fake-ip1", "fake-name1"},
+					},
+				}))
+
+				contents, err := fakeFileSystem.ReadFile(stateFilePath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(MatchJSON(`{
+					"version": 2,
+					"records": [["fake-ip0", "fake-name0"], ["fake-ip1", "fake-name1"]],
+					"record_keys": ["id", "ip"],
+					"record_infos": [["id-0", "fake-ip0"], ["id-1", "fake-ip1"]]
+				}`))
+			})
+
+			It("reports version and record count for callers that do not provide a full blob", func() {
+				response, err := action.Run("fake-delta-blobstore-id", multiDigest, 2)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
+			})
+
+			It("reports the local version when already synced", func() {
+				err := fakeFileSystem.WriteFileString(stateFilePath, `{"version": 2, "records": [["fake-ip0", "fake-name0"]]}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				response, err := action.Run("fake-delta-blobstore-id", multiDigest, 2, fullBlob)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 1}))
+				Expect(fakeBlobstore.GetCallCount()).To(Equal(0))
+			})
+
+			Context("when the delta base version does not match the local version", func() {
+				BeforeEach(func() {
+					err := fakeFileSystem.WriteFileString(stateFilePath, `{"version": 0, "records": []}`)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("falls back to the full blob", func() {
+					response, err := action.Run("fake-delta-blobstore-id", multiDigest, 2, fullBlob)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
+
+					Expect(fakeBlobstore.GetCallCount()).To(Equal(2))
+					blobID, _ := fakeBlobstore.GetArgsForCall(1)
+					Expect(blobID).To(Equal("fake-full-blobstore-id"))
+
+					contents, err := fakeFileSystem.ReadFile(stateFilePath)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(contents).To(MatchJSON(fakeDNSRecordsString))
+				})
+
+				It("returns an error when there is no full blob to fall back to", func() {
+					_, err := action.Run("fake-delta-blobstore-id", multiDigest, 2)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("applying DNS records delta: delta base version 1 does not match local version 0"))
+				})
+
+				It("returns an error when the full blob is a delta", func() {
+					err := fakeFileSystem.WriteFileString("fake-blobstore-file-path", `{"version": 2, "base_version": 0}`)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = action.Run("fake-delta-blobstore-id", multiDigest, 2, fullBlob)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("full dns blob fake-full-blobstore-id contains a delta"))
+				})
+			})
+
+			Context("when there is no local DNS state", func() {
+				BeforeEach(func() {
+					err := fakeFileSystem.RemoveAll(stateFilePath)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("falls back to the full blob", func() {
+					response, err := action.Run("fake-delta-blobstore-id", multiDigest, 2, fullBlob)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal(SyncDNSResult{Status: "synced", Version: 2, RecordCount: 2}))
+				})
+			})
+
+			Context("when the delta removes records that are not present locally", func() {
+				BeforeEach(func() {
+					err := fakeFileSystem.WriteFileString("fake-delta-file-path", `{
+						"version": 2,
+						"base_version": 1,
+						"removed": {"records": [["fake-ip9", "fake-name9"]]}
+					}`)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("falls back to the full blob", func() {
+					_, err := action.Run("fake-delta-blobstore-id", multiDigest, 2, fullBlob)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeBlobstore.GetCallCount()).To(Equal(2))
+				})
+			})
+		})
 	})
 })
@@ -65,6 +65,11 @@ func (a UpdateSettingsAction) Run(newUpdateSettings boshsettings.UpdateSettings)
 		return "", err
 	}
 
+	err = a.trustedCertManager.UpdateBundles(newUpdateSettings.CertBundles)
+	if err != nil {
+		return "", err
+	}
+
 	updateSettingsJSON, err := json.Marshal(newUpdateSettings)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Marshalling updateSettings json")
@@ -83,6 +83,30 @@ var _ = Describe("UpdateSettings", func() {
 		})
 	})
 
+	Context("when updating the certificate bundles fails", func() {
+		BeforeEach(func() {
+			certManager.UpdateBundlesReturns(errors.New("fake-bundles-err"))
+		})
+
+		It("returns the error", func() {
+			result, err := action.Run(newUpdateSettings)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-bundles-err"))
+			Expect(result).To(BeEmpty())
+		})
+	})
+
+	It("updates trusted certificates and certificate bundles", func() {
+		newUpdateSettings.TrustedCerts = "fake-trusted-certs"
+		newUpdateSettings.CertBundles = map[string]string{"uaa": "fake-uaa-certs"}
+
+		_, err := action.Run(newUpdateSettings)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(certManager.UpdateCertificatesArgsForCall(0)).To(Equal("fake-trusted-certs"))
+		Expect(certManager.UpdateBundlesArgsForCall(0)).To(Equal(map[string]string{"uaa": "fake-uaa-certs"}))
+	})
+
 	It("loads settings", func() {
 		_, err := action.Run(newUpdateSettings)
 		Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,60 @@
+package action
+
+import (
+	"errors"
+
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type VerifyBundlesAction struct {
+	bundleVerifier boshbc.Verifier
+}
+
+type VerifyBundlesResult struct {
+	Drifted bool                        `json:"drifted"`
+	Bundles []boshbc.BundleVerification `json:"bundles"`
+}
+
+func NewVerifyBundles(bundleVerifier boshbc.Verifier) (action VerifyBundlesAction) {
+	action.bundleVerifier = bundleVerifier
+	return
+}
+
+// Digests of all installed files are recalculated which may take a while
+func (a VerifyBundlesAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a VerifyBundlesAction) IsPersistent() bool {
+	return false
+}
+
+func (a VerifyBundlesAction) IsLoggable() bool {
+	return true
+}
+
+func (a VerifyBundlesAction) Run() (VerifyBundlesResult, error) {
+	bundles, err := a.bundleVerifier.Verify()
+	if err != nil {
+		return VerifyBundlesResult{}, bosherr.WrapError(err, "Verifying installed bundles")
+	}
+
+	result := VerifyBundlesResult{Bundles: bundles}
+
+	for _, bundle := range bundles {
+		if bundle.HasDrift() {
+			result.Drifted = true
+		}
+	}
+
+	return result, nil
+}
+
+func (a VerifyBundlesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a VerifyBundlesAction) Cancel() error {
+	return errors.New("not supported")
+}
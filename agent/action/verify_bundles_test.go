@@ -0,0 +1,66 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
+)
+
+var _ = Describe("VerifyBundlesAction", func() {
+	var (
+		bundleVerifier *fakebc.FakeVerifier
+		action         VerifyBundlesAction
+	)
+
+	BeforeEach(func() {
+		bundleVerifier = &fakebc.FakeVerifier{}
+		action = NewVerifyBundles(bundleVerifier)
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("returns verification results of all bundles", func() {
+			bundleVerifier.VerifyResults = []boshbc.BundleVerification{
+				{Path: "/fake-job"},
+				{Path: "/fake-package", BundleDrift: boshbc.BundleDrift{Modified: []string{"fake-file"}}},
+			}
+
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(VerifyBundlesResult{
+				Drifted: true,
+				Bundles: bundleVerifier.VerifyResults,
+			}))
+		})
+
+		It("does not report drift when bundles are intact or cannot be verified", func() {
+			bundleVerifier.VerifyResults = []boshbc.BundleVerification{
+				{Path: "/fake-job"},
+				{Path: "/fake-package", Error: "bundle manifest is not recorded"},
+			}
+
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Drifted).To(BeFalse())
+		})
+
+		It("returns error when bundles cannot be verified", func() {
+			bundleVerifier.VerifyErr = errors.New("fake-verify-err")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-verify-err"))
+		})
+	})
+})
@@ -1,15 +1,19 @@
 package agent
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/pivotal-golang/clock"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshsyslog "github.com/cloudfoundry/bosh-agent/syslog"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -19,8 +23,49 @@ import (
 
 const (
 	agentLogTag = "agent"
+
+	defaultTimeDriftAlertThreshold = time.Second
+
+	defaultCertExpiryAlertThresholdDays = 30
 )
 
+type TimeDriftOptions struct {
+	// Drift alert is sent when absolute NTP offset exceeds threshold; defaults to 1s when not set
+	AlertThresholdMilliseconds int
+}
+
+func (o TimeDriftOptions) AlertThreshold() time.Duration {
+	if o.AlertThresholdMilliseconds <= 0 {
+		return defaultTimeDriftAlertThreshold
+	}
+	return time.Duration(o.AlertThresholdMilliseconds) * time.Millisecond
+}
+
+type CertExpiryOptions struct {
+	// Expiry alert is sent for trusted and mbus certificates expiring within threshold; defaults to 30 days when not set
+	AlertThresholdDays int
+}
+
+func (o CertExpiryOptions) AlertThreshold() time.Duration {
+	days := o.AlertThresholdDays
+	if days <= 0 {
+		days = defaultCertExpiryAlertThresholdDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// certExpiryState is shared between heartbeats so that alert is only sent once per certificate
+type certExpiryState struct {
+	threshold time.Duration
+	alerted   map[string]bool
+}
+
+// timeDriftState is shared between heartbeats so that alert is only sent once per drift
+type timeDriftState struct {
+	threshold time.Duration
+	alerted   bool
+}
+
 type Agent struct {
 	logger            boshlog.Logger
 	mbusHandler       boshhandler.Handler
@@ -33,6 +78,9 @@ type Agent struct {
 	settingsService   boshsettings.Service
 	uuidGenerator     boshuuid.Generator
 	timeService       clock.Clock
+	bundleDrift       boshbc.DriftMonitor
+	timeDrift         *timeDriftState
+	certExpiry        *certExpiryState
 }
 
 func New(
@@ -47,6 +95,9 @@ func New(
 	settingsService boshsettings.Service,
 	uuidGenerator boshuuid.Generator,
 	timeService clock.Clock,
+	bundleDrift boshbc.DriftMonitor,
+	timeDrift TimeDriftOptions,
+	certExpiry CertExpiryOptions,
 ) Agent {
 	return Agent{
 		logger:            logger,
@@ -60,6 +111,9 @@ func New(
 		settingsService:   settingsService,
 		uuidGenerator:     uuidGenerator,
 		timeService:       timeService,
+		bundleDrift:       bundleDrift,
+		timeDrift:         &timeDriftState{threshold: timeDrift.AlertThreshold()},
+		certExpiry:        &certExpiryState{threshold: certExpiry.AlertThreshold(), alerted: map[string]bool{}},
 	}
 }
 
@@ -79,6 +133,13 @@ func (a Agent) Run() error {
 		}
 	}()
 
+	go func() {
+		err := a.bundleDrift.MonitorDrift(a.handleBundleDrift)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
 	go func() {
 		err := a.syslogServer.Start(a.handleSyslogMsg(errCh))
 		if err != nil {
@@ -132,9 +193,166 @@ func (a Agent) sendHeartbeat(errCh chan error) {
 	if err != nil {
 		err = bosherr.WrapError(err, "Sending heartbeat")
 		errCh <- err
+		return
+	}
+
+	a.sendDiskHealthAlerts(errCh)
+	a.sendTimeDriftAlert(heartbeat.Ntp, errCh)
+	a.sendCertExpiryAlerts(errCh)
+}
+
+func (a Agent) sendDiskHealthAlerts(errCh chan error) {
+	state, err := a.platform.GetDiskHealthState()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to load disk health state: %s", err.Error())
+		return
+	}
+
+	for diskCID, result := range state.Disks {
+		if result.Alerted {
+			continue
+		}
+
+		alertAdapter := boshalert.NewDiskHealthAdapter(
+			diskCID,
+			result,
+			a.settingsService,
+			a.uuidGenerator,
+			a.timeService,
+		)
+		if alertAdapter.IsIgnorable() {
+			continue
+		}
+
+		alert, err := alertAdapter.Alert()
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Adapting disk health alert")
+			return
+		}
+
+		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Sending disk health alert")
+			return
+		}
+
+		result.Alerted = true
+		state.Disks[diskCID] = result
+
+		err = state.SaveState()
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Saving disk health state")
+			return
+		}
 	}
 }
 
+func (a Agent) sendTimeDriftAlert(info boshntp.Info, errCh chan error) {
+	alertAdapter := boshalert.NewTimeDriftAdapter(
+		info,
+		a.timeDrift.threshold,
+		a.settingsService,
+		a.uuidGenerator,
+		a.timeService,
+	)
+	if alertAdapter.IsIgnorable() {
+		a.timeDrift.alerted = false
+		return
+	}
+
+	if a.timeDrift.alerted {
+		return
+	}
+
+	alert, err := alertAdapter.Alert()
+	if err != nil {
+		errCh <- bosherr.WrapError(err, "Adapting time drift alert")
+		return
+	}
+
+	err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+	if err != nil {
+		errCh <- bosherr.WrapError(err, "Sending time drift alert")
+		return
+	}
+
+	a.timeDrift.alerted = true
+}
+
+func (a Agent) sendCertExpiryAlerts(errCh chan error) {
+	alerted := map[string]bool{}
+
+	for source, infos := range a.getCertificates() {
+		for _, info := range infos {
+			alertAdapter := boshalert.NewCertExpiryAdapter(
+				source,
+				info,
+				a.certExpiry.threshold,
+				a.uuidGenerator,
+				a.timeService,
+			)
+			if alertAdapter.IsIgnorable() {
+				continue
+			}
+
+			key := fmt.Sprintf("%s/%s/%d", source, info.Subject, info.NotAfter.Unix())
+			alerted[key] = true
+
+			if a.certExpiry.alerted[key] {
+				continue
+			}
+
+			alert, err := alertAdapter.Alert()
+			if err != nil {
+				errCh <- bosherr.WrapError(err, "Adapting certificate expiry alert")
+				return
+			}
+
+			err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+			if err != nil {
+				errCh <- bosherr.WrapError(err, "Sending certificate expiry alert")
+				return
+			}
+
+			a.certExpiry.alerted[key] = true
+		}
+	}
+
+	// Forget certificates that were replaced so that alerts are sent again if they expire
+	a.certExpiry.alerted = alerted
+}
+
+// getCertificates returns certificates grouped by where they are used
+func (a Agent) getCertificates() map[string][]boshcert.CertificateInfo {
+	certificates := map[string][]boshcert.CertificateInfo{}
+
+	installed, err := a.platform.GetCertManager().Certificates()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to load installed certificates: %s", err.Error())
+	}
+
+	for _, info := range installed {
+		source := "trusted certs"
+		if info.Bundle != "" {
+			source = fmt.Sprintf("bundle %s", info.Bundle)
+		}
+		certificates[source] = append(certificates[source], info)
+	}
+
+	mbusCert := a.settingsService.GetSettings().Env.Bosh.Mbus.Cert.Certificate
+
+	mbusInfos, err := boshcert.DescribeCertificates("", mbusCert)
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to parse mbus certificate: %s", err.Error())
+	}
+
+	if len(mbusInfos) > 0 {
+		certificates["mbus"] = mbusInfos
+	}
+
+	return certificates
+}
+
 func (a Agent) getHeartbeat() (Heartbeat, error) {
 	a.logger.Debug(agentLogTag, "Building heartbeat")
 	vitalsService := a.platform.GetVitalsService()
@@ -156,6 +374,7 @@ func (a Agent) getHeartbeat() (Heartbeat, error) {
 		JobState:   a.jobSupervisor.Status(),
 		Vitals:     vitals,
 		NodeID:     spec.NodeID,
+		Ntp:        a.platform.GetNTPService().GetInfo(),
 	}
 	return hb, nil
 }
@@ -187,6 +406,32 @@ func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler
 	}
 }
 
+func (a Agent) handleBundleDrift(drifted []boshbc.BundleVerification) error {
+	for _, verification := range drifted {
+		alertAdapter := boshalert.NewBundleDriftAdapter(
+			verification,
+			a.settingsService,
+			a.uuidGenerator,
+			a.timeService,
+		)
+		if alertAdapter.IsIgnorable() {
+			continue
+		}
+
+		alert, err := alertAdapter.Alert()
+		if err != nil {
+			return bosherr.WrapError(err, "Adapting bundle drift alert")
+		}
+
+		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		if err != nil {
+			return bosherr.WrapError(err, "Sending bundle drift alert")
+		}
+	}
+
+	return nil
+}
+
 func (a Agent) handleSyslogMsg(errCh chan error) boshsyslog.CallbackFunc {
 	return func(msg boshsyslog.Msg) {
 		alertAdapter := boshalert.NewSSHAdapter(
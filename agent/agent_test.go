@@ -12,11 +12,16 @@ import (
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
 	fakeagent "github.com/cloudfoundry/bosh-agent/agent/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	boshsyslog "github.com/cloudfoundry/bosh-agent/syslog"
@@ -39,6 +44,7 @@ func init() {
 			settingsService  *fakesettings.FakeSettingsService
 			uuidGenerator    *fakeuuid.FakeGenerator
 			timeService      *fakeclock.FakeClock
+			bundleDrift      *fakebc.FakeDriftMonitor
 			agent            Agent
 		)
 
@@ -53,6 +59,7 @@ func init() {
 			settingsService = &fakesettings.FakeSettingsService{}
 			uuidGenerator = &fakeuuid.FakeGenerator{}
 			timeService = fakeclock.NewFakeClock(time.Now())
+			bundleDrift = &fakebc.FakeDriftMonitor{}
 			agent = New(
 				logger,
 				handler,
@@ -65,6 +72,9 @@ func init() {
 				settingsService,
 				uuidGenerator,
 				timeService,
+				bundleDrift,
+				TimeDriftOptions{},
+				CertExpiryOptions{},
 			)
 		})
 
@@ -144,6 +154,9 @@ func init() {
 						settingsService,
 						uuidGenerator,
 						timeService,
+						bundleDrift,
+						TimeDriftOptions{},
+						CertExpiryOptions{},
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -252,6 +265,194 @@ func init() {
 				}))
 			})
 
+			It("sends disk health alerts to health manager only once", func() {
+				handler.KeepOnRunning()
+
+				err := platform.Fs.WriteFileString(
+					"/var/vcap/bosh/disk_health.json",
+					`{"disks":{"fake-disk-cid":{"partition_path":"/dev/sdb1","file_system_type":"ext4","status":"repaired","exit_status":1,"output":"fake-output"}}}`,
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				uuidGenerator.GeneratedUUID = "fake-uuid"
+
+				// Stop after a few heartbeats so that disk health was checked more than once
+				sentHeartbeats := 0
+				handler.SendCallback = func(input fakembus.SendInput) {
+					if input.Topic == boshhandler.Heartbeat {
+						sentHeartbeats++
+						if sentHeartbeats == 3 {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+				}
+
+				err = agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				expectedAlert := boshalert.Alert{
+					ID:        "fake-uuid",
+					Severity:  boshalert.SeverityWarning,
+					Title:     "persistent disk fake-disk-cid - filesystem repaired",
+					Summary:   "Checking ext4 filesystem on /dev/sdb1 exited with status 1: fake-output",
+					CreatedAt: timeService.Now().Unix(),
+				}
+
+				alerts := []fakembus.SendInput{}
+				for _, input := range handler.SendInputs() {
+					if input.Topic == boshhandler.Alert {
+						alerts = append(alerts, input)
+					}
+				}
+
+				Expect(alerts).To(Equal([]fakembus.SendInput{
+					{
+						Target:  boshhandler.HealthMonitor,
+						Topic:   boshhandler.Alert,
+						Message: expectedAlert,
+					},
+				}))
+
+				state, err := platform.GetDiskHealthState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Disks["fake-disk-cid"].Alerted).To(BeTrue())
+			})
+
+			It("sends time drift alert to health manager only once while drifted", func() {
+				handler.KeepOnRunning()
+
+				platform.FakeNTPService.GetOffsetNTPOffset = boshntp.Info{
+					Offset:  "2.500000",
+					Stratum: 3,
+					State:   boshntp.StateSynchronized,
+				}
+
+				uuidGenerator.GeneratedUUID = "fake-uuid"
+
+				// Stop after a few heartbeats so that drift was checked more than once
+				sentHeartbeats := 0
+				handler.SendCallback = func(input fakembus.SendInput) {
+					if input.Topic == boshhandler.Heartbeat {
+						sentHeartbeats++
+						if sentHeartbeats == 3 {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				alerts := []fakembus.SendInput{}
+				for _, input := range handler.SendInputs() {
+					if input.Topic == boshhandler.Alert {
+						alerts = append(alerts, input)
+					}
+				}
+
+				Expect(alerts).To(Equal([]fakembus.SendInput{
+					{
+						Target: boshhandler.HealthMonitor,
+						Topic:  boshhandler.Alert,
+						Message: boshalert.Alert{
+							ID:        "fake-uuid",
+							Severity:  boshalert.SeverityWarning,
+							Title:     "system time - drifted from NTP time",
+							Summary:   "Offset of 2.500000s exceeds threshold of 1s (state: synchronized, stratum: 3)",
+							CreatedAt: timeService.Now().Unix(),
+						},
+					},
+				}))
+			})
+
+			It("sends certificate expiry alerts to health manager only once per certificate", func() {
+				handler.KeepOnRunning()
+
+				certManager := platform.GetCertManager().(*fakecert.FakeManager)
+				certManager.CertificatesReturns([]boshcert.CertificateInfo{
+					{Bundle: "uaa", Subject: "CN=fake-uaa-ca", NotAfter: timeService.Now().Add(-time.Hour)},
+					{Subject: "CN=fake-ca", NotAfter: timeService.Now().Add(365 * 24 * time.Hour)},
+				}, nil)
+
+				uuidGenerator.GeneratedUUID = "fake-uuid"
+
+				// Stop after a few heartbeats so that certificates were checked more than once
+				sentHeartbeats := 0
+				handler.SendCallback = func(input fakembus.SendInput) {
+					if input.Topic == boshhandler.Heartbeat {
+						sentHeartbeats++
+						if sentHeartbeats == 3 {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				alerts := []fakembus.SendInput{}
+				for _, input := range handler.SendInputs() {
+					if input.Topic == boshhandler.Alert {
+						alerts = append(alerts, input)
+					}
+				}
+
+				Expect(alerts).To(Equal([]fakembus.SendInput{
+					{
+						Target: boshhandler.HealthMonitor,
+						Topic:  boshhandler.Alert,
+						Message: boshalert.Alert{
+							ID:        "fake-uuid",
+							Severity:  boshalert.SeverityError,
+							Title:     "certificate CN=fake-uaa-ca (bundle uaa) - expired",
+							Summary:   "Certificate CN=fake-uaa-ca in bundle uaa expired at " + timeService.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+							CreatedAt: timeService.Now().Unix(),
+						},
+					},
+				}))
+			})
+
+			It("sends bundle drift alerts to health manager", func() {
+				handler.KeepOnRunning()
+
+				uuidGenerator.GeneratedUUID = "fake-uuid"
+
+				bundleDrift.DriftedBundles = [][]boshbc.BundleVerification{
+					{
+						{
+							Path:        "/fake-bundle",
+							BundleDrift: boshbc.BundleDrift{Modified: []string{"bin/ctl"}},
+						},
+					},
+				}
+
+				// Fail the first time handler.Send is called for an alert (ignore heartbeats)
+				handler.SendCallback = func(input fakembus.SendInput) {
+					if input.Topic == boshhandler.Alert {
+						handler.SendErr = errors.New("stop")
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+					Target: boshhandler.HealthMonitor,
+					Topic:  boshhandler.Alert,
+					Message: boshalert.Alert{
+						ID:        "fake-uuid",
+						Severity:  boshalert.SeverityError,
+						Title:     "bundle /fake-bundle - installed files changed",
+						Summary:   "modified: bin/ctl",
+						CreatedAt: timeService.Now().Unix(),
+					},
+				}))
+			})
+
 			It("sends ssh alerts to health manager", func() {
 				handler.KeepOnRunning()
 
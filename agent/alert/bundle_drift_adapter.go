@@ -0,0 +1,86 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type bundleDriftAdapter struct {
+	verification    boshbc.BundleVerification
+	settingsService boshsettings.Service
+	uuidGenerator   boshuuid.Generator
+	timeService     clock.Clock
+}
+
+func NewBundleDriftAdapter(
+	verification boshbc.BundleVerification,
+	settingsService boshsettings.Service,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &bundleDriftAdapter{
+		verification:    verification,
+		settingsService: settingsService,
+		uuidGenerator:   uuidGenerator,
+		timeService:     timeService,
+	}
+}
+
+func (m *bundleDriftAdapter) IsIgnorable() bool {
+	return !m.verification.HasDrift()
+}
+
+func (m *bundleDriftAdapter) Alert() (Alert, error) {
+	uuid, err := m.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	return Alert{
+		ID:        uuid,
+		Severity:  SeverityError,
+		Title:     fmt.Sprintf("%s - installed files changed", m.bundle()),
+		Summary:   m.summary(),
+		CreatedAt: m.timeService.Now().Unix(),
+	}, nil
+}
+
+func (m *bundleDriftAdapter) bundle() string {
+	settings := m.settingsService.GetSettings()
+
+	ips := settings.Networks.IPs()
+	sort.Strings(ips)
+
+	bundle := fmt.Sprintf("bundle %s", m.verification.Path)
+
+	if len(ips) > 0 {
+		bundle = fmt.Sprintf("%s (%s)", bundle, strings.Join(ips, ", "))
+	}
+
+	return bundle
+}
+
+func (m *bundleDriftAdapter) summary() string {
+	var parts []string
+
+	if len(m.verification.Modified) > 0 {
+		parts = append(parts, "modified: "+strings.Join(m.verification.Modified, ", "))
+	}
+
+	if len(m.verification.Missing) > 0 {
+		parts = append(parts, "missing: "+strings.Join(m.verification.Missing, ", "))
+	}
+
+	if len(m.verification.Extra) > 0 {
+		parts = append(parts, "extra: "+strings.Join(m.verification.Extra, ", "))
+	}
+
+	return strings.Join(parts, "; ")
+}
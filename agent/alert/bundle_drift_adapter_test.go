@@ -0,0 +1,78 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("bundleDriftAdapter", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+		uuidGenerator   *fakeuuid.FakeGenerator
+		verification    boshbc.BundleVerification
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+		verification = boshbc.BundleVerification{
+			Path: "/var/vcap/data/jobs/fake-job/fake-version",
+			BundleDrift: boshbc.BundleDrift{
+				Modified: []string{"bin/ctl", "config/fake.yml"},
+				Missing:  []string{"monit"},
+				Extra:    []string{"bin/patch"},
+			},
+		}
+	})
+
+	buildAdapter := func() Adapter {
+		return NewBundleDriftAdapter(verification, settingsService, uuidGenerator, timeService)
+	}
+
+	Describe("IsIgnorable", func() {
+		It("does not ignore drifted bundles", func() {
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+		})
+
+		It("ignores bundles without drift", func() {
+			verification.BundleDrift = boshbc.BundleDrift{}
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+	})
+
+	Describe("Alert", func() {
+		It("lists changed files", func() {
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert).To(Equal(Alert{
+				ID:        "fake-uuid",
+				Severity:  SeverityError,
+				Title:     "bundle /var/vcap/data/jobs/fake-job/fake-version - installed files changed",
+				Summary:   "modified: bin/ctl, config/fake.yml; missing: monit; extra: bin/patch",
+				CreatedAt: timeService.Now().Unix(),
+			}))
+		})
+
+		It("includes instance IPs in title", func() {
+			settingsService.Settings.Networks = boshsettings.Networks{
+				"fake-net": boshsettings.Network{IP: "10.0.0.1"},
+			}
+
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Title).To(Equal("bundle /var/vcap/data/jobs/fake-job/fake-version (10.0.0.1) - installed files changed"))
+		})
+	})
+})
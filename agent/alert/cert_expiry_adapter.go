@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type certExpiryAdapter struct {
+	source        string
+	info          boshcert.CertificateInfo
+	threshold     time.Duration
+	uuidGenerator boshuuid.Generator
+	timeService   clock.Clock
+}
+
+// NewCertExpiryAdapter alerts when certificate from source (e.g. mbus, trusted certs)
+// expires within threshold or has already expired
+func NewCertExpiryAdapter(
+	source string,
+	info boshcert.CertificateInfo,
+	threshold time.Duration,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &certExpiryAdapter{
+		source:        source,
+		info:          info,
+		threshold:     threshold,
+		uuidGenerator: uuidGenerator,
+		timeService:   timeService,
+	}
+}
+
+func (m *certExpiryAdapter) IsIgnorable() bool {
+	return m.info.NotAfter.Sub(m.timeService.Now()) > m.threshold
+}
+
+func (m *certExpiryAdapter) Alert() (Alert, error) {
+	uuid, err := m.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	severity := SeverityWarning
+	expiry := "expires"
+
+	remaining := m.info.NotAfter.Sub(m.timeService.Now())
+	if remaining <= 0 {
+		severity = SeverityError
+		expiry = "expired"
+	}
+
+	return Alert{
+		ID:       uuid,
+		Severity: severity,
+		Title:    fmt.Sprintf("certificate %s (%s) - %s", m.info.Subject, m.source, m.describeExpiry(remaining)),
+		Summary: fmt.Sprintf(
+			"Certificate %s in %s %s at %s",
+			m.info.Subject,
+			m.source,
+			expiry,
+			m.info.NotAfter.UTC().Format(time.RFC3339),
+		),
+		CreatedAt: m.timeService.Now().Unix(),
+	}, nil
+}
+
+func (m *certExpiryAdapter) describeExpiry(remaining time.Duration) string {
+	if remaining <= 0 {
+		return "expired"
+	}
+
+	days := int(remaining / (24 * time.Hour))
+	if days == 1 {
+		return "expires in 1 day"
+	}
+
+	return fmt.Sprintf("expires in %d days", days)
+}
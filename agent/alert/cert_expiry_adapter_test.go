@@ -0,0 +1,81 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("certExpiryAdapter", func() {
+	var (
+		timeService   *fakeclock.FakeClock
+		uuidGenerator *fakeuuid.FakeGenerator
+		now           time.Time
+		info          boshcert.CertificateInfo
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2020, time.May, 6, 12, 0, 0, 0, time.UTC)
+		timeService = fakeclock.NewFakeClock(now)
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+		info = boshcert.CertificateInfo{
+			Subject:  "CN=fake-ca",
+			NotAfter: now.Add(10*24*time.Hour + time.Hour),
+		}
+	})
+
+	buildAdapter := func() Adapter {
+		return NewCertExpiryAdapter("mbus", info, 30*24*time.Hour, uuidGenerator, timeService)
+	}
+
+	Describe("IsIgnorable", func() {
+		It("does not ignore certificates expiring within threshold", func() {
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+		})
+
+		It("does not ignore expired certificates", func() {
+			info.NotAfter = now.Add(-time.Hour)
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+		})
+
+		It("ignores certificates expiring after threshold", func() {
+			info.NotAfter = now.Add(31 * 24 * time.Hour)
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+	})
+
+	Describe("Alert", func() {
+		It("warns about certificates that expire soon", func() {
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert).To(Equal(Alert{
+				ID:        "fake-uuid",
+				Severity:  SeverityWarning,
+				Title:     "certificate CN=fake-ca (mbus) - expires in 10 days",
+				Summary:   "Certificate CN=fake-ca in mbus expires at 2020-05-16T13:00:00Z",
+				CreatedAt: now.Unix(),
+			}))
+		})
+
+		It("reports expired certificates as errors", func() {
+			info.NotAfter = now.Add(-time.Hour)
+
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert).To(Equal(Alert{
+				ID:        "fake-uuid",
+				Severity:  SeverityError,
+				Title:     "certificate CN=fake-ca (mbus) - expired",
+				Summary:   "Certificate CN=fake-ca in mbus expired at 2020-05-06T11:00:00Z",
+				CreatedAt: now.Unix(),
+			}))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type diskHealthAdapter struct {
+	diskCID         string
+	result          boshdisk.FilesystemCheckResult
+	settingsService boshsettings.Service
+	uuidGenerator   boshuuid.Generator
+	timeService     clock.Clock
+}
+
+func NewDiskHealthAdapter(
+	diskCID string,
+	result boshdisk.FilesystemCheckResult,
+	settingsService boshsettings.Service,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &diskHealthAdapter{
+		diskCID:         diskCID,
+		result:          result,
+		settingsService: settingsService,
+		uuidGenerator:   uuidGenerator,
+		timeService:     timeService,
+	}
+}
+
+func (m *diskHealthAdapter) IsIgnorable() bool {
+	return !m.result.NeedsAlert()
+}
+
+func (m *diskHealthAdapter) Alert() (Alert, error) {
+	uuid, err := m.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	severity := SeverityWarning
+	event := "filesystem repaired"
+
+	if m.result.Status == boshdisk.FilesystemCheckUnrepaired {
+		severity = SeverityCritical
+		event = "filesystem has unrepaired errors"
+	}
+
+	return Alert{
+		ID:        uuid,
+		Severity:  severity,
+		Title:     fmt.Sprintf("%s - %s", m.disk(), event),
+		Summary:   m.summary(),
+		CreatedAt: m.timeService.Now().Unix(),
+	}, nil
+}
+
+func (m *diskHealthAdapter) disk() string {
+	settings := m.settingsService.GetSettings()
+
+	ips := settings.Networks.IPs()
+	sort.Strings(ips)
+
+	disk := fmt.Sprintf("persistent disk %s", m.diskCID)
+
+	if len(ips) > 0 {
+		disk = fmt.Sprintf("%s (%s)", disk, strings.Join(ips, ", "))
+	}
+
+	return disk
+}
+
+func (m *diskHealthAdapter) summary() string {
+	return fmt.Sprintf(
+		"Checking %s filesystem on %s exited with status %d: %s",
+		m.result.FileSystemType,
+		m.result.PartitionPath,
+		m.result.ExitStatus,
+		strings.TrimSpace(m.result.Output),
+	)
+}
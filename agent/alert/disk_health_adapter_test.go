@@ -0,0 +1,97 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("diskHealthAdapter", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+		uuidGenerator   *fakeuuid.FakeGenerator
+		result          boshdisk.FilesystemCheckResult
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+		result = boshdisk.FilesystemCheckResult{
+			PartitionPath:  "/dev/sdb1",
+			FileSystemType: boshdisk.FileSystemExt4,
+			Status:         boshdisk.FilesystemCheckRepaired,
+			ExitStatus:     1,
+			Output:         "fake-output\n",
+		}
+	})
+
+	buildAdapter := func() Adapter {
+		return NewDiskHealthAdapter("fake-disk-cid", result, settingsService, uuidGenerator, timeService)
+	}
+
+	Describe("IsIgnorable", func() {
+		It("does not ignore repaired filesystems", func() {
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+		})
+
+		It("does not ignore unrepaired filesystems", func() {
+			result.Status = boshdisk.FilesystemCheckUnrepaired
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+		})
+
+		It("ignores clean filesystems", func() {
+			result.Status = boshdisk.FilesystemCheckClean
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+
+		It("ignores skipped checks", func() {
+			result.Status = boshdisk.FilesystemCheckSkipped
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+	})
+
+	Describe("Alert", func() {
+		It("warns about repaired filesystem", func() {
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert).To(Equal(Alert{
+				ID:        "fake-uuid",
+				Severity:  SeverityWarning,
+				Title:     "persistent disk fake-disk-cid - filesystem repaired",
+				Summary:   "Checking ext4 filesystem on /dev/sdb1 exited with status 1: fake-output",
+				CreatedAt: timeService.Now().Unix(),
+			}))
+		})
+
+		It("reports unrepaired filesystem as critical", func() {
+			result.Status = boshdisk.FilesystemCheckUnrepaired
+
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Severity).To(Equal(SeverityCritical))
+			Expect(alert.Title).To(Equal("persistent disk fake-disk-cid - filesystem has unrepaired errors"))
+		})
+
+		It("includes VM ips in the title", func() {
+			settingsService.Settings.Networks = boshsettings.Networks{
+				"fake-net1": boshsettings.Network{IP: "10.0.0.2"},
+				"fake-net2": boshsettings.Network{IP: "10.0.0.1"},
+			}
+
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Title).To(Equal("persistent disk fake-disk-cid (10.0.0.1, 10.0.0.2) - filesystem repaired"))
+		})
+	})
+})
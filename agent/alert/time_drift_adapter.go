@@ -0,0 +1,103 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type timeDriftAdapter struct {
+	info            boshntp.Info
+	threshold       time.Duration
+	settingsService boshsettings.Service
+	uuidGenerator   boshuuid.Generator
+	timeService     clock.Clock
+}
+
+// NewTimeDriftAdapter alerts when offset reported by a time sync daemon exceeds threshold;
+// offsets of one-shot ntpdate runs are ignored since time was stepped right after
+func NewTimeDriftAdapter(
+	info boshntp.Info,
+	threshold time.Duration,
+	settingsService boshsettings.Service,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &timeDriftAdapter{
+		info:            info,
+		threshold:       threshold,
+		settingsService: settingsService,
+		uuidGenerator:   uuidGenerator,
+		timeService:     timeService,
+	}
+}
+
+func (m *timeDriftAdapter) IsIgnorable() bool {
+	if m.info.State == "" {
+		return true
+	}
+
+	offset, found := m.info.OffsetDuration()
+	if !found {
+		return true
+	}
+
+	if offset < 0 {
+		offset = -offset
+	}
+
+	return offset <= m.threshold
+}
+
+func (m *timeDriftAdapter) Alert() (Alert, error) {
+	uuid, err := m.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	return Alert{
+		ID:        uuid,
+		Severity:  SeverityWarning,
+		Title:     fmt.Sprintf("%s - drifted from NTP time", m.systemTime()),
+		Summary:   m.summary(),
+		CreatedAt: m.timeService.Now().Unix(),
+	}, nil
+}
+
+func (m *timeDriftAdapter) systemTime() string {
+	settings := m.settingsService.GetSettings()
+
+	ips := settings.Networks.IPs()
+	sort.Strings(ips)
+
+	systemTime := "system time"
+
+	if len(ips) > 0 {
+		systemTime = fmt.Sprintf("%s (%s)", systemTime, strings.Join(ips, ", "))
+	}
+
+	return systemTime
+}
+
+func (m *timeDriftAdapter) summary() string {
+	summary := fmt.Sprintf(
+		"Offset of %ss exceeds threshold of %s (state: %s, stratum: %d",
+		m.info.Offset,
+		m.threshold,
+		m.info.State,
+		m.info.Stratum,
+	)
+
+	if m.info.LastSync != "" {
+		summary = fmt.Sprintf("%s, last sync: %s", summary, m.info.LastSync)
+	}
+
+	return summary + ")"
+}
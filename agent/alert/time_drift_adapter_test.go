@@ -0,0 +1,83 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("timeDriftAdapter", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+		uuidGenerator   *fakeuuid.FakeGenerator
+		info            boshntp.Info
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+		info = boshntp.Info{
+			Offset:   "-2.500000",
+			Stratum:  3,
+			State:    boshntp.StateSynchronized,
+			LastSync: "2020-05-06T17:27:00Z",
+		}
+	})
+
+	buildAdapter := func() Adapter {
+		return NewTimeDriftAdapter(info, time.Second, settingsService, uuidGenerator, timeService)
+	}
+
+	Describe("IsIgnorable", func() {
+		It("does not ignore offsets exceeding threshold in either direction", func() {
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+
+			info.Offset = "2.500000"
+			Expect(buildAdapter().IsIgnorable()).To(BeFalse())
+		})
+
+		It("ignores offsets within threshold", func() {
+			info.Offset = "-0.000120"
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+
+		It("ignores unknown offsets", func() {
+			info.Offset = ""
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+
+		It("ignores offsets reported by ntpdate", func() {
+			info = boshntp.Info{Offset: "-2.500000", Timestamp: "12 Oct 17:37:58"}
+			Expect(buildAdapter().IsIgnorable()).To(BeTrue())
+		})
+	})
+
+	Describe("Alert", func() {
+		It("warns about time drift", func() {
+			settingsService.Settings.Networks = boshsettings.Networks{
+				"fake-net": boshsettings.Network{IP: "10.0.0.5"},
+			}
+
+			alert, err := buildAdapter().Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert).To(Equal(Alert{
+				ID:        "fake-uuid",
+				Severity:  SeverityWarning,
+				Title:     "system time (10.0.0.5) - drifted from NTP time",
+				Summary:   "Offset of -2.500000s exceeds threshold of 1s (state: synchronized, stratum: 3, last sync: 2020-05-06T17:27:00Z)",
+				CreatedAt: timeService.Now().Unix(),
+			}))
+		})
+	})
+})
@@ -7,6 +7,13 @@ import (
 type JobTemplateSpec struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+
+	// Seconds job's processes are given to exit before they are killed
+	StopTimeout int `json:"stop_timeout,omitempty"`
+
+	// Jobs with lower stop order are stopped before jobs with higher one;
+	// jobs with the same stop order are stopped in reverse order of templates
+	StopOrder int `json:"stop_order,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
@@ -25,4 +25,7 @@ type Bundle interface {
 
 	Enable() (fs boshsys.FileSystem, path string, err error)
 	Disable() (err error)
+
+	// Verify reports changes to installed contents made after installation
+	Verify() (drift BundleDrift, err error)
 }
@@ -0,0 +1,150 @@
+package bundlecollection
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	manifestEntryFile    = "file"
+	manifestEntryDir     = "dir"
+	manifestEntrySymlink = "symlink"
+)
+
+// BundleDrift lists bundle paths that changed since bundle was installed
+type BundleDrift struct {
+	Modified []string `json:"modified,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+	Extra    []string `json:"extra,omitempty"`
+}
+
+func (d BundleDrift) HasDrift() bool {
+	return len(d.Modified) > 0 || len(d.Missing) > 0 || len(d.Extra) > 0
+}
+
+// bundleManifest describes bundle contents keyed by slash separated paths relative to bundle root
+type bundleManifest struct {
+	Entries map[string]bundleManifestEntry `json:"entries"`
+}
+
+type bundleManifestEntry struct {
+	Type   string `json:"type"`
+	Digest string `json:"digest,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+func newBundleManifest(fs boshsys.FileSystem, rootPath string) (bundleManifest, error) {
+	manifest := bundleManifest{Entries: map[string]bundleManifestEntry{}}
+
+	err := fs.Walk(rootPath, func(entryPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(rootPath, entryPath)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		var entry bundleManifestEntry
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := fs.Readlink(entryPath)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Reading symlink '%s'", entryPath)
+			}
+			entry = bundleManifestEntry{Type: manifestEntrySymlink, Target: target}
+
+		case info.IsDir():
+			entry = bundleManifestEntry{Type: manifestEntryDir}
+
+		default:
+			digest, err := boshcrypto.NewMultipleDigestFromPath(entryPath, fs, []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA256})
+			if err != nil {
+				return err
+			}
+			entry = bundleManifestEntry{Type: manifestEntryFile, Digest: digest.String()}
+		}
+
+		manifest.Entries[filepath.ToSlash(relPath)] = entry
+
+		return nil
+	})
+	if err != nil {
+		return manifest, bosherr.WrapErrorf(err, "Building manifest of '%s'", rootPath)
+	}
+
+	return manifest, nil
+}
+
+func readBundleManifest(fs boshsys.FileSystem, manifestPath string) (bundleManifest, error) {
+	var manifest bundleManifest
+
+	bytes, err := fs.ReadFile(manifestPath)
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Reading bundle manifest")
+	}
+
+	err = json.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Unmarshalling bundle manifest")
+	}
+
+	return manifest, nil
+}
+
+func (m bundleManifest) Write(fs boshsys.FileSystem, manifestPath string) error {
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling bundle manifest")
+	}
+
+	err = fs.MkdirAll(filepath.Dir(manifestPath), installDirsPerms)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating bundle manifest directory")
+	}
+
+	err = fs.WriteFile(manifestPath, bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing bundle manifest")
+	}
+
+	return nil
+}
+
+// Diff compares recorded manifest against manifest of current contents
+func (m bundleManifest) Diff(current bundleManifest) BundleDrift {
+	var drift BundleDrift
+
+	for entryPath, entry := range m.Entries {
+		currentEntry, found := current.Entries[entryPath]
+		if !found {
+			drift.Missing = append(drift.Missing, entryPath)
+		} else if currentEntry != entry {
+			drift.Modified = append(drift.Modified, entryPath)
+		}
+	}
+
+	for entryPath := range current.Entries {
+		if _, found := m.Entries[entryPath]; !found {
+			drift.Extra = append(drift.Extra, entryPath)
+		}
+	}
+
+	sort.Strings(drift.Modified)
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Extra)
+
+	return drift
+}
@@ -0,0 +1,94 @@
+package bundlecollection
+
+import (
+	"reflect"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
+)
+
+const driftMonitorLogTag = "bundleDriftMonitor"
+
+type DriftMonitorOptions struct {
+	// Installed bundles are not periodically verified when not set
+	IntervalSeconds int
+}
+
+// DriftHandler is called with bundles that drifted since last verification
+type DriftHandler func(drifted []BundleVerification) error
+
+type DriftMonitor interface {
+	// MonitorDrift blocks until handler returns error
+	MonitorDrift(handler DriftHandler) error
+}
+
+type driftMonitor struct {
+	verifier    Verifier
+	options     DriftMonitorOptions
+	timeService clock.Clock
+	logger      boshlog.Logger
+}
+
+func NewDriftMonitor(
+	verifier Verifier,
+	options DriftMonitorOptions,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) DriftMonitor {
+	return driftMonitor{
+		verifier:    verifier,
+		options:     options,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (m driftMonitor) MonitorDrift(handler DriftHandler) error {
+	if m.options.IntervalSeconds <= 0 {
+		m.logger.Debug(driftMonitorLogTag, "Periodic bundle verification is disabled")
+		return nil
+	}
+
+	ticker := m.timeService.NewTicker(time.Duration(m.options.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	// Same drift is reported once; it is reported again only after it changes
+	reported := map[string]BundleDrift{}
+
+	for range ticker.C() {
+		results, err := m.verifier.Verify()
+		if err != nil {
+			m.logger.Warn(driftMonitorLogTag, "Failed to verify bundles: %s", err.Error())
+			continue
+		}
+
+		current := map[string]BundleDrift{}
+		drifted := []BundleVerification{}
+
+		for _, result := range results {
+			if !result.HasDrift() {
+				continue
+			}
+
+			current[result.Path] = result.BundleDrift
+
+			if previous, found := reported[result.Path]; !found || !reflect.DeepEqual(previous, result.BundleDrift) {
+				drifted = append(drifted, result)
+			}
+		}
+
+		reported = current
+
+		if len(drifted) == 0 {
+			continue
+		}
+
+		err = handler(drifted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package bundlecollection_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("DriftMonitor", func() {
+	var (
+		verifier    *fakebc.FakeVerifier
+		timeService *fakeclock.FakeClock
+		logger      boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		verifier = &fakebc.FakeVerifier{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	It("does not verify bundles when interval is not configured", func() {
+		monitor := NewDriftMonitor(verifier, DriftMonitorOptions{}, timeService, logger)
+
+		err := monitor.MonitorDrift(func([]BundleVerification) error { return nil })
+		Expect(err).ToNot(HaveOccurred())
+		Expect(verifier.VerifyCallCount).To(Equal(0))
+	})
+
+	Context("when interval is configured", func() {
+		var (
+			monitor   DriftMonitor
+			driftedCh chan []BundleVerification
+			errCh     chan error
+		)
+
+		drifted := BundleVerification{Path: "/fake-job", BundleDrift: BundleDrift{Modified: []string{"bin/ctl"}}}
+
+		BeforeEach(func() {
+			monitor = NewDriftMonitor(verifier, DriftMonitorOptions{IntervalSeconds: 60}, timeService, logger)
+			driftedCh = make(chan []BundleVerification, 10)
+			errCh = make(chan error, 1)
+
+			verifier.VerifyResults = []BundleVerification{{Path: "/fake-package"}, drifted}
+		})
+
+		startMonitor := func(handlerErr error) {
+			go func() {
+				errCh <- monitor.MonitorDrift(func(results []BundleVerification) error {
+					driftedCh <- results
+					return handlerErr
+				})
+			}()
+		}
+
+		It("reports drifted bundles periodically", func() {
+			startMonitor(nil)
+
+			timeService.WaitForWatcherAndIncrement(60 * time.Second)
+			Eventually(driftedCh).Should(Receive(Equal([]BundleVerification{drifted})))
+		})
+
+		It("reports same drift only once", func() {
+			startMonitor(nil)
+
+			timeService.WaitForWatcherAndIncrement(60 * time.Second)
+			Eventually(driftedCh).Should(Receive())
+
+			timeService.Increment(60 * time.Second)
+			Consistently(driftedCh).ShouldNot(Receive())
+		})
+
+		It("stops monitoring when handler fails", func() {
+			startMonitor(errors.New("fake-handler-err"))
+
+			timeService.WaitForWatcherAndIncrement(60 * time.Second)
+			Eventually(errCh).Should(Receive(MatchError("fake-handler-err")))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package fakes
 
 import (
+	bc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
@@ -30,6 +31,9 @@ type FakeBundle struct {
 	DisableErr error
 
 	UninstallErr error
+
+	VerifyDrift bc.BundleDrift
+	VerifyErr   error
 }
 
 func NewFakeBundle() (bundle *FakeBundle) {
@@ -78,3 +82,8 @@ func (s *FakeBundle) Uninstall() error {
 	s.ActionsCalled = append(s.ActionsCalled, "Uninstall")
 	return s.UninstallErr
 }
+
+func (s *FakeBundle) Verify() (bc.BundleDrift, error) {
+	s.ActionsCalled = append(s.ActionsCalled, "Verify")
+	return s.VerifyDrift, s.VerifyErr
+}
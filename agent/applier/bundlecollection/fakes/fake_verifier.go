@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	bc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+)
+
+type FakeVerifier struct {
+	VerifyCallCount int
+	VerifyResults   []bc.BundleVerification
+	VerifyErr       error
+}
+
+func (v *FakeVerifier) Verify() ([]bc.BundleVerification, error) {
+	v.VerifyCallCount++
+	return v.VerifyResults, v.VerifyErr
+}
+
+type FakeDriftMonitor struct {
+	// Each element is passed to handler in order
+	DriftedBundles [][]bc.BundleVerification
+	MonitorErr     error
+}
+
+func (m *FakeDriftMonitor) MonitorDrift(handler bc.DriftHandler) error {
+	for _, drifted := range m.DriftedBundles {
+		err := handler(drifted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.MonitorErr
+}
@@ -19,20 +19,25 @@ const (
 type FileBundle struct {
 	installPath string
 	enablePath  string
-	fs          boshsys.FileSystem
-	logger      boshlog.Logger
+
+	// Records installed contents so that they can be verified later
+	manifestPath string
+
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
 }
 
 func NewFileBundle(
-	installPath, enablePath string,
+	installPath, enablePath, manifestPath string,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) FileBundle {
 	return FileBundle{
-		installPath: installPath,
-		enablePath:  enablePath,
-		fs:          fs,
-		logger:      logger,
+		installPath:  installPath,
+		enablePath:   enablePath,
+		manifestPath: manifestPath,
+		fs:           fs,
+		logger:       logger,
 	}
 }
 
@@ -49,6 +54,16 @@ func (b FileBundle) Install(sourcePath string) (boshsys.FileSystem, string, erro
 		return nil, "", bosherr.WrapError(err, "Creating parent installation directory")
 	}
 
+	manifest, err := newBundleManifest(b.fs, sourcePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = manifest.Write(b.fs, b.manifestPath)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Rename MUST be the last possibly-failing operation
 	// because IsInstalled() relies on installPath presence.
 	err = b.fs.Rename(sourcePath, b.installPath)
@@ -97,7 +112,7 @@ func (b FileBundle) Enable() (boshsys.FileSystem, string, error) {
 		return nil, "", bosherr.WrapError(err, "failed to create enable dir")
 	}
 
-	err = b.fs.Symlink(b.installPath, b.enablePath)
+	err = b.switchEnablePath()
 	if err != nil {
 		return nil, "", bosherr.WrapError(err, "failed to enable")
 	}
@@ -105,6 +120,41 @@ func (b FileBundle) Enable() (boshsys.FileSystem, string, error) {
 	return b.fs, b.enablePath, nil
 }
 
+// switchEnablePath points enable path at install path. Enabled version is replaced
+// by renaming a staged symlink over it so that enable path never goes missing.
+func (b FileBundle) switchEnablePath() error {
+	if !b.fs.FileExists(b.enablePath) {
+		return b.fs.Symlink(b.installPath, b.enablePath)
+	}
+
+	target, err := b.fs.Readlink(b.enablePath)
+	if err == nil && filepath.Clean(target) == filepath.Clean(b.installPath) {
+		return nil
+	}
+
+	stagedPath := b.enablePath + ".enabling"
+
+	err = b.fs.Symlink(b.installPath, stagedPath)
+	if err != nil {
+		return err
+	}
+
+	err = b.fs.Rename(stagedPath, b.enablePath)
+	if err != nil {
+		// Some platforms (e.g. Windows) cannot rename over existing link
+		b.logger.Warn(fileBundleLogTag, "Failed to switch %s atomically: %s", b.enablePath, err.Error())
+
+		err = b.fs.RemoveAll(b.enablePath)
+		if err != nil {
+			return bosherr.WrapError(err, "Removing previously enabled version")
+		}
+
+		return b.fs.Rename(stagedPath, b.enablePath)
+	}
+
+	return nil
+}
+
 func (b FileBundle) Disable() error {
 	b.logger.Debug(fileBundleLogTag, "Disabling %v", b)
 
@@ -123,9 +173,38 @@ func (b FileBundle) Disable() error {
 	return nil
 }
 
+// Verify compares installed contents against manifest recorded during installation.
+// Bundles installed without contents do not have manifest and cannot be verified.
+func (b FileBundle) Verify() (BundleDrift, error) {
+	if !b.fs.FileExists(b.installPath) {
+		return BundleDrift{}, bosherr.Error("bundle must be installed")
+	}
+
+	if !b.fs.FileExists(b.manifestPath) {
+		return BundleDrift{}, bosherr.Error("bundle manifest is not recorded")
+	}
+
+	recorded, err := readBundleManifest(b.fs, b.manifestPath)
+	if err != nil {
+		return BundleDrift{}, err
+	}
+
+	current, err := newBundleManifest(b.fs, b.installPath)
+	if err != nil {
+		return BundleDrift{}, err
+	}
+
+	return recorded.Diff(current), nil
+}
+
 func (b FileBundle) Uninstall() error {
 	b.logger.Debug(fileBundleLogTag, "Uninstalling %v", b)
 
+	err := b.fs.RemoveAll(b.manifestPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing bundle manifest")
+	}
+
 	// RemoveAll MUST be the last possibly-failing operation
 	// because IsInstalled() relies on installPath presence.
 	return b.fs.RemoveAll(b.installPath)
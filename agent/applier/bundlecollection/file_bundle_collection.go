@@ -11,7 +11,12 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-const fileBundleCollectionLogTag = "FileBundleCollection"
+const (
+	fileBundleCollectionLogTag = "FileBundleCollection"
+
+	// Kept outside of installPath/name so that manifests are not listed as bundles
+	manifestsDirName = "manifests"
+)
 
 type fileBundleDefinition struct {
 	name    string
@@ -76,10 +81,7 @@ func (bc FileBundleCollection) Get(definition BundleDefinition) (Bundle, error)
 		return FileBundle{}, err
 	}
 
-	installPath := path.Join(bc.installPath, bc.name, definition.BundleName(), bundleVersionDigest.String())
-	enablePath := path.Join(bc.enablePath, bc.name, definition.BundleName())
-
-	return NewFileBundle(installPath, enablePath, bc.fs, bc.logger), nil
+	return bc.newBundle(definition.BundleName(), bundleVersionDigest.String()), nil
 }
 
 func (bc FileBundleCollection) getDigested(definition BundleDefinition) (Bundle, error) {
@@ -91,9 +93,14 @@ func (bc FileBundleCollection) getDigested(definition BundleDefinition) (Bundle,
 		return nil, bosherr.Error("Missing bundle version")
 	}
 
-	installPath := path.Join(bc.installPath, bc.name, definition.BundleName(), definition.BundleVersion())
-	enablePath := path.Join(bc.enablePath, bc.name, definition.BundleName())
-	return NewFileBundle(installPath, enablePath, bc.fs, bc.logger), nil
+	return bc.newBundle(definition.BundleName(), definition.BundleVersion()), nil
+}
+
+func (bc FileBundleCollection) newBundle(name, digestedVersion string) Bundle {
+	installPath := path.Join(bc.installPath, bc.name, name, digestedVersion)
+	enablePath := path.Join(bc.enablePath, bc.name, name)
+	manifestPath := path.Join(bc.installPath, manifestsDirName, bc.name, name, digestedVersion+".json")
+	return NewFileBundle(installPath, enablePath, manifestPath, bc.fs, bc.logger)
 }
 
 func (bc FileBundleCollection) List() ([]Bundle, error) {
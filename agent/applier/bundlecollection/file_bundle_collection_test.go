@@ -51,6 +51,7 @@ var _ = Describe("FileBundleCollection", func() {
 			expectedBundle := NewFileBundle(
 				"/fake-collection-path/data/fake-collection-name/fake-bundle-name/faf990988742db852eec285122b5c4e7180e7be5",
 				"/fake-collection-path/fake-collection-name/fake-bundle-name",
+				"/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-name/faf990988742db852eec285122b5c4e7180e7be5.json",
 				fs,
 				logger,
 			)
@@ -93,18 +94,21 @@ var _ = Describe("FileBundleCollection", func() {
 				NewFileBundle(
 					installPath+"/fake-bundle-1-name/fake-bundle-1-version-1",
 					enablePath+"/fake-bundle-1-name",
+					"/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-1-name/fake-bundle-1-version-1.json",
 					fs,
 					logger,
 				),
 				NewFileBundle(
 					installPath+"/fake-bundle-1-name/fake-bundle-1-version-2",
 					enablePath+"/fake-bundle-1-name",
+					"/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-1-name/fake-bundle-1-version-2.json",
 					fs,
 					logger,
 				),
 				NewFileBundle(
 					installPath+"/fake-bundle-2-name/fake-bundle-2-version-1",
 					enablePath+"/fake-bundle-2-name",
+					"/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-2-name/fake-bundle-2-version-1.json",
 					fs,
 					logger,
 				),
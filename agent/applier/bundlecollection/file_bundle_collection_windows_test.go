@@ -43,6 +43,7 @@ var _ = Describe("FileBundleCollection", func() {
 			expectedBundle := NewFileBundle(
 				`C:/fake-collection-path/data/fake-collection-name/fake-bundle-name/faf990988742db852eec285122b5c4e7180e7be5`,
 				`C:/fake-collection-path/fake-collection-name/fake-bundle-name`,
+				`C:/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-name/faf990988742db852eec285122b5c4e7180e7be5.json`,
 				fs,
 				logger,
 			)
@@ -85,18 +86,21 @@ var _ = Describe("FileBundleCollection", func() {
 				NewFileBundle(
 					cleanPath(installPath+`\fake-bundle-1-name\fake-bundle-1-version-1`),
 					cleanPath(enablePath+`\fake-bundle-1-name`),
+					`C:/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-1-name/fake-bundle-1-version-1.json`,
 					fs,
 					logger,
 				),
 				NewFileBundle(
 					cleanPath(installPath+`\fake-bundle-1-name\fake-bundle-1-version-2`),
 					cleanPath(enablePath+`\fake-bundle-1-name`),
+					`C:/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-1-name/fake-bundle-1-version-2.json`,
 					fs,
 					logger,
 				),
 				NewFileBundle(
 					cleanPath(installPath+`\fake-bundle-1-name\fake-bundle-2-version-1`),
 					cleanPath(enablePath+`\fake-bundle-1-name`),
+					`C:/fake-collection-path/data/manifests/fake-collection-name/fake-bundle-1-name/fake-bundle-2-version-1.json`,
 					fs,
 					logger,
 				),
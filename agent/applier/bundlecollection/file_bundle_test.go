@@ -1,33 +1,44 @@
 package bundlecollection_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
+func sha256Hex(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
 var _ = Describe("FileBundle", func() {
 	var (
-		fs          *fakesys.FakeFileSystem
-		logger      boshlog.Logger
-		sourcePath  string
-		installPath string
-		enablePath  string
-		fileBundle  FileBundle
+		fs           *fakesys.FakeFileSystem
+		logger       boshlog.Logger
+		sourcePath   string
+		installPath  string
+		enablePath   string
+		manifestPath string
+		fileBundle   FileBundle
 	)
 
 	BeforeEach(func() {
 		fs = fakesys.NewFakeFileSystem()
 		installPath = "/install-path"
 		enablePath = "/enable-path"
+		manifestPath = "/manifests/install-path.json"
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		fileBundle = NewFileBundle(installPath, enablePath, fs, logger)
+		fileBundle = NewFileBundle(installPath, enablePath, manifestPath, fs, logger)
 	})
 
 	createSourcePath := func() string {
@@ -89,6 +100,33 @@ var _ = Describe("FileBundle", func() {
 			Expect(fs.RenameNewPaths[0]).To(Equal(installPath))
 		})
 
+		It("records manifest of installed contents", func() {
+			fs.WriteFileString(sourcePath+"/bin/ctl", "fake-ctl")
+			fs.Symlink("/fake-target", sourcePath+"/link")
+
+			_, _, err := fileBundle.Install(sourcePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := fs.ReadFileString(manifestPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest).To(MatchJSON(`{
+				"entries": {
+					"bin": {"type": "dir"},
+					"bin/ctl": {"type": "file", "digest": "sha256:` + sha256Hex("fake-ctl") + `"},
+					"link": {"type": "symlink", "target": "/fake-target"}
+				}
+			}`))
+		})
+
+		It("does not install bundle if manifest cannot be recorded", func() {
+			fs.WriteFileErrors[manifestPath] = errors.New("fake-write-error")
+
+			_, _, err := fileBundle.Install(sourcePath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+			Expect(fs.FileExists(installPath)).To(BeFalse())
+		})
+
 		It("returns error when moving source to install path fails", func() {
 			fs.RenameError = errors.New("fake-rename-error")
 
@@ -216,6 +254,77 @@ var _ = Describe("FileBundle", func() {
 			})
 		})
 
+		Context("when different version is enabled", func() {
+			var newerFileBundle FileBundle
+
+			BeforeEach(func() {
+				_, _, err := fileBundle.Install(sourcePath)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = fileBundle.Enable()
+				Expect(err).NotTo(HaveOccurred())
+
+				newerFileBundle = NewFileBundle("/newer-install-path", enablePath, "/manifests/newer-install-path.json", fs, logger)
+
+				_, _, err = newerFileBundle.Install(createSourcePath())
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("switches enabled version by renaming staged symlink over enable path", func() {
+				_, _, err := newerFileBundle.Enable()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fs.RenameOldPaths).To(ContainElement("/enable-path.enabling"))
+				Expect(fs.RenameNewPaths).To(ContainElement(enablePath))
+				Expect(fs.FileExists("/enable-path.enabling")).To(BeFalse())
+			})
+
+			It("returns error when staged symlink cannot be renamed over enable path", func() {
+				fs.RenameError = errors.New("fake-rename-error")
+
+				_, _, err := newerFileBundle.Enable()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-rename-error"))
+			})
+		})
+
+		Context("when switching versions on real file system", func() {
+			It("points enable path at newly enabled version", func() {
+				osFs := boshsys.NewOsFileSystem(logger)
+
+				tmpDir, err := osFs.TempDir("file-bundle-test")
+				Expect(err).NotTo(HaveOccurred())
+
+				defer osFs.RemoveAll(tmpDir)
+
+				realEnablePath := filepath.Join(tmpDir, "enabled")
+
+				for _, version := range []string{"v1", "v2"} {
+					source := filepath.Join(tmpDir, "source-"+version)
+					Expect(osFs.MkdirAll(source, os.ModePerm)).To(Succeed())
+
+					bundle := NewFileBundle(
+						filepath.Join(tmpDir, "installed", version),
+						realEnablePath,
+						filepath.Join(tmpDir, "manifests", version+".json"),
+						osFs,
+						logger,
+					)
+
+					_, _, err = bundle.Install(source)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, err = bundle.Enable()
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				target, err := osFs.Readlink(realEnablePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(target).To(Equal(filepath.Join(tmpDir, "installed", "v2")))
+				Expect(osFs.FileExists(realEnablePath + ".enabling")).To(BeFalse())
+			})
+		})
+
 		Context("when bundle is not installed", func() {
 			It("returns error", func() {
 				_, _, err := fileBundle.Enable()
@@ -304,13 +413,14 @@ var _ = Describe("FileBundle", func() {
 				_, _, err = fileBundle.Enable()
 				Expect(err).NotTo(HaveOccurred())
 
-				newerFileBundle := NewFileBundle(newerInstallPath, enablePath, fs, logger)
+				newerFileBundle := NewFileBundle(newerInstallPath, enablePath, "/manifests/newer-install-path.json", fs, logger)
 
 				otherSourcePath := createSourcePath()
 				_, _, err = newerFileBundle.Install(otherSourcePath)
 				Expect(err).NotTo(HaveOccurred())
 
-				_, _, err = newerFileBundle.Enable()
+				// Fake file system does not keep symlink targets across renames
+				err = fs.Symlink(newerInstallPath, enablePath)
 				Expect(err).NotTo(HaveOccurred())
 			})
 
@@ -336,6 +446,59 @@ var _ = Describe("FileBundle", func() {
 		})
 	})
 
+	Describe("Verify", func() {
+		BeforeEach(func() {
+			fs.WriteFileString(sourcePath+"/bin/ctl", "fake-ctl")
+			fs.WriteFileString(sourcePath+"/config", "fake-config")
+			fs.Symlink("/fake-target", sourcePath+"/link")
+
+			_, _, err := fileBundle.Install(sourcePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Fake file system does not move directory contents
+			fs.WriteFileString(installPath+"/bin/ctl", "fake-ctl")
+			fs.WriteFileString(installPath+"/config", "fake-config")
+			fs.Symlink("/fake-target", installPath+"/link")
+		})
+
+		It("reports no drift when contents are intact", func() {
+			drift, err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift.HasDrift()).To(BeFalse())
+		})
+
+		It("reports modified, missing and extra files", func() {
+			fs.WriteFileString(installPath+"/bin/ctl", "fake-patched-ctl")
+			fs.RemoveAll(installPath + "/config")
+			fs.Symlink("/fake-other-target", installPath+"/link")
+			fs.WriteFileString(installPath+"/bin/patch", "fake-patch")
+
+			drift, err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(Equal(BundleDrift{
+				Modified: []string{"bin/ctl", "link"},
+				Missing:  []string{"config"},
+				Extra:    []string{"bin/patch"},
+			}))
+		})
+
+		It("returns error when manifest was not recorded", func() {
+			fs.RemoveAll(manifestPath)
+
+			_, err := fileBundle.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bundle manifest is not recorded"))
+		})
+
+		It("returns error when bundle is not installed", func() {
+			fs.RemoveAll(installPath)
+
+			_, err := fileBundle.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bundle must be installed"))
+		})
+	})
+
 	Describe("Uninstall", func() {
 		It("removes the files from disk", func() {
 			_, _, err := fileBundle.Install(sourcePath)
@@ -345,6 +508,7 @@ var _ = Describe("FileBundle", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fs.FileExists(installPath)).To(BeFalse())
+			Expect(fs.FileExists(manifestPath)).To(BeFalse())
 		})
 
 		It("is idempotent", func() {
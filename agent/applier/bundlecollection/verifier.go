@@ -0,0 +1,96 @@
+package bundlecollection
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const verifierLogTag = "bundleVerifier"
+
+// VerifiedCollection is a collection whose bundles are verified.
+// Extra contents under IgnoredPaths are expected to be added after installation
+// (e.g. job specific package symlinks).
+type VerifiedCollection struct {
+	Collection   BundleCollection
+	IgnoredPaths []string
+}
+
+type BundleVerification struct {
+	Path string `json:"path"`
+
+	BundleDrift
+
+	// Set when bundle could not be verified
+	Error string `json:"error,omitempty"`
+}
+
+type Verifier interface {
+	Verify() ([]BundleVerification, error)
+}
+
+type verifier struct {
+	collections []VerifiedCollection
+	logger      boshlog.Logger
+}
+
+func NewVerifier(collections []VerifiedCollection, logger boshlog.Logger) Verifier {
+	return verifier{collections: collections, logger: logger}
+}
+
+// Verify returns results for all installed bundles;
+// bundles that cannot be verified do not stop verification of others.
+func (v verifier) Verify() ([]BundleVerification, error) {
+	results := []BundleVerification{}
+
+	for _, collection := range v.collections {
+		bundles, err := collection.Collection.List()
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Listing installed bundles")
+		}
+
+		for _, bundle := range bundles {
+			_, installPath, err := bundle.GetInstallPath()
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Getting bundle install path")
+			}
+
+			result := BundleVerification{Path: installPath}
+
+			drift, err := bundle.Verify()
+			if err != nil {
+				v.logger.Warn(verifierLogTag, "Failed to verify bundle '%s': %s", installPath, err.Error())
+				result.Error = err.Error()
+			} else {
+				drift.Extra = withoutIgnoredPaths(drift.Extra, collection.IgnoredPaths)
+				result.BundleDrift = drift
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+func withoutIgnoredPaths(paths, ignoredPaths []string) []string {
+	var kept []string
+
+	for _, p := range paths {
+		ignored := false
+
+		for _, ignoredPath := range ignoredPaths {
+			if p == ignoredPath || strings.HasPrefix(p, ignoredPath+"/") {
+				ignored = true
+				break
+			}
+		}
+
+		if !ignored {
+			kept = append(kept, p)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,81 @@
+package bundlecollection_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		jobsBc     *fakebc.FakeBundleCollection
+		packagesBc *fakebc.FakeBundleCollection
+		jobBundle  *fakebc.FakeBundle
+		pkgBundle  *fakebc.FakeBundle
+		verifier   Verifier
+	)
+
+	BeforeEach(func() {
+		jobsBc = fakebc.NewFakeBundleCollection()
+		packagesBc = fakebc.NewFakeBundleCollection()
+
+		jobBundle = fakebc.NewFakeBundle()
+		jobBundle.GetDirPath = "/fake-job"
+		jobsBc.ListBundles = []Bundle{jobBundle}
+
+		pkgBundle = fakebc.NewFakeBundle()
+		pkgBundle.GetDirPath = "/fake-package"
+		packagesBc.ListBundles = []Bundle{pkgBundle}
+
+		verifier = NewVerifier(
+			[]VerifiedCollection{
+				{Collection: jobsBc, IgnoredPaths: []string{"packages"}},
+				{Collection: packagesBc},
+			},
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	It("verifies bundles of all collections", func() {
+		pkgBundle.VerifyDrift = BundleDrift{Modified: []string{"bin/fake"}}
+
+		results, err := verifier.Verify()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(Equal([]BundleVerification{
+			{Path: "/fake-job"},
+			{Path: "/fake-package", BundleDrift: BundleDrift{Modified: []string{"bin/fake"}}},
+		}))
+	})
+
+	It("does not report extra contents under ignored paths", func() {
+		jobBundle.VerifyDrift = BundleDrift{Extra: []string{"packages", "packages/fake-pkg", "packages-other"}}
+
+		results, err := verifier.Verify()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results[0].Extra).To(Equal([]string{"packages-other"}))
+	})
+
+	It("continues verifying other bundles when bundle cannot be verified", func() {
+		jobBundle.VerifyErr = errors.New("fake-verify-err")
+
+		results, err := verifier.Verify()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(Equal([]BundleVerification{
+			{Path: "/fake-job", Error: "fake-verify-err"},
+			{Path: "/fake-package"},
+		}))
+	})
+
+	It("returns error when bundles cannot be listed", func() {
+		packagesBc.ListErr = errors.New("fake-list-err")
+
+		_, err := verifier.Verify()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-list-err"))
+	})
+})
@@ -1,21 +1,46 @@
 package applier
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
 	as "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
+	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	concreteApplierLogTag = "concreteApplier"
+
+	DefaultPrepareWorkers = 5
 )
 
+type Options struct {
+	// Number of jobs and packages that are downloaded and installed
+	// concurrently; DefaultPrepareWorkers is used when not set
+	PrepareWorkers int
+}
+
 type concreteApplier struct {
 	jobApplier        jobs.Applier
 	packageApplier    packages.Applier
 	logrotateDelegate LogrotateDelegate
 	jobSupervisor     boshjobsuper.JobSupervisor
 	dirProvider       boshdirs.Provider
+	options           Options
+	logger            boshlog.Logger
+}
+
+type prepareTask struct {
+	Description string
+	Prepare     func() error
 }
 
 func NewConcreteApplier(
@@ -24,6 +49,8 @@ func NewConcreteApplier(
 	logrotateDelegate LogrotateDelegate,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	dirProvider boshdirs.Provider,
+	options Options,
+	logger boshlog.Logger,
 ) Applier {
 	return &concreteApplier{
 		jobApplier:        jobApplier,
@@ -31,54 +58,156 @@ func NewConcreteApplier(
 		logrotateDelegate: logrotateDelegate,
 		jobSupervisor:     jobSupervisor,
 		dirProvider:       dirProvider,
+		options:           options,
+		logger:            logger,
 	}
 }
 
+// Prepare downloads and installs all jobs and packages concurrently.
+// It does not stop on the first failure so that all errors are reported.
 func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
+	tasks := []prepareTask{}
+
 	for _, job := range desiredApplySpec.Jobs() {
-		err := a.jobApplier.Prepare(job)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Preparing job %s", job.Name)
-		}
+		job := job
+		tasks = append(tasks, prepareTask{
+			Description: fmt.Sprintf("job %s", job.Name),
+			Prepare:     func() error { return a.jobApplier.Prepare(job) },
+		})
 	}
 
 	for _, pkg := range desiredApplySpec.Packages() {
-		err := a.packageApplier.Prepare(pkg)
-		if err != nil {
-			return bosherr.WrapErrorf(err, "Preparing package %s", pkg.Name)
-		}
+		pkg := pkg
+		tasks = append(tasks, prepareTask{
+			Description: fmt.Sprintf("package %s", pkg.Name),
+			Prepare:     func() error { return a.packageApplier.Prepare(pkg) },
+		})
+	}
+
+	return a.runPrepareTasks(tasks)
+}
+
+func (a *concreteApplier) runPrepareTasks(tasks []prepareTask) error {
+	workers := a.options.PrepareWorkers
+	if workers <= 0 {
+		workers = DefaultPrepareWorkers
+	}
+
+	taskCh := make(chan prepareTask)
+	errCh := make(chan error, len(tasks))
+
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for task := range taskCh {
+				startTime := time.Now()
+
+				err := task.Prepare()
+				if err != nil {
+					errCh <- bosherr.WrapErrorf(err, "Preparing %s", task.Description)
+					continue
+				}
+
+				a.logger.Debug(concreteApplierLogTag, "Prepared %s in %s", task.Description, time.Since(startTime))
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		taskCh <- task
+	}
+
+	close(taskCh)
+	wg.Wait()
+	close(errCh)
+
+	errs := []error{}
+	for err := range errCh {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
 	}
 
 	return nil
 }
 
 func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec) error {
+	// Downloads everything that is not yet installed before jobs are touched
+	err := a.Prepare(desiredApplySpec)
+	if err != nil {
+		return err
+	}
+
+	// Bundles of both specs stay installed so that current spec can be restored
+	err = a.enable(
+		desiredApplySpec,
+		append(currentApplySpec.Jobs(), desiredApplySpec.Jobs()...),
+		append(currentApplySpec.Packages(), desiredApplySpec.Packages()...),
+	)
+	if err != nil {
+		return a.rollBack(currentApplySpec, err)
+	}
+
+	return a.setUpLogrotate(desiredApplySpec)
+}
+
+// rollBack restores jobs and packages of current spec after failing
+// to switch to desired spec so that instance is not left with a mix of both.
+// Director does not send start after failed apply so job supervisor
+// is configured for restored jobs right away.
+func (a *concreteApplier) rollBack(currentApplySpec as.ApplySpec, applyErr error) error {
+	a.logger.Error(concreteApplierLogTag, "Rolling back to current jobs and packages after failure: %s", applyErr.Error())
+
+	err := a.enable(currentApplySpec, currentApplySpec.Jobs(), currentApplySpec.Packages())
+	if err != nil {
+		return bosherr.NewMultiError(applyErr, bosherr.WrapError(err, "Rolling back to current jobs and packages"))
+	}
+
+	err = a.ConfigureJobs(currentApplySpec)
+	if err != nil {
+		return bosherr.NewMultiError(applyErr, bosherr.WrapError(err, "Configuring rolled back jobs"))
+	}
+
+	return bosherr.WrapError(applyErr, "Rolled back to current jobs and packages")
+}
+
+// enable switches enabled jobs and packages to the ones in applySpec
+// and removes all installed bundles except for keepJobs and keepPackages.
+// Bundles are already staged by Prepare and each enable symlink
+// is switched atomically (see FileBundle.Enable).
+func (a *concreteApplier) enable(applySpec as.ApplySpec, keepJobs []models.Job, keepPackages []models.Package) error {
 	err := a.jobSupervisor.RemoveAllJobs()
 	if err != nil {
 		return bosherr.WrapError(err, "Removing all jobs")
 	}
 
-	jobs := desiredApplySpec.Jobs()
-	for _, job := range jobs {
+	for _, job := range applySpec.Jobs() {
 		err = a.jobApplier.Apply(job)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Applying job %s", job.Name)
 		}
 	}
 
-	err = a.jobApplier.KeepOnly(append(currentApplySpec.Jobs(), desiredApplySpec.Jobs()...))
+	err = a.jobApplier.KeepOnly(keepJobs)
 	if err != nil {
 		return bosherr.WrapError(err, "Keeping only needed jobs")
 	}
 
-	for _, pkg := range desiredApplySpec.Packages() {
+	for _, pkg := range applySpec.Packages() {
 		err = a.packageApplier.Apply(pkg)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Applying package %s", pkg.Name)
 		}
 	}
 
-	err = a.packageApplier.KeepOnly(append(currentApplySpec.Packages(), desiredApplySpec.Packages()...))
+	err = a.packageApplier.KeepOnly(keepPackages)
 	if err != nil {
 		return bosherr.WrapError(err, "Keeping only needed packages")
 	}
@@ -88,7 +217,7 @@ func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec)
 		return bosherr.WrapError(err, "Reloading jobSupervisor")
 	}
 
-	return a.setUpLogrotate(desiredApplySpec)
+	return nil
 }
 
 func (a *concreteApplier) ConfigureJobs(desiredApplySpec as.ApplySpec) error {
@@ -17,6 +17,7 @@ import (
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
@@ -71,6 +72,8 @@ func init() {
 				logRotateDelegate,
 				jobSupervisor,
 				boshdirs.NewProvider("/fake-base-dir"),
+				Options{PrepareWorkers: 2},
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 		})
 
@@ -105,7 +108,7 @@ func init() {
 					&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg1, pkg2}},
 				)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(packageApplier.PreparedPackages).To(Equal([]models.Package{pkg1, pkg2}))
+				Expect(packageApplier.PreparedPackages).To(ConsistOf(pkg1, pkg2))
 			})
 
 			It("returns error when preparing packages fails", func() {
@@ -119,6 +122,50 @@ func init() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-prepare-package-error"))
 			})
+
+			It("prepares jobs and packages concurrently", func() {
+				pkgs := []models.Package{buildPackage(), buildPackage(), buildPackage(), buildPackage()}
+
+				startedCh := make(chan struct{}, len(pkgs))
+				releaseCh := make(chan struct{})
+
+				packageApplier.PrepareCallBack = func() {
+					startedCh <- struct{}{}
+					<-releaseCh
+				}
+
+				errCh := make(chan error)
+				go func() {
+					errCh <- applier.Prepare(&fakeas.FakeApplySpec{PackageResults: pkgs})
+				}()
+
+				// Only as many packages as there are workers are prepared at once
+				Eventually(startedCh).Should(HaveLen(2))
+				Consistently(startedCh).Should(HaveLen(2))
+
+				close(releaseCh)
+				Eventually(errCh).Should(Receive(BeNil()))
+				Expect(packageApplier.PreparedPackages).To(ConsistOf(pkgs[0], pkgs[1], pkgs[2], pkgs[3]))
+			})
+
+			It("continues preparing after failure and returns all errors", func() {
+				job := buildJob()
+				pkg1 := buildPackage()
+				pkg2 := buildPackage()
+
+				jobApplier.PrepareError = errors.New("fake-prepare-job-error")
+				packageApplier.PrepareError = errors.New("fake-prepare-package-error")
+
+				err := applier.Prepare(&fakeas.FakeApplySpec{
+					JobResults:     []models.Job{job},
+					PackageResults: []models.Package{pkg1, pkg2},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Preparing job " + job.Name + ": fake-prepare-job-error"))
+				Expect(err.Error()).To(ContainSubstring("Preparing package " + pkg1.Name + ": fake-prepare-package-error"))
+				Expect(err.Error()).To(ContainSubstring("Preparing package " + pkg2.Name + ": fake-prepare-package-error"))
+				Expect(packageApplier.PreparedPackages).To(HaveLen(2))
+			})
 		})
 
 		Describe("Configure jobs", func() {
@@ -147,6 +194,22 @@ func init() {
 		})
 
 		Describe("Apply", func() {
+			It("prepares jobs and packages before removing jobs from job supervisor", func() {
+				job := buildJob()
+				pkg := buildPackage()
+
+				jobApplier.PrepareError = errors.New("fake-prepare-job-error")
+
+				err := applier.Apply(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{
+					JobResults:     []models.Job{job},
+					PackageResults: []models.Package{pkg},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-prepare-job-error"))
+				Expect(packageApplier.PreparedPackages).To(Equal([]models.Package{pkg}))
+				Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+			})
+
 			It("removes all jobs from job supervisor", func() {
 				err := applier.Apply(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{})
 				Expect(err).ToNot(HaveOccurred())
@@ -299,6 +362,81 @@ func init() {
 				Expect(err.Error()).To(ContainSubstring("error reloading monit"))
 			})
 
+			Context("when switching to desired jobs and packages fails", func() {
+				var (
+					currentJob, desiredJob models.Job
+					currentPkg, desiredPkg models.Package
+				)
+
+				BeforeEach(func() {
+					currentJob = buildJob()
+					desiredJob = buildJob()
+					currentPkg = buildPackage()
+					desiredPkg = buildPackage()
+
+					jobApplier.ApplyErrors = map[string]error{desiredJob.Name: errors.New("fake-apply-job-error")}
+				})
+
+				apply := func() error {
+					return applier.Apply(
+						&fakeas.FakeApplySpec{
+							JobResults:     []models.Job{currentJob},
+							PackageResults: []models.Package{currentPkg},
+						},
+						&fakeas.FakeApplySpec{
+							JobResults:     []models.Job{desiredJob},
+							PackageResults: []models.Package{desiredPkg},
+						},
+					)
+				}
+
+				It("restores current jobs and packages and returns original error", func() {
+					err := apply()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Rolled back to current jobs and packages"))
+					Expect(err.Error()).To(ContainSubstring("fake-apply-job-error"))
+
+					Expect(jobApplier.AppliedJobs).To(Equal([]models.Job{desiredJob, currentJob}))
+					Expect(jobApplier.KeepOnlyJobs).To(Equal([]models.Job{currentJob}))
+					Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{currentPkg}))
+					Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{currentPkg}))
+					Expect(jobSupervisor.Reloaded).To(BeTrue())
+				})
+
+				It("configures job supervisor for restored jobs", func() {
+					err := apply()
+					Expect(err).To(HaveOccurred())
+
+					Expect(jobApplier.ConfiguredJobs).To(Equal([]models.Job{currentJob}))
+				})
+
+				It("returns both errors when restored jobs cannot be configured", func() {
+					jobApplier.ConfigureError = errors.New("fake-configure-job-error")
+
+					err := apply()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-apply-job-error"))
+					Expect(err.Error()).To(ContainSubstring("Configuring rolled back jobs"))
+					Expect(err.Error()).To(ContainSubstring("fake-configure-job-error"))
+				})
+
+				It("does not set up logrotation", func() {
+					err := apply()
+					Expect(err).To(HaveOccurred())
+					Expect(logRotateDelegate.SetupLogrotateArgs).To(Equal(SetupLogrotateArgs{}))
+				})
+
+				It("returns both errors when current jobs and packages cannot be restored", func() {
+					jobApplier.ApplyErrors[currentJob.Name] = errors.New("fake-restore-job-error")
+
+					err := apply()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-apply-job-error"))
+					Expect(err.Error()).To(ContainSubstring("Rolling back to current jobs and packages"))
+					Expect(err.Error()).To(ContainSubstring("fake-restore-job-error"))
+				})
+			})
+
 			It("apply sets up logrotation", func() {
 				err := applier.Apply(
 					&fakeas.FakeApplySpec{},
@@ -0,0 +1,246 @@
+package contentstore
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	logTag = "fileContentStore"
+
+	storeDirPerms = os.FileMode(0755)
+)
+
+// Copy-on-write clones on file systems that support them (e.g. btrfs, xfs).
+// Contents are never hard-linked because installed bundles may be modified
+// in place (e.g. hot-patched) which would also modify stored contents.
+var reflinkCommand = []string{"cp", "-a", "--reflink=always"}
+
+// Plain copy that still preserves modes and symlinks of copied contents
+var copyCommand = []string{"cp", "-a"}
+
+var safeKeyPart = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ContentStore keeps unpacked blob contents keyed by their digest
+// so that identical contents are downloaded and stored only once
+// regardless of bundle names and versions they are installed as.
+type ContentStore interface {
+	Has(digest boshcrypto.Digest) bool
+
+	// Add clones contents of sourcePath into the store leaving sourcePath intact
+	Add(digest boshcrypto.Digest, sourcePath string) error
+
+	// Checkout clones stored contents to destinationPath which must not be in use
+	Checkout(digest boshcrypto.Digest, destinationPath string) error
+
+	// KeepOnly removes contents of all digests that are not listed
+	KeepOnly(digests []boshcrypto.Digest) error
+}
+
+type fileContentStore struct {
+	storePath string
+	fs        boshsys.FileSystem
+	cmdRunner boshsys.CmdRunner
+	logger    boshlog.Logger
+
+	// Same contents may be added concurrently under different temporary paths
+	tmpCounter *uint64
+}
+
+// NewFileContentStore returns store that keeps contents in directories under storePath.
+// Contents are reflinked when possible so storePath should be on the same device
+// as directories contents are added from and checked out to; otherwise they are copied.
+func NewFileContentStore(
+	storePath string,
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	logger boshlog.Logger,
+) ContentStore {
+	return fileContentStore{
+		storePath: storePath,
+		fs:        fs,
+		cmdRunner: cmdRunner,
+		logger:    logger,
+
+		tmpCounter: new(uint64),
+	}
+}
+
+func (s fileContentStore) Has(digest boshcrypto.Digest) bool {
+	key, err := contentKey(digest)
+	if err != nil {
+		return false
+	}
+
+	return s.fs.FileExists(path.Join(s.storePath, key))
+}
+
+func (s fileContentStore) Add(digest boshcrypto.Digest, sourcePath string) error {
+	key, err := contentKey(digest)
+	if err != nil {
+		return err
+	}
+
+	contentPath := path.Join(s.storePath, key)
+	if s.fs.FileExists(contentPath) {
+		return nil
+	}
+
+	err = s.fs.MkdirAll(s.storePath, storeDirPerms)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating content store directory")
+	}
+
+	// Partially cloned contents must never be visible under the final path
+	tmpContentPath := fmt.Sprintf("%s.tmp-%d", contentPath, atomic.AddUint64(s.tmpCounter, 1))
+
+	err = s.clone(sourcePath, tmpContentPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding contents of '%s'", key)
+	}
+
+	err = s.fs.Rename(tmpContentPath, contentPath)
+	if err != nil {
+		_ = s.fs.RemoveAll(tmpContentPath)
+
+		// Contents were added concurrently by someone else
+		if s.fs.FileExists(contentPath) {
+			return nil
+		}
+
+		return bosherr.WrapErrorf(err, "Adding contents of '%s'", key)
+	}
+
+	return nil
+}
+
+func (s fileContentStore) Checkout(digest boshcrypto.Digest, destinationPath string) error {
+	key, err := contentKey(digest)
+	if err != nil {
+		return err
+	}
+
+	contentPath := path.Join(s.storePath, key)
+	if !s.fs.FileExists(contentPath) {
+		return bosherr.Errorf("Contents of '%s' are not stored", key)
+	}
+
+	err = s.fs.MkdirAll(path.Dir(destinationPath), storeDirPerms)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating parent checkout directory")
+	}
+
+	err = s.clone(contentPath, destinationPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Checking out contents of '%s'", key)
+	}
+
+	return nil
+}
+
+func (s fileContentStore) KeepOnly(digests []boshcrypto.Digest) error {
+	keep := map[string]bool{}
+
+	for _, digest := range digests {
+		key, err := contentKey(digest)
+		if err == nil {
+			keep[key] = true
+		}
+	}
+
+	contentPaths, err := s.fs.Glob(path.Join(s.storePath, "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing stored contents")
+	}
+
+	for _, contentPath := range contentPaths {
+		if keep[path.Base(contentPath)] {
+			continue
+		}
+
+		s.logger.Debug(logTag, "Removing stored contents '%s'", contentPath)
+
+		err = s.fs.RemoveAll(contentPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing stored contents '%s'", contentPath)
+		}
+	}
+
+	return nil
+}
+
+// clone makes destinationPath a copy-on-write clone of sourcePath
+// and falls back to copying when clones are not supported (e.g. ext4, across devices).
+func (s fileContentStore) clone(sourcePath, destinationPath string) error {
+	err := s.fs.RemoveAll(destinationPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing '%s'", destinationPath)
+	}
+
+	err = s.runCopyCommand(reflinkCommand, sourcePath, destinationPath)
+	if err == nil {
+		return nil
+	}
+
+	s.logger.Debug(logTag, "Failed to clone '%s' with '%s': %s", sourcePath, strings.Join(reflinkCommand, " "), err.Error())
+
+	err = s.fs.RemoveAll(destinationPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing '%s'", destinationPath)
+	}
+
+	err = s.runCopyCommand(copyCommand, sourcePath, destinationPath)
+	if err != nil {
+		_ = s.fs.RemoveAll(destinationPath)
+		return bosherr.WrapErrorf(err, "Copying '%s'", sourcePath)
+	}
+
+	return nil
+}
+
+func (s fileContentStore) runCopyCommand(command []string, sourcePath, destinationPath string) error {
+	args := append(append([]string{}, command[1:]...), sourcePath, destinationPath)
+
+	_, _, _, err := s.cmdRunner.RunCommand(command[0], args...)
+
+	return err
+}
+
+// contentKey uses the strongest of possibly multiple digests,
+// e.g. 'sha256:abc;sha1:def' is stored as 'sha256-abc'.
+func contentKey(digest boshcrypto.Digest) (string, error) {
+	if digest == nil {
+		return "", bosherr.Error("Missing digest")
+	}
+
+	algorithmName := digest.Algorithm().Name()
+
+	for _, digestStr := range strings.Split(digest.String(), ";") {
+		name, value := boshcrypto.DigestAlgorithmSHA1.Name(), digestStr
+
+		if i := strings.Index(digestStr, ":"); i >= 0 {
+			name, value = digestStr[:i], digestStr[i+1:]
+		}
+
+		if name != algorithmName {
+			continue
+		}
+
+		if !safeKeyPart.MatchString(name) || !safeKeyPart.MatchString(value) {
+			break
+		}
+
+		return name + "-" + value, nil
+	}
+
+	return "", bosherr.Errorf("Digest '%s' cannot be used as content key", digest.String())
+}
@@ -0,0 +1,259 @@
+package contentstore_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+// noReflinkCmdRunner behaves as if file system did not support reflinks (e.g. ext4)
+type noReflinkCmdRunner struct {
+	boshsys.CmdRunner
+}
+
+func (r noReflinkCmdRunner) RunCommand(cmdName string, args ...string) (string, string, int, error) {
+	for _, arg := range args {
+		if arg == "--reflink=always" {
+			return "", "", 1, errors.New("fake-reflink-not-supported")
+		}
+	}
+
+	return r.CmdRunner.RunCommand(cmdName, args...)
+}
+
+var _ = Describe("FileContentStore", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		digest    boshcrypto.Digest
+		store     ContentStore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "abc123")
+		store = NewFileContentStore("/store", fs, cmdRunner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	cloneFails := func(cmd string) {
+		cmdRunner.AddCmdResult(cmd, fakesys.FakeCmdResult{Error: errors.New("fake-clone-err")})
+	}
+
+	Describe("Has", func() {
+		It("returns true only when contents are stored", func() {
+			Expect(store.Has(digest)).To(BeFalse())
+
+			fs.MkdirAll("/store/sha1-abc123", 0755)
+			Expect(store.Has(digest)).To(BeTrue())
+		})
+
+		It("returns false for missing digest", func() {
+			Expect(store.Has(nil)).To(BeFalse())
+		})
+	})
+
+	Describe("Add", func() {
+		It("reflinks contents into temporary path and moves them into place", func() {
+			cmdRunner.SetCmdCallback("cp -a --reflink=always /source /store/sha1-abc123.tmp-1", func() {
+				fs.MkdirAll("/store/sha1-abc123.tmp-1", 0755)
+			})
+
+			err := store.Add(digest, "/source")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"cp", "-a", "--reflink=always", "/source", "/store/sha1-abc123.tmp-1"},
+			}))
+			Expect(fs.RenameOldPaths).To(Equal([]string{"/store/sha1-abc123.tmp-1"}))
+			Expect(fs.RenameNewPaths).To(Equal([]string{"/store/sha1-abc123"}))
+			Expect(store.Has(digest)).To(BeTrue())
+		})
+
+		It("copies contents instead of hard linking them when reflinks are not supported", func() {
+			cloneFails("cp -a --reflink=always /source /store/sha1-abc123.tmp-1")
+			cmdRunner.SetCmdCallback("cp -a /source /store/sha1-abc123.tmp-1", func() {
+				fs.MkdirAll("/store/sha1-abc123.tmp-1", 0755)
+			})
+
+			err := store.Add(digest, "/source")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"cp", "-a", "--reflink=always", "/source", "/store/sha1-abc123.tmp-1"},
+				{"cp", "-a", "/source", "/store/sha1-abc123.tmp-1"},
+			}))
+			Expect(store.Has(digest)).To(BeTrue())
+		})
+
+		It("does not add contents that are already stored", func() {
+			fs.MkdirAll("/store/sha1-abc123", 0755)
+
+			err := store.Add(digest, "/source")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("uses strongest digest as content key", func() {
+			digest = boshcrypto.MustNewMultipleDigest(
+				boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "abc123"),
+				boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "def456"),
+			)
+			cmdRunner.SetCmdCallback("cp -a --reflink=always /source /store/sha256-def456.tmp-1", func() {
+				fs.MkdirAll("/store/sha256-def456.tmp-1", 0755)
+			})
+
+			err := store.Add(digest, "/source")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/store/sha256-def456")).To(BeTrue())
+		})
+
+		It("returns error when digest cannot be used as file name", func() {
+			digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "../abc")
+
+			err := store.Add(digest, "/source")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Digest 'sha256:../abc' cannot be used as content key"))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error and cleans up when contents cannot be copied", func() {
+			cloneFails("cp -a --reflink=always /source /store/sha1-abc123.tmp-1")
+			cmdRunner.AddCmdResult("cp -a /source /store/sha1-abc123.tmp-1", fakesys.FakeCmdResult{Error: errors.New("fake-copy-err")})
+
+			err := store.Add(digest, "/source")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Copying '/source'"))
+			Expect(err.Error()).To(ContainSubstring("fake-copy-err"))
+			Expect(store.Has(digest)).To(BeFalse())
+		})
+	})
+
+	Describe("Checkout", func() {
+		BeforeEach(func() {
+			fs.WriteFileString("/store/sha1-abc123/file", "fake-contents")
+		})
+
+		It("clones stored contents to destination", func() {
+			fs.WriteFileString("/destination/stale-file", "")
+			cmdRunner.SetCmdCallback("cp -a --reflink=always /store/sha1-abc123 /destination", func() {
+				Expect(fs.FileExists("/destination/stale-file")).To(BeFalse())
+			})
+
+			err := store.Checkout(digest, "/destination")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"cp", "-a", "--reflink=always", "/store/sha1-abc123", "/destination"},
+			}))
+		})
+
+		It("copies contents when they cannot be cloned", func() {
+			cloneFails("cp -a --reflink=always /store/sha1-abc123 /destination")
+
+			err := store.Checkout(digest, "/destination")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"cp", "-a", "--reflink=always", "/store/sha1-abc123", "/destination"},
+				{"cp", "-a", "/store/sha1-abc123", "/destination"},
+			}))
+		})
+
+		It("returns error when contents are not stored", func() {
+			digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "missing")
+
+			err := store.Checkout(digest, "/destination")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Contents of 'sha1-missing' are not stored"))
+		})
+	})
+
+	Context("when reflinks are not supported by real file system", func() {
+		var (
+			tmpDir string
+		)
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("Relies on cp")
+			}
+
+			var err error
+			tmpDir, err = ioutil.TempDir("", "content-store")
+			Expect(err).ToNot(HaveOccurred())
+
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			osFs := boshsys.NewOsFileSystem(logger)
+			cmdRunner := noReflinkCmdRunner{CmdRunner: boshsys.NewExecCmdRunner(logger)}
+			store = NewFileContentStore(filepath.Join(tmpDir, "store"), osFs, cmdRunner, logger)
+		})
+
+		AfterEach(func() {
+			_ = os.RemoveAll(tmpDir)
+		})
+
+		It("preserves executable files and directory symlinks of checked out contents", func() {
+			sourcePath := filepath.Join(tmpDir, "source")
+			Expect(os.MkdirAll(filepath.Join(sourcePath, "bin"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(sourcePath, "lib"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourcePath, "bin", "ctl"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourcePath, "lib", "file"), []byte("fake-contents"), 0644)).To(Succeed())
+			Expect(os.Symlink("lib", filepath.Join(sourcePath, "current"))).To(Succeed())
+
+			err := store.Add(digest, sourcePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			destinationPath := filepath.Join(tmpDir, "destination")
+
+			err = store.Checkout(digest, destinationPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			info, err := os.Stat(filepath.Join(destinationPath, "bin", "ctl"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			info, err = os.Lstat(filepath.Join(destinationPath, "current"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode() & os.ModeSymlink).ToNot(BeZero())
+
+			target, err := os.Readlink(filepath.Join(destinationPath, "current"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal("lib"))
+		})
+	})
+
+	Describe("KeepOnly", func() {
+		It("removes contents of digests that are not listed", func() {
+			fs.MkdirAll("/store/sha1-abc123", 0755)
+			fs.MkdirAll("/store/sha1-old", 0755)
+			fs.MkdirAll("/store/sha1-partial.tmp-1", 0755)
+			fs.SetGlob("/store/*", []string{"/store/sha1-abc123", "/store/sha1-old", "/store/sha1-partial.tmp-1"})
+
+			err := store.KeepOnly([]boshcrypto.Digest{digest})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/store/sha1-abc123")).To(BeTrue())
+			Expect(fs.FileExists("/store/sha1-old")).To(BeFalse())
+			Expect(fs.FileExists("/store/sha1-partial.tmp-1")).To(BeFalse())
+		})
+
+		It("returns error when contents cannot be removed", func() {
+			fs.SetGlob("/store/*", []string{"/store/sha1-old"})
+			fs.RemoveAllStub = func(string) error { return errors.New("fake-remove-err") }
+
+			err := store.KeepOnly([]boshcrypto.Digest{digest})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-remove-err"))
+		})
+	})
+})
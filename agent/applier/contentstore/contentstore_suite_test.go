@@ -0,0 +1,13 @@
+package contentstore_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestContentstore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Content Store Suite")
+}
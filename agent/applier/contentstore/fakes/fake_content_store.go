@@ -0,0 +1,68 @@
+package fakes
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+)
+
+type FakeContentStore struct {
+	// Stored contents keyed by digest string
+	Contents map[string]string
+
+	AddDigests     []boshcrypto.Digest
+	AddSourcePaths []string
+	AddErr         error
+
+	CheckoutDigests          []boshcrypto.Digest
+	CheckoutDestinationPaths []string
+	CheckoutCallBack         func(destinationPath string)
+	CheckoutErr              error
+
+	KeepOnlyDigests [][]boshcrypto.Digest
+	KeepOnlyErr     error
+}
+
+func NewFakeContentStore() *FakeContentStore {
+	return &FakeContentStore{Contents: map[string]string{}}
+}
+
+func (s *FakeContentStore) Has(digest boshcrypto.Digest) bool {
+	if digest == nil {
+		return false
+	}
+
+	_, found := s.Contents[digest.String()]
+	return found
+}
+
+func (s *FakeContentStore) Add(digest boshcrypto.Digest, sourcePath string) error {
+	s.AddDigests = append(s.AddDigests, digest)
+	s.AddSourcePaths = append(s.AddSourcePaths, sourcePath)
+
+	if s.AddErr != nil {
+		return s.AddErr
+	}
+
+	s.Contents[digest.String()] = sourcePath
+
+	return nil
+}
+
+func (s *FakeContentStore) Checkout(digest boshcrypto.Digest, destinationPath string) error {
+	s.CheckoutDigests = append(s.CheckoutDigests, digest)
+	s.CheckoutDestinationPaths = append(s.CheckoutDestinationPaths, destinationPath)
+
+	if s.CheckoutErr != nil {
+		return s.CheckoutErr
+	}
+
+	if s.CheckoutCallBack != nil {
+		s.CheckoutCallBack(destinationPath)
+	}
+
+	return nil
+}
+
+func (s *FakeContentStore) KeepOnly(digests []boshcrypto.Digest) error {
+	s.KeepOnlyDigests = append(s.KeepOnlyDigests, digests)
+	return s.KeepOnlyErr
+}
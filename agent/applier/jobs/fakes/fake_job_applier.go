@@ -1,16 +1,24 @@
 package fakes
 
 import (
+	"sync"
+
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 )
 
 type FakeApplier struct {
+	// Jobs may be prepared concurrently
+	prepareLock sync.Mutex
+
 	PreparedJobs []models.Job
 	PrepareError error
 
 	AppliedJobs []models.Job
 	ApplyError  error
 
+	// Errors returned only for jobs with given names
+	ApplyErrors map[string]error
+
 	ConfiguredJobs       []models.Job
 	ConfiguredJobIndices []int
 	ConfigureError       error
@@ -26,12 +34,20 @@ func NewFakeApplier() *FakeApplier {
 }
 
 func (s *FakeApplier) Prepare(job models.Job) error {
+	s.prepareLock.Lock()
+	defer s.prepareLock.Unlock()
+
 	s.PreparedJobs = append(s.PreparedJobs, job)
 	return s.PrepareError
 }
 
 func (s *FakeApplier) Apply(job models.Job) error {
 	s.AppliedJobs = append(s.AppliedJobs, job)
+
+	if err, found := s.ApplyErrors[job.Name]; found {
+		return err
+	}
+
 	return s.ApplyError
 }
 
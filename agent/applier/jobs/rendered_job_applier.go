@@ -7,10 +7,12 @@ import (
 	"strings"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshcs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore"
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -24,6 +26,7 @@ type renderedJobApplier struct {
 	jobSupervisor          boshjobsuper.JobSupervisor
 	packageApplierProvider packages.ApplierProvider
 	blobstore              boshblob.DigestBlobstore
+	contentStore           boshcs.ContentStore
 	compressor             boshcmd.Compressor
 	fs                     boshsys.FileSystem
 	logger                 boshlog.Logger
@@ -34,6 +37,7 @@ func NewRenderedJobApplier(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	packageApplierProvider packages.ApplierProvider,
 	blobstore boshblob.DigestBlobstore,
+	contentStore boshcs.ContentStore,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -43,6 +47,7 @@ func NewRenderedJobApplier(
 		jobSupervisor:          jobSupervisor,
 		packageApplierProvider: packageApplierProvider,
 		blobstore:              blobstore,
+		contentStore:           contentStore,
 		compressor:             compressor,
 		fs:                     fs,
 		logger:                 logger,
@@ -105,6 +110,33 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 		}
 	}()
 
+	installPath := path.Join(tmpDir, job.Source.PathInArchive)
+
+	if s.contentStore.Has(job.Source.Sha1) {
+		err = s.checkout(job, installPath)
+		if err == nil {
+			s.logger.Debug(logTag, "Installing job %s from content store", job.Name)
+			return s.install(jobBundle, installPath)
+		}
+
+		s.logger.Warn(logTag, "Failed to check out job %s from content store: %s", job.Name, err.Error())
+	}
+
+	err = s.download(job, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	// Job is still installed from downloaded contents when they cannot be stored
+	err = s.contentStore.Add(job.Source.Sha1, installPath)
+	if err != nil {
+		s.logger.Warn(logTag, "Failed to add job %s to content store: %s", job.Name, err.Error())
+	}
+
+	return s.install(jobBundle, installPath)
+}
+
+func (s *renderedJobApplier) download(job models.Job, tmpDir string) error {
 	file, err := s.blobstore.Get(job.Source.BlobstoreID, job.Source.Sha1)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting job source from blobstore")
@@ -121,8 +153,24 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 		return bosherr.WrapError(err, "Decompressing files to temp dir")
 	}
 
-	binPath := path.Join(tmpDir, job.Source.PathInArchive, "bin") + "/"
-	err = s.fs.Walk(path.Join(tmpDir, job.Source.PathInArchive), func(path string, info os.FileInfo, err error) error {
+	return s.correctPermissions(path.Join(tmpDir, job.Source.PathInArchive))
+}
+
+// checkout installs job from content store with the same permissions
+// as if it was downloaded since stored contents may have been copied
+// by means that do not preserve them
+func (s *renderedJobApplier) checkout(job models.Job, installPath string) error {
+	err := s.contentStore.Checkout(job.Source.Sha1, installPath)
+	if err != nil {
+		return err
+	}
+
+	return s.correctPermissions(installPath)
+}
+
+func (s *renderedJobApplier) correctPermissions(jobPath string) error {
+	binPath := path.Join(jobPath, "bin") + "/"
+	err := s.fs.Walk(jobPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		} else if info.IsDir() || strings.HasPrefix(path, binPath) {
@@ -135,7 +183,11 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 		return bosherr.WrapError(err, "Correcting file permissions")
 	}
 
-	_, _, err = jobBundle.Install(path.Join(tmpDir, job.Source.PathInArchive))
+	return nil
+}
+
+func (s *renderedJobApplier) install(jobBundle boshbc.Bundle, installPath string) error {
+	_, _, err := jobBundle.Install(installPath)
 	if err != nil {
 		return bosherr.WrapError(err, "Installing job bundle")
 	}
@@ -178,6 +230,19 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		return
 	}
 
+	// Process manifest takes precedence over monit files which
+	// may still be shipped for compatibility with older agents
+	for _, manifestName := range []string{boshjobsuper.ProcessManifestPath, boshjobsuper.ProcessManifestJSONPath} {
+		manifestPath := path.Join(jobDir, manifestName)
+		if fs.FileExists(manifestPath) {
+			err = s.jobSupervisor.AddProcessManifest(job.Name, jobIndex, manifestPath)
+			if err != nil {
+				err = bosherr.WrapError(err, "Adding process manifest")
+			}
+			return
+		}
+	}
+
 	monitFilePath := path.Join(jobDir, "monit")
 	if fs.FileExists(monitFilePath) {
 		err = s.jobSupervisor.AddJob(job.Name, jobIndex, monitFilePath)
@@ -246,5 +311,15 @@ func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 		}
 	}
 
+	digests := []boshcrypto.Digest{}
+	for _, job := range jobs {
+		digests = append(digests, job.Source.Sha1)
+	}
+
+	err = s.contentStore.KeepOnly(digests)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing unused jobs from content store")
+	}
+
 	return nil
 }
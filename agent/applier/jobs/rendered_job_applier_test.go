@@ -8,6 +8,7 @@ import (
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
+	fakecs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore/fakes"
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
@@ -77,6 +78,7 @@ func init() {
 			jobSupervisor          *fakejobsuper.FakeJobSupervisor
 			packageApplierProvider *fakepackages.FakeApplierProvider
 			blobstore              *fakeblob.FakeDigestBlobstore
+			contentStore           *fakecs.FakeContentStore
 			compressor             *fakecmd.FakeCompressor
 			fs                     *fakesys.FakeFileSystem
 			applier                Applier
@@ -87,6 +89,7 @@ func init() {
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 			packageApplierProvider = fakepackages.NewFakeApplierProvider()
 			blobstore = &fakeblob.FakeDigestBlobstore{}
+			contentStore = fakecs.NewFakeContentStore()
 			fs = fakesys.NewFakeFileSystem()
 			compressor = fakecmd.NewFakeCompressor()
 			logger := boshlog.NewLogger(boshlog.LevelNone)
@@ -95,6 +98,7 @@ func init() {
 				jobSupervisor,
 				packageApplierProvider,
 				blobstore,
+				contentStore,
 				compressor,
 				fs,
 				logger,
@@ -305,6 +309,76 @@ func init() {
 					Expect(int(config1Stats.FileMode)).To(Equal(0644))
 					Expect(int(config2Stats.FileMode)).To(Equal(0644))
 				})
+
+				It("adds downloaded job to content store before installing it", func() {
+					var addedBeforeInstall bool
+
+					bundle.InstallCallBack = func() {
+						addedBeforeInstall = len(contentStore.AddDigests) == 1
+					}
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(addedBeforeInstall).To(BeTrue())
+					Expect(contentStore.AddDigests).To(Equal([]boshcrypto.Digest{job.Source.Sha1}))
+					Expect(contentStore.AddSourcePaths).To(Equal([]string{"/fake-tmp-dir/fake-path-in-archive"}))
+				})
+
+				It("installs downloaded job even when it cannot be added to content store", func() {
+					contentStore.AddErr = errors.New("fake-add-err")
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(bundle.InstallSourcePath).To(Equal("/fake-tmp-dir/fake-path-in-archive"))
+				})
+
+				Context("when job contents are already stored", func() {
+					BeforeEach(func() {
+						contentStore.Contents[job.Source.Sha1.String()] = "/fake-stored-contents"
+					})
+
+					It("installs job from content store without downloading it", func() {
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(contentStore.CheckoutDigests).To(Equal([]boshcrypto.Digest{job.Source.Sha1}))
+						Expect(contentStore.CheckoutDestinationPaths).To(Equal([]string{"/fake-tmp-dir/fake-path-in-archive"}))
+						Expect(bundle.InstallSourcePath).To(Equal("/fake-tmp-dir/fake-path-in-archive"))
+						Expect(blobstore.GetCallCount()).To(Equal(0))
+						Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+					})
+
+					It("sets permissions of checked out job as for downloaded job", func() {
+						contentStore.CheckoutCallBack = func(destinationPath string) {
+							fs.WriteFile(destinationPath+"/bin/ctl", []byte{})
+							fs.WriteFile(destinationPath+"/config/config.yml", []byte{})
+						}
+
+						var binStats, configStats *fakesys.FakeFileStats
+
+						bundle.InstallCallBack = func() {
+							binStats = fs.GetFileTestStat("/fake-tmp-dir/fake-path-in-archive/bin/ctl")
+							configStats = fs.GetFileTestStat("/fake-tmp-dir/fake-path-in-archive/config/config.yml")
+						}
+
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(blobstore.GetCallCount()).To(Equal(0))
+
+						Expect(int(binStats.FileMode)).To(Equal(0755))
+						Expect(int(configStats.FileMode)).To(Equal(0644))
+					})
+
+					It("downloads job when it cannot be checked out", func() {
+						contentStore.CheckoutErr = errors.New("fake-checkout-err")
+
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(blobstore.GetCallCount()).To(Equal(1))
+						Expect(bundle.InstallSourcePath).To(Equal("/fake-tmp-dir/fake-path-in-archive"))
+					})
+				})
 			}
 
 			ItUpdatesPackages := func(act func() error) {
@@ -505,6 +579,68 @@ func init() {
 				}))
 			})
 
+			It("adds process manifest instead of monit files when job has one", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/config/processes.json", "{}")
+				fs.WriteFileString("/path/to/job/monit", "some conf")
+				fs.SetGlob("/path/to/job/*.monit", []string{"/path/to/job/subjob.monit"})
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 1)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.AddProcessManifestArgs).To(Equal([]fakejobsuper.AddJobArgs{
+					{
+						Name:       job.Name,
+						Index:      1,
+						ConfigPath: "/path/to/job/config/processes.json",
+					},
+				}))
+				Expect(jobSupervisor.AddJobArgs).To(BeEmpty())
+			})
+
+			It("prefers YAML process manifest over JSON one", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/config/processes.yml", "processes: []")
+				fs.WriteFileString("/path/to/job/config/processes.json", "{}")
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 1)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.AddProcessManifestArgs).To(Equal([]fakejobsuper.AddJobArgs{
+					{
+						Name:       job.Name,
+						Index:      1,
+						ConfigPath: "/path/to/job/config/processes.yml",
+					},
+				}))
+			})
+
+			It("returns error when process manifest cannot be added", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/config/processes.json", "{}")
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				jobSupervisor.AddProcessManifestErr = errors.New("fake-add-manifest-err")
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-add-manifest-err"))
+			})
+
 			It("does not require monit script", func() {
 				job, bundle := buildJob(jobsBc)
 
@@ -575,6 +711,22 @@ func init() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-bc-uninstall-error"))
 			})
+
+			It("removes contents of jobs that are not in keeponly list from content store", func() {
+				job1, _ := buildJob(jobsBc)
+
+				err := applier.KeepOnly([]models.Job{job1})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contentStore.KeepOnlyDigests).To(Equal([][]boshcrypto.Digest{{job1.Source.Sha1}}))
+			})
+
+			It("returns error when content store cannot be cleaned up", func() {
+				contentStore.KeepOnlyErr = errors.New("fake-keep-only-err")
+
+				err := applier.KeepOnly([]models.Job{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-keep-only-err"))
+			})
 		})
 	})
 }
@@ -2,8 +2,10 @@ package packages
 
 import (
 	bc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshcs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore"
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -18,16 +20,18 @@ type compiledPackageApplier struct {
 	// KeepOnly will permanently uninstall packages when operating as owner
 	packagesBcOwner bool
 
-	blobstore  boshblob.DigestBlobstore
-	compressor boshcmd.Compressor
-	fs         boshsys.FileSystem
-	logger     boshlog.Logger
+	blobstore    boshblob.DigestBlobstore
+	contentStore boshcs.ContentStore
+	compressor   boshcmd.Compressor
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
 }
 
 func NewCompiledPackageApplier(
 	packagesBc bc.BundleCollection,
 	packagesBcOwner bool,
 	blobstore boshblob.DigestBlobstore,
+	contentStore boshcs.ContentStore,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -36,6 +40,7 @@ func NewCompiledPackageApplier(
 		packagesBc:      packagesBc,
 		packagesBcOwner: packagesBcOwner,
 		blobstore:       blobstore,
+		contentStore:    contentStore,
 		compressor:      compressor,
 		fs:              fs,
 		logger:          logger,
@@ -98,6 +103,31 @@ func (s *compiledPackageApplier) downloadAndInstall(pkg models.Package, pkgBundl
 		}
 	}()
 
+	if s.contentStore.Has(pkg.Source.Sha1) {
+		err = s.contentStore.Checkout(pkg.Source.Sha1, tmpDir)
+		if err == nil {
+			s.logger.Debug(logTag, "Installing package %s from content store", pkg.Name)
+			return s.install(pkgBundle, tmpDir)
+		}
+
+		s.logger.Warn(logTag, "Failed to check out package %s from content store: %s", pkg.Name, err.Error())
+	}
+
+	err = s.download(pkg, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	// Package is still installed from downloaded contents when they cannot be stored
+	err = s.contentStore.Add(pkg.Source.Sha1, tmpDir)
+	if err != nil {
+		s.logger.Warn(logTag, "Failed to add package %s to content store: %s", pkg.Name, err.Error())
+	}
+
+	return s.install(pkgBundle, tmpDir)
+}
+
+func (s *compiledPackageApplier) download(pkg models.Package, tmpDir string) error {
 	file, err := s.blobstore.Get(pkg.Source.BlobstoreID, pkg.Source.Sha1)
 	if err != nil {
 		return bosherr.WrapError(err, "Fetching package blob")
@@ -114,7 +144,11 @@ func (s *compiledPackageApplier) downloadAndInstall(pkg models.Package, pkgBundl
 		return bosherr.WrapError(err, "Decompressing package files")
 	}
 
-	_, _, err = pkgBundle.Install(tmpDir)
+	return nil
+}
+
+func (s *compiledPackageApplier) install(pkgBundle bc.Bundle, installPath string) error {
+	_, _, err := pkgBundle.Install(installPath)
 	if err != nil {
 		return bosherr.WrapError(err, "Installling package directory")
 	}
@@ -163,5 +197,18 @@ func (s *compiledPackageApplier) KeepOnly(pkgs []models.Package) error {
 		}
 	}
 
+	// Contents may only be removed once no bundle can be using them
+	if s.packagesBcOwner {
+		digests := []boshcrypto.Digest{}
+		for _, pkg := range pkgs {
+			digests = append(digests, pkg.Source.Sha1)
+		}
+
+		err = s.contentStore.KeepOnly(digests)
+		if err != nil {
+			return bosherr.WrapError(err, "Removing unused packages from content store")
+		}
+	}
+
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"path"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshcs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -16,15 +17,17 @@ type compiledPackageApplierProvider struct {
 	jobSpecificEnablePath string
 	name                  string
 
-	blobstore  boshblob.DigestBlobstore
-	compressor boshcmd.Compressor
-	fs         boshsys.FileSystem
-	logger     boshlog.Logger
+	blobstore    boshblob.DigestBlobstore
+	contentStore boshcs.ContentStore
+	compressor   boshcmd.Compressor
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
 }
 
 func NewCompiledPackageApplierProvider(
 	installPath, rootEnablePath, jobSpecificEnablePath, name string,
 	blobstore boshblob.DigestBlobstore,
+	contentStore boshcs.ContentStore,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -33,18 +36,19 @@ func NewCompiledPackageApplierProvider(
 		installPath:           installPath,
 		rootEnablePath:        rootEnablePath,
 		jobSpecificEnablePath: jobSpecificEnablePath,
-		name:       name,
-		blobstore:  blobstore,
-		compressor: compressor,
-		fs:         fs,
-		logger:     logger,
+		name:                  name,
+		blobstore:             blobstore,
+		contentStore:          contentStore,
+		compressor:            compressor,
+		fs:                    fs,
+		logger:                logger,
 	}
 }
 
 // Root provides package applier that operates on system-wide packages.
 // (e.g manages /var/vcap/packages/pkg-a -> /var/vcap/data/packages/pkg-a)
 func (p compiledPackageApplierProvider) Root() Applier {
-	return NewCompiledPackageApplier(p.RootBundleCollection(), true, p.blobstore, p.compressor, p.fs, p.logger)
+	return NewCompiledPackageApplier(p.RootBundleCollection(), true, p.blobstore, p.contentStore, p.compressor, p.fs, p.logger)
 }
 
 // JobSpecific provides package applier that operates on job-specific packages.
@@ -52,7 +56,7 @@ func (p compiledPackageApplierProvider) Root() Applier {
 func (p compiledPackageApplierProvider) JobSpecific(jobName string) Applier {
 	enablePath := path.Join(p.jobSpecificEnablePath, jobName)
 	packagesBc := boshbc.NewFileBundleCollection(p.installPath, enablePath, p.name, p.fs, p.logger)
-	return NewCompiledPackageApplier(packagesBc, false, p.blobstore, p.compressor, p.fs, p.logger)
+	return NewCompiledPackageApplier(packagesBc, false, p.blobstore, p.contentStore, p.compressor, p.fs, p.logger)
 }
 
 func (p compiledPackageApplierProvider) RootBundleCollection() boshbc.BundleCollection {
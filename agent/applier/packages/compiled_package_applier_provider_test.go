@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	fakecs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore/fakes"
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
@@ -14,15 +15,17 @@ import (
 
 var _ = Describe("compiledPackageApplierProvider", func() {
 	var (
-		blobstore  *fakeblob.FakeDigestBlobstore
-		compressor *fakecmd.FakeCompressor
-		fs         *fakesys.FakeFileSystem
-		logger     boshlog.Logger
-		provider   ApplierProvider
+		blobstore    *fakeblob.FakeDigestBlobstore
+		contentStore *fakecs.FakeContentStore
+		compressor   *fakecmd.FakeCompressor
+		fs           *fakesys.FakeFileSystem
+		logger       boshlog.Logger
+		provider     ApplierProvider
 	)
 
 	BeforeEach(func() {
 		blobstore = &fakeblob.FakeDigestBlobstore{}
+		contentStore = fakecs.NewFakeContentStore()
 		compressor = fakecmd.NewFakeCompressor()
 		fs = fakesys.NewFakeFileSystem()
 		logger = boshlog.NewLogger(boshlog.LevelNone)
@@ -32,6 +35,7 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 			"fake-job-specific-enable-path",
 			"fake-name",
 			blobstore,
+			contentStore,
 			compressor,
 			fs,
 			logger,
@@ -50,6 +54,7 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 				),
 				true,
 				blobstore,
+				contentStore,
 				compressor,
 				fs,
 				logger,
@@ -74,6 +79,7 @@ var _ = Describe("compiledPackageApplierProvider", func() {
 				false,
 
 				blobstore,
+				contentStore,
 				compressor,
 				fs,
 				logger,
@@ -8,6 +8,7 @@ import (
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
+	fakecs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore/fakes"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
@@ -40,21 +41,23 @@ func buildPkg(bc *fakebc.FakeBundleCollection) (models.Package, *fakebc.FakeBund
 func init() {
 	Describe("compiledPackageApplier", func() {
 		var (
-			packagesBc *fakebc.FakeBundleCollection
-			blobstore  *fakeblob.FakeDigestBlobstore
-			compressor *fakecmd.FakeCompressor
-			fs         *fakesys.FakeFileSystem
-			logger     boshlog.Logger
-			applier    Applier
+			packagesBc   *fakebc.FakeBundleCollection
+			blobstore    *fakeblob.FakeDigestBlobstore
+			contentStore *fakecs.FakeContentStore
+			compressor   *fakecmd.FakeCompressor
+			fs           *fakesys.FakeFileSystem
+			logger       boshlog.Logger
+			applier      Applier
 		)
 
 		BeforeEach(func() {
 			packagesBc = fakebc.NewFakeBundleCollection()
 			blobstore = &fakeblob.FakeDigestBlobstore{}
+			contentStore = fakecs.NewFakeContentStore()
 			compressor = fakecmd.NewFakeCompressor()
 			fs = fakesys.NewFakeFileSystem()
 			logger = boshlog.NewLogger(boshlog.LevelNone)
-			applier = NewCompiledPackageApplier(packagesBc, true, blobstore, compressor, fs, logger)
+			applier = NewCompiledPackageApplier(packagesBc, true, blobstore, contentStore, compressor, fs, logger)
 		})
 
 		Describe("Prepare & Apply", func() {
@@ -185,6 +188,49 @@ func init() {
 					// make sure that bundle install happened after decompression
 					Expect(bundle.InstallSourcePath).To(Equal("/fake-tmp-dir"))
 				})
+
+				It("adds downloaded package to content store", func() {
+					fs.TempDirDir = "/fake-tmp-dir"
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(contentStore.AddDigests).To(Equal([]boshcrypto.Digest{pkg.Source.Sha1}))
+					Expect(contentStore.AddSourcePaths).To(Equal([]string{"/fake-tmp-dir"}))
+				})
+
+				It("installs downloaded package even when it cannot be added to content store", func() {
+					contentStore.AddErr = errors.New("fake-add-err")
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(bundle.ActionsCalled).To(ContainElement("Install"))
+				})
+
+				Context("when package contents are already stored", func() {
+					BeforeEach(func() {
+						fs.TempDirDir = "/fake-tmp-dir"
+						contentStore.Contents[pkg.Source.Sha1.String()] = "/fake-stored-contents"
+					})
+
+					It("installs package from content store without downloading it", func() {
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(contentStore.CheckoutDestinationPaths).To(Equal([]string{"/fake-tmp-dir"}))
+						Expect(bundle.InstallSourcePath).To(Equal("/fake-tmp-dir"))
+						Expect(blobstore.GetCallCount()).To(Equal(0))
+					})
+
+					It("downloads package when it cannot be checked out", func() {
+						contentStore.CheckoutErr = errors.New("fake-checkout-err")
+
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(blobstore.GetCallCount()).To(Equal(1))
+						Expect(bundle.InstallSourcePath).To(Equal("/fake-tmp-dir"))
+					})
+				})
 			}
 
 			Describe("Prepare", func() {
@@ -343,7 +389,7 @@ func init() {
 
 			Context("when operating on packages as a package owner", func() {
 				BeforeEach(func() {
-					applier = NewCompiledPackageApplier(packagesBc, true, blobstore, compressor, fs, logger)
+					applier = NewCompiledPackageApplier(packagesBc, true, blobstore, contentStore, compressor, fs, logger)
 				})
 
 				It("first disables and then uninstalls packages that are not in keeponly list", func() {
@@ -363,6 +409,22 @@ func init() {
 					Expect(bundle4.ActionsCalled).To(Equal([]string{}))
 				})
 
+				It("removes contents of packages that are not in keeponly list from content store", func() {
+					pkg1, _ := buildPkg(packagesBc)
+
+					err := applier.KeepOnly([]models.Package{pkg1})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(contentStore.KeepOnlyDigests).To(Equal([][]boshcrypto.Digest{{pkg1.Source.Sha1}}))
+				})
+
+				It("returns error when content store cannot be cleaned up", func() {
+					contentStore.KeepOnlyErr = errors.New("fake-keep-only-err")
+
+					err := applier.KeepOnly([]models.Package{})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-keep-only-err"))
+				})
+
 				ItReturnsErrors()
 
 				It("returns error when at least one bundle cannot be uninstalled", func() {
@@ -379,7 +441,7 @@ func init() {
 
 			Context("when operating on packages not as a package owner", func() {
 				BeforeEach(func() {
-					applier = NewCompiledPackageApplier(packagesBc, false, blobstore, compressor, fs, logger)
+					applier = NewCompiledPackageApplier(packagesBc, false, blobstore, contentStore, compressor, fs, logger)
 				})
 
 				It("disables and but does not uninstall packages that are not in keeponly list", func() {
@@ -399,6 +461,12 @@ func init() {
 					Expect(bundle4.ActionsCalled).To(Equal([]string{}))
 				})
 
+				It("does not remove anything from content store", func() {
+					err := applier.KeepOnly([]models.Package{})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(contentStore.KeepOnlyDigests).To(BeEmpty())
+				})
+
 				ItReturnsErrors()
 			})
 
@@ -1,14 +1,20 @@
 package fakes
 
 import (
+	"sync"
+
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 )
 
 type FakeApplier struct {
+	// Packages may be prepared concurrently
+	lock sync.Mutex
+
 	ActionsCalled []string
 
 	PreparedPackages []models.Package
 	PrepareError     error
+	PrepareCallBack  func()
 
 	AppliedPackages []models.Package
 	ApplyError      error
@@ -24,18 +30,31 @@ func NewFakeApplier() *FakeApplier {
 }
 
 func (s *FakeApplier) Prepare(pkg models.Package) error {
+	if s.PrepareCallBack != nil {
+		s.PrepareCallBack()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	s.ActionsCalled = append(s.ActionsCalled, "Prepare")
 	s.PreparedPackages = append(s.PreparedPackages, pkg)
 	return s.PrepareError
 }
 
 func (s *FakeApplier) Apply(pkg models.Package) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	s.ActionsCalled = append(s.ActionsCalled, "Apply")
 	s.AppliedPackages = append(s.AppliedPackages, pkg)
 	return s.ApplyError
 }
 
 func (s *FakeApplier) KeepOnly(pkgs []models.Package) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	s.ActionsCalled = append(s.ActionsCalled, "KeepOnly")
 	s.KeptOnlyPackages = pkgs
 	return s.KeepOnlyErr
@@ -0,0 +1,192 @@
+package cmdrunner
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+
+	// All script cgroups are created under this parent cgroup
+	cgroupParentName = "bosh-scripts"
+
+	cgroupCPUPeriodMicros = 100000
+
+	// Processes may fork while they are being killed
+	cgroupKillAttempts = 5
+	cgroupKillInterval = 100 * time.Millisecond
+)
+
+// SandboxOptions configure running scripts in their own cgroups.
+type SandboxOptions struct {
+	// When set to true each script runs in its own cgroup
+	Enabled bool
+
+	Limits CgroupLimits
+}
+
+// CgroupLimits restrict resources that all processes of a cgroup
+// may use together. Zero values mean no limit.
+type CgroupLimits struct {
+	MemoryMB int
+
+	// Percent of a single CPU, e.g. 200 allows to fully use two CPUs
+	CPUPercent int
+
+	MaxProcesses int
+}
+
+type CgroupUsage struct {
+	PeakMemoryBytes uint64
+	CPUTime         time.Duration
+}
+
+type Cgroup interface {
+	// Wrap returns command that moves itself into the cgroup
+	// before executing given command
+	Wrap(cmd boshsys.Command) boshsys.Command
+
+	Usage() (CgroupUsage, error)
+
+	// Kill kills all processes that are still in the cgroup
+	Kill() error
+
+	Remove() error
+}
+
+type CgroupProvider interface {
+	NewCgroup(name string, limits CgroupLimits) (Cgroup, error)
+}
+
+type concreteCgroupProvider struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+	root   string
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewCgroupProvider returns provider that creates cgroups using
+// unified (v2) hierarchy when it is mounted at root and v1 hierarchies otherwise.
+func NewCgroupProvider(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	root string,
+	logger boshlog.Logger,
+) CgroupProvider {
+	return concreteCgroupProvider{
+		fs:     fs,
+		runner: runner,
+		root:   root,
+		logger: logger,
+		logTag: "cgroupProvider",
+	}
+}
+
+func (p concreteCgroupProvider) NewCgroup(name string, limits CgroupLimits) (Cgroup, error) {
+	if p.fs.FileExists(path.Join(p.root, "cgroup.controllers")) {
+		p.logger.Debug(p.logTag, "Creating cgroup v2 '%s'", name)
+		return newCgroupV2(p.fs, p.runner, p.root, name, limits)
+	}
+
+	p.logger.Debug(p.logTag, "Creating cgroup v1 '%s'", name)
+	return newCgroupV1(p.fs, p.runner, p.root, name, limits)
+}
+
+func wrapCgroupCommand(cmd boshsys.Command, procsPaths []string) boshsys.Command {
+	script := ""
+
+	for _, procsPath := range procsPaths {
+		script += fmt.Sprintf("echo $$ > %s && ", cgroupShellQuote(procsPath))
+	}
+
+	script += `exec "$0" "$@"`
+
+	wrappedCmd := cmd
+	wrappedCmd.Name = "/bin/sh"
+	wrappedCmd.Args = append([]string{"-c", script, cmd.Name}, cmd.Args...)
+
+	return wrappedCmd
+}
+
+// killCgroupProcs sends SIGKILL to processes listed in cgroup.procs files
+// until there are no processes left.
+func killCgroupProcs(fs boshsys.FileSystem, runner boshsys.CmdRunner, procsPaths []string) error {
+	for i := 0; i < cgroupKillAttempts; i++ {
+		pids, err := readCgroupProcs(fs, procsPaths)
+		if err != nil {
+			return err
+		}
+
+		if len(pids) == 0 {
+			return nil
+		}
+
+		// Processes might exit on their own before being killed
+		_, _, _, _ = runner.RunCommand("kill", append([]string{"-KILL"}, pids...)...)
+
+		time.Sleep(cgroupKillInterval)
+	}
+
+	pids, err := readCgroupProcs(fs, procsPaths)
+	if err != nil {
+		return err
+	}
+
+	if len(pids) > 0 {
+		return bosherr.Errorf("Processes '%s' are still running", strings.Join(pids, ", "))
+	}
+
+	return nil
+}
+
+func readCgroupProcs(fs boshsys.FileSystem, procsPaths []string) ([]string, error) {
+	pids := []string{}
+	seen := map[string]bool{}
+
+	for _, procsPath := range procsPaths {
+		if !fs.FileExists(procsPath) {
+			continue
+		}
+
+		contents, err := fs.ReadFileString(procsPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading cgroup processes from '%s'", procsPath)
+		}
+
+		for _, pid := range strings.Fields(contents) {
+			if !seen[pid] {
+				seen[pid] = true
+				pids = append(pids, pid)
+			}
+		}
+	}
+
+	return pids, nil
+}
+
+func readCgroupUint(fs boshsys.FileSystem, filePath string) (uint64, error) {
+	contents, err := fs.ReadFileString(filePath)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Reading '%s'", filePath)
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(contents), 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing '%s'", filePath)
+	}
+
+	return value, nil
+}
+
+func cgroupShellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
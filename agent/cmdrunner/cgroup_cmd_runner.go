@@ -0,0 +1,176 @@
+package cmdrunner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// ResourceUsageReporter is implemented by processes that keep track
+// of resources used by them and all of their children.
+type ResourceUsageReporter interface {
+	// ResourceUsage must only be called after process exited
+	ResourceUsage() (CgroupUsage, error)
+}
+
+// ResourceUsageCmdRunner is implemented by runners that keep track of resources
+// used by synchronously run commands and all of their children.
+type ResourceUsageCmdRunner interface {
+	RunComplexCommandWithUsage(cmd boshsys.Command) (string, string, int, CgroupUsage, error)
+}
+
+var cgroupNameUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// CgroupCmdRunner runs each complex command in its own cgroup.
+// Simple commands are used by the agent itself and run as is.
+type CgroupCmdRunner struct {
+	cmdRunner      boshsys.CmdRunner
+	cgroupProvider CgroupProvider
+	limits         CgroupLimits
+
+	// Makes cgroup names unique among concurrently running commands
+	counter *uint64
+
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewCgroupCmdRunner(
+	cmdRunner boshsys.CmdRunner,
+	cgroupProvider CgroupProvider,
+	limits CgroupLimits,
+	logger boshlog.Logger,
+) boshsys.CmdRunner {
+	return CgroupCmdRunner{
+		cmdRunner:      cmdRunner,
+		cgroupProvider: cgroupProvider,
+		limits:         limits,
+		counter:        new(uint64),
+		logger:         logger,
+		logTag:         "cgroupCmdRunner",
+	}
+}
+
+func (r CgroupCmdRunner) RunComplexCommand(cmd boshsys.Command) (string, string, int, error) {
+	stdout, stderr, exitStatus, _, err := r.RunComplexCommandWithUsage(cmd)
+	return stdout, stderr, exitStatus, err
+}
+
+func (r CgroupCmdRunner) RunComplexCommandWithUsage(cmd boshsys.Command) (string, string, int, CgroupUsage, error) {
+	cgroup, err := r.newCgroup(cmd)
+	if err != nil {
+		return "", "", -1, CgroupUsage{}, err
+	}
+
+	defer r.cleanUp(cgroup)
+
+	stdout, stderr, exitStatus, err := r.cmdRunner.RunComplexCommand(cgroup.Wrap(cmd))
+
+	// Usage has to be collected before cgroup is removed
+	usage, usageErr := cgroup.Usage()
+	if usageErr != nil {
+		r.logger.Warn(r.logTag, "Failed to collect resource usage: %s", usageErr.Error())
+	}
+
+	return stdout, stderr, exitStatus, usage, err
+}
+
+func (r CgroupCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	cgroup, err := r.newCgroup(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	process, err := r.cmdRunner.RunComplexCommandAsync(cgroup.Wrap(cmd))
+	if err != nil {
+		r.cleanUp(cgroup)
+		return nil, err
+	}
+
+	return &cgroupProcess{process: process, cgroup: cgroup, runner: r}, nil
+}
+
+func (r CgroupCmdRunner) RunCommand(cmdName string, args ...string) (string, string, int, error) {
+	return r.cmdRunner.RunCommand(cmdName, args...)
+}
+
+func (r CgroupCmdRunner) RunCommandWithInput(input, cmdName string, args ...string) (string, string, int, error) {
+	return r.cmdRunner.RunCommandWithInput(input, cmdName, args...)
+}
+
+func (r CgroupCmdRunner) CommandExists(cmdName string) bool {
+	return r.cmdRunner.CommandExists(cmdName)
+}
+
+func (r CgroupCmdRunner) newCgroup(cmd boshsys.Command) (Cgroup, error) {
+	baseName := cgroupNameUnsafeChars.ReplaceAllString(filepath.Base(cmd.Name), "_")
+	name := fmt.Sprintf("%s-%d", baseName, atomic.AddUint64(r.counter, 1))
+
+	cgroup, err := r.cgroupProvider.NewCgroup(name, r.limits)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating cgroup for '%s'", cmd.Name)
+	}
+
+	return cgroup, nil
+}
+
+// cleanUp kills processes that command left behind and removes its cgroup
+func (r CgroupCmdRunner) cleanUp(cgroup Cgroup) {
+	err := cgroup.Kill()
+	if err != nil {
+		r.logger.Warn(r.logTag, "Failed to kill remaining processes: %s", err.Error())
+	}
+
+	err = cgroup.Remove()
+	if err != nil {
+		r.logger.Warn(r.logTag, "Failed to remove cgroup: %s", err.Error())
+	}
+}
+
+type cgroupProcess struct {
+	process boshsys.Process
+	cgroup  Cgroup
+	runner  CgroupCmdRunner
+
+	usage    CgroupUsage
+	usageErr error
+}
+
+func (p *cgroupProcess) Wait() <-chan boshsys.Result {
+	resultCh := make(chan boshsys.Result, 1)
+
+	go func() {
+		result := <-p.process.Wait()
+
+		// Usage has to be collected before cgroup is removed
+		p.usage, p.usageErr = p.cgroup.Usage()
+		p.runner.cleanUp(p.cgroup)
+
+		resultCh <- result
+	}()
+
+	return resultCh
+}
+
+// TerminateNicely terminates process group of the command
+// and then kills processes that escaped it but are still in the cgroup.
+func (p *cgroupProcess) TerminateNicely(killGracePeriod time.Duration) error {
+	err := p.process.TerminateNicely(killGracePeriod)
+
+	killErr := p.cgroup.Kill()
+	if killErr != nil {
+		return bosherr.WrapError(killErr, "Killing cgroup processes")
+	}
+
+	return err
+}
+
+func (p *cgroupProcess) ResourceUsage() (CgroupUsage, error) {
+	return p.usage, p.usageErr
+}
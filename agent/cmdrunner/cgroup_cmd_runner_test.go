@@ -0,0 +1,153 @@
+package cmdrunner_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("CgroupCmdRunner", func() {
+	var (
+		cmdRunner      *fakesys.FakeCmdRunner
+		cgroupProvider *fakecmdrunner.FakeCgroupProvider
+		limits         CgroupLimits
+		runner         boshsys.CmdRunner
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		cgroupProvider = fakecmdrunner.NewFakeCgroupProvider()
+		limits = CgroupLimits{MemoryMB: 256}
+		runner = NewCgroupCmdRunner(cmdRunner, cgroupProvider, limits, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("RunComplexCommand", func() {
+		It("runs command in its own cgroup and removes cgroup afterwards", func() {
+			cmdRunner.AddCmdResult("fake-cgroup-wrapper fake-script-1 /jobs/fake-job/bin/fake-script fake-arg", fakesys.FakeCmdResult{
+				Stdout:     "fake-stdout",
+				ExitStatus: 0,
+			})
+
+			stdout, _, _, err := runner.RunComplexCommand(boshsys.Command{
+				Name: "/jobs/fake-job/bin/fake-script",
+				Args: []string{"fake-arg"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stdout).To(Equal("fake-stdout"))
+
+			Expect(cgroupProvider.Cgroups).To(HaveLen(1))
+			Expect(cgroupProvider.Cgroups[0].Name).To(Equal("fake-script-1"))
+			Expect(cgroupProvider.Cgroups[0].Limits).To(Equal(limits))
+			Expect(cgroupProvider.Cgroups[0].KillCallCount).To(Equal(1))
+			Expect(cgroupProvider.Cgroups[0].Removed).To(BeTrue())
+		})
+
+		It("uses unique cgroup names that are safe to use as directory names", func() {
+			runner.RunComplexCommand(boshsys.Command{Name: "/fake script"})
+			runner.RunComplexCommand(boshsys.Command{Name: "/fake script"})
+
+			Expect(cgroupProvider.Cgroups[0].Name).To(Equal("fake_script-1"))
+			Expect(cgroupProvider.Cgroups[1].Name).To(Equal("fake_script-2"))
+		})
+
+		It("does not run command when cgroup cannot be created", func() {
+			cgroupProvider.NewCgroupErr = errors.New("fake-cgroup-err")
+
+			_, _, _, err := runner.RunComplexCommand(boshsys.Command{Name: "/fake-script"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Creating cgroup for '/fake-script'"))
+			Expect(err.Error()).To(ContainSubstring("fake-cgroup-err"))
+			Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("RunComplexCommandWithUsage", func() {
+		It("reports resource usage collected before cgroup is removed", func() {
+			cmdRunner.AddCmdResult("fake-cgroup-wrapper fake-script-1 /fake-script", fakesys.FakeCmdResult{
+				ExitStatus: 1,
+				Error:      errors.New("fake-run-err"),
+			})
+			cgroupProvider.UsageResult = CgroupUsage{PeakMemoryBytes: 1024, CPUTime: time.Second}
+
+			usageRunner := runner.(ResourceUsageCmdRunner)
+
+			_, _, exitStatus, usage, err := usageRunner.RunComplexCommandWithUsage(boshsys.Command{Name: "/fake-script"})
+			Expect(err).To(HaveOccurred())
+			Expect(exitStatus).To(Equal(1))
+			Expect(usage).To(Equal(CgroupUsage{PeakMemoryBytes: 1024, CPUTime: time.Second}))
+			Expect(cgroupProvider.Cgroups[0].Removed).To(BeTrue())
+		})
+	})
+
+	Describe("RunComplexCommandAsync", func() {
+		var (
+			process *fakesys.FakeProcess
+		)
+
+		BeforeEach(func() {
+			process = &fakesys.FakeProcess{}
+			cmdRunner.AddProcess("fake-cgroup-wrapper fake-script-1 /fake-script", process)
+			cgroupProvider.UsageResult = CgroupUsage{PeakMemoryBytes: 1024, CPUTime: time.Second}
+		})
+
+		It("reports resource usage and removes cgroup after process exits", func() {
+			process.WaitResult = boshsys.Result{ExitStatus: 0}
+
+			asyncProcess, err := runner.RunComplexCommandAsync(boshsys.Command{Name: "/fake-script"})
+			Expect(err).ToNot(HaveOccurred())
+
+			result := <-asyncProcess.Wait()
+			Expect(result.ExitStatus).To(Equal(0))
+
+			usage, err := asyncProcess.(ResourceUsageReporter).ResourceUsage()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage).To(Equal(CgroupUsage{PeakMemoryBytes: 1024, CPUTime: time.Second}))
+
+			Expect(cgroupProvider.Cgroups[0].Removed).To(BeTrue())
+		})
+
+		It("kills whole cgroup when process is terminated", func() {
+			asyncProcess, err := runner.RunComplexCommandAsync(boshsys.Command{Name: "/fake-script"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(asyncProcess.TerminateNicely(10 * time.Second)).ToNot(HaveOccurred())
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+			Expect(cgroupProvider.Cgroups[0].KillCallCount).To(Equal(1))
+		})
+
+		It("returns error when cgroup cannot be killed", func() {
+			asyncProcess, err := runner.RunComplexCommandAsync(boshsys.Command{Name: "/fake-script"})
+			Expect(err).ToNot(HaveOccurred())
+
+			cgroupProvider.Cgroups[0].KillErr = errors.New("fake-kill-err")
+
+			err = asyncProcess.TerminateNicely(10 * time.Second)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-kill-err"))
+		})
+
+		It("removes cgroup when command cannot be started", func() {
+			process.StartErr = errors.New("fake-start-err")
+
+			_, err := runner.RunComplexCommandAsync(boshsys.Command{Name: "/fake-script"})
+			Expect(err).To(HaveOccurred())
+			Expect(cgroupProvider.Cgroups[0].Removed).To(BeTrue())
+		})
+	})
+
+	It("runs simple commands without cgroup", func() {
+		runner.RunCommand("fake-cmd", "fake-arg")
+
+		Expect(cmdRunner.RunCommands).To(Equal([][]string{{"fake-cmd", "fake-arg"}}))
+		Expect(cgroupProvider.Cgroups).To(BeEmpty())
+	})
+})
@@ -0,0 +1,239 @@
+package cmdrunner_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("CgroupProvider", func() {
+	var (
+		fs       *fakesys.FakeFileSystem
+		runner   *fakesys.FakeCmdRunner
+		provider CgroupProvider
+		limits   CgroupLimits
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		provider = NewCgroupProvider(fs, runner, "/cgroup", boshlog.NewLogger(boshlog.LevelNone))
+		limits = CgroupLimits{MemoryMB: 512, CPUPercent: 150, MaxProcesses: 100}
+	})
+
+	Context("when unified hierarchy is mounted", func() {
+		const cgroupDir = "/cgroup/bosh-scripts/fake-script-1"
+
+		BeforeEach(func() {
+			fs.WriteFileString("/cgroup/cgroup.controllers", "cpu memory pids")
+		})
+
+		It("creates cgroup with enabled controllers and limits", func() {
+			_, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/cgroup/cgroup.subtree_control")).To(Equal("+memory +cpu +pids"))
+			Expect(fs.ReadFileString("/cgroup/bosh-scripts/cgroup.subtree_control")).To(Equal("+memory +cpu +pids"))
+			Expect(fs.ReadFileString(cgroupDir + "/memory.max")).To(Equal("536870912"))
+			Expect(fs.ReadFileString(cgroupDir + "/cpu.max")).To(Equal("150000 100000"))
+			Expect(fs.ReadFileString(cgroupDir + "/pids.max")).To(Equal("100"))
+		})
+
+		It("does not set limits that are not configured", func() {
+			_, err := provider.NewCgroup("fake-script-1", CgroupLimits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(cgroupDir)).To(BeTrue())
+			Expect(fs.FileExists(cgroupDir + "/memory.max")).To(BeFalse())
+			Expect(fs.FileExists(cgroupDir + "/cpu.max")).To(BeFalse())
+			Expect(fs.FileExists(cgroupDir + "/pids.max")).To(BeFalse())
+		})
+
+		It("returns error when controllers cannot be enabled", func() {
+			fs.WriteFileErrors["/cgroup/cgroup.subtree_control"] = errors.New("fake-write-err")
+
+			_, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Enabling cgroup controllers in '/cgroup'"))
+		})
+
+		It("removes cgroup when limits cannot be set", func() {
+			fs.WriteFileErrors[cgroupDir+"/pids.max"] = errors.New("fake-write-err")
+
+			_, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Setting cgroup limit 'pids.max'"))
+			Expect(fs.FileExists(cgroupDir)).To(BeFalse())
+		})
+
+		It("wraps command so that it moves into cgroup before executing", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			cmd := cgroup.Wrap(boshsys.Command{
+				Name: "/fake-script",
+				Args: []string{"fake-arg"},
+				Env:  map[string]string{"FAKE": "env"},
+			})
+			Expect(cmd).To(Equal(boshsys.Command{
+				Name: "/bin/sh",
+				Args: []string{
+					"-c",
+					`echo $$ > '` + cgroupDir + `/cgroup.procs' && exec "$0" "$@"`,
+					"/fake-script",
+					"fake-arg",
+				},
+				Env: map[string]string{"FAKE": "env"},
+			}))
+		})
+
+		It("reports peak memory and cpu time", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString(cgroupDir+"/memory.peak", "1048576\n")
+			fs.WriteFileString(cgroupDir+"/cpu.stat", "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n")
+
+			usage, err := cgroup.Usage()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage).To(Equal(CgroupUsage{PeakMemoryBytes: 1048576, CPUTime: 2500 * time.Millisecond}))
+		})
+
+		It("kills all processes through cgroup.kill", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString(cgroupDir+"/cgroup.kill", "")
+
+			Expect(cgroup.Kill()).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString(cgroupDir + "/cgroup.kill")).To(Equal("1"))
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("kills listed processes when cgroup.kill is not available", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString(cgroupDir+"/cgroup.procs", "123\n456\n")
+			runner.SetCmdCallback("kill -KILL 123 456", func() {
+				fs.WriteFileString(cgroupDir+"/cgroup.procs", "")
+			})
+
+			Expect(cgroup.Kill()).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{{"kill", "-KILL", "123", "456"}}))
+		})
+
+		It("returns error when processes cannot be killed", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString(cgroupDir+"/cgroup.procs", "123\n")
+
+			err = cgroup.Kill()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Processes '123' are still running"))
+		})
+
+		It("removes cgroup", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cgroup.Remove()).ToNot(HaveOccurred())
+			Expect(fs.FileExists(cgroupDir)).To(BeFalse())
+			Expect(fs.FileExists("/cgroup/bosh-scripts")).To(BeTrue())
+		})
+	})
+
+	Context("when only v1 hierarchies are mounted", func() {
+		BeforeEach(func() {
+			fs.MkdirAll("/cgroup/memory", 0755)
+			fs.MkdirAll("/cgroup/cpu", 0755)
+			fs.MkdirAll("/cgroup/cpuacct", 0755)
+		})
+
+		It("creates cgroup in every mounted controller with limits", func() {
+			_, err := provider.NewCgroup("fake-script-1", CgroupLimits{MemoryMB: 512, CPUPercent: 50})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/cgroup/memory/bosh-scripts/fake-script-1/memory.limit_in_bytes")).To(Equal("536870912"))
+			Expect(fs.ReadFileString("/cgroup/cpu/bosh-scripts/fake-script-1/cpu.cfs_period_us")).To(Equal("100000"))
+			Expect(fs.ReadFileString("/cgroup/cpu/bosh-scripts/fake-script-1/cpu.cfs_quota_us")).To(Equal("50000"))
+			Expect(fs.FileExists("/cgroup/cpuacct/bosh-scripts/fake-script-1")).To(BeTrue())
+			Expect(fs.FileExists("/cgroup/pids/bosh-scripts/fake-script-1")).To(BeFalse())
+		})
+
+		It("returns error when limit requires controller that is not mounted", func() {
+			_, err := provider.NewCgroup("fake-script-1", CgroupLimits{MaxProcesses: 10})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Cgroup controller 'pids' is not mounted"))
+			Expect(fs.FileExists("/cgroup/memory/bosh-scripts/fake-script-1")).To(BeFalse())
+		})
+
+		It("returns error when no controllers are mounted", func() {
+			fs.RemoveAll("/cgroup")
+
+			_, err := provider.NewCgroup("fake-script-1", limits)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No cgroup controllers are mounted in '/cgroup'"))
+		})
+
+		It("wraps command so that it moves into cgroup of every controller", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", CgroupLimits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			cmd := cgroup.Wrap(boshsys.Command{Name: "/fake-script"})
+			Expect(cmd.Args).To(Equal([]string{
+				"-c",
+				`echo $$ > '/cgroup/memory/bosh-scripts/fake-script-1/cgroup.procs' && ` +
+					`echo $$ > '/cgroup/cpu/bosh-scripts/fake-script-1/cgroup.procs' && ` +
+					`echo $$ > '/cgroup/cpuacct/bosh-scripts/fake-script-1/cgroup.procs' && ` +
+					`exec "$0" "$@"`,
+				"/fake-script",
+			}))
+		})
+
+		It("reports peak memory and cpu time", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", CgroupLimits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString("/cgroup/memory/bosh-scripts/fake-script-1/memory.max_usage_in_bytes", "2048\n")
+			fs.WriteFileString("/cgroup/cpuacct/bosh-scripts/fake-script-1/cpuacct.usage", "1500000000\n")
+
+			usage, err := cgroup.Usage()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage).To(Equal(CgroupUsage{PeakMemoryBytes: 2048, CPUTime: 1500 * time.Millisecond}))
+		})
+
+		It("kills processes of all controllers once", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", CgroupLimits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString("/cgroup/memory/bosh-scripts/fake-script-1/cgroup.procs", "123\n")
+			fs.WriteFileString("/cgroup/cpu/bosh-scripts/fake-script-1/cgroup.procs", "123\n456\n")
+			runner.SetCmdCallback("kill -KILL 123 456", func() {
+				fs.WriteFileString("/cgroup/memory/bosh-scripts/fake-script-1/cgroup.procs", "")
+				fs.WriteFileString("/cgroup/cpu/bosh-scripts/fake-script-1/cgroup.procs", "")
+			})
+
+			Expect(cgroup.Kill()).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{{"kill", "-KILL", "123", "456"}}))
+		})
+
+		It("removes cgroup from all controllers", func() {
+			cgroup, err := provider.NewCgroup("fake-script-1", CgroupLimits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cgroup.Remove()).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/cgroup/memory/bosh-scripts/fake-script-1")).To(BeFalse())
+			Expect(fs.FileExists("/cgroup/cpu/bosh-scripts/fake-script-1")).To(BeFalse())
+			Expect(fs.FileExists("/cgroup/cpuacct/bosh-scripts/fake-script-1")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,168 @@
+package cmdrunner
+
+import (
+	"path"
+	"strconv"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Controllers are usually mounted as separate hierarchies;
+// cpu and cpuacct are often links to the same hierarchy.
+var cgroupV1Controllers = []string{"memory", "cpu", "cpuacct", "pids"}
+
+type cgroupV1 struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+
+	// Cgroup directory per mounted controller
+	dirs map[string]string
+}
+
+func newCgroupV1(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	root string,
+	name string,
+	limits CgroupLimits,
+) (Cgroup, error) {
+	cgroup := cgroupV1{fs: fs, runner: runner, dirs: map[string]string{}}
+
+	for _, controller := range cgroupV1Controllers {
+		if !fs.FileExists(path.Join(root, controller)) {
+			continue
+		}
+
+		dir := path.Join(root, controller, cgroupParentName, name)
+
+		err := fs.MkdirAll(dir, 0755)
+		if err != nil {
+			_ = cgroup.Remove()
+			return nil, bosherr.WrapErrorf(err, "Creating cgroup '%s'", dir)
+		}
+
+		cgroup.dirs[controller] = dir
+	}
+
+	if len(cgroup.dirs) == 0 {
+		return nil, bosherr.Errorf("No cgroup controllers are mounted in '%s'", root)
+	}
+
+	err := cgroup.applyLimits(limits)
+	if err != nil {
+		_ = cgroup.Remove()
+		return nil, err
+	}
+
+	return cgroup, nil
+}
+
+func (c cgroupV1) applyLimits(limits CgroupLimits) error {
+	if limits.MemoryMB > 0 {
+		err := c.write("memory", "memory.limit_in_bytes", strconv.Itoa(limits.MemoryMB*1024*1024))
+		if err != nil {
+			return err
+		}
+	}
+
+	if limits.CPUPercent > 0 {
+		err := c.write("cpu", "cpu.cfs_period_us", strconv.Itoa(cgroupCPUPeriodMicros))
+		if err != nil {
+			return err
+		}
+
+		err = c.write("cpu", "cpu.cfs_quota_us", strconv.Itoa(limits.CPUPercent*cgroupCPUPeriodMicros/100))
+		if err != nil {
+			return err
+		}
+	}
+
+	if limits.MaxProcesses > 0 {
+		err := c.write("pids", "pids.max", strconv.Itoa(limits.MaxProcesses))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c cgroupV1) write(controller, fileName, value string) error {
+	dir, found := c.dirs[controller]
+	if !found {
+		return bosherr.Errorf("Cgroup controller '%s' is not mounted", controller)
+	}
+
+	err := c.fs.WriteFileString(path.Join(dir, fileName), value)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting cgroup limit '%s'", fileName)
+	}
+
+	return nil
+}
+
+func (c cgroupV1) Wrap(cmd boshsys.Command) boshsys.Command {
+	return wrapCgroupCommand(cmd, c.procsPaths())
+}
+
+func (c cgroupV1) Usage() (CgroupUsage, error) {
+	var usage CgroupUsage
+
+	if dir, found := c.dirs["memory"]; found {
+		peak, err := readCgroupUint(c.fs, path.Join(dir, "memory.max_usage_in_bytes"))
+		if err != nil {
+			return usage, err
+		}
+		usage.PeakMemoryBytes = peak
+	}
+
+	if dir, found := c.dirs["cpuacct"]; found {
+		nanos, err := readCgroupUint(c.fs, path.Join(dir, "cpuacct.usage"))
+		if err != nil {
+			return usage, err
+		}
+		usage.CPUTime = time.Duration(nanos)
+	}
+
+	return usage, nil
+}
+
+func (c cgroupV1) Kill() error {
+	err := killCgroupProcs(c.fs, c.runner, c.procsPaths())
+	if err != nil {
+		return bosherr.WrapError(err, "Killing cgroup")
+	}
+
+	return nil
+}
+
+func (c cgroupV1) Remove() error {
+	for _, controller := range cgroupV1Controllers {
+		dir, found := c.dirs[controller]
+		if !found {
+			continue
+		}
+
+		err := c.fs.RemoveAll(dir)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing cgroup '%s'", dir)
+		}
+	}
+
+	return nil
+}
+
+// procsPaths are listed in a stable order so that generated commands are predictable
+func (c cgroupV1) procsPaths() []string {
+	paths := []string{}
+
+	for _, controller := range cgroupV1Controllers {
+		if dir, found := c.dirs[controller]; found {
+			paths = append(paths, path.Join(dir, "cgroup.procs"))
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,154 @@
+package cmdrunner
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type cgroupV2 struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+	dir    string
+}
+
+func newCgroupV2(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	root string,
+	name string,
+	limits CgroupLimits,
+) (Cgroup, error) {
+	parentDir := path.Join(root, cgroupParentName)
+	cgroup := cgroupV2{fs: fs, runner: runner, dir: path.Join(parentDir, name)}
+
+	err := fs.MkdirAll(parentDir, 0755)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating cgroup '%s'", parentDir)
+	}
+
+	// Controllers have to be enabled on every level above the script cgroup
+	for _, dir := range []string{root, parentDir} {
+		err = fs.WriteFileString(path.Join(dir, "cgroup.subtree_control"), "+memory +cpu +pids")
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Enabling cgroup controllers in '%s'", dir)
+		}
+	}
+
+	err = fs.MkdirAll(cgroup.dir, 0755)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating cgroup '%s'", cgroup.dir)
+	}
+
+	err = cgroup.applyLimits(limits)
+	if err != nil {
+		_ = cgroup.Remove()
+		return nil, err
+	}
+
+	return cgroup, nil
+}
+
+func (c cgroupV2) applyLimits(limits CgroupLimits) error {
+	values := map[string]string{}
+
+	if limits.MemoryMB > 0 {
+		values["memory.max"] = strconv.Itoa(limits.MemoryMB * 1024 * 1024)
+	}
+
+	if limits.CPUPercent > 0 {
+		quota := limits.CPUPercent * cgroupCPUPeriodMicros / 100
+		values["cpu.max"] = fmt.Sprintf("%d %d", quota, cgroupCPUPeriodMicros)
+	}
+
+	if limits.MaxProcesses > 0 {
+		values["pids.max"] = strconv.Itoa(limits.MaxProcesses)
+	}
+
+	for fileName, value := range values {
+		err := c.fs.WriteFileString(path.Join(c.dir, fileName), value)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Setting cgroup limit '%s'", fileName)
+		}
+	}
+
+	return nil
+}
+
+func (c cgroupV2) Wrap(cmd boshsys.Command) boshsys.Command {
+	return wrapCgroupCommand(cmd, []string{c.procsPath()})
+}
+
+func (c cgroupV2) Usage() (CgroupUsage, error) {
+	var usage CgroupUsage
+
+	// memory.peak is only available on newer kernels
+	peakPath := path.Join(c.dir, "memory.peak")
+	if c.fs.FileExists(peakPath) {
+		peak, err := readCgroupUint(c.fs, peakPath)
+		if err != nil {
+			return usage, err
+		}
+		usage.PeakMemoryBytes = peak
+	}
+
+	statPath := path.Join(c.dir, "cpu.stat")
+
+	stat, err := c.fs.ReadFileString(statPath)
+	if err != nil {
+		return usage, bosherr.WrapErrorf(err, "Reading '%s'", statPath)
+	}
+
+	for _, line := range strings.Split(stat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "usage_usec" {
+			continue
+		}
+
+		usec, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return usage, bosherr.WrapErrorf(err, "Parsing '%s'", statPath)
+		}
+
+		usage.CPUTime = time.Duration(usec) * time.Microsecond
+	}
+
+	return usage, nil
+}
+
+func (c cgroupV2) Kill() error {
+	// cgroup.kill atomically kills all processes including newly forked ones
+	killPath := path.Join(c.dir, "cgroup.kill")
+	if c.fs.FileExists(killPath) {
+		err := c.fs.WriteFileString(killPath, "1")
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Killing cgroup '%s'", c.dir)
+		}
+	}
+
+	// Makes sure that processes are gone before cgroup is removed
+	err := killCgroupProcs(c.fs, c.runner, []string{c.procsPath()})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Killing cgroup '%s'", c.dir)
+	}
+
+	return nil
+}
+
+func (c cgroupV2) Remove() error {
+	err := c.fs.RemoveAll(c.dir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing cgroup '%s'", c.dir)
+	}
+
+	return nil
+}
+
+func (c cgroupV2) procsPath() string {
+	return path.Join(c.dir, "cgroup.procs")
+}
@@ -1,6 +1,8 @@
 package cmdrunner
 
 import (
+	"time"
+
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
@@ -13,6 +15,15 @@ type CmdResult struct {
 	Stderr []byte
 
 	ExitStatus int
+
+	// Full output is kept in these files
+	StdoutPath string
+	StderrPath string
+
+	Duration time.Duration
+
+	// Only known when command ran in its own cgroup
+	ResourceUsage *CgroupUsage
 }
 
 type CmdRunner interface {
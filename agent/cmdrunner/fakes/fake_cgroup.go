@@ -0,0 +1,70 @@
+package fakes
+
+import (
+	boshcmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type FakeCgroup struct {
+	Name   string
+	Limits boshcmdrunner.CgroupLimits
+
+	WrappedCmds []boshsys.Command
+
+	UsageResult boshcmdrunner.CgroupUsage
+	UsageErr    error
+
+	KillCallCount int
+	KillErr       error
+
+	Removed   bool
+	RemoveErr error
+}
+
+func (c *FakeCgroup) Wrap(cmd boshsys.Command) boshsys.Command {
+	c.WrappedCmds = append(c.WrappedCmds, cmd)
+
+	wrappedCmd := cmd
+	wrappedCmd.Name = "fake-cgroup-wrapper"
+	wrappedCmd.Args = append([]string{c.Name, cmd.Name}, cmd.Args...)
+
+	return wrappedCmd
+}
+
+func (c *FakeCgroup) Usage() (boshcmdrunner.CgroupUsage, error) {
+	return c.UsageResult, c.UsageErr
+}
+
+func (c *FakeCgroup) Kill() error {
+	c.KillCallCount++
+	return c.KillErr
+}
+
+func (c *FakeCgroup) Remove() error {
+	c.Removed = true
+	return c.RemoveErr
+}
+
+type FakeCgroupProvider struct {
+	// Returned cgroups are recorded in creation order
+	Cgroups []*FakeCgroup
+
+	UsageResult boshcmdrunner.CgroupUsage
+
+	NewCgroupErr error
+}
+
+func NewFakeCgroupProvider() *FakeCgroupProvider {
+	return &FakeCgroupProvider{}
+}
+
+func (p *FakeCgroupProvider) NewCgroup(name string, limits boshcmdrunner.CgroupLimits) (boshcmdrunner.Cgroup, error) {
+	if p.NewCgroupErr != nil {
+		return nil, p.NewCgroupErr
+	}
+
+	cgroup := &FakeCgroup{Name: name, Limits: limits, UsageResult: p.UsageResult}
+	p.Cgroups = append(p.Cgroups, cgroup)
+
+	return cgroup, nil
+}
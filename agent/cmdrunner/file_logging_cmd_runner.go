@@ -9,6 +9,7 @@ import (
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
 )
 
 const (
@@ -19,6 +20,7 @@ const (
 type FileLoggingCmdRunner struct {
 	fs             boshsys.FileSystem
 	cmdRunner      boshsys.CmdRunner
+	timeService    clock.Clock
 	baseDir        string
 	truncateLength int64
 }
@@ -47,15 +49,26 @@ func (f FileLoggingExecErr) Error() string {
 	)
 }
 
+func NewFileLoggingExecErr(result *CmdResult) FileLoggingExecErr {
+	return FileLoggingExecErr{result: result}
+}
+
+// Result returns output and details of the failed command
+func (f FileLoggingExecErr) Result() *CmdResult {
+	return f.result
+}
+
 func NewFileLoggingCmdRunner(
 	fs boshsys.FileSystem,
 	cmdRunner boshsys.CmdRunner,
+	timeService clock.Clock,
 	baseDir string,
 	truncateLength int64,
 ) CmdRunner {
 	return FileLoggingCmdRunner{
 		fs:             fs,
 		cmdRunner:      cmdRunner,
+		timeService:    timeService,
 		baseDir:        baseDir,
 		truncateLength: truncateLength,
 	}
@@ -97,8 +110,12 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 
 	cmd.Stderr = stderrFile
 
+	startedAt := f.timeService.Now()
+
 	// Stdout/stderr are redirected to the files
-	_, _, exitStatus, runErr := f.cmdRunner.RunComplexCommand(cmd)
+	exitStatus, resourceUsage, runErr := f.runCommand(cmd)
+
+	duration := f.timeService.Since(startedAt)
 
 	stdout, isStdoutTruncated, err := f.getTruncatedOutput(stdoutFile, f.truncateLength)
 	if err != nil {
@@ -118,15 +135,31 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 		Stderr: stderr,
 
 		ExitStatus: exitStatus,
+
+		StdoutPath: stdoutPath,
+		StderrPath: stderrPath,
+
+		Duration:      duration,
+		ResourceUsage: resourceUsage,
 	}
 
 	if runErr != nil {
-		return nil, FileLoggingExecErr{result}
+		return nil, NewFileLoggingExecErr(result)
 	}
 
 	return result, nil
 }
 
+func (f FileLoggingCmdRunner) runCommand(cmd boshsys.Command) (int, *CgroupUsage, error) {
+	if usageRunner, ok := f.cmdRunner.(ResourceUsageCmdRunner); ok {
+		_, _, exitStatus, usage, err := usageRunner.RunComplexCommandWithUsage(cmd)
+		return exitStatus, &usage, err
+	}
+
+	_, _, exitStatus, err := f.cmdRunner.RunComplexCommand(cmd)
+	return exitStatus, nil, err
+}
+
 func (f FileLoggingCmdRunner) getTruncatedOutput(file boshsys.File, truncateLength int64) ([]byte, bool, error) {
 	isTruncated := false
 
@@ -3,19 +3,24 @@ package cmdrunner_test
 import (
 	"errors"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 var _ = Describe("FileLoggingCmdRunner", func() {
 	var (
 		fs        *fakesys.FakeFileSystem
 		cmdRunner *fakesys.FakeCmdRunner
+		clock     *fakeclock.FakeClock
 		cmd       boshsys.Command
 		runner    CmdRunner
 	)
@@ -23,7 +28,8 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 	BeforeEach(func() {
 		fs = fakesys.NewFakeFileSystem()
 		cmdRunner = fakesys.NewFakeCmdRunner()
-		runner = NewFileLoggingCmdRunner(fs, cmdRunner, "/fake-base-dir", 15)
+		clock = fakeclock.NewFakeClock(time.Now())
+		runner = NewFileLoggingCmdRunner(fs, cmdRunner, clock, "/fake-base-dir", 15)
 
 		cmd = boshsys.Command{
 			Name:       "fake-cmd",
@@ -101,6 +107,8 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 					Stdout:            []byte("fake-stdout"),
 					Stderr:            []byte("fake-stderr"),
 					ExitStatus:        0,
+					StdoutPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log",
+					StderrPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stderr.log",
 				}
 
 				result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
@@ -131,6 +139,56 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 			})
 		})
 
+		It("reports how long command ran", func() {
+			cmdRunner.SetCmdCallback("fake-cmd fake-args", func() {
+				clock.Increment(3 * time.Second)
+			})
+
+			result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Duration).To(Equal(3 * time.Second))
+		})
+
+		It("does not report resource usage when command runner does not keep track of it", func() {
+			result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ResourceUsage).To(BeNil())
+		})
+
+		Context("when command runner keeps track of resource usage", func() {
+			var (
+				cgroupProvider *fakecmdrunner.FakeCgroupProvider
+			)
+
+			BeforeEach(func() {
+				cgroupProvider = fakecmdrunner.NewFakeCgroupProvider()
+				cgroupProvider.UsageResult = CgroupUsage{PeakMemoryBytes: 2048, CPUTime: 5 * time.Second}
+
+				cgroupRunner := NewCgroupCmdRunner(cmdRunner, cgroupProvider, CgroupLimits{}, boshlog.NewLogger(boshlog.LevelNone))
+				runner = NewFileLoggingCmdRunner(fs, cgroupRunner, clock, "/fake-base-dir", 15)
+			})
+
+			It("reports resource usage of successful command", func() {
+				result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.ResourceUsage).To(Equal(&CgroupUsage{PeakMemoryBytes: 2048, CPUTime: 5 * time.Second}))
+			})
+
+			It("reports resource usage of failed command", func() {
+				cmdRunner.AddCmdResult("fake-cgroup-wrapper fake-cmd-1 fake-cmd fake-args", fakesys.FakeCmdResult{
+					ExitStatus: 1,
+					Error:      errors.New("fake-result-error"),
+				})
+
+				_, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
+				Expect(err).To(HaveOccurred())
+
+				execErr, ok := err.(FileLoggingExecErr)
+				Expect(ok).To(BeTrue())
+				Expect(execErr.Result().ResourceUsage).To(Equal(&CgroupUsage{PeakMemoryBytes: 2048, CPUTime: 5 * time.Second}))
+			})
+		})
+
 		Context("when comamnd fails", func() {
 			BeforeEach(func() {
 				cmdRunner.AddCmdResult("fake-cmd fake-args", fakesys.FakeCmdResult{
@@ -148,6 +206,17 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 				Expect(result).To(BeNil())
 			})
 
+			It("returns result of failed command with the error", func() {
+				_, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
+				Expect(err).To(HaveOccurred())
+
+				execErr, ok := err.(FileLoggingExecErr)
+				Expect(ok).To(BeTrue())
+				Expect(execErr.Result().ExitStatus).To(Equal(1))
+				Expect(execErr.Result().StdoutPath).To(Equal("/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log"))
+				Expect(execErr.Result().StderrPath).To(Equal("/fake-base-dir/fake-log-dir-name/fake-log-file-name.stderr.log"))
+			})
+
 			It("saves stdout to log file", func() {
 				_, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
 				Expect(err).To(HaveOccurred())
@@ -185,6 +254,8 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 					Stdout:            []byte("g-output-stdout"),
 					Stderr:            []byte("g-output-stderr"),
 					ExitStatus:        0,
+					StdoutPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log",
+					StderrPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stderr.log",
 				}
 
 				result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
@@ -205,6 +276,8 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 					Stdout:            []byte("output-stdout"),
 					Stderr:            []byte("output-stderr"),
 					ExitStatus:        0,
+					StdoutPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log",
+					StderrPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stderr.log",
 				}
 
 				result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
@@ -225,6 +298,8 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 					Stdout:            []byte("-output-std\nout"),
 					Stderr:            []byte("-output-std\nerr"),
 					ExitStatus:        0,
+					StdoutPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log",
+					StderrPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stderr.log",
 				}
 
 				result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
@@ -245,6 +320,8 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 					Stdout:            []byte("иветstdout"),
 					Stderr:            []byte("иветstderr"),
 					ExitStatus:        0,
+					StdoutPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stdout.log",
+					StderrPath:        "/fake-base-dir/fake-log-dir-name/fake-log-file-name.stderr.log",
 				}
 
 				result, err := runner.RunCommand("fake-log-dir-name", "fake-log-file-name", cmd)
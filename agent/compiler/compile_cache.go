@@ -0,0 +1,103 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CompileCache remembers compiled packages so that package with the same
+// sources compiled against the same dependencies is not compiled again.
+type CompileCache interface {
+	Get(pkg Package, deps []boshmodels.Package) (CompiledPackage, bool, error)
+	Save(pkg Package, deps []boshmodels.Package, compiledPkg CompiledPackage) error
+}
+
+type fileCompileCache struct {
+	cachePath string
+	options   Options
+	fs        boshsys.FileSystem
+}
+
+// NewFileCompileCache returns cache that only finds packages
+// compiled with the same options since they affect compilation results.
+func NewFileCompileCache(cachePath string, options Options, fs boshsys.FileSystem) CompileCache {
+	return fileCompileCache{cachePath: cachePath, options: options, fs: fs}
+}
+
+func (c fileCompileCache) Get(pkg Package, deps []boshmodels.Package) (CompiledPackage, bool, error) {
+	var compiledPkg CompiledPackage
+
+	entryPath := c.entryPath(pkg, deps)
+	if !c.fs.FileExists(entryPath) {
+		return compiledPkg, false, nil
+	}
+
+	bytes, err := c.fs.ReadFile(entryPath)
+	if err != nil {
+		return compiledPkg, false, bosherr.WrapErrorf(err, "Reading compile cache entry '%s'", entryPath)
+	}
+
+	err = json.Unmarshal(bytes, &compiledPkg)
+	if err != nil {
+		return compiledPkg, false, bosherr.WrapErrorf(err, "Unmarshalling compile cache entry '%s'", entryPath)
+	}
+
+	return compiledPkg, true, nil
+}
+
+func (c fileCompileCache) Save(pkg Package, deps []boshmodels.Package, compiledPkg CompiledPackage) error {
+	bytes, err := json.Marshal(compiledPkg)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling compile cache entry")
+	}
+
+	err = c.fs.MkdirAll(c.cachePath, os.FileMode(0755))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating compile cache directory")
+	}
+
+	entryPath := c.entryPath(pkg, deps)
+
+	err = c.fs.WriteFile(entryPath, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing compile cache entry '%s'", entryPath)
+	}
+
+	return nil
+}
+
+func (c fileCompileCache) entryPath(pkg Package, deps []boshmodels.Package) string {
+	return path.Join(c.cachePath, compileCacheKey(pkg, deps, c.options)+".json")
+}
+
+// compileCacheKey identifies compilation by package sources, exact dependencies
+// and options packaging script runs with; dependency order does not change compilation results.
+func compileCacheKey(pkg Package, deps []boshmodels.Package, options Options) string {
+	depKeys := []string{}
+	for _, dep := range deps {
+		depKeys = append(depKeys, fmt.Sprintf("%s/%s", dep.Name, dep.Source.Sha1.String()))
+	}
+
+	sort.Strings(depKeys)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s/%s\n", pkg.Name, pkg.Sha1.String())
+
+	for _, depKey := range depKeys {
+		fmt.Fprintf(hash, "%s\n", depKey)
+	}
+
+	fmt.Fprintf(hash, "isolated=%t network-enabled=%t source-date-epoch=%d\n",
+		options.Isolated, options.NetworkEnabled, sourceDateEpochOrDefault(options.SourceDateEpoch))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package compiler_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("fileCompileCache", func() {
+	var (
+		fs           *fakesys.FakeFileSystem
+		compileCache CompileCache
+
+		pkg         Package
+		pkgDeps     []boshmodels.Package
+		compiledPkg CompiledPackage
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		compileCache = NewFileCompileCache("/fake-compile-cache", Options{}, fs)
+
+		pkg, pkgDeps = getCompileArgs()
+
+		compiledPkg = CompiledPackage{
+			BlobstoreID: "fake-compiled-blob-id",
+			Digest:      boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "fakecompiledsha256")),
+		}
+	})
+
+	It("returns saved compiled package", func() {
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		foundPkg, found, err := compileCache.Get(pkg, pkgDeps)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(foundPkg.BlobstoreID).To(Equal("fake-compiled-blob-id"))
+		Expect(foundPkg.Digest.String()).To(Equal("sha256:fakecompiledsha256"))
+	})
+
+	It("finds compiled package regardless of dependency order", func() {
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found, err := compileCache.Get(pkg, []boshmodels.Package{pkgDeps[1], pkgDeps[0]})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+	})
+
+	It("does not find package that was not compiled", func() {
+		_, found, err := compileCache.Get(pkg, pkgDeps)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not find package with different sources", func() {
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		pkg.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "other-sha1"))
+
+		_, found, err := compileCache.Get(pkg, pkgDeps)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not find package compiled against different dependencies", func() {
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		pkgDeps[1].Source.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "other-sha1"))
+
+		_, found, err := compileCache.Get(pkg, pkgDeps)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		_, found, err = compileCache.Get(pkg, pkgDeps[:1])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not find package compiled with different options", func() {
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, options := range []Options{
+			{Isolated: true},
+			{NetworkEnabled: true},
+			{SourceDateEpoch: 1234},
+		} {
+			_, found, err := NewFileCompileCache("/fake-compile-cache", options, fs).Get(pkg, pkgDeps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		}
+
+		_, found, err := NewFileCompileCache("/fake-compile-cache", Options{MaxInstalledPackages: 1}, fs).Get(pkg, pkgDeps)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+	})
+
+	It("returns an error if saved entry cannot be read", func() {
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.ReadFileError = errors.New("fake-read-error")
+
+		_, found, err := compileCache.Get(pkg, pkgDeps)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns an error if entry cannot be written", func() {
+		fs.WriteFileError = errors.New("fake-write-error")
+
+		err := compileCache.Save(pkg, pkgDeps, compiledPkg)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+	})
+})
@@ -1,12 +1,70 @@
 package compiler
 
 import (
+	"time"
+
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type Compiler interface {
-	Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error)
+	Compile(pkg Package, deps []boshmodels.Package) (CompiledPackage, error)
+}
+
+// CompiledPackage is a compiled package uploaded to the blobstore
+type CompiledPackage struct {
+	BlobstoreID string                    `json:"blobstore_id"`
+	Digest      boshcrypto.MultipleDigest `json:"digest"`
+
+	// Full output of the packaging script;
+	// empty when there is no packaging script or output could not be uploaded
+	LogsBlobstoreID string `json:"logs_blobstore_id,omitempty"`
+
+	Packaging PackagingStats `json:"packaging"`
+}
+
+// PackagingScriptError is returned when packaging script fails;
+// LogsBlobstoreID is empty when script output could not be uploaded
+type PackagingScriptError struct {
+	Err             error
+	LogsBlobstoreID string
+}
+
+func (e PackagingScriptError) Error() string {
+	return e.wrappedErr().Error()
+}
+
+func (e PackagingScriptError) ShortError() string {
+	return e.wrappedErr().ShortError()
+}
+
+// Details exposes uploaded packaging script output in exception responses
+func (e PackagingScriptError) Details() map[string]interface{} {
+	if e.LogsBlobstoreID == "" {
+		return nil
+	}
+
+	return map[string]interface{}{"logs_blobstore_id": e.LogsBlobstoreID}
+}
+
+func (e PackagingScriptError) wrappedErr() bosherr.ComplexError {
+	if e.LogsBlobstoreID == "" {
+		return bosherr.ComplexError{Err: bosherr.Error("Running packaging script"), Cause: e.Err}
+	}
+
+	return bosherr.ComplexError{
+		Err:   bosherr.Errorf("Running packaging script (full output uploaded to blob '%s')", e.LogsBlobstoreID),
+		Cause: e.Err,
+	}
+}
+
+type PackagingStats struct {
+	Duration time.Duration `json:"duration"`
+
+	// Only known when packaging script ran in its own cgroup
+	PeakMemoryBytes uint64        `json:"peak_memory_bytes,omitempty"`
+	CPUTime         time.Duration `json:"cpu_time,omitempty"`
 }
 
 type Package struct {
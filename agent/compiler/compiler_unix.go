@@ -3,11 +3,21 @@
 package compiler
 
 import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+func (c concreteCompiler) packagingCommand(
+	compilePath, installPath, enablePath string,
+	pkg Package,
+	deps []boshmodels.Package,
+) (boshsys.Command, error) {
 	command := boshsys.Command{
 		Name: "bash",
 		Args: []string{"-x", PackagingScriptName},
@@ -19,9 +29,81 @@ func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pk
 		},
 		WorkingDir: compilePath,
 	}
-	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
-	if err != nil {
-		return bosherr.WrapError(err, "Running packaging script")
+
+	if !c.options.Isolated {
+		return command, nil
+	}
+
+	return c.isolatePackagingCommand(command, compilePath, installPath, enablePath, deps)
+}
+
+// isolatePackagingCommand makes packaging script only depend on its inputs:
+// environment is limited to documented variables and private mount namespace
+// only exposes declared dependencies (read-only) and the package being compiled.
+func (c concreteCompiler) isolatePackagingCommand(
+	command boshsys.Command,
+	compilePath, installPath, enablePath string,
+	deps []boshmodels.Package,
+) (boshsys.Command, error) {
+	command.UseIsolatedEnv = true
+	command.Env["PATH"] = "/usr/sbin:/usr/bin:/sbin:/bin"
+	command.Env["HOME"] = compilePath
+	command.Env["LANG"] = "C.UTF-8"
+	command.Env["LC_ALL"] = "C.UTF-8"
+	command.Env["TZ"] = "UTC"
+	command.Env["SOURCE_DATE_EPOCH"] = strconv.FormatInt(sourceDateEpochOrDefault(c.options.SourceDateEpoch), 10)
+
+	enableDir := path.Dir(enablePath)
+	installDir := path.Dir(path.Dir(installPath))
+
+	// Installed bundles are hidden last since bind mounts keep referring to them
+	script := []string{
+		"set -e",
+		fmt.Sprintf("mount -t tmpfs -o mode=0755 tmpfs %s", shellQuote(enableDir)),
+	}
+
+	for _, dep := range deps {
+		depBundle, err := c.packagesBc.Get(dep)
+		if err != nil {
+			return command, bosherr.WrapErrorf(err, "Getting bundle for dependent package '%s'", dep.Name)
+		}
+
+		_, depInstallPath, err := depBundle.GetInstallPath()
+		if err != nil {
+			return command, bosherr.WrapErrorf(err, "Getting install path of dependent package '%s'", dep.Name)
+		}
+
+		depEnablePath := shellQuote(path.Join(enableDir, dep.Name))
+
+		script = append(script,
+			fmt.Sprintf("mkdir %s", depEnablePath),
+			fmt.Sprintf("mount --bind %s %s", shellQuote(depInstallPath), depEnablePath),
+			fmt.Sprintf("mount -o remount,bind,ro %s", depEnablePath),
+		)
+	}
+
+	script = append(script,
+		fmt.Sprintf("mkdir %s", shellQuote(enablePath)),
+		fmt.Sprintf("mount --bind %s %s", shellQuote(installPath), shellQuote(enablePath)),
+		fmt.Sprintf("mount -t tmpfs -o mode=0755 tmpfs %s", shellQuote(installDir)),
+		`exec "$0" "$@"`,
+	)
+
+	args := []string{"--mount", "--propagation", "private"}
+
+	if !c.options.NetworkEnabled {
+		// New network namespace only has loopback interface
+		args = append(args, "--net")
 	}
-	return nil
+
+	args = append(args, "--", "/bin/sh", "-c", strings.Join(script, "\n"), command.Name)
+
+	command.Name = "unshare"
+	command.Args = append(args, command.Args...)
+
+	return command, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
 }
@@ -3,11 +3,16 @@ package compiler
 import (
 	"fmt"
 
-	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+// Packaging scripts are not isolated on Windows
+func (c concreteCompiler) packagingCommand(
+	compilePath, installPath, enablePath string,
+	pkg Package,
+	deps []boshmodels.Package,
+) (boshsys.Command, error) {
 	command := boshsys.Command{
 		Name: "powershell",
 		Args: []string{"-command", fmt.Sprintf(`"iex (get-content -raw %s)"`, PackagingScriptName)},
@@ -20,9 +25,5 @@ func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pk
 		WorkingDir: compilePath,
 	}
 
-	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
-	if err != nil {
-		return bosherr.WrapError(err, "Running packaging script")
-	}
-	return nil
+	return command, nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sync"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
@@ -13,10 +14,15 @@ import (
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-const PackagingScriptName = "packaging"
+const (
+	PackagingScriptName = "packaging"
+
+	logTag = "concreteCompiler"
+)
 
 type CompileDirProvider interface {
 	CompileDir() string
@@ -30,6 +36,17 @@ type concreteCompiler struct {
 	compileDirProvider CompileDirProvider
 	packageApplier     packages.Applier
 	packagesBc         boshbc.BundleCollection
+	compileCache       CompileCache
+	options            Options
+	logger             boshlog.Logger
+
+	// Installed package bundles ordered from most recently used by compiles
+	usedPackagesLock *sync.Mutex
+	usedPackages     *[]boshbc.Bundle
+
+	// Compiled package blobs that are known to exist in blobstore
+	verifiedBlobsLock *sync.Mutex
+	verifiedBlobs     map[string]bool
 }
 
 func NewConcreteCompiler(
@@ -40,6 +57,9 @@ func NewConcreteCompiler(
 	compileDirProvider CompileDirProvider,
 	packageApplier packages.Applier,
 	packagesBc boshbc.BundleCollection,
+	compileCache CompileCache,
+	options Options,
+	logger boshlog.Logger,
 ) Compiler {
 	return concreteCompiler{
 		compressor:         compressor,
@@ -49,19 +69,49 @@ func NewConcreteCompiler(
 		compileDirProvider: compileDirProvider,
 		packageApplier:     packageApplier,
 		packagesBc:         packagesBc,
+		compileCache:       compileCache,
+		options:            options,
+		logger:             logger,
+
+		usedPackagesLock: &sync.Mutex{},
+		usedPackages:     &[]boshbc.Bundle{},
+
+		verifiedBlobsLock: &sync.Mutex{},
+		verifiedBlobs:     map[string]bool{},
 	}
 }
 
-func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
-	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
+func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (compiled CompiledPackage, err error) {
+	compiledPkgFromCache, found, err := c.compileCache.Get(pkg, deps)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Removing packages")
+		c.logger.Warn(logTag, "Failed to look up package %s in compile cache: %s", pkg.Name, err.Error())
+	} else if found {
+		verifyErr := c.verifyCompiledBlob(compiledPkgFromCache)
+		if verifyErr == nil {
+			c.logger.Info(logTag, "Using previously compiled package %s from blob %s", pkg.Name, compiledPkgFromCache.BlobstoreID)
+			return compiledPkgFromCache, nil
+		}
+
+		c.logger.Warn(logTag, "Compiling package %s again since previously compiled blob %s cannot be used: %s",
+			pkg.Name, compiledPkgFromCache.BlobstoreID, verifyErr.Error())
+	}
+
+	// Packages installed by previous compiles stay installed so that
+	// dependencies shared between packages are only downloaded once
+	err = c.uninstallLeastRecentlyUsedPackages(deps)
+	if err != nil {
+		return CompiledPackage{}, bosherr.WrapError(err, "Uninstalling unused packages")
+	}
+
+	err = c.disablePackagesExcept(deps)
+	if err != nil {
+		return CompiledPackage{}, bosherr.WrapError(err, "Disabling packages")
 	}
 
 	for _, dep := range deps {
 		err := c.packageApplier.Apply(dep)
 		if err != nil {
-			return "", nil, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
+			return CompiledPackage{}, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
 		}
 	}
 
@@ -69,7 +119,7 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 
 	err = c.fetchAndUncompress(pkg, compilePath)
 	if err != nil {
-		return "", nil, bosherr.WrapErrorf(err, "Fetching package %s", pkg.Name)
+		return CompiledPackage{}, bosherr.WrapErrorf(err, "Fetching package %s", pkg.Name)
 	}
 
 	defer c.fs.RemoveAll(compilePath)
@@ -88,30 +138,43 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 
 	compiledPkgBundle, err := c.packagesBc.Get(compiledPkg)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Getting bundle for new package")
+		return CompiledPackage{}, bosherr.WrapError(err, "Getting bundle for new package")
+	}
+
+	// Output of previously failed compile must not end up in compiled package
+	err = compiledPkgBundle.Uninstall()
+	if err != nil {
+		return CompiledPackage{}, bosherr.WrapError(err, "Uninstalling previously compiled package")
 	}
 
 	_, installPath, err := compiledPkgBundle.InstallWithoutContents()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Setting up new package bundle")
+		return CompiledPackage{}, bosherr.WrapError(err, "Setting up new package bundle")
 	}
 
+	defer func() {
+		if err != nil {
+			c.cleanUpFailedCompile(pkg, compiledPkgBundle)
+		}
+	}()
+
 	_, enablePath, err := compiledPkgBundle.Enable()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Enabling new package bundle")
+		return CompiledPackage{}, bosherr.WrapError(err, "Enabling new package bundle")
 	}
 
 	scriptPath := path.Join(compilePath, PackagingScriptName)
 
 	if c.fs.FileExists(scriptPath) {
-		if err := c.runPackagingCommand(compilePath, enablePath, pkg); err != nil {
-			return "", nil, bosherr.WrapError(err, "Running packaging script")
+		err = c.runPackagingScript(compilePath, installPath, enablePath, pkg, deps, &compiled)
+		if err != nil {
+			return CompiledPackage{}, err
 		}
 	}
 
 	tmpPackageTar, err := c.compressor.CompressFilesInDir(installPath)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Compressing compiled package")
+		return CompiledPackage{}, bosherr.WrapError(err, "Compressing compiled package")
 	}
 
 	defer func() {
@@ -120,36 +183,268 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 
 	file, err := c.fs.OpenFile(tmpPackageTar, os.O_RDONLY, os.ModePerm)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Opening compiled package")
+		return CompiledPackage{}, bosherr.WrapError(err, "Opening compiled package")
 	}
 
 	// Use SHA256, not the strongest algo from the source blob
-	digest, err = pkg.Sha1.Algorithm().CreateDigest(file)
+	digest, err := pkg.Sha1.Algorithm().CreateDigest(file)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Calculating compiled package digest")
+		return CompiledPackage{}, bosherr.WrapError(err, "Calculating compiled package digest")
 	}
 
 	uploadedBlobID, _, err := c.blobstore.Create(tmpPackageTar)
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Uploading compiled package")
+		return CompiledPackage{}, bosherr.WrapError(err, "Uploading compiled package")
 	}
 
 	err = compiledPkgBundle.Disable()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Disabling compiled package")
+		return CompiledPackage{}, bosherr.WrapError(err, "Disabling compiled package")
+	}
+
+	err = compiledPkgBundle.Uninstall()
+	if err != nil {
+		return CompiledPackage{}, bosherr.WrapError(err, "Uninstalling compiled package")
+	}
+
+	err = c.disablePackagesExcept([]boshmodels.Package{})
+	if err != nil {
+		return CompiledPackage{}, bosherr.WrapError(err, "Disabling packages")
+	}
+
+	compiled.BlobstoreID = uploadedBlobID
+	compiled.Digest = boshcrypto.MustNewMultipleDigest(digest)
+
+	c.markVerifiedBlob(uploadedBlobID)
+
+	err = c.compileCache.Save(pkg, deps, compiled)
+	if err != nil {
+		c.logger.Warn(logTag, "Failed to save package %s to compile cache: %s", pkg.Name, err.Error())
+	}
+
+	return compiled, nil
+}
+
+// runPackagingScript records how packaging script ran and uploads its full output
+// regardless of whether it succeeded so that failures can be investigated later.
+func (c concreteCompiler) runPackagingScript(
+	compilePath, installPath, enablePath string,
+	pkg Package,
+	deps []boshmodels.Package,
+	compiled *CompiledPackage,
+) error {
+	command, err := c.packagingCommand(compilePath, installPath, enablePath, pkg, deps)
+	if err != nil {
+		return bosherr.WrapError(err, "Preparing packaging script")
+	}
+
+	cmdResult, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
+	if execErr, ok := err.(boshcmdrunner.FileLoggingExecErr); ok {
+		cmdResult = execErr.Result()
+	}
+
+	if cmdResult != nil {
+		compiled.Packaging.Duration = cmdResult.Duration
+
+		if cmdResult.ResourceUsage != nil {
+			compiled.Packaging.PeakMemoryBytes = cmdResult.ResourceUsage.PeakMemoryBytes
+			compiled.Packaging.CPUTime = cmdResult.ResourceUsage.CPUTime
+		}
+
+		compiled.LogsBlobstoreID = c.uploadPackagingLogs(pkg, cmdResult)
+	}
+
+	if err != nil {
+		return PackagingScriptError{Err: err, LogsBlobstoreID: compiled.LogsBlobstoreID}
+	}
+
+	return nil
+}
+
+// uploadPackagingLogs returns empty blob ID when logs cannot be uploaded
+// since compilation result does not depend on them
+func (c concreteCompiler) uploadPackagingLogs(pkg Package, cmdResult *boshcmdrunner.CmdResult) string {
+	logsDir := path.Dir(cmdResult.StdoutPath)
+	logFiles := []string{path.Base(cmdResult.StdoutPath), path.Base(cmdResult.StderrPath)}
+
+	logsTar, err := c.compressor.CompressSpecificFilesInDir(logsDir, logFiles)
+	if err != nil {
+		c.logger.Warn(logTag, "Failed to compress packaging logs of package %s: %s", pkg.Name, err.Error())
+		return ""
+	}
+
+	defer func() {
+		_ = c.compressor.CleanUp(logsTar)
+	}()
+
+	logsBlobID, _, err := c.blobstore.Create(logsTar)
+	if err != nil {
+		c.logger.Warn(logTag, "Failed to upload packaging logs of package %s: %s", pkg.Name, err.Error())
+		return ""
+	}
+
+	return logsBlobID
+}
+
+// cleanUpFailedCompile removes partial output of packaging script
+func (c concreteCompiler) cleanUpFailedCompile(pkg Package, compiledPkgBundle boshbc.Bundle) {
+	err := compiledPkgBundle.Disable()
+	if err != nil {
+		c.logger.Warn(logTag, "Failed to disable package %s after failed compile: %s", pkg.Name, err.Error())
 	}
 
 	err = compiledPkgBundle.Uninstall()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Uninstalling compiled package")
+		c.logger.Warn(logTag, "Failed to uninstall package %s after failed compile: %s", pkg.Name, err.Error())
+	}
+}
+
+// verifyCompiledBlob makes sure that blob remembered in compile cache
+// still exists and has not changed since it was uploaded.
+// Blob is only downloaded the first time it is reused after agent started
+// since blobs uploaded or verified by this agent are already known to be valid.
+func (c concreteCompiler) verifyCompiledBlob(compiledPkg CompiledPackage) error {
+	if c.isVerifiedBlob(compiledPkg.BlobstoreID) {
+		return nil
+	}
+
+	blobPath, err := c.blobstore.Get(compiledPkg.BlobstoreID, compiledPkg.Digest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Fetching compiled package blob %s", compiledPkg.BlobstoreID)
+	}
+
+	err = c.blobstore.CleanUp(blobPath)
+	if err != nil {
+		c.logger.Warn(logTag, "Failed to clean up compiled package blob %s: %s", compiledPkg.BlobstoreID, err.Error())
+	}
+
+	c.markVerifiedBlob(compiledPkg.BlobstoreID)
+
+	return nil
+}
+
+func (c concreteCompiler) isVerifiedBlob(blobID string) bool {
+	c.verifiedBlobsLock.Lock()
+	defer c.verifiedBlobsLock.Unlock()
+
+	return c.verifiedBlobs[blobID]
+}
+
+func (c concreteCompiler) markVerifiedBlob(blobID string) {
+	c.verifiedBlobsLock.Lock()
+	defer c.verifiedBlobsLock.Unlock()
+
+	c.verifiedBlobs[blobID] = true
+}
+
+// uninstallLeastRecentlyUsedPackages keeps number of packages installed
+// by previous compiles within configured limit so that they do not fill up the disk.
+// Packages installed before agent started are considered least recently used.
+func (c concreteCompiler) uninstallLeastRecentlyUsedPackages(deps []boshmodels.Package) error {
+	depBundles := []boshbc.Bundle{}
+
+	for _, dep := range deps {
+		depBundle, err := c.packagesBc.Get(dep)
+		if err != nil {
+			return bosherr.WrapError(err, "Getting package bundle")
+		}
+
+		depBundles = append(depBundles, depBundle)
+	}
+
+	installedBundles, err := c.packagesBc.List()
+	if err != nil {
+		return bosherr.WrapError(err, "Retrieving installed bundles")
+	}
+
+	c.usedPackagesLock.Lock()
+	defer c.usedPackagesLock.Unlock()
+
+	usedBundles := append([]boshbc.Bundle{}, depBundles...)
+
+	for _, usedBundle := range *c.usedPackages {
+		if !containsBundle(depBundles, usedBundle) && containsBundle(installedBundles, usedBundle) {
+			usedBundles = append(usedBundles, usedBundle)
+		}
 	}
 
-	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
+	for _, installedBundle := range installedBundles {
+		if !containsBundle(usedBundles, installedBundle) {
+			usedBundles = append(usedBundles, installedBundle)
+		}
+	}
+
+	maxInstalled := maxInstalledPackagesOrDefault(c.options.MaxInstalledPackages)
+
+	for len(usedBundles) > maxInstalled {
+		leastUsedBundle := usedBundles[len(usedBundles)-1]
+
+		if containsBundle(depBundles, leastUsedBundle) {
+			break
+		}
+
+		if containsBundle(installedBundles, leastUsedBundle) {
+			err = leastUsedBundle.Disable()
+			if err != nil {
+				return bosherr.WrapError(err, "Disabling package bundle")
+			}
+
+			err = leastUsedBundle.Uninstall()
+			if err != nil {
+				return bosherr.WrapError(err, "Uninstalling package bundle")
+			}
+		}
+
+		usedBundles = usedBundles[:len(usedBundles)-1]
+	}
+
+	*c.usedPackages = usedBundles
+
+	return nil
+}
+
+func containsBundle(bundles []boshbc.Bundle, bundle boshbc.Bundle) bool {
+	for _, b := range bundles {
+		if b == bundle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// disablePackagesExcept makes sure packaging script only sees declared dependencies
+// while keeping other packages installed for later compiles.
+func (c concreteCompiler) disablePackagesExcept(deps []boshmodels.Package) error {
+	installedBundles, err := c.packagesBc.List()
 	if err != nil {
-		return "", nil, bosherr.WrapError(err, "Removing packages")
+		return bosherr.WrapError(err, "Retrieving installed bundles")
 	}
 
-	return uploadedBlobID, digest, nil
+	for _, installedBundle := range installedBundles {
+		var isDep bool
+
+		for _, dep := range deps {
+			depBundle, err := c.packagesBc.Get(dep)
+			if err != nil {
+				return bosherr.WrapError(err, "Getting package bundle")
+			}
+
+			if depBundle == installedBundle {
+				isDep = true
+				break
+			}
+		}
+
+		if !isDep {
+			err = installedBundle.Disable()
+			if err != nil {
+				return bosherr.WrapError(err, "Disabling package bundle")
+			}
+		}
+	}
+
+	return nil
 }
 
 func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
@@ -5,18 +5,24 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
+	boshcmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
 	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	fakecomp "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -67,6 +73,8 @@ func init() {
 			runner         *fakecmdrunner.FakeFileLoggingCmdRunner
 			packageApplier *fakepackages.FakeApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			compileCache   *fakecomp.FakeCompileCache
+			options        Options
 		)
 
 		BeforeEach(func() {
@@ -76,7 +84,14 @@ func init() {
 			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 			packageApplier = fakepackages.NewFakeApplier()
 			packagesBc = fakebc.NewFakeBundleCollection()
+			compileCache = fakecomp.NewFakeCompileCache()
+			options = Options{}
 
+			fs.MkdirAll("/fake-compile-dir", os.ModePerm)
+			Expect(fs.WriteFileString("/tmp/compressed-compiled-package", "fake-contents")).ToNot(HaveOccurred())
+		})
+
+		JustBeforeEach(func() {
 			compiler = NewConcreteCompiler(
 				compressor,
 				blobstore,
@@ -85,10 +100,10 @@ func init() {
 				FakeCompileDirProvider{Dir: "/fake-compile-dir"},
 				packageApplier,
 				packagesBc,
+				compileCache,
+				options,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
-
-			fs.MkdirAll("/fake-compile-dir", os.ModePerm)
-			Expect(fs.WriteFileString("/tmp/compressed-compiled-package", "fake-contents")).ToNot(HaveOccurred())
 		})
 
 		Describe("Compile", func() {
@@ -115,11 +130,11 @@ func init() {
 			It("returns blob id and sha1 of created compiled package", func() {
 				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
 
-				blobID, digest, err := compiler.Compile(pkg, pkgDeps)
+				compiledPkg, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(blobID).To(Equal("fake-blob-id"))
-				Expect(digest).To(Equal(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "978ad524a02039f261773fe93d94973ae7de6470")))
+				Expect(compiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
+				Expect(compiledPkg.Digest).To(Equal(boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "978ad524a02039f261773fe93d94973ae7de6470"))))
 			})
 
 			It("returns blob id and correct sha algo of created compiled package", func() {
@@ -128,29 +143,236 @@ func init() {
 				// Currently algo of source package is used for compilation pkg algo
 				pkg.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "fakesha"))
 
-				_, digest, err := compiler.Compile(pkg, pkgDeps)
+				compiledPkg, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				// echo -n fake-contents|shasum -a 256
-				Expect(digest.String()).To(Equal("sha256:d12d3a3ee8dcdc9e7ea3416fd618298ea50abde2cf434313c6c3edb213f441cd"))
+				Expect(compiledPkg.Digest.String()).To(Equal("sha256:d12d3a3ee8dcdc9e7ea3416fd618298ea50abde2cf434313c6c3edb213f441cd"))
 
 				blobID, fingerprint := blobstore.GetArgsForCall(0)
 				Expect(blobID).To(Equal("blobstore_id"))
 				Expect(fingerprint).To(Equal(pkg.Sha1))
 			})
 
-			It("cleans up all packages before and after applying dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+			It("disables packages that are not dependencies before applying dependent packages", func() {
+				firstDepBundle := packagesBc.FakeGet(pkgDeps[0])
+				otherBundle := packagesBc.FakeGet(boshmodels.LocalPackage{Name: "other_name", Version: "other_version"})
+				packagesBc.ListBundles = []boshbc.Bundle{firstDepBundle, otherBundle}
+
+				packageApplier.ApplyError = errors.New("fake-apply-error")
+
+				_, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+
+				Expect(firstDepBundle.ActionsCalled).To(BeEmpty())
+				Expect(otherBundle.ActionsCalled).To(Equal([]string{"Disable"}))
+			})
+
+			It("keeps installed packages and disables them after compiling", func() {
+				firstDepBundle := packagesBc.FakeGet(pkgDeps[0])
+				otherBundle := packagesBc.FakeGet(boshmodels.LocalPackage{Name: "other_name", Version: "other_version"})
+				packagesBc.ListBundles = []boshbc.Bundle{firstDepBundle, otherBundle}
+
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply", "KeepOnly"}))
-				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
+
+				Expect(packageApplier.ActionsCalled).To(Equal([]string{"Apply", "Apply"}))
+				Expect(firstDepBundle.ActionsCalled).To(Equal([]string{"Disable"}))
+				Expect(otherBundle.ActionsCalled).To(Equal([]string{"Disable", "Disable"}))
 			})
 
-			It("returns an error if cleaning up packages fails", func() {
-				packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
+			Context("when number of installed packages is limited", func() {
+				BeforeEach(func() {
+					options.MaxInstalledPackages = 2
+				})
+
+				It("uninstalls packages from previous compiles when more of them than allowed are installed", func() {
+					firstDepBundle := packagesBc.FakeGet(pkgDeps[0])
+					secDepBundle := packagesBc.FakeGet(pkgDeps[1])
+					otherBundle := packagesBc.FakeGet(boshmodels.LocalPackage{Name: "other_name", Version: "other_version"})
+					packagesBc.ListBundles = []boshbc.Bundle{otherBundle, firstDepBundle, secDepBundle}
+
+					_, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(otherBundle.ActionsCalled[:2]).To(Equal([]string{"Disable", "Uninstall"}))
+					Expect(firstDepBundle.ActionsCalled).ToNot(ContainElement("Uninstall"))
+					Expect(secDepBundle.ActionsCalled).ToNot(ContainElement("Uninstall"))
+				})
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				It("uninstalls least recently used packages first", func() {
+					firstDepBundle := packagesBc.FakeGet(pkgDeps[0])
+					secDepBundle := packagesBc.FakeGet(pkgDeps[1])
+					packagesBc.ListBundles = []boshbc.Bundle{secDepBundle, firstDepBundle}
+
+					_, err := compiler.Compile(pkg, pkgDeps[:1])
+					Expect(err).ToNot(HaveOccurred())
+
+					otherDep := boshmodels.Package{
+						Name:    "other_name",
+						Version: "other_version",
+						Source: boshmodels.Source{
+							Sha1:        boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "other_sha1")),
+							BlobstoreID: "other_blobstore_id",
+						},
+					}
+					otherBundle := packagesBc.FakeGet(otherDep)
+					packagesBc.ListBundles = []boshbc.Bundle{firstDepBundle, secDepBundle, otherBundle}
+
+					_, err = compiler.Compile(pkg, []boshmodels.Package{otherDep})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(secDepBundle.ActionsCalled).To(ContainElement("Uninstall"))
+					Expect(firstDepBundle.ActionsCalled).ToNot(ContainElement("Uninstall"))
+					Expect(otherBundle.ActionsCalled).ToNot(ContainElement("Uninstall"))
+				})
+
+				It("returns an error if uninstalling unused packages fails", func() {
+					otherBundle := packagesBc.FakeGet(boshmodels.LocalPackage{Name: "other_name", Version: "other_version"})
+					otherBundle.UninstallErr = errors.New("fake-uninstall-error")
+					packagesBc.ListBundles = []boshbc.Bundle{otherBundle, packagesBc.FakeGet(pkgDeps[0]), packagesBc.FakeGet(pkgDeps[1])}
+
+					_, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-uninstall-error"))
+					Expect(packageApplier.ActionsCalled).To(BeEmpty())
+				})
+			})
+
+			It("returns an error if disabling packages fails", func() {
+				otherBundle := packagesBc.FakeGet(boshmodels.LocalPackage{Name: "other_name", Version: "other_version"})
+				otherBundle.DisableErr = errors.New("fake-disable-error")
+				packagesBc.ListBundles = []boshbc.Bundle{otherBundle}
+
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
+				Expect(err.Error()).To(ContainSubstring("fake-disable-error"))
+				Expect(packageApplier.ActionsCalled).To(BeEmpty())
+			})
+
+			It("returns an error if listing installed packages fails", func() {
+				packagesBc.ListErr = errors.New("fake-list-error")
+
+				_, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-list-error"))
+			})
+
+			Context("when package was already compiled with the same dependencies", func() {
+				BeforeEach(func() {
+					compileCache.GetFound = true
+					compileCache.GetCompiledPkg = CompiledPackage{
+						BlobstoreID: "cached-blob-id",
+						Digest:      boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "cachedsha1")),
+					}
+				})
+
+				It("returns previously compiled package without compiling", func() {
+					compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(compiledPkg).To(Equal(compileCache.GetCompiledPkg))
+
+					Expect(compileCache.GetPkg).To(Equal(pkg))
+					Expect(compileCache.GetDeps).To(Equal(pkgDeps))
+
+					Expect(packageApplier.ActionsCalled).To(BeEmpty())
+					Expect(bundle.ActionsCalled).To(BeEmpty())
+					Expect(blobstore.CreateCallCount()).To(Equal(0))
+					Expect(compileCache.SaveCalled).To(BeFalse())
+				})
+
+				It("makes sure previously compiled blob still exists and matches its digest", func() {
+					blobstore.GetReturns("/fake-cached-blob", nil)
+
+					_, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobstore.GetCallCount()).To(Equal(1))
+					blobID, digest := blobstore.GetArgsForCall(0)
+					Expect(blobID).To(Equal("cached-blob-id"))
+					Expect(digest).To(Equal(compileCache.GetCompiledPkg.Digest))
+
+					Expect(blobstore.CleanUpCallCount()).To(Equal(1))
+					Expect(blobstore.CleanUpArgsForCall(0)).To(Equal("/fake-cached-blob"))
+				})
+
+				It("only downloads previously compiled blob the first time it is reused", func() {
+					_, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobstore.GetCallCount()).To(Equal(1))
+				})
+
+				It("does not download blob that was uploaded by the same agent", func() {
+					compileCache.GetFound = false
+					blobstore.CreateReturns("cached-blob-id", boshcrypto.MultipleDigest{}, nil)
+
+					_, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(blobstore.GetCallCount()).To(Equal(1))
+
+					compileCache.GetFound = true
+
+					_, err = compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(blobstore.GetCallCount()).To(Equal(1))
+				})
+
+				It("compiles package again if previously compiled blob cannot be fetched", func() {
+					blobstore.GetStub = func(blobID string, _ boshcrypto.Digest) (string, error) {
+						if blobID == "cached-blob-id" {
+							return "", errors.New("fake-get-error")
+						}
+						return "/fake-blob", nil
+					}
+					blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
+
+					compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(compiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
+					Expect(compileCache.SaveCompiledPkg).To(Equal(compiledPkg))
+				})
+			})
+
+			It("compiles package if looking it up in compile cache fails", func() {
+				compileCache.GetErr = errors.New("fake-get-error")
+				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
+
+				compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(compiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
+			})
+
+			It("saves compiled package to compile cache", func() {
+				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
+
+				compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(compileCache.SavePkg).To(Equal(pkg))
+				Expect(compileCache.SaveDeps).To(Equal(pkgDeps))
+				Expect(compileCache.SaveCompiledPkg).To(Equal(compiledPkg))
+				Expect(compileCache.SaveCompiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
+			})
+
+			It("does not save package to compile cache if compiling fails", func() {
+				blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
+
+				_, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(compileCache.SaveCalled).To(BeFalse())
+			})
+
+			It("returns compiled package even if saving it to compile cache fails", func() {
+				compileCache.SaveErr = errors.New("fake-save-error")
+				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
+
+				compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(compiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
 			})
 
 			It("returns an error if removing compile target directory during uncompression fails", func() {
@@ -161,7 +383,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -174,7 +396,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -187,7 +409,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -195,7 +417,7 @@ func init() {
 			It("returns an error if creating temporary compile target directory during uncompression fails", func() {
 				fs.RegisterMkdirAllError("/fake-compile-dir/pkg_name-bosh-agent-unpack", errors.New("fake-mkdir-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -203,27 +425,42 @@ func init() {
 			It("returns an error if target directory is empty during uncompression", func() {
 				pkg.BlobstoreID = ""
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("Blobstore ID for package '%s' is empty", pkg.Name))
 			})
 
 			It("installs dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.AppliedPackages).To(Equal(pkgDeps))
 			})
 
 			It("cleans up the compile directory", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(fs.FileExists("/fake-compile-dir/pkg_name")).To(BeFalse())
 			})
 
 			It("installs, enables and later cleans up bundle", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(bundle.ActionsCalled).To(Equal([]string{
+					"Uninstall",
+					"InstallWithoutContents",
+					"Enable",
+					"Disable",
+					"Uninstall",
+				}))
+			})
+
+			It("removes partial output of compile when it fails so that it does not end up in later compiles", func() {
+				blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
+
+				_, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(bundle.ActionsCalled).To(Equal([]string{
+					"Uninstall",
 					"InstallWithoutContents",
 					"Enable",
 					"Disable",
@@ -231,6 +468,15 @@ func init() {
 				}))
 			})
 
+			It("returns an error if previously compiled package cannot be uninstalled", func() {
+				bundle.UninstallErr = errors.New("fake-uninstall-error")
+
+				_, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-uninstall-error"))
+				Expect(bundle.ActionsCalled).To(Equal([]string{"Uninstall"}))
+			})
+
 			It("returns an error if removing the compile directory fails", func() {
 				callCount := 0
 				fs.RemoveAllStub = func(path string) error {
@@ -243,7 +489,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -258,7 +504,7 @@ func init() {
 				})
 
 				It("runs packaging script ", func() {
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, err := compiler.Compile(pkg, pkgDeps)
 					Expect(err).ToNot(HaveOccurred())
 
 					expectedCmd := boshsys.Command{
@@ -289,20 +535,204 @@ func init() {
 				It("propagates the error from packaging script", func() {
 					runner.RunCommandErr = errors.New("fake-packaging-error")
 
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, err := compiler.Compile(pkg, pkgDeps)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
 				})
+
+				Context("when packaging scripts are isolated", func() {
+					BeforeEach(func() {
+						if runtime.GOOS == "windows" {
+							Skip("Packaging scripts are not isolated on Windows")
+						}
+
+						options = Options{Isolated: true}
+
+						packagesBc.FakeGet(pkgDeps[0]).GetDirPath = "/fake-dir/data/packages/first_dep_name/first_dep_version"
+						packagesBc.FakeGet(pkgDeps[1]).GetDirPath = "/fake-dir/data/packages/sec_dep_name/sec_dep_version"
+					})
+
+					It("runs packaging script with clean documented environment", func() {
+						_, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						cmd := runner.RunCommands[0]
+						Expect(cmd.UseIsolatedEnv).To(BeTrue())
+						Expect(cmd.WorkingDir).To(Equal("/fake-compile-dir/pkg_name"))
+						Expect(cmd.Env).To(Equal(map[string]string{
+							"BOSH_COMPILE_TARGET":  "/fake-compile-dir/pkg_name",
+							"BOSH_INSTALL_TARGET":  "/fake-dir/packages/pkg_name",
+							"BOSH_PACKAGE_NAME":    "pkg_name",
+							"BOSH_PACKAGE_VERSION": "pkg_version",
+							"PATH":                 "/usr/sbin:/usr/bin:/sbin:/bin",
+							"HOME":                 "/fake-compile-dir/pkg_name",
+							"LANG":                 "C.UTF-8",
+							"LC_ALL":               "C.UTF-8",
+							"TZ":                   "UTC",
+							"SOURCE_DATE_EPOCH":    "315532800",
+						}))
+					})
+
+					Context("when source date epoch is configured", func() {
+						BeforeEach(func() {
+							options.SourceDateEpoch = 1500000000
+						})
+
+						It("uses configured source date epoch", func() {
+							_, err := compiler.Compile(pkg, pkgDeps)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(runner.RunCommands[0].Env["SOURCE_DATE_EPOCH"]).To(Equal("1500000000"))
+						})
+					})
+
+					It("runs packaging script without network in a private mount namespace that only exposes dependencies", func() {
+						_, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						cmd := runner.RunCommands[0]
+						Expect(cmd.Name).To(Equal("unshare"))
+						Expect(cmd.Args[:6]).To(Equal([]string{"--mount", "--propagation", "private", "--net", "--", "/bin/sh"}))
+						Expect(cmd.Args[6]).To(Equal("-c"))
+						Expect(cmd.Args[8:]).To(Equal([]string{"bash", "-x", PackagingScriptName}))
+
+						Expect(cmd.Args[7]).To(Equal(strings.Join([]string{
+							"set -e",
+							"mount -t tmpfs -o mode=0755 tmpfs '/fake-dir/packages'",
+							"mkdir '/fake-dir/packages/first_dep_name'",
+							"mount --bind '/fake-dir/data/packages/first_dep_name/first_dep_version' '/fake-dir/packages/first_dep_name'",
+							"mount -o remount,bind,ro '/fake-dir/packages/first_dep_name'",
+							"mkdir '/fake-dir/packages/sec_dep_name'",
+							"mount --bind '/fake-dir/data/packages/sec_dep_name/sec_dep_version' '/fake-dir/packages/sec_dep_name'",
+							"mount -o remount,bind,ro '/fake-dir/packages/sec_dep_name'",
+							"mkdir '/fake-dir/packages/pkg_name'",
+							"mount --bind '/fake-dir/data/packages/pkg_name/pkg_version' '/fake-dir/packages/pkg_name'",
+							"mount -t tmpfs -o mode=0755 tmpfs '/fake-dir/data/packages'",
+							`exec "$0" "$@"`,
+						}, "\n")))
+					})
+
+					Context("when network is enabled", func() {
+						BeforeEach(func() {
+							options.NetworkEnabled = true
+						})
+
+						It("keeps network of packaging script", func() {
+							_, err := compiler.Compile(pkg, pkgDeps)
+							Expect(err).ToNot(HaveOccurred())
+
+							cmd := runner.RunCommands[0]
+							Expect(cmd.Args[:5]).To(Equal([]string{"--mount", "--propagation", "private", "--", "/bin/sh"}))
+						})
+					})
+
+					It("returns an error if install path of dependency cannot be determined", func() {
+						packagesBc.FakeGet(pkgDeps[1]).GetDirError = errors.New("fake-install-path-error")
+
+						_, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-install-path-error"))
+						Expect(runner.RunCommands).To(BeEmpty())
+					})
+				})
+
+				Context("when packaging script output was logged", func() {
+					var (
+						cmdResult *boshcmdrunner.CmdResult
+					)
+
+					BeforeEach(func() {
+						cmdResult = &boshcmdrunner.CmdResult{
+							ExitStatus: 0,
+							StdoutPath: "/fake-logs-dir/compilation/packaging.stdout.log",
+							StderrPath: "/fake-logs-dir/compilation/packaging.stderr.log",
+							Duration:   90 * time.Second,
+						}
+						runner.RunCommandResult = cmdResult
+
+						compressor.CompressSpecificFilesInDirTarballPath = "/tmp/compressed-logs"
+
+						blobstore.CreateStub = func(fileName string) (string, boshcrypto.MultipleDigest, error) {
+							if fileName == "/tmp/compressed-logs" {
+								return "fake-logs-blob-id", boshcrypto.MultipleDigest{}, nil
+							}
+							return "fake-blob-id", boshcrypto.MultipleDigest{}, nil
+						}
+					})
+
+					It("uploads full packaging script output and returns its blob id", func() {
+						compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(compressor.CompressSpecificFilesInDirDir).To(Equal("/fake-logs-dir/compilation"))
+						Expect(compressor.CompressSpecificFilesInDirFiles).To(Equal([]string{"packaging.stdout.log", "packaging.stderr.log"}))
+
+						Expect(compiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
+						Expect(compiledPkg.LogsBlobstoreID).To(Equal("fake-logs-blob-id"))
+					})
+
+					It("returns how long packaging script ran", func() {
+						compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(compiledPkg.Packaging).To(Equal(PackagingStats{Duration: 90 * time.Second}))
+					})
+
+					It("returns resource usage of packaging script when it is known", func() {
+						cmdResult.ResourceUsage = &boshcmdrunner.CgroupUsage{PeakMemoryBytes: 4096, CPUTime: 80 * time.Second}
+
+						compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(compiledPkg.Packaging).To(Equal(PackagingStats{
+							Duration:        90 * time.Second,
+							PeakMemoryBytes: 4096,
+							CPUTime:         80 * time.Second,
+						}))
+					})
+
+					It("uploads full packaging script output when packaging script fails", func() {
+						runner.RunCommandResult = nil
+						runner.RunCommandErr = boshcmdrunner.NewFileLoggingExecErr(cmdResult)
+
+						_, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Running packaging script (full output uploaded to blob 'fake-logs-blob-id')"))
+						Expect(err.Error()).To(ContainSubstring("Command exited with 0"))
+
+						scriptErr, ok := err.(PackagingScriptError)
+						Expect(ok).To(BeTrue())
+						Expect(scriptErr.LogsBlobstoreID).To(Equal("fake-logs-blob-id"))
+						Expect(scriptErr.Details()).To(Equal(map[string]interface{}{"logs_blobstore_id": "fake-logs-blob-id"}))
+					})
+
+					It("does not report logs blob when packaging script fails and its output cannot be uploaded", func() {
+						runner.RunCommandResult = nil
+						runner.RunCommandErr = boshcmdrunner.NewFileLoggingExecErr(cmdResult)
+						compressor.CompressSpecificFilesInDirErr = errors.New("fake-compress-error")
+
+						_, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(HavePrefix("Running packaging script: "))
+						Expect(err.(PackagingScriptError).Details()).To(BeNil())
+					})
+
+					It("compiles package even if packaging script output cannot be uploaded", func() {
+						compressor.CompressSpecificFilesInDirErr = errors.New("fake-compress-error")
+
+						compiledPkg, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(compiledPkg.BlobstoreID).To(Equal("fake-blob-id"))
+						Expect(compiledPkg.LogsBlobstoreID).To(BeEmpty())
+					})
+				})
 			})
 
 			It("does not run packaging script when script does not exist", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(runner.RunCommands).To(BeEmpty())
 			})
 
 			It("compresses compiled package", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				// archive was downloaded from the blobstore and decompress to this temp dir
@@ -321,7 +751,7 @@ func init() {
 			It("uploads compressed package to blobstore", func() {
 				compressor.CompressFilesInDirTarballPath = "/tmp/compressed-compiled-package"
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(blobstore.CreateArgsForCall(0)).To(Equal("/tmp/compressed-compiled-package"))
 			})
@@ -329,7 +759,7 @@ func init() {
 			It("returs error if uploading compressed package fails", func() {
 				blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-err"))
 			})
@@ -342,7 +772,7 @@ func init() {
 					return "my-blob-id", boshcrypto.MultipleDigest{}, nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				// Compressed package is not cleaned up before blobstore upload
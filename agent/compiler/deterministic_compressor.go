@@ -0,0 +1,75 @@
+package compiler
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type deterministicCompressor struct {
+	boshcmd.Compressor
+
+	cmdRunner       boshsys.CmdRunner
+	fs              boshsys.FileSystem
+	sourceDateEpoch int64
+}
+
+// NewDeterministicCompressor returns compressor that archives directories so that
+// identical contents always result in identical tarballs: entries are sorted by name,
+// have the same owner and modification time, and gzip header has no name or timestamp.
+// Other operations are delegated to given compressor.
+func NewDeterministicCompressor(
+	compressor boshcmd.Compressor,
+	cmdRunner boshsys.CmdRunner,
+	fs boshsys.FileSystem,
+	sourceDateEpoch int64,
+) boshcmd.Compressor {
+	return deterministicCompressor{
+		Compressor:      compressor,
+		cmdRunner:       cmdRunner,
+		fs:              fs,
+		sourceDateEpoch: sourceDateEpochOrDefault(sourceDateEpoch),
+	}
+}
+
+func (c deterministicCompressor) CompressFilesInDir(dir string) (string, error) {
+	tarFile, err := c.fs.TempFile("bosh-agent-compiler-DeterministicCompressor-CompressFilesInDir")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for tarball")
+	}
+
+	tarPath := tarFile.Name()
+
+	err = tarFile.Close()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Closing temporary file for tarball")
+	}
+
+	_, _, _, err = c.cmdRunner.RunCommand(
+		"tar",
+		"--sort=name",
+		fmt.Sprintf("--mtime=@%d", c.sourceDateEpoch),
+		"--owner=0",
+		"--group=0",
+		"--numeric-owner",
+		"--pax-option=exthdr.name=%d/PaxHeaders/%f,delete=atime,delete=ctime",
+		"-cf", tarPath,
+		"-C", dir,
+		".",
+	)
+	if err != nil {
+		_ = c.fs.RemoveAll(tarPath)
+		return "", bosherr.WrapError(err, "Shelling out to tar")
+	}
+
+	// gzip replaces tar file with compressed one
+	_, _, _, err = c.cmdRunner.RunCommand("gzip", "-n", "-f", tarPath)
+	if err != nil {
+		_ = c.fs.RemoveAll(tarPath)
+		return "", bosherr.WrapError(err, "Shelling out to gzip")
+	}
+
+	return tarPath + ".gz", nil
+}
@@ -0,0 +1,100 @@
+package compiler_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("deterministicCompressor", func() {
+	var (
+		delegate   *fakecmd.FakeCompressor
+		cmdRunner  *fakesys.FakeCmdRunner
+		fs         *fakesys.FakeFileSystem
+		compressor boshcmd.Compressor
+	)
+
+	BeforeEach(func() {
+		delegate = fakecmd.NewFakeCompressor()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		fs.ReturnTempFile = fakesys.NewFakeFile("/fake-tmp/tarball", fs)
+
+		compressor = NewDeterministicCompressor(delegate, cmdRunner, fs, 1500000000)
+	})
+
+	Describe("CompressFilesInDir", func() {
+		It("archives files sorted by name with fixed owner and modification time", func() {
+			tarballPath, err := compressor.CompressFilesInDir("/fake-install-dir")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tarballPath).To(Equal("/fake-tmp/tarball.gz"))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{
+					"tar",
+					"--sort=name",
+					"--mtime=@1500000000",
+					"--owner=0",
+					"--group=0",
+					"--numeric-owner",
+					"--pax-option=exthdr.name=%d/PaxHeaders/%f,delete=atime,delete=ctime",
+					"-cf", "/fake-tmp/tarball",
+					"-C", "/fake-install-dir",
+					".",
+				},
+				{"gzip", "-n", "-f", "/fake-tmp/tarball"},
+			}))
+
+			Expect(delegate.CompressFilesInDirDir).To(BeEmpty())
+		})
+
+		It("uses default source date epoch when it is not configured", func() {
+			compressor = NewDeterministicCompressor(delegate, cmdRunner, fs, 0)
+
+			_, err := compressor.CompressFilesInDir("/fake-install-dir")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands[0]).To(ContainElement("--mtime=@315532800"))
+		})
+
+		It("returns an error and removes tarball if archiving fails", func() {
+			cmdRunner.AddCmdResult("tar --sort=name --mtime=@1500000000 --owner=0 --group=0 --numeric-owner --pax-option=exthdr.name=%d/PaxHeaders/%f,delete=atime,delete=ctime -cf /fake-tmp/tarball -C /fake-install-dir .", fakesys.FakeCmdResult{
+				Error: errors.New("fake-tar-error"),
+			})
+
+			_, err := compressor.CompressFilesInDir("/fake-install-dir")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-tar-error"))
+			Expect(fs.FileExists("/fake-tmp/tarball")).To(BeFalse())
+		})
+
+		It("returns an error if compressing archive fails", func() {
+			cmdRunner.AddCmdResult("gzip -n -f /fake-tmp/tarball", fakesys.FakeCmdResult{
+				Error: errors.New("fake-gzip-error"),
+			})
+
+			_, err := compressor.CompressFilesInDir("/fake-install-dir")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-gzip-error"))
+		})
+
+		It("returns an error if temporary file cannot be created", func() {
+			fs.TempFileError = errors.New("fake-temp-file-error")
+
+			_, err := compressor.CompressFilesInDir("/fake-install-dir")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-temp-file-error"))
+		})
+	})
+
+	It("delegates decompression", func() {
+		err := compressor.DecompressFileToDir("/fake-tarball", "/fake-dir", boshcmd.CompressorOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(delegate.DecompressFileToDirTarballPaths).To(Equal([]string{"/fake-tarball"}))
+	})
+})
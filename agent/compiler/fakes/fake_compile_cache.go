@@ -0,0 +1,38 @@
+package fakes
+
+import (
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
+)
+
+type FakeCompileCache struct {
+	GetPkg         boshcomp.Package
+	GetDeps        []boshmodels.Package
+	GetCompiledPkg boshcomp.CompiledPackage
+	GetFound       bool
+	GetErr         error
+
+	SaveCalled      bool
+	SavePkg         boshcomp.Package
+	SaveDeps        []boshmodels.Package
+	SaveCompiledPkg boshcomp.CompiledPackage
+	SaveErr         error
+}
+
+func NewFakeCompileCache() *FakeCompileCache {
+	return &FakeCompileCache{}
+}
+
+func (c *FakeCompileCache) Get(pkg boshcomp.Package, deps []boshmodels.Package) (boshcomp.CompiledPackage, bool, error) {
+	c.GetPkg = pkg
+	c.GetDeps = deps
+	return c.GetCompiledPkg, c.GetFound, c.GetErr
+}
+
+func (c *FakeCompileCache) Save(pkg boshcomp.Package, deps []boshmodels.Package, compiledPkg boshcomp.CompiledPackage) error {
+	c.SaveCalled = true
+	c.SavePkg = pkg
+	c.SaveDeps = deps
+	c.SaveCompiledPkg = compiledPkg
+	return c.SaveErr
+}
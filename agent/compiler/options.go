@@ -0,0 +1,43 @@
+package compiler
+
+// DefaultSourceDateEpoch is 1980-01-01 UTC, the earliest time all archive formats can represent
+const DefaultSourceDateEpoch int64 = 315532800
+
+// DefaultMaxInstalledPackages limits packages kept installed between compiles
+const DefaultMaxInstalledPackages = 50
+
+// Options configure environment packaging scripts run in
+type Options struct {
+	// When set to true packaging scripts run with a clean environment
+	// in a private mount namespace that only exposes declared dependencies
+	// and compiled packages are archived deterministically. Only supported on Linux.
+	Isolated bool
+
+	// Isolated packaging scripts have no network access unless enabled
+	NetworkEnabled bool
+
+	// Used as SOURCE_DATE_EPOCH and as modification time of archived compiled files;
+	// DefaultSourceDateEpoch is used when not set
+	SourceDateEpoch int64
+
+	// Number of packages kept installed between compiles so that shared dependencies
+	// are only downloaded once; least recently used packages are uninstalled first.
+	// DefaultMaxInstalledPackages is used when not set
+	MaxInstalledPackages int
+}
+
+func sourceDateEpochOrDefault(sourceDateEpoch int64) int64 {
+	if sourceDateEpoch > 0 {
+		return sourceDateEpoch
+	}
+
+	return DefaultSourceDateEpoch
+}
+
+func maxInstalledPackagesOrDefault(maxInstalledPackages int) int {
+	if maxInstalledPackages > 0 {
+		return maxInstalledPackages
+	}
+
+	return DefaultMaxInstalledPackages
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 )
 
@@ -14,6 +15,7 @@ type Heartbeat struct {
 	JobState   string            `json:"job_state"`
 	Vitals     boshvitals.Vitals `json:"vitals"`
 	NodeID     string            `json:"node_id"`
+	Ntp        boshntp.Info      `json:"ntp"`
 }
 
 //Heartbeat payload example:
@@ -33,6 +35,9 @@ type Heartbeat struct {
 //    },
 //  "ntp": {
 //      "offset": "-0.06423",
-//      "timestamp": "14 Oct 11:13:19"
+//      "timestamp": "14 Oct 11:13:19",
+//      "stratum": 3,
+//      "state": "synchronized",
+//      "last_sync": "2014-10-14T11:13:19Z"
 //  }
 //}
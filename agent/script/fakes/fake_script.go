@@ -164,5 +164,77 @@ func (fake *FakeCancellableScript) CancelReturns(result1 error) {
 	}{result1}
 }
 
+type FakeReportingScript struct {
+	FakeCancellableScript
+	RunWithResultStub        func() script.ScriptResult
+	runWithResultMutex       sync.RWMutex
+	runWithResultArgsForCall []struct{}
+	runWithResultReturns     struct {
+		result1 script.ScriptResult
+	}
+}
+
+func (fake *FakeReportingScript) RunWithResult() script.ScriptResult {
+	fake.runWithResultMutex.Lock()
+	fake.runWithResultArgsForCall = append(fake.runWithResultArgsForCall, struct{}{})
+	fake.runWithResultMutex.Unlock()
+	if fake.RunWithResultStub != nil {
+		return fake.RunWithResultStub()
+	} else {
+		return fake.runWithResultReturns.result1
+	}
+}
+
+func (fake *FakeReportingScript) RunWithResultCallCount() int {
+	fake.runWithResultMutex.RLock()
+	defer fake.runWithResultMutex.RUnlock()
+	return len(fake.runWithResultArgsForCall)
+}
+
+func (fake *FakeReportingScript) RunWithResultReturns(result1 script.ScriptResult) {
+	fake.RunWithResultStub = nil
+	fake.runWithResultReturns = struct {
+		result1 script.ScriptResult
+	}{result1}
+}
+
+type FakeParallelReportingScript struct {
+	FakeCancellableScript
+	RunWithResultsStub        func() (map[string]script.ScriptResult, error)
+	runWithResultsMutex       sync.RWMutex
+	runWithResultsArgsForCall []struct{}
+	runWithResultsReturns     struct {
+		result1 map[string]script.ScriptResult
+		result2 error
+	}
+}
+
+func (fake *FakeParallelReportingScript) RunWithResults() (map[string]script.ScriptResult, error) {
+	fake.runWithResultsMutex.Lock()
+	fake.runWithResultsArgsForCall = append(fake.runWithResultsArgsForCall, struct{}{})
+	fake.runWithResultsMutex.Unlock()
+	if fake.RunWithResultsStub != nil {
+		return fake.RunWithResultsStub()
+	} else {
+		return fake.runWithResultsReturns.result1, fake.runWithResultsReturns.result2
+	}
+}
+
+func (fake *FakeParallelReportingScript) RunWithResultsCallCount() int {
+	fake.runWithResultsMutex.RLock()
+	defer fake.runWithResultsMutex.RUnlock()
+	return len(fake.runWithResultsArgsForCall)
+}
+
+func (fake *FakeParallelReportingScript) RunWithResultsReturns(result1 map[string]script.ScriptResult, result2 error) {
+	fake.RunWithResultsStub = nil
+	fake.runWithResultsReturns = struct {
+		result1 map[string]script.ScriptResult
+		result2 error
+	}{result1, result2}
+}
+
 var _ script.Script = new(FakeScript)
 var _ script.CancellableScript = new(FakeCancellableScript)
+var _ script.ReportingScript = new(FakeReportingScript)
+var _ script.ParallelReportingScript = new(FakeParallelReportingScript)
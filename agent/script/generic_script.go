@@ -1,15 +1,22 @@
 package script
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 const (
 	fileOpenFlag int         = os.O_RDWR | os.O_CREATE | os.O_APPEND
 	fileOpenPerm os.FileMode = os.FileMode(0640)
+
+	// Full output is kept in job's log files
+	scriptOutputTailBytes = 10 * 1024
 )
 
 type GenericScript struct {
@@ -21,6 +28,8 @@ type GenericScript struct {
 
 	stdoutLogPath string
 	stderrLogPath string
+
+	cancelCh chan struct{}
 }
 
 func NewScript(
@@ -40,6 +49,8 @@ func NewScript(
 
 		stdoutLogPath: stdoutLogPath,
 		stderrLogPath: stderrLogPath,
+
+		cancelCh: make(chan struct{}, 1),
 	}
 }
 
@@ -48,19 +59,41 @@ func (s GenericScript) Path() string { return s.path }
 func (s GenericScript) Exists() bool { return s.fs.FileExists(s.path) }
 
 func (s GenericScript) Run() error {
+	return s.RunWithResult().Error
+}
+
+func (s GenericScript) RunWithResult() ScriptResult {
+	startTime := time.Now()
+
+	result := s.run()
+	result.Duration = time.Since(startTime)
+
+	return result
+}
+
+func (s GenericScript) run() ScriptResult {
+	result := ScriptResult{
+		Tag:      s.tag,
+		Status:   ScriptStatusFailed,
+		ExitCode: -1,
+	}
+
 	err := s.ensureContainingDir(s.stdoutLogPath)
 	if err != nil {
-		return err
+		result.Error = err
+		return result
 	}
 
 	err = s.ensureContainingDir(s.stderrLogPath)
 	if err != nil {
-		return err
+		result.Error = err
+		return result
 	}
 
 	stdoutFile, err := s.fs.OpenFile(s.stdoutLogPath, fileOpenFlag, fileOpenPerm)
 	if err != nil {
-		return err
+		result.Error = err
+		return result
 	}
 	defer func() {
 		_ = stdoutFile.Close()
@@ -68,23 +101,80 @@ func (s GenericScript) Run() error {
 
 	stderrFile, err := s.fs.OpenFile(s.stderrLogPath, fileOpenFlag, fileOpenPerm)
 	if err != nil {
-		return err
+		result.Error = err
+		return result
 	}
 	defer func() {
 		_ = stderrFile.Close()
 	}()
 
+	stdoutTail := NewOutputTail(scriptOutputTailBytes)
+	stderrTail := NewOutputTail(scriptOutputTailBytes)
+
 	command := boshsys.Command{
 		Name: s.path,
 		Env: map[string]string{
 			"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
 		},
-		Stdout: stdoutFile,
-		Stderr: stderrFile,
+		Stdout: io.MultiWriter(stdoutFile, stdoutTail),
+		Stderr: io.MultiWriter(stderrFile, stderrTail),
 	}
-	_, _, _, err = s.runner.RunComplexCommand(command)
 
-	return err
+	process, err := s.runner.RunComplexCommandAsync(command)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	var cmdResult boshsys.Result
+
+	isCanceled := false
+
+	// Can only wait once on a process but cancelling can happen multiple times
+	for processExitedCh := process.Wait(); processExitedCh != nil; {
+		select {
+		case cmdResult = <-processExitedCh:
+			processExitedCh = nil
+		case <-s.cancelCh:
+			// Terminates whole process group of the script;
+			// ignore possible error since process is waited on anyway
+			_ = process.TerminateNicely(10 * time.Second)
+			isCanceled = true
+		}
+	}
+
+	result.ExitCode = cmdResult.ExitStatus
+
+	if reporter, ok := process.(boshrunner.ResourceUsageReporter); ok {
+		usage, err := reporter.ResourceUsage()
+		if err == nil {
+			result.PeakMemoryBytes = usage.PeakMemoryBytes
+			result.CPUTime = usage.CPUTime
+		}
+	}
+
+	result.Stdout = stdoutTail.String()
+	result.Stderr = stderrTail.String()
+
+	switch {
+	case isCanceled:
+		result.Status = ScriptStatusCancelled
+		result.Error = bosherr.Error("Script was cancelled by user request")
+	case cmdResult.Error != nil:
+		result.Error = cmdResult.Error
+	default:
+		result.Status = ScriptStatusSucceeded
+	}
+
+	return result
+}
+
+func (s GenericScript) Cancel() error {
+	select {
+	case s.cancelCh <- struct{}{}:
+	default:
+	}
+	return nil
 }
 
 func (s GenericScript) ensureContainingDir(fullLogFilename string) error {
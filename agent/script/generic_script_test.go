@@ -3,18 +3,42 @@ package script_test
 import (
 	"errors"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
+// outputCmdRunner writes output to command's writers as real runner does
+// when custom Stdout and Stderr are specified
+type outputCmdRunner struct {
+	*fakesys.FakeCmdRunner
+
+	Stdout string
+	Stderr string
+}
+
+func (r *outputCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	process, err := r.FakeCmdRunner.RunComplexCommandAsync(cmd)
+	if err == nil {
+		_, _ = cmd.Stdout.Write([]byte(r.Stdout))
+		_, _ = cmd.Stderr.Write([]byte(r.Stderr))
+	}
+
+	return process, err
+}
+
 var _ = Describe("GenericScript", func() {
 	var (
 		fs            *fakesys.FakeFileSystem
-		cmdRunner     *fakesys.FakeCmdRunner
+		cmdRunner     *outputCmdRunner
 		genericScript boshscript.GenericScript
 		stdoutLogPath string
 		stderrLogPath string
@@ -22,7 +46,7 @@ var _ = Describe("GenericScript", func() {
 
 	BeforeEach(func() {
 		fs = fakesys.NewFakeFileSystem()
-		cmdRunner = fakesys.NewFakeCmdRunner()
+		cmdRunner = &outputCmdRunner{FakeCmdRunner: fakesys.NewFakeCmdRunner()}
 		stdoutLogPath = filepath.Join("base", "stdout", "logdir", "stdout.log")
 		stderrLogPath = filepath.Join("base", "stderr", "logdir", "stderr.log")
 		genericScript = boshscript.NewScript(
@@ -58,8 +82,11 @@ var _ = Describe("GenericScript", func() {
 
 	Describe("Run", func() {
 		It("executes given command", func() {
+			cmdRunner.AddProcess("/path-to-script", &fakesys.FakeProcess{})
+
 			err := genericScript.Run()
 			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunComplexCommands[0].Env).To(Equal(map[string]string{"PATH": "/usr/sbin:/usr/bin:/sbin:/bin"}))
 		})
 
 		It("returns an error if it fails to create logs directory", func() {
@@ -80,11 +107,10 @@ var _ = Describe("GenericScript", func() {
 
 		Context("when command succeeds", func() {
 			BeforeEach(func() {
-				cmdRunner.AddCmdResult("/path-to-script", fakesys.FakeCmdResult{
-					Stdout:     "fake-stdout",
-					Stderr:     "fake-stderr",
-					ExitStatus: 0,
-					Error:      nil,
+				cmdRunner.Stdout = "fake-stdout"
+				cmdRunner.Stderr = "fake-stderr"
+				cmdRunner.AddProcess("/path-to-script", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{ExitStatus: 0},
 				})
 			})
 
@@ -107,11 +133,13 @@ var _ = Describe("GenericScript", func() {
 
 		Context("when command fails", func() {
 			BeforeEach(func() {
-				cmdRunner.AddCmdResult("/path-to-script", fakesys.FakeCmdResult{
-					Stdout:     "fake-stdout",
-					Stderr:     "fake-stderr",
-					ExitStatus: 1,
-					Error:      errors.New("fake-command-error"),
+				cmdRunner.Stdout = "fake-stdout"
+				cmdRunner.Stderr = "fake-stderr"
+				cmdRunner.AddProcess("/path-to-script", &fakesys.FakeProcess{
+					WaitResult: boshsys.Result{
+						ExitStatus: 1,
+						Error:      errors.New("fake-command-error"),
+					},
 				})
 			})
 
@@ -132,5 +160,112 @@ var _ = Describe("GenericScript", func() {
 				Expect(stderr).To(Equal("fake-stderr"))
 			})
 		})
+
+		Context("when command cannot be started", func() {
+			BeforeEach(func() {
+				cmdRunner.AddProcess("/path-to-script", &fakesys.FakeProcess{
+					StartErr: errors.New("fake-start-error"),
+				})
+			})
+
+			It("returns error", func() {
+				err := genericScript.Run()
+				Expect(err).To(MatchError("fake-start-error"))
+			})
+		})
+	})
+
+	Describe("RunWithResult", func() {
+		BeforeEach(func() {
+			cmdRunner.Stdout = "fake-stdout"
+			cmdRunner.Stderr = "fake-stderr"
+		})
+
+		It("returns succeeded result with output", func() {
+			cmdRunner.AddProcess("/path-to-script", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: 0},
+			})
+
+			result := genericScript.RunWithResult()
+			Expect(result.Tag).To(Equal("my-tag"))
+			Expect(result.Status).To(Equal(boshscript.ScriptStatusSucceeded))
+			Expect(result.ExitCode).To(Equal(0))
+			Expect(result.Stdout).To(Equal("fake-stdout"))
+			Expect(result.Stderr).To(Equal("fake-stderr"))
+			Expect(result.Duration).To(BeNumerically(">=", 0))
+			Expect(result.Error).ToNot(HaveOccurred())
+		})
+
+		It("returns failed result with exit code", func() {
+			cmdRunner.AddProcess("/path-to-script", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{
+					ExitStatus: 3,
+					Error:      errors.New("fake-command-error"),
+				},
+			})
+
+			result := genericScript.RunWithResult()
+			Expect(result.Status).To(Equal(boshscript.ScriptStatusFailed))
+			Expect(result.ExitCode).To(Equal(3))
+			Expect(result.Stderr).To(Equal("fake-stderr"))
+			Expect(result.Error).To(MatchError("fake-command-error"))
+		})
+
+		It("returns failed result when logs cannot be opened", func() {
+			fs.OpenFileErr = errors.New("fake-open-file-error")
+
+			result := genericScript.RunWithResult()
+			Expect(result.Status).To(Equal(boshscript.ScriptStatusFailed))
+			Expect(result.ExitCode).To(Equal(-1))
+			Expect(result.Error).To(MatchError("fake-open-file-error"))
+		})
+
+		It("returns resource usage when script runs in its own cgroup", func() {
+			cgroupProvider := fakecmdrunner.NewFakeCgroupProvider()
+			cgroupProvider.UsageResult = boshrunner.CgroupUsage{PeakMemoryBytes: 4096, CPUTime: 2 * time.Second}
+
+			cgroupRunner := boshrunner.NewCgroupCmdRunner(cmdRunner, cgroupProvider, boshrunner.CgroupLimits{}, boshlog.NewLogger(boshlog.LevelNone))
+			genericScript = boshscript.NewScript(fs, cgroupRunner, "my-tag", "/path-to-script", stdoutLogPath, stderrLogPath)
+
+			cmdRunner.AddProcess("fake-cgroup-wrapper path-to-script-1 /path-to-script", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: 0},
+			})
+
+			result := genericScript.RunWithResult()
+			Expect(result.Status).To(Equal(boshscript.ScriptStatusSucceeded))
+			Expect(result.PeakMemoryBytes).To(Equal(uint64(4096)))
+			Expect(result.CPUTime).To(Equal(2 * time.Second))
+			Expect(cgroupProvider.Cgroups[0].Removed).To(BeTrue())
+		})
+	})
+
+	Describe("Cancel", func() {
+		It("terminates process group of the script giving it 10 secs to exit on its own", func() {
+			process := &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{
+						ExitStatus: 143,
+						Error:      errors.New("fake-terminated-error"),
+					}
+				},
+			}
+			cmdRunner.AddProcess("/path-to-script", process)
+
+			err := genericScript.Cancel()
+			Expect(err).ToNot(HaveOccurred())
+
+			result := genericScript.RunWithResult()
+			Expect(result.Status).To(Equal(boshscript.ScriptStatusCancelled))
+			Expect(result.ExitCode).To(Equal(143))
+			Expect(result.Error).To(MatchError("Script was cancelled by user request"))
+
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+		})
+
+		It("allows to cancel script second time without returning an error", func() {
+			Expect(genericScript.Cancel()).ToNot(HaveOccurred())
+			Expect(genericScript.Cancel()).ToNot(HaveOccurred())
+		})
 	})
 })
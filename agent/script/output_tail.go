@@ -0,0 +1,54 @@
+package script
+
+import (
+	"sync"
+)
+
+// OutputTail keeps only last maxBytes written to it so that
+// output of long running commands does not have to be kept in memory.
+type OutputTail struct {
+	maxBytes  int
+	buf       []byte
+	truncated bool
+	lock      sync.Mutex
+}
+
+func NewOutputTail(maxBytes int) *OutputTail {
+	return &OutputTail{maxBytes: maxBytes}
+}
+
+func (t *OutputTail) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	written := len(p)
+
+	if len(p) >= t.maxBytes {
+		t.truncated = t.truncated || len(t.buf) > 0 || len(p) > t.maxBytes
+		t.buf = append(t.buf[:0], p[len(p)-t.maxBytes:]...)
+		return written, nil
+	}
+
+	if overflow := len(t.buf) + len(p) - t.maxBytes; overflow > 0 {
+		t.truncated = true
+		t.buf = append(t.buf[:0], t.buf[overflow:]...)
+	}
+
+	t.buf = append(t.buf, p...)
+
+	return written, nil
+}
+
+func (t *OutputTail) String() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return string(t.buf)
+}
+
+func (t *OutputTail) Truncated() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.truncated
+}
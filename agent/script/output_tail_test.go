@@ -0,0 +1,45 @@
+package script_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+)
+
+var _ = Describe("OutputTail", func() {
+	var (
+		tail *boshscript.OutputTail
+	)
+
+	BeforeEach(func() {
+		tail = boshscript.NewOutputTail(10)
+	})
+
+	It("keeps everything that fits", func() {
+		_, err := tail.Write([]byte("12345"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = tail.Write([]byte("67890"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(tail.String()).To(Equal("1234567890"))
+		Expect(tail.Truncated()).To(BeFalse())
+	})
+
+	It("keeps only last bytes written across multiple writes", func() {
+		tail.Write([]byte("12345678"))
+		tail.Write([]byte("abcd"))
+
+		Expect(tail.String()).To(Equal("345678abcd"))
+		Expect(tail.Truncated()).To(BeTrue())
+	})
+
+	It("keeps only last bytes of single large write", func() {
+		n, err := tail.Write([]byte("1234567890abcd"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(14))
+
+		Expect(tail.String()).To(Equal("567890abcd"))
+		Expect(tail.Truncated()).To(BeTrue())
+	})
+})
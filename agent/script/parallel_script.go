@@ -1,7 +1,9 @@
 package script
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -15,11 +17,6 @@ type ParallelScript struct {
 	logger boshlog.Logger
 }
 
-type scriptResult struct {
-	Script Script
-	Error  error
-}
-
 func NewParallelScript(name string, scripts []Script, logger boshlog.Logger) ParallelScript {
 	return ParallelScript{
 		name:       name,
@@ -35,35 +32,67 @@ func (s ParallelScript) Path() string { return "" }
 func (s ParallelScript) Exists() bool { return true }
 
 func (s ParallelScript) Run() error {
+	_, err := s.RunWithResults()
+	return err
+}
+
+// RunWithResults returns results of scripts that exist keyed by their tags
+func (s ParallelScript) RunWithResults() (map[string]ScriptResult, error) {
 	existingScripts := s.findExistingScripts(s.allScripts)
 
 	s.logger.Info(s.logTag, "Will run %d %s scripts in parallel", len(existingScripts), s.name)
 
-	resultsChan := make(chan scriptResult)
+	resultsChan := make(chan ScriptResult)
 
 	for _, script := range existingScripts {
 		script := script
-		go func() { resultsChan <- scriptResult{script, script.Run()} }()
+		go func() { resultsChan <- s.runScript(script) }()
 	}
 
+	results := map[string]ScriptResult{}
+
 	var failedScripts, passedScripts []string
 
 	for i := 0; i < len(existingScripts); i++ {
 		select {
 		case r := <-resultsChan:
-			jobName := r.Script.Tag()
+			results[r.Tag] = r
 
 			if r.Error == nil {
-				passedScripts = append(passedScripts, jobName)
-				s.logger.Info(s.logTag, "'%s' script has successfully executed", r.Script.Path())
+				passedScripts = append(passedScripts, r.Tag)
+				s.logger.Info(s.logTag, "'%s' script has successfully executed", r.Tag)
 			} else {
-				failedScripts = append(failedScripts, jobName)
-				s.logger.Error(s.logTag, "'%s' script has failed with error: %s", r.Script.Path(), r.Error)
+				failedScripts = append(failedScripts, s.describeFailure(r))
+				s.logger.Error(s.logTag, "'%s' script has failed with error: %s", r.Tag, r.Error)
 			}
 		}
 	}
 
-	return s.summarizeErrs(passedScripts, failedScripts)
+	return results, s.summarizeErrs(passedScripts, failedScripts)
+}
+
+func (s ParallelScript) runScript(script Script) ScriptResult {
+	if script, ok := script.(ReportingScript); ok {
+		return script.RunWithResult()
+	}
+
+	startTime := time.Now()
+
+	err := script.Run()
+
+	result := ScriptResult{
+		Tag:      script.Tag(),
+		Status:   ScriptStatusSucceeded,
+		Duration: time.Since(startTime),
+		Error:    err,
+	}
+
+	if err != nil {
+		result.Status = ScriptStatusFailed
+		result.ExitCode = -1
+	}
+
+	return result
 }
 
 func (s ParallelScript) Cancel() error {
@@ -97,6 +126,26 @@ func (s ParallelScript) findExistingScripts(all []Script) []Script {
 	return existing
 }
 
+// describeFailure includes exit code and last line of stderr when script reports them
+func (s ParallelScript) describeFailure(r ScriptResult) string {
+	if r.Status == ScriptStatusCancelled {
+		return fmt.Sprintf("%s (cancelled)", r.Tag)
+	}
+
+	if r.ExitCode <= 0 {
+		return r.Tag
+	}
+
+	lines := strings.Split(strings.TrimSpace(r.Stderr), "\n")
+	lastLine := strings.TrimSpace(lines[len(lines)-1])
+
+	if len(lastLine) == 0 {
+		return fmt.Sprintf("%s (exit code %d)", r.Tag, r.ExitCode)
+	}
+
+	return fmt.Sprintf("%s (exit code %d: %s)", r.Tag, r.ExitCode, lastLine)
+}
+
 func (s ParallelScript) summarizeErrs(passedScripts, failedScripts []string) error {
 	if len(failedScripts) > 0 {
 		errMsg := "Failed Jobs: " + strings.Join(failedScripts, ", ")
@@ -206,6 +206,114 @@ var _ = Describe("ParallelScript", func() {
 		})
 	})
 
+	Describe("RunWithResults", func() {
+		Context("when scripts report results", func() {
+			var (
+				reportingScript1 *fakescript.FakeReportingScript
+				reportingScript2 *fakescript.FakeReportingScript
+			)
+
+			BeforeEach(func() {
+				reportingScript1 = &fakescript.FakeReportingScript{}
+				reportingScript1.TagReturns("fake-job-1")
+				reportingScript1.ExistsReturns(true)
+				scripts = append(scripts, reportingScript1)
+
+				reportingScript2 = &fakescript.FakeReportingScript{}
+				reportingScript2.TagReturns("fake-job-2")
+				reportingScript2.ExistsReturns(true)
+				scripts = append(scripts, reportingScript2)
+			})
+
+			It("returns result of each script keyed by its tag", func() {
+				result1 := boshscript.ScriptResult{
+					Tag:      "fake-job-1",
+					Status:   boshscript.ScriptStatusSucceeded,
+					Duration: time.Second,
+					Stdout:   "fake-stdout",
+				}
+				reportingScript1.RunWithResultReturns(result1)
+
+				result2 := boshscript.ScriptResult{
+					Tag:      "fake-job-2",
+					Status:   boshscript.ScriptStatusSucceeded,
+					Duration: 2 * time.Second,
+					Stderr:   "fake-stderr",
+				}
+				reportingScript2.RunWithResultReturns(result2)
+
+				results, err := parallelScript.RunWithResults()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(Equal(map[string]boshscript.ScriptResult{
+					"fake-job-1": result1,
+					"fake-job-2": result2,
+				}))
+
+				Expect(reportingScript1.RunCallCount()).To(Equal(0))
+			})
+
+			It("includes exit code and last line of stderr of failed scripts in error", func() {
+				reportingScript1.RunWithResultReturns(boshscript.ScriptResult{
+					Tag:      "fake-job-1",
+					Status:   boshscript.ScriptStatusFailed,
+					ExitCode: 3,
+					Stderr:   "fake-stderr-1\nfake-stderr-2\n",
+					Error:    errors.New("fake-error"),
+				})
+				reportingScript2.RunWithResultReturns(boshscript.ScriptResult{
+					Tag:    "fake-job-2",
+					Status: boshscript.ScriptStatusSucceeded,
+				})
+
+				_, err := parallelScript.RunWithResults()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("1 of 2 run-me scripts failed. Failed Jobs: fake-job-1 (exit code 3: fake-stderr-2). Successful Jobs: fake-job-2."))
+			})
+
+			It("marks cancelled scripts in error", func() {
+				reportingScript1.RunWithResultReturns(boshscript.ScriptResult{
+					Tag:      "fake-job-1",
+					Status:   boshscript.ScriptStatusCancelled,
+					ExitCode: -1,
+					Error:    errors.New("fake-error"),
+				})
+				reportingScript2.RunWithResultReturns(boshscript.ScriptResult{
+					Tag:      "fake-job-2",
+					Status:   boshscript.ScriptStatusFailed,
+					ExitCode: 1,
+					Error:    errors.New("fake-error"),
+				})
+
+				_, err := parallelScript.RunWithResults()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-job-1 (cancelled)"))
+				Expect(err.Error()).To(ContainSubstring("fake-job-2 (exit code 1)"))
+			})
+		})
+
+		Context("when scripts do not report results", func() {
+			var existingScript *fakescript.FakeScript
+
+			BeforeEach(func() {
+				existingScript = &fakescript.FakeScript{}
+				existingScript.TagReturns("fake-job-1")
+				existingScript.ExistsReturns(true)
+				scripts = append(scripts, existingScript)
+			})
+
+			It("returns status based on script error", func() {
+				existingScript.RunReturns(errors.New("fake-error"))
+
+				results, err := parallelScript.RunWithResults()
+				Expect(err).To(HaveOccurred())
+				Expect(results).To(HaveKey("fake-job-1"))
+				Expect(results["fake-job-1"].Status).To(Equal(boshscript.ScriptStatusFailed))
+				Expect(results["fake-job-1"].ExitCode).To(Equal(-1))
+				Expect(results["fake-job-1"].Error).To(MatchError("fake-error"))
+			})
+		})
+	})
+
 	Describe("Cancel", func() {
 		Context("when there are no scripts", func() {
 			BeforeEach(func() {
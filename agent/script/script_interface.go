@@ -1,6 +1,8 @@
 package script
 
 import (
+	"time"
+
 	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
 )
 
@@ -26,3 +28,39 @@ type CancellableScript interface {
 	Script
 	Cancel() error
 }
+
+const (
+	ScriptStatusSucceeded = "succeeded"
+	ScriptStatusFailed    = "failed"
+	ScriptStatusCancelled = "cancelled"
+)
+
+// ScriptResult describes how single job script ran.
+// Stdout and Stderr only include last part of script output.
+type ScriptResult struct {
+	Tag      string
+	Status   string
+	ExitCode int
+	Duration time.Duration
+	Stdout   string
+	Stderr   string
+	Error    error
+
+	// Only known when scripts run in their own cgroups
+	PeakMemoryBytes uint64
+	CPUTime         time.Duration
+}
+
+//go:generate counterfeiter . ReportingScript
+
+type ReportingScript interface {
+	CancellableScript
+	RunWithResult() ScriptResult
+}
+
+//go:generate counterfeiter . ParallelReportingScript
+
+type ParallelReportingScript interface {
+	CancellableScript
+	RunWithResults() (map[string]ScriptResult, error)
+}
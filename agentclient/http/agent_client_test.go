@@ -872,6 +872,18 @@ var _ = Describe("AgentClient", func() {
 			})
 		})
 
+		Context("when agent responds with sync result", func() {
+			BeforeEach(func() {
+				fakeHTTPClient.SetPostBehavior(`{"value":{"status":"synced","version":42,"record_count":3}}`, 200, nil)
+			})
+
+			It("returns the status of sync result", func() {
+				responseValue, err := agentClient.SyncDNS("fake-blob-store-id", "fake-blob-store-id-sha1", 42)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(responseValue).To(Equal("synced"))
+			})
+		})
+
 		Context("when agent does not respond with 200", func() {
 			BeforeEach(func() {
 				fakeHTTPClient.SetPostBehavior("", http.StatusInternalServerError, nil)
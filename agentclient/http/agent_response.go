@@ -46,8 +46,37 @@ func (r *SyncDNSResponse) ServerError() error {
 	return nil
 }
 
+// Unmarshal accepts both "synced" returned by older agents
+// and sync result object returned by newer agents
 func (r *SyncDNSResponse) Unmarshal(message []byte) error {
-	return json.Unmarshal(message, r)
+	var response struct {
+		Value     json.RawMessage
+		Exception *exception
+	}
+
+	err := json.Unmarshal(message, &response)
+	if err != nil {
+		return err
+	}
+
+	r.Exception = response.Exception
+
+	if len(response.Value) == 0 || json.Unmarshal(response.Value, &r.Value) == nil {
+		return nil
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+
+	err = json.Unmarshal(response.Value, &result)
+	if err != nil {
+		return err
+	}
+
+	r.Value = result.Status
+
+	return nil
 }
 
 type ListResponse struct {
@@ -13,6 +13,7 @@ import (
 	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshcs "github.com/cloudfoundry/bosh-agent/agent/applier/contentstore"
 	boshaj "github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	boshap "github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
@@ -149,7 +150,9 @@ func (app *app) Setup(opts Options) error {
 
 	notifier := boshnotif.NewNotifier(mbusHandler)
 
-	applier, compiler := app.buildApplierAndCompiler(app.dirProvider, blobstore, jobSupervisor)
+	scriptRunner := app.buildScriptRunner(config.Sandbox)
+
+	applier, compiler, bundleVerifier := app.buildApplierAndCompiler(app.dirProvider, blobstore, jobSupervisor, scriptRunner, config.Applier, config.Compiler, timeService)
 
 	uuidGen := boshuuid.NewGenerator()
 
@@ -168,7 +171,7 @@ func (app *app) Setup(opts Options) error {
 	)
 
 	jobScriptProvider := boshscript.NewConcreteJobScriptProvider(
-		app.platform.GetRunner(),
+		scriptRunner,
 		app.platform.GetFs(),
 		app.platform.GetDirProvider(),
 		timeService,
@@ -187,6 +190,8 @@ func (app *app) Setup(opts Options) error {
 		jobSupervisor,
 		specService,
 		jobScriptProvider,
+		scriptRunner,
+		bundleVerifier,
 		app.logger,
 	)
 
@@ -214,6 +219,9 @@ func (app *app) Setup(opts Options) error {
 		settingsService,
 		uuidGen,
 		timeService,
+		boshbc.NewDriftMonitor(bundleVerifier, config.BundleDrift, timeService, app.logger),
+		config.TimeDrift,
+		config.CertExpiry,
 	)
 
 	return nil
@@ -235,7 +243,11 @@ func (app *app) buildApplierAndCompiler(
 	dirProvider boshdirs.Provider,
 	blobstore boshblob.DigestBlobstore,
 	jobSupervisor boshjobsuper.JobSupervisor,
-) (boshapplier.Applier, boshcomp.Compiler) {
+	scriptRunner boshsys.CmdRunner,
+	applierOptions boshapplier.Options,
+	compilerOptions boshcomp.Options,
+	timeService clock.Clock,
+) (boshapplier.Applier, boshcomp.Compiler, boshbc.Verifier) {
 	fileSystem := app.platform.GetFs()
 
 	jobsBc := boshbc.NewFileBundleCollection(
@@ -246,12 +258,28 @@ func (app *app) buildApplierAndCompiler(
 		app.logger,
 	)
 
+	// Kept on the same device as installed bundles so that contents can be reflinked
+	jobsContentStore := boshcs.NewFileContentStore(
+		filepath.Join(dirProvider.DataDir(), "content", "jobs"),
+		fileSystem,
+		app.platform.GetRunner(),
+		app.logger,
+	)
+
+	packagesContentStore := boshcs.NewFileContentStore(
+		filepath.Join(dirProvider.DataDir(), "content", "packages"),
+		fileSystem,
+		app.platform.GetRunner(),
+		app.logger,
+	)
+
 	packageApplierProvider := boshap.NewCompiledPackageApplierProvider(
 		dirProvider.DataDir(),
 		dirProvider.BaseDir(),
 		dirProvider.JobsDir(),
 		"packages",
 		blobstore,
+		packagesContentStore,
 		app.platform.GetCompressor(),
 		fileSystem,
 		app.logger,
@@ -262,6 +290,7 @@ func (app *app) buildApplierAndCompiler(
 		jobSupervisor,
 		packageApplierProvider,
 		blobstore,
+		jobsContentStore,
 		app.platform.GetCompressor(),
 		fileSystem,
 		app.logger,
@@ -273,26 +302,74 @@ func (app *app) buildApplierAndCompiler(
 		app.platform,
 		jobSupervisor,
 		dirProvider,
+		applierOptions,
+		app.logger,
 	)
 
 	cmdRunner := boshrunner.NewFileLoggingCmdRunner(
 		fileSystem,
-		app.platform.GetRunner(),
+		scriptRunner,
+		timeService,
 		dirProvider.LogsDir(),
 		10*1024, // 10 Kb
 	)
 
+	compileCompressor := app.platform.GetCompressor()
+	if compilerOptions.Isolated {
+		// Compiling identical inputs results in identical compiled package digests
+		compileCompressor = boshcomp.NewDeterministicCompressor(
+			compileCompressor,
+			app.platform.GetRunner(),
+			fileSystem,
+			compilerOptions.SourceDateEpoch,
+		)
+	}
+
 	compiler := boshcomp.NewConcreteCompiler(
-		app.platform.GetCompressor(),
+		compileCompressor,
 		blobstore,
 		fileSystem,
 		cmdRunner,
 		dirProvider,
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
+		boshcomp.NewFileCompileCache(filepath.Join(dirProvider.DataDir(), "compile_cache"), compilerOptions, fileSystem),
+		compilerOptions,
+		app.logger,
+	)
+
+	bundleVerifier := boshbc.NewVerifier(
+		[]boshbc.VerifiedCollection{
+			// Job specific package symlinks are added to jobs after installation
+			{Collection: jobsBc, IgnoredPaths: []string{"packages"}},
+			{Collection: packageApplierProvider.RootBundleCollection()},
+		},
+		app.logger,
 	)
 
-	return applier, compiler
+	return applier, compiler, bundleVerifier
+}
+
+// buildScriptRunner returns runner for job scripts and packaging scripts
+// that optionally puts each of them into its own resource-limited cgroup
+func (app *app) buildScriptRunner(sandboxOptions boshrunner.SandboxOptions) boshsys.CmdRunner {
+	if !sandboxOptions.Enabled {
+		return app.platform.GetRunner()
+	}
+
+	cgroupProvider := boshrunner.NewCgroupProvider(
+		app.platform.GetFs(),
+		app.platform.GetRunner(),
+		boshrunner.DefaultCgroupRoot,
+		app.logger,
+	)
+
+	return boshrunner.NewCgroupCmdRunner(
+		app.platform.GetRunner(),
+		cgroupProvider,
+		sandboxOptions.Limits,
+		app.logger,
+	)
 }
 
 func (app *app) loadConfig(path string) (Config, error) {
@@ -3,6 +3,11 @@ package app
 import (
 	"encoding/json"
 
+	boshagent "github.com/cloudfoundry/bosh-agent/agent"
+	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -12,6 +17,23 @@ import (
 type Config struct {
 	Platform       boshplatform.Options
 	Infrastructure boshinf.Options
+
+	// Controls whether job scripts and package compilation run in cgroups
+	Sandbox boshrunner.SandboxOptions
+
+	Applier boshapplier.Options
+
+	// Controls environment of packaging scripts run by compile_package
+	Compiler boshcomp.Options
+
+	// Controls periodic verification of installed jobs and packages
+	BundleDrift boshbc.DriftMonitorOptions
+
+	// Controls when heartbeats raise time drift alerts
+	TimeDrift boshagent.TimeDriftOptions
+
+	// Controls when heartbeats raise certificate expiry alerts
+	CertExpiry boshagent.CertExpiryOptions
 }
 
 func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
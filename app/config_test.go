@@ -4,6 +4,11 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshagent "github.com/cloudfoundry/bosh-agent/agent"
+	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
+	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -58,11 +63,45 @@ var _ = Describe("LoadConfigFromPath", func() {
 						"URI": "/fake-uri",
 						"Headers": {"fake": "headers"},
 						"SettingsPath": "/fake-settings-path"
+					  },
+					  {
+						"Type": "NoCloud",
+						"Label": "fake-label",
+						"SettingsPath": "/fake-settings-path"
+					  },
+					  {
+						"Type": "GuestInfo",
+						"RPCToolPath": "/fake-rpctool",
+						"UserDataKey": "guestinfo.fake-userdata"
 					  }
 				  ],
 				  "UseServerName": true,
 				  "UseRegistry": true
 				}
+			},
+			"Sandbox": {
+				"Enabled": true,
+				"Limits": {
+					"MemoryMB": 1024,
+					"CPUPercent": 200,
+					"MaxProcesses": 512
+				}
+			},
+			"Applier": {
+				"PrepareWorkers": 10
+			},
+			"Compiler": {
+				"Isolated": true,
+				"SourceDateEpoch": 1500000000
+			},
+			"BundleDrift": {
+				"IntervalSeconds": 3600
+			},
+			"TimeDrift": {
+				"AlertThresholdMilliseconds": 500
+			},
+			"CertExpiry": {
+				"AlertThresholdDays": 14
 			}
 		}`)
 
@@ -103,11 +142,43 @@ var _ = Describe("LoadConfigFromPath", func() {
 							Headers:      map[string]string{"fake": "headers"},
 							SettingsPath: "/fake-settings-path",
 						},
+						boshinf.NoCloudSourceOptions{
+							Label:        "fake-label",
+							SettingsPath: "/fake-settings-path",
+						},
+						boshinf.GuestInfoSourceOptions{
+							RPCToolPath: "/fake-rpctool",
+							UserDataKey: "guestinfo.fake-userdata",
+						},
 					},
 					UseServerName: true,
 					UseRegistry:   true,
 				},
 			},
+			Sandbox: boshrunner.SandboxOptions{
+				Enabled: true,
+				Limits: boshrunner.CgroupLimits{
+					MemoryMB:     1024,
+					CPUPercent:   200,
+					MaxProcesses: 512,
+				},
+			},
+			Applier: boshapplier.Options{
+				PrepareWorkers: 10,
+			},
+			Compiler: boshcomp.Options{
+				Isolated:        true,
+				SourceDateEpoch: 1500000000,
+			},
+			BundleDrift: boshbc.DriftMonitorOptions{
+				IntervalSeconds: 3600,
+			},
+			TimeDrift: boshagent.TimeDriftOptions{
+				AlertThresholdMilliseconds: 500,
+			},
+			CertExpiry: boshagent.CertExpiryOptions{
+				AlertThresholdDays: 14,
+			},
 		}))
 	})
 
@@ -273,4 +344,38 @@ var _ = Describe("LoadConfigFromPath", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("Unmarshalling source type 'CDROM'"))
 	})
+
+	It("returns errors if failed to decode NoCloud source options", func() {
+		fs.WriteFileString("/fake-config.conf", `{
+			"Infrastructure": {
+			  "Settings": {
+				  "Sources": [{
+				  	"Type": "NoCloud",
+				  	"Label": 1
+				  }]
+				}
+			}
+		}`)
+
+		_, err := LoadConfigFromPath(fs, "/fake-config.conf")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling source type 'NoCloud'"))
+	})
+
+	It("returns errors if failed to decode GuestInfo source options", func() {
+		fs.WriteFileString("/fake-config.conf", `{
+			"Infrastructure": {
+			  "Settings": {
+				  "Sources": [{
+				  	"Type": "GuestInfo",
+				  	"RPCToolPath": 1
+				  }]
+				}
+			}
+		}`)
+
+		_, err := LoadConfigFromPath(fs, "/fake-config.conf")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling source type 'GuestInfo'"))
+	})
 })
@@ -22,9 +22,17 @@ func (r valueResponse) Shorten() Response {
 	return r
 }
 
+// DetailedError is implemented by errors that carry structured
+// information for API consumers in addition to the error message
+type DetailedError interface {
+	error
+	Details() map[string]interface{}
+}
+
 type exceptionResponse struct {
 	Exception struct {
-		Message string `json:"message,omitempty"`
+		Message string                 `json:"message,omitempty"`
+		Details map[string]interface{} `json:"details,omitempty"`
 	} `json:"exception"`
 
 	err error
@@ -33,6 +41,7 @@ type exceptionResponse struct {
 func NewExceptionResponse(err error) (resp Response) {
 	r := exceptionResponse{}
 	r.Exception.Message = err.Error()
+	r.Exception.Details = errorDetails(err)
 	r.err = err
 	return r
 }
@@ -41,9 +50,28 @@ func (r exceptionResponse) Shorten() Response {
 	if typedErr, ok := r.err.(bosherr.ShortenableError); ok {
 		sr := exceptionResponse{}
 		sr.Exception.Message = typedErr.ShortError()
+		sr.Exception.Details = r.Exception.Details
 		sr.err = typedErr
 		return sr
 	}
 
 	return r
 }
+
+// errorDetails returns details of the first detailed error among wrapped causes
+func errorDetails(err error) map[string]interface{} {
+	for err != nil {
+		if detailedErr, ok := err.(DetailedError); ok {
+			return detailedErr.Details()
+		}
+
+		complexErr, ok := err.(bosherr.ComplexError)
+		if !ok {
+			return nil
+		}
+
+		err = complexErr.Cause
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/handler"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type testShortError struct {
@@ -22,6 +23,14 @@ func (e *testShortError) ShortError() string {
 	return msg
 }
 
+type testDetailedError struct{}
+
+func (e testDetailedError) Error() string { return "fake-detailed-msg" }
+
+func (e testDetailedError) Details() map[string]interface{} {
+	return map[string]interface{}{"fake-key": "fake-value"}
+}
+
 var _ = Describe("NewValueResponse", func() {
 	It("can be serialized to JSON", func() {
 		resp := NewValueResponse("fake-value")
@@ -91,4 +100,26 @@ var _ = Describe("NewExceptionResponse", func() {
 			)
 		})
 	})
+
+	Context("with error that wraps error with details", func() {
+		err := bosherr.WrapError(bosherr.WrapError(testDetailedError{}, "fake-inner-wrap"), "fake-outer-wrap")
+
+		It("can be serialized to JSON with details", func() {
+			resp := NewExceptionResponse(err)
+			boshassert.MatchesJSONString(
+				GinkgoT(),
+				resp,
+				`{"exception":{"message":"fake-outer-wrap: fake-inner-wrap: fake-detailed-msg","details":{"fake-key":"fake-value"}}}`,
+			)
+		})
+
+		It("keeps details when shortened", func() {
+			resp := NewExceptionResponse(err)
+			boshassert.MatchesJSONString(
+				GinkgoT(),
+				resp.Shorten(),
+				`{"exception":{"message":"fake-outer-wrap: fake-inner-wrap: fake-detailed-msg","details":{"fake-key":"fake-value"}}}`,
+			)
+		})
+	})
 })
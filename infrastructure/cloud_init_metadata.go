@@ -0,0 +1,269 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// CloudInitMetadataContentsType is meta-data provided by
+// cloud-init style datasources (NoCloud, VMware guestinfo)
+type CloudInitMetadataContentsType struct {
+	InstanceID    string
+	LocalHostname string
+	PublicKeys    []string
+}
+
+// cloudInitDataReader reads named meta-data/user-data documents from a datasource
+type cloudInitDataReader interface {
+	read(name string) ([]byte, error)
+}
+
+type cloudInitMetadataService struct {
+	resolver DNSResolver
+	reader   cloudInitDataReader
+
+	metaDataName string
+	userDataName string
+
+	// Loaded state
+	metaDataContents CloudInitMetadataContentsType
+	userDataContents UserDataContentsType
+
+	description string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func (ms *cloudInitMetadataService) GetPublicKey() (string, error) {
+	if len(ms.metaDataContents.PublicKeys) > 0 {
+		return ms.metaDataContents.PublicKeys[0], nil
+	}
+
+	return "", bosherr.Errorf("Failed to load public-keys from %s metadata service", ms.description)
+}
+
+func (ms *cloudInitMetadataService) GetInstanceID() (string, error) {
+	if ms.metaDataContents.InstanceID == "" {
+		return "", bosherr.Errorf("Failed to load instance-id from %s metadata service", ms.description)
+	}
+
+	ms.logger.Debug(ms.logTag, "Getting instance id: %s", ms.metaDataContents.InstanceID)
+	return ms.metaDataContents.InstanceID, nil
+}
+
+func (ms *cloudInitMetadataService) GetServerName() (string, error) {
+	if ms.userDataContents.Server.Name == "" {
+		return "", bosherr.Errorf("Failed to load server name from %s metadata service", ms.description)
+	}
+
+	ms.logger.Debug(ms.logTag, "Getting server name: %s", ms.userDataContents.Server.Name)
+	return ms.userDataContents.Server.Name, nil
+}
+
+func (ms *cloudInitMetadataService) GetRegistryEndpoint() (string, error) {
+	if ms.userDataContents.Registry.Endpoint == "" {
+		return "", bosherr.Errorf("Failed to load registry endpoint from %s metadata service", ms.description)
+	}
+
+	endpoint := ms.userDataContents.Registry.Endpoint
+	nameServers := ms.userDataContents.DNS.Nameserver
+
+	if len(nameServers) == 0 {
+		ms.logger.Debug(ms.logTag, "Getting registry endpoint %s", endpoint)
+		return endpoint, nil
+	}
+
+	resolvedEndpoint, err := ms.resolver.LookupHost(nameServers, endpoint)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Resolving registry endpoint")
+	}
+
+	ms.logger.Debug(ms.logTag, "Registry endpoint %s was resolved to %s", endpoint, resolvedEndpoint)
+	return resolvedEndpoint, nil
+}
+
+func (ms *cloudInitMetadataService) GetNetworks() (boshsettings.Networks, error) {
+	return ms.userDataContents.Networks, nil
+}
+
+func (ms *cloudInitMetadataService) IsAvailable() bool {
+	err := ms.load()
+	if err != nil {
+		ms.logger.Warn(ms.logTag, "Failed to load %s metadata - %s", ms.description, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (ms *cloudInitMetadataService) load() error {
+	ms.logger.Debug(ms.logTag, "Loading %s metadata service", ms.description)
+
+	metaDataBytes, err := ms.reader.read(ms.metaDataName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading %s meta-data", ms.description)
+	}
+
+	metadata, err := parseCloudInitMetadata(metaDataBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing %s meta-data from '%s'", ms.description, ms.metaDataName)
+	}
+
+	userDataBytes, err := ms.reader.read(ms.userDataName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading %s user-data", ms.description)
+	}
+
+	var userdata UserDataContentsType
+
+	err = json.Unmarshal(userDataBytes, &userdata)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing %s user-data from '%s'", ms.description, ms.userDataName)
+	}
+
+	ms.metaDataContents = metadata
+	ms.userDataContents = userdata
+
+	return nil
+}
+
+// parseCloudInitMetadata accepts JSON or the flat YAML subset that
+// cloud-init datasources use for meta-data (scalars and lists of scalars)
+func parseCloudInitMetadata(contents []byte) (CloudInitMetadataContentsType, error) {
+	var metadata CloudInitMetadataContentsType
+	var values map[string]interface{}
+
+	trimmed := bytes.TrimSpace(contents)
+
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		err := json.Unmarshal(trimmed, &values)
+		if err != nil {
+			return metadata, bosherr.WrapError(err, "Unmarshalling JSON meta-data")
+		}
+	} else {
+		var err error
+
+		values, err = parseCloudInitYAML(trimmed)
+		if err != nil {
+			return metadata, bosherr.WrapError(err, "Unmarshalling YAML meta-data")
+		}
+	}
+
+	metadata.InstanceID, _ = values["instance-id"].(string)
+	metadata.LocalHostname, _ = values["local-hostname"].(string)
+	metadata.PublicKeys = cloudInitPublicKeys(values["public-keys"])
+
+	return metadata, nil
+}
+
+// cloudInitPublicKeys accepts a single key, a list of keys
+// or OpenStack style map of {"openssh-key": key} entries
+func cloudInitPublicKeys(value interface{}) []string {
+	var keys []string
+
+	switch typedValue := value.(type) {
+	case string:
+		if typedValue != "" {
+			keys = append(keys, typedValue)
+		}
+
+	case []interface{}:
+		for _, item := range typedValue {
+			keys = append(keys, cloudInitPublicKeys(item)...)
+		}
+
+	case map[string]interface{}:
+		var names []string
+		for name := range typedValue {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if entry, ok := typedValue[name].(map[string]interface{}); ok {
+				keys = append(keys, cloudInitPublicKeys(entry["openssh-key"])...)
+			} else {
+				keys = append(keys, cloudInitPublicKeys(typedValue[name])...)
+			}
+		}
+	}
+
+	return keys
+}
+
+func parseCloudInitYAML(contents []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	var listKey string
+
+	for i, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimRight(line, "\r \t")
+		trimmedLine := strings.TrimSpace(line)
+
+		if trimmedLine == "" || trimmedLine == "---" || strings.HasPrefix(trimmedLine, "#") {
+			continue
+		}
+
+		if trimmedLine == "-" || strings.HasPrefix(trimmedLine, "- ") {
+			if listKey == "" {
+				return nil, bosherr.Errorf("Unexpected list item on line %d", i+1)
+			}
+
+			item := unquoteCloudInitYAML(strings.TrimSpace(strings.TrimPrefix(trimmedLine, "-")))
+			values[listKey] = append(values[listKey].([]interface{}), item)
+			continue
+		}
+
+		// Nested mappings are not used by supported keys
+		if line != trimmedLine {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, bosherr.Errorf("Expected key and value on line %d", i+1)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if value == "" {
+			listKey = key
+			values[key] = []interface{}{}
+			continue
+		}
+
+		listKey = ""
+		values[key] = unquoteCloudInitYAML(value)
+	}
+
+	return values, nil
+}
+
+func unquoteCloudInitYAML(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.Replace(value[1:len(value)-1], "''", "'", -1)
+	}
+
+	return value
+}
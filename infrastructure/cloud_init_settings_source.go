@@ -0,0 +1,103 @@
+package infrastructure
+
+import (
+	"encoding/json"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CloudInitSettingsSource reads settings from cloud-init style
+// datasources such as NoCloud volume or VMware guestinfo
+type CloudInitSettingsSource struct {
+	reader cloudInitDataReader
+
+	metaDataName string
+	settingsName string
+
+	description string
+}
+
+func NewNoCloudSettingsSource(
+	diskPaths []string,
+	metaDataPath string,
+	settingsPath string,
+	platform boshplatform.Platform,
+	logger boshlog.Logger,
+) *CloudInitSettingsSource {
+	return &CloudInitSettingsSource{
+		reader: noCloudVolume{
+			diskPaths: diskPaths,
+			platform:  platform,
+
+			logTag: "NoCloudSettingsSource",
+			logger: logger,
+		},
+
+		metaDataName: metaDataPath,
+		settingsName: settingsPath,
+
+		description: "NoCloud",
+	}
+}
+
+func NewGuestInfoSettingsSource(
+	rpcToolPath string,
+	metaDataKey string,
+	settingsKey string,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+) *CloudInitSettingsSource {
+	return &CloudInitSettingsSource{
+		reader: guestInfo{
+			rpcToolPath: rpcToolPath,
+			runner:      runner,
+
+			logTag: "GuestInfoSettingsSource",
+			logger: logger,
+		},
+
+		metaDataName: metaDataKey,
+		settingsName: settingsKey,
+
+		description: "guestinfo",
+	}
+}
+
+func (s *CloudInitSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
+	contents, err := s.reader.read(s.metaDataName)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := parseCloudInitMetadata(contents)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing %s meta-data from '%s'", s.description, s.metaDataName)
+	}
+
+	if len(metadata.PublicKeys) > 0 {
+		return metadata.PublicKeys[0], nil
+	}
+
+	return "", nil
+}
+
+func (s *CloudInitSettingsSource) Settings() (boshsettings.Settings, error) {
+	contents, err := s.reader.read(s.settingsName)
+	if err != nil {
+		return boshsettings.Settings{}, err
+	}
+
+	var settings boshsettings.Settings
+
+	err = json.Unmarshal(contents, &settings)
+	if err != nil {
+		return boshsettings.Settings{}, bosherr.WrapErrorf(
+			err, "Parsing %s settings from '%s'", s.description, s.settingsName)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,148 @@
+package infrastructure_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("CloudInitSettingsSource", func() {
+	var (
+		source *CloudInitSettingsSource
+		logger boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	Context("when reading from NoCloud volume", func() {
+		var (
+			platform *fakeplatform.FakePlatform
+		)
+
+		BeforeEach(func() {
+			platform = fakeplatform.NewFakePlatform()
+			source = NewNoCloudSettingsSource(
+				[]string{"/dev/disk/by-label/cidata", "/dev/disk/by-label/CIDATA"},
+				"meta-data",
+				"user-data",
+				platform,
+				logger,
+			)
+		})
+
+		Describe("PublicSSHKeyForUsername", func() {
+			It("returns first public key from meta-data", func() {
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", []byte(`
+instance-id: fake-instance-id
+public-keys:
+- ssh-rsa fake-public-key
+`), nil)
+
+				publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(publicKey).To(Equal("ssh-rsa fake-public-key"))
+			})
+
+			It("returns empty string when meta-data has no public keys", func() {
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", []byte("instance-id: fake-instance-id\n"), nil)
+
+				publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(publicKey).To(Equal(""))
+			})
+
+			It("returns error when meta-data cannot be read from any volume", func() {
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", nil, errors.New("fake-mount-err-1"))
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/CIDATA/meta-data", nil, errors.New("fake-mount-err-2"))
+
+				_, err := source.PublicSSHKeyForUsername("fake-username")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Loading file 'meta-data' from NoCloud volume"))
+				Expect(err.Error()).To(ContainSubstring("fake-mount-err-2"))
+			})
+		})
+
+		Describe("Settings", func() {
+			It("returns settings from user-data", func() {
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/CIDATA/user-data", []byte(`{"agent_id": "fake-agent-id"}`), nil)
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/user-data", nil, errors.New("fake-mount-err"))
+
+				settings, err := source.Settings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings).To(Equal(boshsettings.Settings{AgentID: "fake-agent-id"}))
+			})
+
+			It("returns error when settings cannot be parsed", func() {
+				platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/user-data", []byte("#cloud-config\n"), nil)
+
+				_, err := source.Settings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Parsing NoCloud settings from 'user-data'"))
+			})
+		})
+	})
+
+	Context("when reading from VMware guestinfo", func() {
+		var (
+			runner *fakesys.FakeCmdRunner
+		)
+
+		BeforeEach(func() {
+			runner = fakesys.NewFakeCmdRunner()
+			source = NewGuestInfoSettingsSource(
+				"/usr/bin/vmware-rpctool",
+				"guestinfo.metadata",
+				"guestinfo.userdata",
+				runner,
+				logger,
+			)
+		})
+
+		Describe("PublicSSHKeyForUsername", func() {
+			It("returns first public key from meta-data", func() {
+				runner.AddCmdResult("/usr/bin/vmware-rpctool info-get guestinfo.metadata", fakesys.FakeCmdResult{
+					Stdout: `{"public-keys": {"0": {"openssh-key": "ssh-rsa fake-public-key"}}}`,
+				})
+
+				publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(publicKey).To(Equal("ssh-rsa fake-public-key"))
+			})
+		})
+
+		Describe("Settings", func() {
+			It("returns settings from user-data", func() {
+				runner.AddCmdResult("/usr/bin/vmware-rpctool info-get guestinfo.userdata", fakesys.FakeCmdResult{
+					Stdout: "eyJhZ2VudF9pZCI6ICJmYWtlLWFnZW50LWlkIn0=\n",
+				})
+				runner.AddCmdResult("/usr/bin/vmware-rpctool info-get guestinfo.userdata.encoding", fakesys.FakeCmdResult{
+					Stdout: "b64\n",
+				})
+
+				settings, err := source.Settings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings).To(Equal(boshsettings.Settings{AgentID: "fake-agent-id"}))
+			})
+
+			It("returns error when vmware-rpctool fails", func() {
+				runner.AddCmdResult("/usr/bin/vmware-rpctool info-get guestinfo.userdata", fakesys.FakeCmdResult{
+					Error: errors.New("fake-rpctool-err"),
+				})
+
+				_, err := source.Settings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Getting guestinfo 'guestinfo.userdata'"))
+				Expect(err.Error()).To(ContainSubstring("fake-rpctool-err"))
+			})
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// guestInfo reads VMware guestinfo variables through vmware-rpctool.
+// Values may be encoded as indicated by '<key>.encoding' variable.
+type guestInfo struct {
+	rpcToolPath string
+	runner      boshsys.CmdRunner
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func (g guestInfo) read(key string) ([]byte, error) {
+	value, err := g.get(key)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting guestinfo '%s'", key)
+	}
+
+	// Missing encoding variable means value is not encoded
+	encoding, err := g.get(key + ".encoding")
+	if err != nil {
+		encoding = ""
+	}
+
+	decoded, err := decodeGuestInfo(value, strings.TrimSpace(encoding))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Decoding guestinfo '%s'", key)
+	}
+
+	g.logger.Debug(g.logTag, "Successfully loaded guestinfo '%s'", key)
+
+	return decoded, nil
+}
+
+func (g guestInfo) get(key string) (string, error) {
+	stdout, _, _, err := g.runner.RunCommand(g.rpcToolPath, "info-get "+key)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout), nil
+}
+
+func decodeGuestInfo(value, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(value), nil
+
+	case "base64", "b64":
+		return base64.StdEncoding.DecodeString(value)
+
+	case "gzip+base64", "gz+b64":
+		compressed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return ioutil.ReadAll(reader)
+	}
+
+	return nil, bosherr.Errorf("Unknown guestinfo encoding '%s'", encoding)
+}
+
+func NewGuestInfoMetadataService(
+	resolver DNSResolver,
+	runner boshsys.CmdRunner,
+	rpcToolPath string,
+	metaDataKey string,
+	userDataKey string,
+	logger boshlog.Logger,
+) MetadataService {
+	logTag := "GuestInfoMetadataService"
+
+	return &cloudInitMetadataService{
+		resolver: resolver,
+		reader: guestInfo{
+			rpcToolPath: rpcToolPath,
+			runner:      runner,
+
+			logTag: logTag,
+			logger: logger,
+		},
+
+		metaDataName: metaDataKey,
+		userDataName: userDataKey,
+
+		description: "guestinfo",
+
+		logTag: logTag,
+		logger: logger,
+	}
+}
@@ -0,0 +1,139 @@
+package infrastructure_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeinf "github.com/cloudfoundry/bosh-agent/infrastructure/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("GuestInfoMetadataService", func() {
+	var (
+		metadataService MetadataService
+		resolver        *fakeinf.FakeDNSResolver
+		runner          *fakesys.FakeCmdRunner
+		guestInfo       map[string]fakesys.FakeCmdResult
+	)
+
+	BeforeEach(func() {
+		resolver = &fakeinf.FakeDNSResolver{}
+		runner = fakesys.NewFakeCmdRunner()
+		metadataService = NewGuestInfoMetadataService(
+			resolver,
+			runner,
+			"vmware-rpctool",
+			"guestinfo.metadata",
+			"guestinfo.userdata",
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+
+		guestInfo = map[string]fakesys.FakeCmdResult{
+			"guestinfo.metadata": {Stdout: `{"instance-id": "fake-instance-id", "public-keys": "ssh-rsa fake-public-key"}` + "\n"},
+			"guestinfo.userdata": {Stdout: `{"registry": {"endpoint": "http://fake-registry.internal:25777"}, "dns": {"nameserver": ["8.8.8.8"]}}` + "\n"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		for key, result := range guestInfo {
+			runner.AddCmdResult("vmware-rpctool info-get "+key, result)
+		}
+	})
+
+	It("reads meta-data and user-data through vmware-rpctool", func() {
+		resolver.RegisterRecord(fakeinf.FakeDNSRecord{
+			DNSServers: []string{"8.8.8.8"},
+			Host:       "http://fake-registry.internal:25777",
+			IP:         "http://10.0.0.5:25777",
+		})
+
+		Expect(metadataService.IsAvailable()).To(BeTrue())
+
+		Expect(runner.RunCommands).To(ContainElement([]string{"vmware-rpctool", "info-get guestinfo.metadata"}))
+		Expect(runner.RunCommands).To(ContainElement([]string{"vmware-rpctool", "info-get guestinfo.userdata"}))
+
+		instanceID, err := metadataService.GetInstanceID()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceID).To(Equal("fake-instance-id"))
+
+		publicKey, err := metadataService.GetPublicKey()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(publicKey).To(Equal("ssh-rsa fake-public-key"))
+
+		endpoint, err := metadataService.GetRegistryEndpoint()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(endpoint).To(Equal("http://10.0.0.5:25777"))
+	})
+
+	Context("when value is base64 encoded", func() {
+		BeforeEach(func() {
+			guestInfo["guestinfo.metadata"] = fakesys.FakeCmdResult{
+				Stdout: base64.StdEncoding.EncodeToString([]byte("instance-id: fake-b64-instance-id\n")),
+			}
+			guestInfo["guestinfo.metadata.encoding"] = fakesys.FakeCmdResult{Stdout: "base64\n"}
+		})
+
+		It("decodes value", func() {
+			Expect(metadataService.IsAvailable()).To(BeTrue())
+
+			instanceID, err := metadataService.GetInstanceID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceID).To(Equal("fake-b64-instance-id"))
+		})
+	})
+
+	Context("when value is gzipped and base64 encoded", func() {
+		BeforeEach(func() {
+			var compressed bytes.Buffer
+
+			writer := gzip.NewWriter(&compressed)
+			_, err := writer.Write([]byte(`{"instance-id": "fake-gz-instance-id"}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+
+			guestInfo["guestinfo.metadata"] = fakesys.FakeCmdResult{
+				Stdout: base64.StdEncoding.EncodeToString(compressed.Bytes()),
+			}
+			guestInfo["guestinfo.metadata.encoding"] = fakesys.FakeCmdResult{Stdout: "gzip+base64\n"}
+		})
+
+		It("decodes value", func() {
+			Expect(metadataService.IsAvailable()).To(BeTrue())
+
+			instanceID, err := metadataService.GetInstanceID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceID).To(Equal("fake-gz-instance-id"))
+		})
+	})
+
+	Context("when value uses unknown encoding", func() {
+		BeforeEach(func() {
+			guestInfo["guestinfo.metadata.encoding"] = fakesys.FakeCmdResult{Stdout: "fake-encoding\n"}
+		})
+
+		It("is not available", func() {
+			Expect(metadataService.IsAvailable()).To(BeFalse())
+		})
+	})
+
+	Context("when guestinfo variable is not set", func() {
+		BeforeEach(func() {
+			guestInfo["guestinfo.userdata"] = fakesys.FakeCmdResult{
+				Stderr:     "No value found",
+				ExitStatus: 1,
+				Error:      errors.New("fake-rpctool-err"),
+			}
+		})
+
+		It("is not available", func() {
+			Expect(metadataService.IsAvailable()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// noCloudVolume reads files from cloud-init NoCloud volume (labeled 'cidata')
+type noCloudVolume struct {
+	diskPaths []string
+	platform  boshplatform.Platform
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func (v noCloudVolume) read(fileName string) ([]byte, error) {
+	if len(v.diskPaths) == 0 {
+		return nil, bosherr.Error("Disk paths are not given")
+	}
+
+	var err error
+	var contents [][]byte
+
+	for _, diskPath := range v.diskPaths {
+		contents, err = v.platform.GetFilesContentsFromDisk(diskPath, []string{fileName})
+		if err == nil {
+			v.logger.Debug(v.logTag, "Successfully loaded file '%s' from NoCloud volume: '%s'", fileName, diskPath)
+			return contents[0], nil
+		}
+
+		v.logger.Warn(v.logTag, "Failed to load file '%s' from %s - %s", fileName, diskPath, err.Error())
+	}
+
+	return nil, bosherr.WrapErrorf(err, "Loading file '%s' from NoCloud volume", fileName)
+}
+
+func NewNoCloudMetadataService(
+	resolver DNSResolver,
+	platform boshplatform.Platform,
+	diskPaths []string,
+	metaDataFilePath string,
+	userDataFilePath string,
+	logger boshlog.Logger,
+) MetadataService {
+	logTag := "NoCloudMetadataService"
+
+	return &cloudInitMetadataService{
+		resolver: resolver,
+		reader: noCloudVolume{
+			diskPaths: diskPaths,
+			platform:  platform,
+
+			logTag: logTag,
+			logger: logger,
+		},
+
+		metaDataName: metaDataFilePath,
+		userDataName: userDataFilePath,
+
+		description: "NoCloud",
+
+		logTag: logTag,
+		logger: logger,
+	}
+}
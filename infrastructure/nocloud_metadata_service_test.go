@@ -0,0 +1,150 @@
+package infrastructure_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeinf "github.com/cloudfoundry/bosh-agent/infrastructure/fakes"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("NoCloudMetadataService", func() {
+	var (
+		metadataService MetadataService
+		resolver        *fakeinf.FakeDNSResolver
+		platform        *fakeplatform.FakePlatform
+	)
+
+	BeforeEach(func() {
+		resolver = &fakeinf.FakeDNSResolver{}
+		platform = fakeplatform.NewFakePlatform()
+		metadataService = NewNoCloudMetadataService(
+			resolver,
+			platform,
+			[]string{"/dev/disk/by-label/cidata", "/dev/disk/by-label/CIDATA"},
+			"meta-data",
+			"user-data",
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+
+		platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", []byte(`
+instance-id: fake-instance-id
+local-hostname: fake-hostname
+public-keys:
+  - ssh-rsa fake-public-key-1
+  - "ssh-rsa fake-public-key-2"
+`), nil)
+
+		platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/user-data", []byte(`{
+			"server": {"name": "fake-server-name"},
+			"registry": {"endpoint": "http://fake-registry.internal:25777"},
+			"networks": {"fake-net": {"type": "dynamic", "mac": "fake-mac"}}
+		}`), nil)
+	})
+
+	Describe("IsAvailable", func() {
+		It("returns true when meta-data and user-data can be loaded from NoCloud volume", func() {
+			Expect(metadataService.IsAvailable()).To(BeTrue())
+			Expect(platform.GetFileContentsFromDiskDiskPaths).To(ContainElement("/dev/disk/by-label/cidata"))
+		})
+
+		It("tries other labeled volumes when first one cannot be read", func() {
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", nil, errors.New("fake-mount-err"))
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/CIDATA/meta-data", []byte(`{"instance-id": "fake-upper-instance-id"}`), nil)
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/CIDATA/user-data", []byte(`{}`), nil)
+
+			Expect(metadataService.IsAvailable()).To(BeTrue())
+
+			instanceID, err := metadataService.GetInstanceID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceID).To(Equal("fake-upper-instance-id"))
+		})
+
+		It("returns false when NoCloud volume cannot be read", func() {
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", nil, errors.New("fake-mount-err"))
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/CIDATA/meta-data", nil, errors.New("fake-mount-err"))
+
+			Expect(metadataService.IsAvailable()).To(BeFalse())
+		})
+
+		It("returns false when meta-data cannot be parsed", func() {
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", []byte(`{broken`), nil)
+			Expect(metadataService.IsAvailable()).To(BeFalse())
+		})
+
+		It("returns false when user-data is not JSON", func() {
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/user-data", []byte("#cloud-config\n"), nil)
+			Expect(metadataService.IsAvailable()).To(BeFalse())
+		})
+	})
+
+	Context("when loaded", func() {
+		BeforeEach(func() {
+			Expect(metadataService.IsAvailable()).To(BeTrue())
+		})
+
+		It("returns first public key", func() {
+			publicKey, err := metadataService.GetPublicKey()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-public-key-1"))
+		})
+
+		It("returns instance id", func() {
+			instanceID, err := metadataService.GetInstanceID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceID).To(Equal("fake-instance-id"))
+		})
+
+		It("returns server name", func() {
+			serverName, err := metadataService.GetServerName()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serverName).To(Equal("fake-server-name"))
+		})
+
+		It("returns registry endpoint", func() {
+			endpoint, err := metadataService.GetRegistryEndpoint()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoint).To(Equal("http://fake-registry.internal:25777"))
+		})
+
+		It("returns networks", func() {
+			networks, err := metadataService.GetNetworks()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(networks).To(Equal(boshsettings.Networks{
+				"fake-net": boshsettings.Network{Type: "dynamic", Mac: "fake-mac"},
+			}))
+		})
+	})
+
+	Context("when meta-data and user-data are missing values", func() {
+		BeforeEach(func() {
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/meta-data", []byte("local-hostname: fake-hostname\n"), nil)
+			platform.SetGetFilesContentsFromDisk("/dev/disk/by-label/cidata/user-data", []byte(`{}`), nil)
+
+			Expect(metadataService.IsAvailable()).To(BeTrue())
+		})
+
+		It("returns errors", func() {
+			_, err := metadataService.GetPublicKey()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to load public-keys from NoCloud metadata service"))
+
+			_, err = metadataService.GetInstanceID()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to load instance-id from NoCloud metadata service"))
+
+			_, err = metadataService.GetServerName()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to load server name from NoCloud metadata service"))
+
+			_, err = metadataService.GetRegistryEndpoint()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to load registry endpoint from NoCloud metadata service"))
+		})
+	})
+})
@@ -2,6 +2,8 @@ package infrastructure
 
 import (
 	"encoding/json"
+	"path"
+	"strings"
 
 	mapstruc "github.com/mitchellh/mapstructure"
 
@@ -72,6 +74,87 @@ type InstanceMetadataSourceOptions struct {
 
 func (o InstanceMetadataSourceOptions) sourceOptionsInterface() {}
 
+// NoCloudSourceOptions reads cloud-init NoCloud volume found by its filesystem label
+type NoCloudSourceOptions struct {
+	// Defaults to 'cidata'; upper case variant is also tried
+	Label string
+
+	// Default to 'meta-data' and 'user-data'
+	MetaDataPath string
+	UserDataPath string
+
+	// Defaults to UserDataPath
+	SettingsPath string
+}
+
+func (o NoCloudSourceOptions) sourceOptionsInterface() {}
+
+func (o NoCloudSourceOptions) diskPaths() []string {
+	label := o.Label
+	if label == "" {
+		label = "cidata"
+	}
+
+	diskPaths := []string{path.Join("/dev/disk/by-label", label)}
+
+	if upperLabel := strings.ToUpper(label); upperLabel != label {
+		diskPaths = append(diskPaths, path.Join("/dev/disk/by-label", upperLabel))
+	}
+
+	return diskPaths
+}
+
+func (o NoCloudSourceOptions) withDefaults() NoCloudSourceOptions {
+	if o.MetaDataPath == "" {
+		o.MetaDataPath = "meta-data"
+	}
+
+	if o.UserDataPath == "" {
+		o.UserDataPath = "user-data"
+	}
+
+	if o.SettingsPath == "" {
+		o.SettingsPath = o.UserDataPath
+	}
+
+	return o
+}
+
+// GuestInfoSourceOptions reads VMware guestinfo variables through vmware-rpctool
+type GuestInfoSourceOptions struct {
+	// Defaults to 'vmware-rpctool'
+	RPCToolPath string
+
+	// Default to 'guestinfo.metadata' and 'guestinfo.userdata'
+	MetaDataKey string
+	UserDataKey string
+
+	// Defaults to UserDataKey
+	SettingsKey string
+}
+
+func (o GuestInfoSourceOptions) sourceOptionsInterface() {}
+
+func (o GuestInfoSourceOptions) withDefaults() GuestInfoSourceOptions {
+	if o.RPCToolPath == "" {
+		o.RPCToolPath = "vmware-rpctool"
+	}
+
+	if o.MetaDataKey == "" {
+		o.MetaDataKey = "guestinfo.metadata"
+	}
+
+	if o.UserDataKey == "" {
+		o.UserDataKey = "guestinfo.userdata"
+	}
+
+	if o.SettingsKey == "" {
+		o.SettingsKey = o.UserDataKey
+	}
+
+	return o
+}
+
 type SettingsSourceFactory struct {
 	options  SettingsOptions
 	platform boshplat.Platform
@@ -139,6 +222,28 @@ func (f SettingsSourceFactory) buildWithRegistry() (boshsettings.Source, error)
 				f.logger,
 			)
 
+		case NoCloudSourceOptions:
+			typedOpts = typedOpts.withDefaults()
+			metadataService = NewNoCloudMetadataService(
+				resolver,
+				f.platform,
+				typedOpts.diskPaths(),
+				typedOpts.MetaDataPath,
+				typedOpts.UserDataPath,
+				f.logger,
+			)
+
+		case GuestInfoSourceOptions:
+			typedOpts = typedOpts.withDefaults()
+			metadataService = NewGuestInfoMetadataService(
+				resolver,
+				f.platform.GetRunner(),
+				typedOpts.RPCToolPath,
+				typedOpts.MetaDataKey,
+				typedOpts.UserDataKey,
+				f.logger,
+			)
+
 		case CDROMSourceOptions:
 			return nil, bosherr.Error("CDROM source is not supported when registry is used")
 
@@ -181,6 +286,26 @@ func (f SettingsSourceFactory) buildWithoutRegistry() (boshsettings.Source, erro
 				f.logger,
 			)
 
+		case NoCloudSourceOptions:
+			typedOpts = typedOpts.withDefaults()
+			settingsSource = NewNoCloudSettingsSource(
+				typedOpts.diskPaths(),
+				typedOpts.MetaDataPath,
+				typedOpts.SettingsPath,
+				f.platform,
+				f.logger,
+			)
+
+		case GuestInfoSourceOptions:
+			typedOpts = typedOpts.withDefaults()
+			settingsSource = NewGuestInfoSettingsSource(
+				typedOpts.RPCToolPath,
+				typedOpts.MetaDataKey,
+				typedOpts.SettingsKey,
+				f.platform.GetRunner(),
+				f.logger,
+			)
+
 		case CDROMSourceOptions:
 			settingsSource = NewCDROMSettingsSource(
 				typedOpts.FileName,
@@ -238,6 +363,14 @@ func (s *SourceOptionsSlice) UnmarshalJSON(data []byte) error {
 				var o CDROMSourceOptions
 				err, opts = mapstruc.Decode(m, &o), o
 
+			case optType == "NoCloud":
+				var o NoCloudSourceOptions
+				err, opts = mapstruc.Decode(m, &o), o
+
+			case optType == "GuestInfo":
+				var o GuestInfoSourceOptions
+				err, opts = mapstruc.Decode(m, &o), o
+
 			default:
 				err = bosherr.Errorf("Unknown source type '%s'", optType)
 			}
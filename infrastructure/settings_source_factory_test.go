@@ -117,6 +117,63 @@ var _ = Describe("SettingsSourceFactory", func() {
 					})
 				})
 
+				Context("when using NoCloud source", func() {
+					BeforeEach(func() {
+						options.Sources = []SourceOptions{
+							NoCloudSourceOptions{},
+						}
+					})
+
+					It("returns a settings source that uses labeled NoCloud volume to fetch settings", func() {
+						resolver := NewRegistryEndpointResolver(NewDigDNSResolver(platform.GetRunner(), logger))
+						noCloudMetadataService := NewNoCloudMetadataService(
+							resolver,
+							platform,
+							[]string{"/dev/disk/by-label/cidata", "/dev/disk/by-label/CIDATA"},
+							"meta-data",
+							"user-data",
+							logger,
+						)
+						multiSourceMetadataService := NewMultiSourceMetadataService(noCloudMetadataService)
+						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), logger)
+						noCloudSettingsSource := NewComplexSettingsSource(multiSourceMetadataService, registryProvider, logger)
+
+						settingsSource, err := factory.New()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(settingsSource).To(Equal(noCloudSettingsSource))
+					})
+				})
+
+				Context("when using GuestInfo source", func() {
+					BeforeEach(func() {
+						options.Sources = []SourceOptions{
+							GuestInfoSourceOptions{
+								RPCToolPath: "/fake-rpctool",
+								MetaDataKey: "guestinfo.fake-metadata",
+							},
+						}
+					})
+
+					It("returns a settings source that uses vmware-rpctool to fetch settings", func() {
+						resolver := NewRegistryEndpointResolver(NewDigDNSResolver(platform.GetRunner(), logger))
+						guestInfoMetadataService := NewGuestInfoMetadataService(
+							resolver,
+							platform.GetRunner(),
+							"/fake-rpctool",
+							"guestinfo.fake-metadata",
+							"guestinfo.userdata",
+							logger,
+						)
+						multiSourceMetadataService := NewMultiSourceMetadataService(guestInfoMetadataService)
+						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), logger)
+						guestInfoSettingsSource := NewComplexSettingsSource(multiSourceMetadataService, registryProvider, logger)
+
+						settingsSource, err := factory.New()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(settingsSource).To(Equal(guestInfoSettingsSource))
+					})
+				})
+
 				Context("when using CDROM source", func() {
 					BeforeEach(func() {
 						options.Sources = []SourceOptions{
@@ -249,6 +306,59 @@ var _ = Describe("SettingsSourceFactory", func() {
 					Expect(settingsSource).To(Equal(multiSettingsSource))
 				})
 			})
+
+			Context("when using NoCloud source", func() {
+				BeforeEach(func() {
+					options.Sources = []SourceOptions{
+						NoCloudSourceOptions{
+							Label:        "fake-label",
+							SettingsPath: "fake-settings-path",
+						},
+					}
+				})
+
+				It("returns a settings source that uses labeled NoCloud volume to fetch settings", func() {
+					noCloudSettingsSource := NewNoCloudSettingsSource(
+						[]string{"/dev/disk/by-label/fake-label", "/dev/disk/by-label/FAKE-LABEL"},
+						"meta-data",
+						"fake-settings-path",
+						platform,
+						logger,
+					)
+
+					multiSettingsSource, err := NewMultiSettingsSource(noCloudSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := factory.New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
+
+			Context("when using GuestInfo source", func() {
+				BeforeEach(func() {
+					options.Sources = []SourceOptions{
+						GuestInfoSourceOptions{},
+					}
+				})
+
+				It("returns a settings source that uses vmware-rpctool to fetch settings", func() {
+					guestInfoSettingsSource := NewGuestInfoSettingsSource(
+						"vmware-rpctool",
+						"guestinfo.metadata",
+						"guestinfo.userdata",
+						platform.GetRunner(),
+						logger,
+					)
+
+					multiSettingsSource, err := NewMultiSettingsSource(guestInfoSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := factory.New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
 		})
 	})
 })
@@ -24,9 +24,9 @@ func (s *dummyJobSupervisor) Stop() error {
 	return nil
 }
 
-func (s *dummyJobSupervisor) StopAndWait() error {
+func (s *dummyJobSupervisor) StopAndWait(options StopOptions) (StopResult, error) {
 	s.status = "stopped"
-	return nil
+	return NewStopResult(), nil
 }
 
 func (s *dummyJobSupervisor) Unmonitor() error {
@@ -45,6 +45,10 @@ func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath str
 	return nil
 }
 
+func (s *dummyJobSupervisor) AddProcessManifest(jobName string, jobIndex int, manifestPath string) error {
+	return nil
+}
+
 func (s *dummyJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
@@ -74,6 +74,10 @@ func (d *dummyNatsJobSupervisor) AddJob(jobName string, jobIndex int, configPath
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) AddProcessManifest(jobName string, jobIndex int, manifestPath string) error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) Start() error {
 	if d.status == "fail_task" {
 		return bosherror.Error("fake-task-fail-error")
@@ -91,8 +95,8 @@ func (d *dummyNatsJobSupervisor) Stop() error {
 	return nil
 }
 
-func (d *dummyNatsJobSupervisor) StopAndWait() error {
-	return d.Stop()
+func (d *dummyNatsJobSupervisor) StopAndWait(options StopOptions) (StopResult, error) {
+	return NewStopResult(), d.Stop()
 }
 
 func (d *dummyNatsJobSupervisor) Unmonitor() error {
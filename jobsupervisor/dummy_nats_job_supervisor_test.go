@@ -111,7 +111,7 @@ var _ = Describe("dummyNatsJobSupervisor", func() {
 
 	Describe("StopAndWait", func() {
 		It("changes status to 'stopped'", func() {
-			err := dummyNats.StopAndWait()
+			_, err := dummyNats.StopAndWait(StopOptions{})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dummyNats.Status()).To(Equal("stopped"))
 		})
@@ -125,7 +125,7 @@ var _ = Describe("dummyNatsJobSupervisor", func() {
 				It("does not change status", func() {
 					statusMessage := boshhandler.NewRequest("", "set_task_fail", []byte(`{"status":"fail_task"}`), 0)
 					handler.RegisteredAdditionalFunc(statusMessage)
-					err := dummyNats.StopAndWait()
+					_, err := dummyNats.StopAndWait(StopOptions{})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(dummyNats.Status()).To(Equal("fail_task"))
 				})
@@ -135,7 +135,7 @@ var _ = Describe("dummyNatsJobSupervisor", func() {
 				It("does not change status", func() {
 					statusMessage := boshhandler.NewRequest("", "set_dummy_status", []byte(`{"status":"failing"}`), 0)
 					handler.RegisteredAdditionalFunc(statusMessage)
-					err := dummyNats.StopAndWait()
+					_, err := dummyNats.StopAndWait(StopOptions{})
 					Expect(err).ToNot(HaveOccurred())
 					Expect(dummyNats.Status()).To(Equal("failing"))
 				})
@@ -11,6 +11,9 @@ type FakeJobSupervisor struct {
 
 	AddJobArgs []AddJobArgs
 
+	AddProcessManifestArgs []AddJobArgs
+	AddProcessManifestErr  error
+
 	RemovedAllJobs    bool
 	RemovedAllJobsErr error
 
@@ -21,6 +24,9 @@ type FakeJobSupervisor struct {
 	StopErr          error
 	StoppedAndWaited bool
 
+	StopAndWaitOptions boshjobsuper.StopOptions
+	StopAndWaitResult  boshjobsuper.StopResult
+
 	Unmonitored  bool
 	UnmonitorErr error
 
@@ -56,6 +62,16 @@ func (m *FakeJobSupervisor) AddJob(jobName string, jobIndex int, configPath stri
 	return nil
 }
 
+func (m *FakeJobSupervisor) AddProcessManifest(jobName string, jobIndex int, manifestPath string) error {
+	args := AddJobArgs{
+		Name:       jobName,
+		Index:      jobIndex,
+		ConfigPath: manifestPath,
+	}
+	m.AddProcessManifestArgs = append(m.AddProcessManifestArgs, args)
+	return m.AddProcessManifestErr
+}
+
 func (m *FakeJobSupervisor) RemoveAllJobs() error {
 	m.RemovedAllJobs = true
 	return m.RemovedAllJobsErr
@@ -71,10 +87,11 @@ func (m *FakeJobSupervisor) Stop() error {
 	return m.StopErr
 }
 
-func (m *FakeJobSupervisor) StopAndWait() error {
+func (m *FakeJobSupervisor) StopAndWait(options boshjobsuper.StopOptions) (boshjobsuper.StopResult, error) {
 	m.Stopped = true
 	m.StoppedAndWaited = true
-	return m.StopErr
+	m.StopAndWaitOptions = options
+	return m.StopAndWaitResult, m.StopErr
 }
 
 func (m *FakeJobSupervisor) Unmonitor() error {
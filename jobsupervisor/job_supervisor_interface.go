@@ -33,7 +33,9 @@ type JobSupervisor interface {
 	// Actions taken on all services
 	Start() error
 	Stop() error
-	StopAndWait() error
+	// StopAndWait stops services job by job and kills
+	// services that do not stop within job's timeout.
+	StopAndWait(options StopOptions) (StopResult, error)
 
 	// Start and Stop should still function after Unmonitor.
 	// Calling Start after Unmonitor should re-monitor all jobs.
@@ -45,6 +47,8 @@ type JobSupervisor interface {
 	Processes() ([]Process, error)
 	// Job management
 	AddJob(jobName string, jobIndex int, configPath string) error
+	// Adds processes declared in a process manifest (see ProcessManifest)
+	AddProcessManifest(jobName string, jobIndex int, manifestPath string) error
 	RemoveAllJobs() error
 
 	MonitorJobFailures(handler JobFailureHandler) error
@@ -27,6 +27,7 @@ type serviceTag struct {
 	Status        int       `xml:"status"`
 	StatusMessage string    `xml:"status_message"`
 	Monitor       int       `xml:"monitor"`
+	PID           int       `xml:"pid"`
 	Uptime        int       `xml:"uptime"`
 	Children      int       `xml:"children"`
 	Memory        memoryTag `xml:"memory"`
@@ -106,6 +107,7 @@ func (status status) ServicesInGroup(name string) (services []Service) {
 				Name:                 serviceTag.Name,
 				Pending:              serviceTag.Pending > 0,
 				Status:               serviceTag.StatusString(),
+				PID:                  serviceTag.PID,
 				Errored:              serviceTag.Status > 0 && serviceTag.StatusMessage != "",
 				StatusMessage:        serviceTag.StatusMessage,
 				Monitored:            serviceTag.Monitor > 0,
@@ -18,6 +18,7 @@ type Service struct {
 	Errored              bool
 	Pending              bool
 	Status               string
+	PID                  int
 	StatusMessage        string
 	Uptime               int
 	MemoryPercentTotal   float64
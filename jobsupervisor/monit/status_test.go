@@ -97,6 +97,7 @@ var _ = Describe("status", func() {
 					Errored:              false,
 					Pending:              false,
 					Status:               "running",
+					PID:                  1,
 					StatusMessage:        "",
 					Uptime:               880183,
 					MemoryPercentTotal:   0,
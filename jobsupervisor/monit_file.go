@@ -0,0 +1,174 @@
+package jobsupervisor
+
+import (
+	"bufio"
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// jobProcess describes a single process that has to be kept running
+// by a job supervisor that does not understand monit configuration natively.
+type jobProcess struct {
+	Name         string
+	StartCommand string
+	StopCommand  string
+	PidFile      string
+
+	// Set when process is declared in a process manifest instead of monit config
+	JobName  string
+	Manifest *ManifestProcess
+}
+
+// storedProcessManifest is saved by supervisors that consume process manifest directly
+type storedProcessManifest struct {
+	JobName   string            `json:"job_name"`
+	Processes []ManifestProcess `json:"processes"`
+}
+
+var (
+	monitCheckRegexp   = regexp.MustCompile(`^check\s+(\S+)\s+(\S+)`)
+	monitPidFileRegexp = regexp.MustCompile(`^(?:with\s+)?pidfile\s+"?([^"\s]+)"?`)
+	monitProgramRegexp = regexp.MustCompile(`^(start|stop)\s+program\s*=?\s*"([^"]*)"`)
+)
+
+// parseMonitProcesses extracts `check process` entries from monit configuration.
+// Other kinds of checks (file, host, etc.) are ignored.
+func parseMonitProcesses(config string) ([]jobProcess, error) {
+	processes := []jobProcess{}
+
+	var current *jobProcess
+
+	finishCurrent := func() error {
+		if current == nil {
+			return nil
+		}
+
+		if current.StartCommand == "" {
+			return bosherr.Errorf("Process '%s' is missing start program", current.Name)
+		}
+
+		processes = append(processes, *current)
+		current = nil
+
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(config))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if matches := monitCheckRegexp.FindStringSubmatch(line); matches != nil {
+			err := finishCurrent()
+			if err != nil {
+				return nil, err
+			}
+
+			if matches[1] == "process" {
+				current = &jobProcess{Name: matches[2]}
+			}
+
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if matches := monitPidFileRegexp.FindStringSubmatch(line); matches != nil {
+			current.PidFile = matches[1]
+			continue
+		}
+
+		if matches := monitProgramRegexp.FindStringSubmatch(line); matches != nil {
+			if matches[1] == "start" {
+				current.StartCommand = matches[2]
+			} else {
+				current.StopCommand = matches[2]
+			}
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading monit config")
+	}
+
+	err = finishCurrent()
+	if err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// readJobProcesses returns processes from all monit files and stored process manifests
+// in the jobs dir. Files are named <index>_<job>.<ext> so that they sort in job order.
+func readJobProcesses(fs boshsys.FileSystem, jobsDir string) ([]jobProcess, error) {
+	configPaths := []string{}
+
+	for _, pattern := range []string{"*.monitrc", "*.processes.json"} {
+		paths, err := fs.Glob(path.Join(jobsDir, pattern))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Listing job configs")
+		}
+
+		configPaths = append(configPaths, paths...)
+	}
+
+	sort.Strings(configPaths)
+
+	processes := []jobProcess{}
+
+	for _, configPath := range configPaths {
+		config, err := fs.ReadFileString(configPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading job config %s", configPath)
+		}
+
+		if strings.HasSuffix(configPath, ".processes.json") {
+			var manifest storedProcessManifest
+
+			err = json.Unmarshal([]byte(config), &manifest)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Unmarshalling process manifest %s", path.Base(configPath))
+			}
+
+			for i := range manifest.Processes {
+				processes = append(processes, jobProcess{
+					Name:     manifest.Processes[i].Name,
+					JobName:  manifest.JobName,
+					Manifest: &manifest.Processes[i],
+				})
+			}
+
+			continue
+		}
+
+		configProcesses, err := parseMonitProcesses(config)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing job config %s", path.Base(configPath))
+		}
+
+		jobName := strings.TrimSuffix(path.Base(configPath), ".monitrc")
+		if i := strings.Index(jobName, "_"); i >= 0 {
+			jobName = jobName[i+1:]
+		}
+
+		for _, process := range configProcesses {
+			process.JobName = jobName
+			processes = append(processes, process)
+		}
+	}
+
+	return processes, nil
+}
@@ -3,6 +3,7 @@ package jobsupervisor
 import (
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -154,13 +155,16 @@ func (m monitJobSupervisor) Stop() error {
 	return nil
 }
 
-func (m monitJobSupervisor) StopAndWait() error {
-	timer := m.timeService.NewTimer(5 * time.Minute)
+func (m monitJobSupervisor) StopAndWait(options StopOptions) (StopResult, error) {
+	result := NewStopResult()
+
+	timeout := options.defaultTimeout()
+	timer := m.timeService.NewTimer(timeout)
 
 	for {
 		services, err := m.checkServices()
 		if err != nil {
-			return err
+			return result, err
 		}
 
 		pendingServices := m.filterServices(services, func(service boshmonit.Service) bool {
@@ -173,14 +177,78 @@ func (m monitJobSupervisor) StopAndWait() error {
 
 		select {
 		case <-timer.C():
-			return bosherr.Errorf("Timed out waiting for services '%s' to no longer be pending after 5 minutes", strings.Join(pendingServices, ", "))
+			return result, bosherr.Errorf("Timed out waiting for services '%s' to no longer be pending after %s", strings.Join(pendingServices, ", "), timeout)
 		default:
 		}
 
 		m.timeService.Sleep(500 * time.Millisecond)
 	}
 
-	_, _, _, err := m.runner.RunCommand("monit", "stop", "-g", "vcap")
+	timer.Stop()
+
+	processes, err := readJobProcesses(m.fs, m.dirProvider.MonitJobsDir())
+	if err != nil {
+		// Services are still stopped, just not in the requested order
+		m.logger.Error(monitJobSupervisorLogTag, "Failed to read job processes, stopping all services at once: %s", err.Error())
+		processes = []jobProcess{}
+	}
+
+	groups := options.stopGroups(processes)
+
+	for i, group := range groups {
+		// Last group includes all services that are not explicitly ordered
+		lastGroup := i == len(groups)-1
+
+		err := m.stopGroup(group, lastGroup, &result)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = m.fs.WriteFileString(m.stoppedFilePath(), "")
+	if err != nil {
+		return result, bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	m.logger.Debug(monitJobSupervisorLogTag, "Successfully stopped all services")
+
+	return result, nil
+}
+
+func (m monitJobSupervisor) stopGroup(group stopGroup, lastGroup bool, result *StopResult) error {
+	inGroup := func(service boshmonit.Service) bool {
+		if lastGroup {
+			// Skip services that were handled as part of previous groups
+			for _, name := range append(result.Stopped, result.Killed...) {
+				if name == service.Name {
+					return false
+				}
+			}
+			return true
+		}
+		for _, name := range group.Processes {
+			if name == service.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var err error
+
+	if lastGroup {
+		_, _, _, err = m.runner.RunCommand("monit", "stop", "-g", "vcap")
+	} else {
+		m.logger.Debug(monitJobSupervisorLogTag, "Stopping services of job %s", group.JobName)
+
+		for _, name := range group.Processes {
+			_, _, _, err = m.runner.RunCommand("monit", "stop", name)
+			if err != nil {
+				break
+			}
+		}
+	}
+
 	if err != nil {
 		stdout, stderr, _, summaryError := m.runner.RunCommand("monit", "summary")
 		if summaryError != nil {
@@ -192,16 +260,24 @@ func (m monitJobSupervisor) StopAndWait() error {
 		return bosherr.WrapErrorf(err, "Stop all services")
 	}
 
-	err = m.fs.WriteFileString(m.stoppedFilePath(), "")
-	if err != nil {
-		return bosherr.WrapError(err, "Creating stopped File")
-	}
+	timer := m.timeService.NewTimer(group.Timeout)
+	defer timer.Stop()
+
 	m.logger.Debug(monitJobSupervisorLogTag, "Waiting for services to stop")
+
 	for {
-		services, err := m.checkServices()
+		allServices, err := m.checkServices()
 		if err != nil {
 			return err
 		}
+
+		services := []boshmonit.Service{}
+		for _, service := range allServices {
+			if inGroup(service) {
+				services = append(services, service)
+			}
+		}
+
 		erroredServices := m.filterServices(services, func(service boshmonit.Service) bool {
 			return service.Errored
 		})
@@ -214,13 +290,29 @@ func (m monitJobSupervisor) StopAndWait() error {
 		}
 
 		if len(servicesToStop) == 0 {
-			m.logger.Debug(monitJobSupervisorLogTag, "Successfully stopped all services")
+			result.Stopped = append(result.Stopped, m.filterServices(services, func(boshmonit.Service) bool { return true })...)
 			return nil
 		}
 
 		select {
 		case <-timer.C():
-			return bosherr.Errorf("Timed out waiting for services '%s' to stop after 5 minutes", strings.Join(servicesToStop, ", "))
+			m.logger.Error(monitJobSupervisorLogTag, "Services '%s' did not stop after %s, killing them", strings.Join(servicesToStop, ", "), group.Timeout)
+
+			for _, service := range services {
+				if !service.Monitored && !service.Pending {
+					result.Stopped = append(result.Stopped, service.Name)
+					continue
+				}
+
+				err := m.killService(service)
+				if err != nil {
+					return err
+				}
+
+				result.Killed = append(result.Killed, service.Name)
+			}
+
+			return nil
 		default:
 		}
 
@@ -229,6 +321,24 @@ func (m monitJobSupervisor) StopAndWait() error {
 	}
 }
 
+// killService sends SIGKILL to service process (if it is still running)
+// and makes sure that monit does not try to start it again.
+func (m monitJobSupervisor) killService(service boshmonit.Service) error {
+	if service.PID > 0 {
+		_, _, _, err := m.runner.RunCommand("kill", "-KILL", strconv.Itoa(service.PID))
+		if err != nil {
+			m.logger.Error(monitJobSupervisorLogTag, "Failed to kill service %s: %s", service.Name, err.Error())
+		}
+	}
+
+	_, _, _, err := m.runner.RunCommand("monit", "unmonitor", service.Name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmonitoring service %s", service.Name)
+	}
+
+	return nil
+}
+
 func (m monitJobSupervisor) Unmonitor() error {
 	services, err := m.client.ServicesInGroup("vcap")
 	if err != nil {
@@ -329,6 +439,34 @@ func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath stri
 	return nil
 }
 
+func (m monitJobSupervisor) AddProcessManifest(jobName string, jobIndex int, manifestPath string) error {
+	manifest, err := LoadProcessManifest(m.fs, manifestPath)
+	if err != nil {
+		return err
+	}
+
+	filePrefix := fmt.Sprintf("%04d_%s", jobIndex, jobName)
+
+	config, ctlScripts, err := renderMonitProcessManifest(m.dirProvider, jobName, filePrefix, manifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Rendering monit configuration for job %s", jobName)
+	}
+
+	for ctlPath, ctlScript := range ctlScripts {
+		err = m.fs.WriteFileString(ctlPath, ctlScript)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing process control script")
+		}
+	}
+
+	err = m.fs.WriteFileString(path.Join(m.dirProvider.MonitJobsDir(), filePrefix+".monitrc"), config)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing to job config file")
+	}
+
+	return nil
+}
+
 func (m monitJobSupervisor) RemoveAllJobs() error {
 	return m.fs.RemoveAll(m.dirProvider.MonitJobsDir())
 }
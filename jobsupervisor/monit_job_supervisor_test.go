@@ -201,7 +201,7 @@ var _ = Describe("monitJobSupervisor", func() {
 
 	Describe("StopAndWait", func() {
 		It("stop stops each monit service in group vcap", func() {
-			err := monit.StopAndWait()
+			_, err := monit.StopAndWait(StopOptions{})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(len(runner.RunCommands)).To(Equal(1))
 			Expect(runner.RunCommands[0]).To(Equal([]string{"monit", "stop", "-g", "vcap"}))
@@ -248,7 +248,7 @@ var _ = Describe("monitJobSupervisor", func() {
 				timeService,
 			)
 
-			err := monit.StopAndWait()
+			_, err := monit.StopAndWait(StopOptions{})
 			Expect(err).To(BeNil())
 		})
 
@@ -263,7 +263,8 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				errchan := make(chan error)
 				go func() {
-					errchan <- monit.StopAndWait()
+					_, err := monit.StopAndWait(StopOptions{})
+					errchan <- err
 				}()
 
 				Eventually(timeService.WatcherCount).Should(Equal(2)) // we hit the sleep
@@ -306,10 +307,11 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				errchan := make(chan error)
 				go func() {
-					errchan <- monit.StopAndWait()
+					_, err := monit.StopAndWait(StopOptions{})
+					errchan <- err
 				}()
 
-				failureMessage := "Timed out waiting for services 'foo' to no longer be pending after 5 minutes"
+				failureMessage := "Timed out waiting for services 'foo' to no longer be pending after 5m0s"
 
 				advanceTime(timeService, 10*time.Minute, 2)
 				Eventually(timeService.WatcherCount).Should(Equal(0))
@@ -317,7 +319,7 @@ var _ = Describe("monitJobSupervisor", func() {
 				Expect(len(runner.RunCommands)).To(Equal(0)) // never called 'monit stop'
 			})
 
-			It("uses the same timer for waiting for pending and waiting for services to stop", func() {
+			It("gives services full stop timeout after waiting for pending services", func() {
 				client.StatusStatus = fakemonit.FakeMonitStatus{
 					Services: []boshmonit.Service{
 						{Monitored: false, Name: "foo", Status: "unknown", Pending: true},
@@ -326,7 +328,8 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				errchan := make(chan error)
 				go func() {
-					errchan <- monit.StopAndWait()
+					_, err := monit.StopAndWait(StopOptions{})
+					errchan <- err
 				}()
 
 				Eventually(timeService.WatcherCount).Should(Equal(2)) // we hit the pending sleep
@@ -342,7 +345,12 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				timeService.Increment(3 * time.Minute)
 
-				Eventually(errchan).Should(Receive(Equal(errors.New("Timed out waiting for services 'foo' to stop after 5 minutes"))))
+				Eventually(timeService.WatcherCount).Should(Equal(2)) // still waiting for foo to stop
+				Consistently(errchan).ShouldNot(Receive())
+
+				advanceTime(timeService, 3*time.Minute, 2)
+				Eventually(errchan).Should(Receive(BeNil()))
+				Expect(runner.RunCommands).To(ContainElement([]string{"monit", "unmonitor", "foo"}))
 			})
 		})
 
@@ -356,7 +364,8 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				errchan := make(chan error)
 				go func() {
-					errchan <- monit.StopAndWait()
+					_, err := monit.StopAndWait(StopOptions{})
+					errchan <- err
 				}()
 
 				Eventually(timeService.WatcherCount).Should(Equal(2)) // we hit the sleep
@@ -380,7 +389,8 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				errchan := make(chan error)
 				go func() {
-					errchan <- monit.StopAndWait()
+					_, err := monit.StopAndWait(StopOptions{})
+					errchan <- err
 				}()
 
 				Eventually(timeService.WatcherCount).Should(Equal(2)) // we hit the sleep
@@ -404,7 +414,7 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				runner.AddCmdResult("monit stop -g vcap", fakeErrorResult)
 
-				err := monit.StopAndWait()
+				_, err := monit.StopAndWait(StopOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal(fmt.Sprintf("%s%s", "Stop all services: ", fakeErrorResult.Error)))
 			})
@@ -452,14 +462,14 @@ var _ = Describe("monitJobSupervisor", func() {
 					timeService,
 				)
 
-				err := monit.StopAndWait()
+				_, err := monit.StopAndWait(StopOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(Equal("Stopping services '[test-service]' errored"))
 			})
 		})
 
 		Context("when a service takes too long to stop", func() {
-			It("exits with an error after a timeout", func() {
+			It("kills services that did not stop after a timeout", func() {
 				statusRequests := 0
 				handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					requestData := make(map[string]string)
@@ -501,22 +511,152 @@ var _ = Describe("monitJobSupervisor", func() {
 					timeService,
 				)
 
-				errchan := make(chan error)
+				resultchan := make(chan StopResult)
 				go func() {
-					errchan <- monit.StopAndWait()
+					defer GinkgoRecover()
+					result, err := monit.StopAndWait(StopOptions{})
+					Expect(err).ToNot(HaveOccurred())
+					resultchan <- result
 				}()
 
-				failureMessage := "Timed out waiting for services 'unmonitored-start-pending, initializing, running, running-stop-pending, unmonitored-stop-pending, failing' to stop after 5 minutes"
-
 				advanceTime(timeService, 5*time.Minute, 2)
 				Eventually(timeService.WatcherCount).Should(Equal(0))
-				Eventually(errchan).Should(Receive(Equal(errors.New(failureMessage))))
+
+				var result StopResult
+				Eventually(resultchan).Should(Receive(&result))
+				Expect(result.Killed).To(Equal([]string{
+					"unmonitored-start-pending",
+					"initializing",
+					"running",
+					"running-stop-pending",
+					"unmonitored-stop-pending",
+					"failing",
+				}))
+				Expect(result.Stopped).ToNot(BeEmpty())
 				Expect(statusRequests).To(Equal(3))
+
+				for _, name := range result.Killed {
+					Expect(runner.RunCommands).To(ContainElement([]string{"monit", "unmonitor", name}))
+				}
+			})
+
+			It("sends SIGKILL to process of service", func() {
+				client.StatusStatus = fakemonit.FakeMonitStatus{
+					Services: []boshmonit.Service{
+						{Monitored: true, Name: "foo", Status: "running", PID: 1234},
+						{Monitored: false, Name: "bar", Status: "unknown"},
+					},
+				}
+
+				resultchan := make(chan StopResult)
+				go func() {
+					defer GinkgoRecover()
+					result, err := monit.StopAndWait(StopOptions{DefaultTimeout: time.Minute})
+					Expect(err).ToNot(HaveOccurred())
+					resultchan <- result
+				}()
+
+				advanceTime(timeService, time.Minute, 2)
+
+				var result StopResult
+				Eventually(resultchan).Should(Receive(&result))
+				Expect(result).To(Equal(StopResult{Stopped: []string{"bar"}, Killed: []string{"foo"}}))
+				Expect(runner.RunCommands).To(Equal([][]string{
+					{"monit", "stop", "-g", "vcap"},
+					{"kill", "-KILL", "1234"},
+					{"monit", "unmonitor", "foo"},
+				}))
+			})
+
+			It("returns error when service cannot be unmonitored", func() {
+				client.StatusStatus = fakemonit.FakeMonitStatus{
+					Services: []boshmonit.Service{
+						{Monitored: true, Name: "foo", Status: "running"},
+					},
+				}
+				runner.AddCmdResult("monit unmonitor foo", fakesys.FakeCmdResult{Error: errors.New("fake-unmonitor-error")})
+
+				errchan := make(chan error)
+				go func() {
+					_, err := monit.StopAndWait(StopOptions{})
+					errchan <- err
+				}()
+
+				advanceTime(timeService, 5*time.Minute, 2)
+				Eventually(errchan).Should(Receive(MatchError("Unmonitoring service foo: fake-unmonitor-error")))
+			})
+		})
+
+		Context("when stop options list jobs", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/var/vcap/monit/job/0000_fake-job-1.monitrc", "check process fake-process-1\n  start program \"/fake-start\"\n")
+				fs.WriteFileString("/var/vcap/monit/job/0001_fake-job-2.monitrc", "check process fake-process-2\n  start program \"/fake-start\"\n")
+				fs.WriteFileString("/var/vcap/monit/job/0002_fake-job-3.monitrc", "check process fake-process-3\n  start program \"/fake-start\"\n")
+				fs.SetGlob("/var/vcap/monit/job/*.monitrc", []string{
+					"/var/vcap/monit/job/0000_fake-job-1.monitrc",
+					"/var/vcap/monit/job/0001_fake-job-2.monitrc",
+					"/var/vcap/monit/job/0002_fake-job-3.monitrc",
+				})
+
+				client.StatusStatus = fakemonit.FakeMonitStatus{
+					Services: []boshmonit.Service{
+						{Monitored: false, Name: "fake-process-1", Status: "unknown"},
+						{Monitored: false, Name: "fake-process-2", Status: "unknown"},
+						{Monitored: false, Name: "fake-process-3", Status: "unknown"},
+					},
+				}
+			})
+
+			It("stops services of listed jobs in order before stopping remaining services", func() {
+				result, err := monit.StopAndWait(StopOptions{
+					Jobs: []JobStopOptions{
+						{Name: "fake-job-3"},
+						{Name: "fake-job-1"},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(StopResult{
+					Stopped: []string{"fake-process-3", "fake-process-1", "fake-process-2"},
+					Killed:  []string{},
+				}))
+				Expect(runner.RunCommands).To(Equal([][]string{
+					{"monit", "stop", "fake-process-3"},
+					{"monit", "stop", "fake-process-1"},
+					{"monit", "stop", "-g", "vcap"},
+				}))
+			})
+
+			It("uses timeout of each job", func() {
+				client.StatusStatus = fakemonit.FakeMonitStatus{
+					Services: []boshmonit.Service{
+						{Monitored: true, Name: "fake-process-1", Status: "running"},
+						{Monitored: false, Name: "fake-process-2", Status: "unknown"},
+						{Monitored: false, Name: "fake-process-3", Status: "unknown"},
+					},
+				}
+
+				resultchan := make(chan StopResult)
+				go func() {
+					defer GinkgoRecover()
+					result, err := monit.StopAndWait(StopOptions{
+						Jobs: []JobStopOptions{{Name: "fake-job-1", Timeout: 10 * time.Second}},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					resultchan <- result
+				}()
+
+				Eventually(timeService.WatcherCount).Should(Equal(2))
+				timeService.Increment(11 * time.Second)
+
+				var result StopResult
+				Eventually(resultchan).Should(Receive(&result))
+				Expect(result.Killed).To(Equal([]string{"fake-process-1"}))
+				Expect(result.Stopped).To(Equal([]string{"fake-process-2", "fake-process-3"}))
 			})
 		})
 
 		It("creates stopped file", func() {
-			err := monit.StopAndWait()
+			_, err := monit.StopAndWait(StopOptions{})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeTrue())
 		})
@@ -756,6 +896,96 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("AddProcessManifest", func() {
+		BeforeEach(func() {
+			fs.WriteFileString("/fake-job/config/processes.json", `{
+  "processes": [
+    {
+      "name": "fake-process",
+      "executable": "/var/vcap/packages/fake/bin/fake",
+      "args": ["--name", "it's fake"],
+      "env": {"FAKE_B": "b", "FAKE_A": "a"},
+      "working_dir": "/var/vcap/data/fake",
+      "limits": {"memory_mb": 512, "open_files": 1024},
+      "health_check": {"port": 8080, "path": "/health"}
+    },
+    {
+      "name": "other-process",
+      "executable": "/var/vcap/packages/fake/bin/other",
+      "shutdown_grace_period": 5
+    }
+  ]
+}`)
+		})
+
+		It("translates processes into monit configuration", func() {
+			err := monit.AddProcessManifest("fake-job", 2, "/fake-job/config/processes.json")
+			Expect(err).ToNot(HaveOccurred())
+
+			writtenConfig, err := fs.ReadFileString("/var/vcap/monit/job/0002_fake-job.monitrc")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writtenConfig).To(Equal(`check process fake-process
+  with pidfile /var/vcap/sys/run/fake-job/fake-process.pid
+  start program "/bin/bash /var/vcap/monit/job/0002_fake-job.fake-process.ctl start"
+  stop program "/bin/bash /var/vcap/monit/job/0002_fake-job.fake-process.ctl stop" with timeout 30 seconds
+  group vcap
+  if totalmem > 512 MB for 3 cycles then restart
+  if failed host 127.0.0.1 port 8080 protocol http request "/health" with timeout 5 seconds for 3 cycles then restart
+
+check process other-process
+  with pidfile /var/vcap/sys/run/fake-job/other-process.pid
+  start program "/bin/bash /var/vcap/monit/job/0002_fake-job.other-process.ctl start"
+  stop program "/bin/bash /var/vcap/monit/job/0002_fake-job.other-process.ctl stop" with timeout 15 seconds
+  group vcap
+
+`))
+		})
+
+		It("writes control script that daemonizes process", func() {
+			err := monit.AddProcessManifest("fake-job", 2, "/fake-job/config/processes.json")
+			Expect(err).ToNot(HaveOccurred())
+
+			ctlScript, err := fs.ReadFileString("/var/vcap/monit/job/0002_fake-job.fake-process.ctl")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctlScript).To(ContainSubstring("PIDFILE='/var/vcap/sys/run/fake-job/fake-process.pid'\n"))
+			Expect(ctlScript).To(ContainSubstring("STDOUT_LOG='/var/vcap/sys/log/fake-job/fake-process.stdout.log'\n"))
+			Expect(ctlScript).To(ContainSubstring("STDERR_LOG='/var/vcap/sys/log/fake-job/fake-process.stderr.log'\n"))
+			Expect(ctlScript).To(ContainSubstring(`
+    ulimit -n 1024
+    cd '/var/vcap/data/fake' || exit 1
+    export FAKE_A='a'
+    export FAKE_B='b'
+
+    setsid '/var/vcap/packages/fake/bin/fake' '--name' 'it'\''s fake' >>"$STDOUT_LOG" 2>>"$STDERR_LOG" </dev/null &
+`))
+			Expect(ctlScript).To(ContainSubstring("for i in $(seq 20); do"))
+
+			otherCtlScript, err := fs.ReadFileString("/var/vcap/monit/job/0002_fake-job.other-process.ctl")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherCtlScript).To(ContainSubstring(`
+    fi
+
+    setsid '/var/vcap/packages/fake/bin/other' >>"$STDOUT_LOG"`))
+			Expect(otherCtlScript).To(ContainSubstring("for i in $(seq 5); do"))
+		})
+
+		It("returns error when manifest is not valid", func() {
+			fs.WriteFileString("/fake-job/config/processes.json", `{"processes": [{"name": "fake-process"}]}`)
+
+			err := monit.AddProcessManifest("fake-job", 2, "/fake-job/config/processes.json")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must specify executable"))
+		})
+
+		It("returns error when writing configuration fails", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			err := monit.AddProcessManifest("fake-job", 2, "/fake-job/config/processes.json")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+
 	Describe("RemoveAllJobs", func() {
 		Context("when jobs directory removal succeeds", func() {
 			It("does not return error because all jobs are removed from monit", func() {
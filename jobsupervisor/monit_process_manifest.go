@@ -0,0 +1,175 @@
+package jobsupervisor
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const monitProcessConfigTemplate = `check process {{ .Name }}
+  with pidfile {{ .PidFile }}
+  start program "/bin/bash {{ .CtlPath }} start"
+  stop program "/bin/bash {{ .CtlPath }} stop" with timeout {{ .StopTimeout }} seconds
+  group vcap
+{{- if .MemoryMB }}
+  if totalmem > {{ .MemoryMB }} MB for 3 cycles then restart
+{{- end }}
+{{- with .HealthCheck }}
+  if failed host 127.0.0.1 port {{ .Port }}{{ if .Path }} protocol http request "{{ .Path }}"{{ end }} with timeout {{ .TimeoutSeconds }} seconds for 3 cycles then restart
+{{- end }}
+
+`
+
+// Script is started by monit; it daemonizes process and keeps track of its pid.
+const monitProcessCtlTemplate = `#!/bin/bash
+# Generated from process manifest of job {{ .JobName }}
+
+PIDFILE={{ quote .PidFile }}
+STDOUT_LOG={{ quote .StdoutLog }}
+STDERR_LOG={{ quote .StderrLog }}
+
+case "$1" in
+  start)
+    mkdir -p "$(dirname "$PIDFILE")" "$(dirname "$STDOUT_LOG")"
+
+    if [ -f "$PIDFILE" ] && kill -0 "$(cat "$PIDFILE")" 2>/dev/null; then
+      exit 0
+    fi
+{{ if .OpenFiles }}
+    ulimit -n {{ .OpenFiles }}
+{{- end }}
+{{- if .WorkingDir }}
+    cd {{ quote .WorkingDir }} || exit 1
+{{- end }}
+{{- range .Env }}
+    export {{ . }}
+{{- end }}
+{{- if or .OpenFiles .WorkingDir .Env }}
+{{ end }}
+    setsid {{ .Command }} >>"$STDOUT_LOG" 2>>"$STDERR_LOG" </dev/null &
+    echo $! > "$PIDFILE"
+    ;;
+
+  stop)
+    if [ ! -f "$PIDFILE" ]; then
+      exit 0
+    fi
+
+    PID="$(cat "$PIDFILE")"
+    kill -TERM "$PID" 2>/dev/null
+
+    for i in $(seq {{ .GracePeriod }}); do
+      kill -0 "$PID" 2>/dev/null || break
+      sleep 1
+    done
+
+    kill -KILL "$PID" 2>/dev/null
+    rm -f "$PIDFILE"
+    ;;
+
+  *)
+    echo "Usage: $0 {start|stop}"
+    exit 1
+    ;;
+esac
+`
+
+type monitProcessFiles struct {
+	JobName     string
+	Name        string
+	PidFile     string
+	CtlPath     string
+	StdoutLog   string
+	StderrLog   string
+	StopTimeout int
+	MemoryMB    int
+	OpenFiles   int
+	WorkingDir  string
+	Env         []string
+	Command     string
+	GracePeriod int
+	HealthCheck *ProcessHealthCheck
+}
+
+// renderMonitProcessManifest translates process manifest into monit configuration
+// and a control script per process that monit uses to start and stop it.
+func renderMonitProcessManifest(
+	dirProvider boshdir.Provider,
+	jobName string,
+	filePrefix string,
+	manifest ProcessManifest,
+) (string, map[string]string, error) {
+	configTemplate := template.Must(template.New("monit-process-config").Parse(monitProcessConfigTemplate))
+	ctlTemplate := template.Must(template.New("monit-process-ctl").Funcs(template.FuncMap{"quote": shellQuote}).Parse(monitProcessCtlTemplate))
+
+	config := bytes.NewBuffer([]byte{})
+	ctlScripts := map[string]string{}
+
+	for _, process := range manifest.Processes {
+		files := monitProcessFiles{
+			JobName:     jobName,
+			Name:        process.Name,
+			PidFile:     path.Join(dirProvider.RunDir(), jobName, process.Name+".pid"),
+			CtlPath:     path.Join(dirProvider.MonitJobsDir(), fmt.Sprintf("%s.%s.ctl", filePrefix, process.Name)),
+			StdoutLog:   path.Join(dirProvider.LogsDir(), jobName, process.Name+".stdout.log"),
+			StderrLog:   path.Join(dirProvider.LogsDir(), jobName, process.Name+".stderr.log"),
+			StopTimeout: process.GracePeriod() + 10,
+			MemoryMB:    process.Limits.MemoryMB,
+			OpenFiles:   process.Limits.OpenFiles,
+			WorkingDir:  process.WorkingDir,
+			Env:         processEnv(process, shellQuote),
+			Command:     processCommand(process, shellQuote),
+			GracePeriod: process.GracePeriod(),
+			HealthCheck: process.HealthCheck,
+		}
+
+		err := configTemplate.Execute(config, files)
+		if err != nil {
+			return "", nil, bosherr.WrapError(err, "Generating monit config from template")
+		}
+
+		ctlScript := bytes.NewBuffer([]byte{})
+
+		err = ctlTemplate.Execute(ctlScript, files)
+		if err != nil {
+			return "", nil, bosherr.WrapError(err, "Generating control script from template")
+		}
+
+		ctlScripts[files.CtlPath] = ctlScript.String()
+	}
+
+	return config.String(), ctlScripts, nil
+}
+
+// processEnv returns sorted list of NAME=value pairs
+func processEnv(process ManifestProcess, quoteFunc func(string) string) []string {
+	env := []string{}
+
+	for name, value := range process.Env {
+		env = append(env, name+"="+quoteFunc(value))
+	}
+
+	sort.Strings(env)
+
+	return env
+}
+
+func processCommand(process ManifestProcess, quoteFunc func(string) string) string {
+	command := []string{quoteFunc(process.Executable)}
+
+	for _, arg := range process.Args {
+		command = append(command, quoteFunc(arg))
+	}
+
+	return strings.Join(command, " ")
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
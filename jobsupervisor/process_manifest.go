@@ -0,0 +1,167 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Location of the process manifest relative to job directory.
+	ProcessManifestPath = "config/processes.yml"
+
+	// Location of the JSON process manifest relative to job directory.
+	// Only used when job does not include ProcessManifestPath.
+	ProcessManifestJSONPath = "config/processes.json"
+
+	defaultShutdownGracePeriod = 20
+	defaultHealthCheckTimeout  = 5
+)
+
+var (
+	processNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+	envNameRegexp     = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ProcessManifest declares processes of a single job so that release authors
+// do not have to write supervisor specific configuration (e.g. monit files).
+type ProcessManifest struct {
+	Processes []ManifestProcess `json:"processes" yaml:"processes"`
+}
+
+type ManifestProcess struct {
+	Name       string            `json:"name" yaml:"name"`
+	Executable string            `json:"executable" yaml:"executable"`
+	Args       []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env        map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty" yaml:"working_dir,omitempty"`
+
+	Limits      ProcessLimits       `json:"limits,omitempty" yaml:"limits,omitempty"`
+	HealthCheck *ProcessHealthCheck `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+
+	// Number of seconds process is given to exit after SIGTERM before it gets SIGKILL
+	ShutdownGracePeriod int `json:"shutdown_grace_period,omitempty" yaml:"shutdown_grace_period,omitempty"`
+}
+
+// ProcessLimits are applied by supervisors on the best effort basis,
+// e.g. monit can only restart process when it exceeds memory limit.
+type ProcessLimits struct {
+	MemoryMB  int `json:"memory_mb,omitempty" yaml:"memory_mb,omitempty"`
+	OpenFiles int `json:"open_files,omitempty" yaml:"open_files,omitempty"`
+	Processes int `json:"processes,omitempty" yaml:"processes,omitempty"`
+}
+
+// ProcessHealthCheck verifies that process accepts connections on a local port.
+// When Path is set HTTP GET request is made and non-2xx response is considered a failure.
+type ProcessHealthCheck struct {
+	Port    int    `json:"port" yaml:"port"`
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+	Timeout int    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+func LoadProcessManifest(fs boshsys.FileSystem, manifestPath string) (ProcessManifest, error) {
+	var manifest ProcessManifest
+
+	bytes, err := fs.ReadFile(manifestPath)
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Reading process manifest")
+	}
+
+	// JSON manifests are decoded as well since YAML is a superset of JSON
+	err = yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Unmarshalling process manifest")
+	}
+
+	err = manifest.Validate()
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Validating process manifest")
+	}
+
+	return manifest, nil
+}
+
+func (m ProcessManifest) Validate() error {
+	names := map[string]bool{}
+
+	for _, process := range m.Processes {
+		if !processNameRegexp.MatchString(process.Name) {
+			return bosherr.Errorf("Process name '%s' must only contain letters, digits, '_', '.' and '-'", process.Name)
+		}
+
+		if names[process.Name] {
+			return bosherr.Errorf("Process '%s' is declared more than once", process.Name)
+		}
+		names[process.Name] = true
+
+		if process.Executable == "" {
+			return bosherr.Errorf("Process '%s' must specify executable", process.Name)
+		}
+
+		for name := range process.Env {
+			if !envNameRegexp.MatchString(name) {
+				return bosherr.Errorf("Process '%s' has invalid environment variable name '%s'", process.Name, name)
+			}
+		}
+
+		if process.ShutdownGracePeriod < 0 {
+			return bosherr.Errorf("Process '%s' must have non-negative shutdown grace period", process.Name)
+		}
+
+		if process.HealthCheck != nil && (process.HealthCheck.Port <= 0 || process.HealthCheck.Port > 65535) {
+			return bosherr.Errorf("Process '%s' must specify valid health check port", process.Name)
+		}
+	}
+
+	return nil
+}
+
+func (p ManifestProcess) GracePeriod() int {
+	if p.ShutdownGracePeriod == 0 {
+		return defaultShutdownGracePeriod
+	}
+	return p.ShutdownGracePeriod
+}
+
+func (c ProcessHealthCheck) TimeoutSeconds() int {
+	if c.Timeout == 0 {
+		return defaultHealthCheckTimeout
+	}
+	return c.Timeout
+}
+
+// Check returns error when process does not respond on the health check port
+func (c ProcessHealthCheck) Check() error {
+	timeout := time.Duration(c.TimeoutSeconds()) * time.Second
+	address := fmt.Sprintf("127.0.0.1:%d", c.Port)
+
+	if c.Path == "" {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Connecting to %s", address)
+		}
+
+		return conn.Close()
+	}
+
+	client := http.Client{Timeout: timeout}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s%s", address, c.Path))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Requesting %s", c.Path)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return bosherr.Errorf("Requesting %s returned status %d", c.Path, resp.StatusCode)
+	}
+
+	return nil
+}
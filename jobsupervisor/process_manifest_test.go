@@ -0,0 +1,215 @@
+package jobsupervisor_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("ProcessManifest", func() {
+	var (
+		fs *fakesys.FakeFileSystem
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	Describe("LoadProcessManifest", func() {
+		It("loads processes from manifest", func() {
+			fs.WriteFileString("/fake-manifest", `{
+  "processes": [
+    {
+      "name": "fake-process",
+      "executable": "/var/vcap/packages/fake/bin/fake",
+      "args": ["--port", "8080"],
+      "env": {"FAKE_ENV": "fake-value"},
+      "working_dir": "/var/vcap/data/fake",
+      "limits": {"memory_mb": 512, "open_files": 1024, "processes": 100},
+      "health_check": {"port": 8080, "path": "/health", "timeout": 2},
+      "shutdown_grace_period": 30
+    }
+  ]
+}`)
+
+			manifest, err := LoadProcessManifest(fs, "/fake-manifest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest).To(Equal(ProcessManifest{
+				Processes: []ManifestProcess{
+					{
+						Name:       "fake-process",
+						Executable: "/var/vcap/packages/fake/bin/fake",
+						Args:       []string{"--port", "8080"},
+						Env:        map[string]string{"FAKE_ENV": "fake-value"},
+						WorkingDir: "/var/vcap/data/fake",
+						Limits: ProcessLimits{
+							MemoryMB:  512,
+							OpenFiles: 1024,
+							Processes: 100,
+						},
+						HealthCheck: &ProcessHealthCheck{
+							Port:    8080,
+							Path:    "/health",
+							Timeout: 2,
+						},
+						ShutdownGracePeriod: 30,
+					},
+				},
+			}))
+		})
+
+		It("loads processes from YAML manifest", func() {
+			fs.WriteFileString("/fake-manifest", `---
+processes:
+- name: fake-process
+  executable: /var/vcap/packages/fake/bin/fake
+  args:
+  - --port
+  - "8080"
+  env:
+    FAKE_ENV: fake-value
+  limits:
+    memory_mb: 512
+  health_check:
+    port: 8080
+  shutdown_grace_period: 30
+`)
+
+			manifest, err := LoadProcessManifest(fs, "/fake-manifest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest).To(Equal(ProcessManifest{
+				Processes: []ManifestProcess{
+					{
+						Name:                "fake-process",
+						Executable:          "/var/vcap/packages/fake/bin/fake",
+						Args:                []string{"--port", "8080"},
+						Env:                 map[string]string{"FAKE_ENV": "fake-value"},
+						Limits:              ProcessLimits{MemoryMB: 512},
+						HealthCheck:         &ProcessHealthCheck{Port: 8080},
+						ShutdownGracePeriod: 30,
+					},
+				},
+			}))
+		})
+
+		It("returns error when manifest cannot be read", func() {
+			_, err := LoadProcessManifest(fs, "/missing-manifest")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading process manifest"))
+		})
+
+		It("returns error when manifest cannot be parsed", func() {
+			fs.WriteFileString("/fake-manifest", "{")
+
+			_, err := LoadProcessManifest(fs, "/fake-manifest")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling process manifest"))
+		})
+
+		It("returns error when manifest is not valid", func() {
+			fs.WriteFileString("/fake-manifest", `{"processes": [{"name": "fake-process"}]}`)
+
+			_, err := LoadProcessManifest(fs, "/fake-manifest")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process 'fake-process' must specify executable"))
+		})
+	})
+
+	Describe("Validate", func() {
+		var (
+			process ManifestProcess
+		)
+
+		BeforeEach(func() {
+			process = ManifestProcess{Name: "fake-process", Executable: "/fake-exe"}
+		})
+
+		validate := func() error {
+			return ProcessManifest{Processes: []ManifestProcess{process}}.Validate()
+		}
+
+		It("accepts minimal process", func() {
+			Expect(validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects process names that are not safe to use in file and unit names", func() {
+			process.Name = "fake/process"
+			Expect(validate()).To(MatchError(ContainSubstring("Process name 'fake/process' must only contain")))
+		})
+
+		It("rejects duplicate process names", func() {
+			err := ProcessManifest{Processes: []ManifestProcess{process, process}}.Validate()
+			Expect(err).To(MatchError(ContainSubstring("Process 'fake-process' is declared more than once")))
+		})
+
+		It("rejects invalid environment variable names", func() {
+			process.Env = map[string]string{"FAKE-ENV": "fake-value"}
+			Expect(validate()).To(MatchError(ContainSubstring("invalid environment variable name 'FAKE-ENV'")))
+		})
+
+		It("rejects negative shutdown grace period", func() {
+			process.ShutdownGracePeriod = -1
+			Expect(validate()).To(MatchError(ContainSubstring("non-negative shutdown grace period")))
+		})
+
+		It("rejects health checks without valid port", func() {
+			process.HealthCheck = &ProcessHealthCheck{Path: "/health"}
+			Expect(validate()).To(MatchError(ContainSubstring("must specify valid health check port")))
+		})
+	})
+
+	Describe("ProcessHealthCheck", func() {
+		portOf := func(address string) int {
+			_, portStr, err := net.SplitHostPort(address)
+			Expect(err).ToNot(HaveOccurred())
+
+			port, err := strconv.Atoi(portStr)
+			Expect(err).ToNot(HaveOccurred())
+
+			return port
+		}
+
+		It("succeeds when port accepts connections", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer listener.Close()
+
+			check := ProcessHealthCheck{Port: portOf(listener.Addr().String())}
+			Expect(check.Check()).ToNot(HaveOccurred())
+		})
+
+		It("fails when port does not accept connections", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			port := portOf(listener.Addr().String())
+			listener.Close()
+
+			check := ProcessHealthCheck{Port: port}
+			Expect(check.Check()).To(HaveOccurred())
+		})
+
+		It("checks HTTP status when path is specified", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/health" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			port := portOf(server.Listener.Addr().String())
+
+			Expect(ProcessHealthCheck{Port: port, Path: "/health"}.Check()).ToNot(HaveOccurred())
+
+			err := ProcessHealthCheck{Port: port, Path: "/other"}.Check()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("returned status 503"))
+		})
+	})
+})
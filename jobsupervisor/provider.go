@@ -50,6 +50,7 @@ func NewProvider(
 		"monit":      monitJobSupervisor,
 		"dummy":      NewDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
+		"systemd":    NewSystemdJobSupervisor(fs, runner, logger, dirProvider, timeService),
 		// Cannot link to "windows" JobSupervisor
 	}
 
@@ -83,6 +83,14 @@ func init() {
 			Expect(actualSupervisor).To(Equal(expectedSupervisor))
 		})
 
+		It("provides a systemd job supervisor", func() {
+			actualSupervisor, err := provider.Get("systemd")
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedSupervisor := NewSystemdJobSupervisor(platform.Fs, platform.Runner, logger, dirProvider, timeService)
+			Expect(actualSupervisor).To(Equal(expectedSupervisor))
+		})
+
 		It("returns an error when the supervisor is not found", func() {
 			_, err := provider.Get("does-not-exist")
 			Expect(err).To(HaveOccurred())
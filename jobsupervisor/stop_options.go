@@ -0,0 +1,103 @@
+package jobsupervisor
+
+import (
+	"strings"
+	"time"
+)
+
+const DefaultStopTimeout = 5 * time.Minute
+
+// StopOptions control order in which StopAndWait stops processes
+// and how long processes are given to exit before they are killed.
+type StopOptions struct {
+	// Jobs are stopped one after another in the listed order.
+	// Processes of jobs that are not listed are stopped last.
+	Jobs []JobStopOptions
+
+	// Used for jobs that do not specify their own timeout
+	DefaultTimeout time.Duration
+}
+
+type JobStopOptions struct {
+	Name    string
+	Timeout time.Duration
+}
+
+// StopResult names processes that exited on their own
+// and processes that had to be forcefully killed after timeout.
+type StopResult struct {
+	Stopped []string `json:"stopped"`
+	Killed  []string `json:"killed"`
+}
+
+type stopGroup struct {
+	JobName   string
+	Timeout   time.Duration
+	Processes []string
+}
+
+func NewStopResult() StopResult {
+	return StopResult{Stopped: []string{}, Killed: []string{}}
+}
+
+func (o StopOptions) defaultTimeout() time.Duration {
+	if o.DefaultTimeout > 0 {
+		return o.DefaultTimeout
+	}
+	return DefaultStopTimeout
+}
+
+// stopGroups splits processes by job in stop order. Last group always
+// includes processes that do not belong to any listed job (possibly none).
+func (o StopOptions) stopGroups(processes []jobProcess) []stopGroup {
+	groups := []stopGroup{}
+	rest := stopGroup{Timeout: o.defaultTimeout(), Processes: []string{}}
+
+	processJobs := make([]string, len(processes))
+	for i, process := range processes {
+		processJobs[i] = o.jobFor(process)
+	}
+
+	for _, job := range o.Jobs {
+		group := stopGroup{JobName: job.Name, Timeout: job.Timeout}
+		if group.Timeout <= 0 {
+			group.Timeout = o.defaultTimeout()
+		}
+
+		for i, process := range processes {
+			if processJobs[i] == job.Name {
+				group.Processes = append(group.Processes, process.Name)
+			}
+		}
+
+		if len(group.Processes) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	for i, process := range processes {
+		if processJobs[i] == "" {
+			rest.Processes = append(rest.Processes, process.Name)
+		}
+	}
+
+	return append(groups, rest)
+}
+
+// jobFor returns the most specific listed job that process belongs to.
+// Additional monit files of a job are added as <job>_<label>.
+func (o StopOptions) jobFor(process jobProcess) string {
+	var jobName string
+
+	for _, job := range o.Jobs {
+		if process.JobName != job.Name && !strings.HasPrefix(process.JobName, job.Name+"_") {
+			continue
+		}
+
+		if len(job.Name) > len(jobName) {
+			jobName = job.Name
+		}
+	}
+
+	return jobName
+}
@@ -0,0 +1,791 @@
+package jobsupervisor
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	systemdJobSupervisorLogTag = "systemdJobSupervisor"
+
+	systemdUnitPrefix = "vcap-"
+	systemdSlice      = "vcap"
+
+	systemdFailuresPollInterval = 10 * time.Second
+
+	// Format of *Timestamp unit properties reported by `systemctl show`
+	systemdTimestampFormat = "Mon 2006-01-02 15:04:05 MST"
+
+	// systemd reports unset numeric properties as (uint64)-1
+	systemdUnsetValue = "18446744073709551615"
+)
+
+var systemdUnitNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9:_.\-]`)
+
+var systemdShowProperties = []string{
+	"ActiveState",
+	"SubState",
+	"MainPID",
+	"NRestarts",
+	"MemoryCurrent",
+	"CPUUsageNSec",
+	"ActiveEnterTimestamp",
+}
+
+// systemdJobSupervisor runs job processes as transient systemd units
+// grouped in vcap.slice. Units are created and driven by running
+// systemd-run and systemctl commands.
+type systemdJobSupervisor struct {
+	fs          boshsys.FileSystem
+	runner      boshsys.CmdRunner
+	logger      boshlog.Logger
+	dirProvider boshdir.Provider
+	timeService clock.Clock
+
+	// Set to 1 when failures should not be reported (e.g. while draining)
+	unmonitored int32
+}
+
+type systemdLoadedUnit struct {
+	Name        string
+	ActiveState string
+}
+
+type systemdUnitStatus struct {
+	ActiveState   string
+	SubState      string
+	MainPID       int
+	NRestarts     int
+	MemoryBytes   uint64
+	CPUUsageNSec  uint64
+	ActiveEntered time.Time
+}
+
+func NewSystemdJobSupervisor(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	dirProvider boshdir.Provider,
+	timeService clock.Clock,
+) JobSupervisor {
+	return &systemdJobSupervisor{
+		fs:          fs,
+		runner:      runner,
+		logger:      logger,
+		dirProvider: dirProvider,
+		timeService: timeService,
+	}
+}
+
+// Reload stops units of processes that are no longer part of any job and
+// restarts running units of processes whose configuration has changed.
+// Units for newly added processes are created by Start.
+func (s *systemdJobSupervisor) Reload() error {
+	processes, err := s.processes()
+	if err != nil {
+		return err
+	}
+
+	configuredProcesses := map[string]jobProcess{}
+	for _, process := range processes {
+		configuredProcesses[s.unitName(process.Name)] = process
+	}
+
+	loadedUnits, err := s.loadedUnits()
+	if err != nil {
+		return err
+	}
+
+	unitConfigs, err := s.readUnitConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, unit := range loadedUnits {
+		process, configured := configuredProcesses[unit.Name]
+
+		if configured && unitConfigs[unit.Name] == s.unitConfig(unit.Name, process) {
+			continue
+		}
+
+		if configured {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Stopping unit %s with changed configuration", unit.Name)
+		} else {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Stopping unit %s that is no longer configured", unit.Name)
+		}
+
+		_, _, _, err := s.runner.RunCommand("systemctl", "stop", unit.Name)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Stopping unit %s", unit.Name)
+		}
+
+		delete(unitConfigs, unit.Name)
+
+		if configured && (unit.ActiveState == "active" || unit.ActiveState == "activating") {
+			err = s.startUnit(unit.Name, process, unitConfigs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.writeUnitConfigs(unitConfigs)
+}
+
+func (s *systemdJobSupervisor) Start() error {
+	processes, err := s.processes()
+	if err != nil {
+		return err
+	}
+
+	unitConfigs, err := s.readUnitConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, process := range processes {
+		unit := s.unitName(process.Name)
+
+		status, err := s.unitStatus(unit)
+		if err != nil {
+			return err
+		}
+
+		if status.ActiveState == "active" || status.ActiveState == "activating" {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Unit %s is already %s", unit, status.ActiveState)
+			continue
+		}
+
+		// Failed transient units stay loaded and prevent unit with the same name from being created
+		if status.ActiveState == "failed" {
+			_, _, _, err = s.runner.RunCommand("systemctl", "reset-failed", unit)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Resetting failed unit %s", unit)
+			}
+		}
+
+		err = s.startUnit(unit, process, unitConfigs)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.writeUnitConfigs(unitConfigs)
+	if err != nil {
+		return err
+	}
+
+	err = s.fs.RemoveAll(s.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	atomic.StoreInt32(&s.unmonitored, 0)
+
+	return nil
+}
+
+// startUnit creates transient unit for the process and records
+// its configuration so that Reload can detect configuration changes
+func (s *systemdJobSupervisor) startUnit(unit string, process jobProcess, unitConfigs map[string]string) error {
+	if process.Manifest != nil {
+		err := s.fs.MkdirAll(path.Join(s.dirProvider.LogsDir(), process.JobName), os.FileMode(0750))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating log directory for unit %s", unit)
+		}
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Starting unit %s", unit)
+
+	_, _, _, err := s.runner.RunCommand("systemd-run", s.runArgs(unit, process)...)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting unit %s", unit)
+	}
+
+	unitConfigs[unit] = s.unitConfig(unit, process)
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) Stop() error {
+	processes, err := s.processes()
+	if err != nil {
+		return err
+	}
+
+	if len(processes) > 0 {
+		args := []string{"stop", "--no-block"}
+		for _, process := range processes {
+			args = append(args, s.unitName(process.Name))
+		}
+
+		s.logger.Debug(systemdJobSupervisorLogTag, "Stopping units %v", args[2:])
+
+		_, _, _, err = s.runner.RunCommand("systemctl", args...)
+		if err != nil {
+			return bosherr.WrapError(err, "Stopping units")
+		}
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+// StopAndWait stops units job by job. Units that do not stop within job's
+// timeout are sent SIGKILL; systemd may kill them earlier when unit's own
+// stop timeout (e.g. manifest shutdown grace period) expires.
+func (s *systemdJobSupervisor) StopAndWait(options StopOptions) (StopResult, error) {
+	result := NewStopResult()
+
+	processes, err := s.processes()
+	if err != nil {
+		return result, err
+	}
+
+	for _, group := range options.stopGroups(processes) {
+		if len(group.Processes) == 0 {
+			continue
+		}
+
+		err = s.stopGroup(group, &result)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return result, bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Successfully stopped all units")
+
+	return result, nil
+}
+
+func (s *systemdJobSupervisor) stopGroup(group stopGroup, result *StopResult) error {
+	args := []string{"stop", "--no-block"}
+	for _, name := range group.Processes {
+		args = append(args, s.unitName(name))
+	}
+
+	_, _, _, err := s.runner.RunCommand("systemctl", args...)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping units")
+	}
+
+	timer := s.timeService.NewTimer(group.Timeout)
+	defer timer.Stop()
+
+	for {
+		runningProcesses := []string{}
+
+		for _, name := range group.Processes {
+			status, err := s.unitStatus(s.unitName(name))
+			if err != nil {
+				return err
+			}
+
+			switch status.ActiveState {
+			case "active", "activating", "deactivating", "reloading":
+				runningProcesses = append(runningProcesses, name)
+			}
+		}
+
+		if len(runningProcesses) == 0 {
+			result.Stopped = append(result.Stopped, group.Processes...)
+			return nil
+		}
+
+		select {
+		case <-timer.C():
+			s.logger.Error(systemdJobSupervisorLogTag, "Processes '%s' did not stop after %s, killing them", strings.Join(runningProcesses, ", "), group.Timeout)
+
+			for _, name := range group.Processes {
+				if !s.contains(runningProcesses, name) {
+					result.Stopped = append(result.Stopped, name)
+					continue
+				}
+
+				_, _, _, err := s.runner.RunCommand("systemctl", "kill", "--signal=SIGKILL", s.unitName(name))
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Killing unit %s", s.unitName(name))
+				}
+
+				result.Killed = append(result.Killed, name)
+			}
+
+			return nil
+		default:
+		}
+
+		s.timeService.Sleep(500 * time.Millisecond)
+	}
+}
+
+func (s *systemdJobSupervisor) contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *systemdJobSupervisor) Unmonitor() error {
+	atomic.StoreInt32(&s.unmonitored, 1)
+	return nil
+}
+
+func (s *systemdJobSupervisor) Status() string {
+	if s.fs.FileExists(s.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	processes, err := s.Processes()
+	if err != nil {
+		s.logger.Error(systemdJobSupervisorLogTag, "Failed to get processes: %s", err.Error())
+		return "unknown"
+	}
+
+	status := "running"
+
+	for _, process := range processes {
+		if process.State == "starting" {
+			return "starting"
+		}
+
+		if process.State != "running" {
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s *systemdJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	jobProcesses, err := s.processes()
+	if err != nil {
+		return processes, err
+	}
+
+	now := s.timeService.Now()
+
+	for _, jobProcess := range jobProcesses {
+		status, err := s.unitStatus(s.unitName(jobProcess.Name))
+		if err != nil {
+			return processes, bosherr.WrapError(err, "Getting unit status")
+		}
+
+		process := Process{
+			Name:  jobProcess.Name,
+			State: s.processState(status),
+			Memory: MemoryVitals{
+				Kb: int(status.MemoryBytes / 1024),
+			},
+		}
+
+		if process.State == "running" && !status.ActiveEntered.IsZero() {
+			uptime := now.Sub(status.ActiveEntered)
+			if uptime > 0 {
+				process.Uptime.Secs = int(uptime.Seconds())
+				process.CPU.Total = float64(status.CPUUsageNSec) / float64(uptime.Nanoseconds()) * 100
+			}
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+func (s *systemdJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	targetFilename := fmt.Sprintf("%04d_%s.monitrc", jobIndex, jobName)
+	targetConfigPath := path.Join(s.dirProvider.MonitJobsDir(), targetFilename)
+
+	configContent, err := s.fs.ReadFileString(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	_, err = parseMonitProcesses(configContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing job config for %s", jobName)
+	}
+
+	err = s.fs.WriteFileString(targetConfigPath, configContent)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing to job config file")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) AddProcessManifest(jobName string, jobIndex int, manifestPath string) error {
+	manifest, err := LoadProcessManifest(s.fs, manifestPath)
+	if err != nil {
+		return err
+	}
+
+	manifestBytes, err := json.Marshal(storedProcessManifest{JobName: jobName, Processes: manifest.Processes})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling process manifest")
+	}
+
+	targetFilename := fmt.Sprintf("%04d_%s.processes.json", jobIndex, jobName)
+
+	err = s.fs.WriteFile(path.Join(s.dirProvider.MonitJobsDir(), targetFilename), manifestBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing process manifest")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) RemoveAllJobs() error {
+	return s.fs.RemoveAll(s.dirProvider.MonitJobsDir())
+}
+
+// MonitorJobFailures polls units and reports units that failed or
+// were restarted by systemd since the previous check.
+func (s *systemdJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	lastStatuses := map[string]systemdUnitStatus{}
+
+	ticker := s.timeService.NewTicker(systemdFailuresPollInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C()
+
+		processes, err := s.processes()
+		if err != nil {
+			s.logger.Error(systemdJobSupervisorLogTag, "Failed to get processes: %s", err.Error())
+			continue
+		}
+
+		for _, process := range processes {
+			unit := s.unitName(process.Name)
+
+			status, err := s.unitStatus(unit)
+			if err != nil {
+				s.logger.Error(systemdJobSupervisorLogTag, "Failed to get status of unit %s: %s", unit, err.Error())
+				continue
+			}
+
+			lastStatus, found := lastStatuses[unit]
+			lastStatuses[unit] = status
+
+			if atomic.LoadInt32(&s.unmonitored) == 1 {
+				continue
+			}
+
+			var monitAlert *boshalert.MonitAlert
+
+			if status.ActiveState == "failed" && (!found || lastStatus.ActiveState != "failed") {
+				monitAlert = s.buildAlert(process.Name, "alert", fmt.Sprintf("unit %s failed", unit))
+			} else if found && status.NRestarts > lastStatus.NRestarts {
+				monitAlert = s.buildAlert(process.Name, "restart", fmt.Sprintf("unit %s was restarted %d time(s)", unit, status.NRestarts-lastStatus.NRestarts))
+			}
+
+			if monitAlert == nil && status.ActiveState == "active" && process.Manifest != nil && process.Manifest.HealthCheck != nil {
+				monitAlert = s.checkHealth(unit, process.Name, *process.Manifest.HealthCheck)
+			}
+
+			if monitAlert == nil {
+				continue
+			}
+
+			err = handler(*monitAlert)
+			if err != nil {
+				s.logger.Error(systemdJobSupervisorLogTag, "Failed to handle failure of unit %s: %s", unit, err.Error())
+			}
+		}
+	}
+}
+
+// checkHealth restarts unit when its health check fails
+func (s *systemdJobSupervisor) checkHealth(unit, processName string, healthCheck ProcessHealthCheck) *boshalert.MonitAlert {
+	err := healthCheck.Check()
+	if err == nil {
+		return nil
+	}
+
+	s.logger.Error(systemdJobSupervisorLogTag, "Health check of unit %s failed: %s", unit, err.Error())
+
+	_, _, _, restartErr := s.runner.RunCommand("systemctl", "restart", unit)
+	if restartErr != nil {
+		s.logger.Error(systemdJobSupervisorLogTag, "Failed to restart unit %s: %s", unit, restartErr.Error())
+	}
+
+	monitAlert := s.buildAlert(processName, "restart", fmt.Sprintf("health check failed: %s", err.Error()))
+	monitAlert.Event = "connection failed"
+
+	return monitAlert
+}
+
+func (s *systemdJobSupervisor) buildAlert(processName, action, description string) *boshalert.MonitAlert {
+	now := s.timeService.Now()
+
+	return &boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@localhost", now.UnixNano(), processName),
+		Service:     processName,
+		Event:       "pid failed",
+		Action:      action,
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+}
+
+func (s *systemdJobSupervisor) runArgs(unit string, process jobProcess) []string {
+	args := []string{
+		"--unit=" + unit,
+		"--slice=" + systemdSlice,
+		"--description=" + process.Name,
+		"--property=Restart=on-failure",
+	}
+
+	if process.Manifest != nil {
+		return append(args, s.manifestRunArgs(process.JobName, *process.Manifest)...)
+	}
+
+	if process.PidFile != "" {
+		// Monit start programs usually daemonize and write pid file
+		args = append(args, "--property=Type=forking", "--property=PIDFile="+process.PidFile)
+	}
+
+	if process.StopCommand != "" {
+		args = append(args, "--property=ExecStop=/bin/sh -c "+systemdQuote(process.StopCommand))
+	}
+
+	return append(args, "/bin/sh", "-c", process.StartCommand)
+}
+
+// systemdExecEscaper escapes characters that systemd interprets
+// in double quoted words of Exec* command lines: C-style escapes,
+// environment variable substitution and unit specifiers
+var systemdExecEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"$", "$$",
+	"%", "%%",
+)
+
+// systemdQuote makes value a single word of systemd Exec* command line
+func systemdQuote(value string) string {
+	return `"` + systemdExecEscaper.Replace(value) + `"`
+}
+
+func (s *systemdJobSupervisor) manifestRunArgs(jobName string, process ManifestProcess) []string {
+	logDir := path.Join(s.dirProvider.LogsDir(), jobName)
+
+	args := []string{
+		fmt.Sprintf("--property=TimeoutStopSec=%d", process.GracePeriod()),
+		fmt.Sprintf("--property=StandardOutput=append:%s", path.Join(logDir, process.Name+".stdout.log")),
+		fmt.Sprintf("--property=StandardError=append:%s", path.Join(logDir, process.Name+".stderr.log")),
+	}
+
+	if process.WorkingDir != "" {
+		args = append(args, "--property=WorkingDirectory="+process.WorkingDir)
+	}
+
+	if process.Limits.MemoryMB > 0 {
+		args = append(args, fmt.Sprintf("--property=MemoryMax=%dM", process.Limits.MemoryMB))
+	}
+
+	if process.Limits.OpenFiles > 0 {
+		args = append(args, fmt.Sprintf("--property=LimitNOFILE=%d", process.Limits.OpenFiles))
+	}
+
+	if process.Limits.Processes > 0 {
+		args = append(args, fmt.Sprintf("--property=TasksMax=%d", process.Limits.Processes))
+	}
+
+	for _, env := range processEnv(process, func(value string) string { return value }) {
+		args = append(args, "--setenv="+env)
+	}
+
+	args = append(args, process.Executable)
+
+	return append(args, process.Args...)
+}
+
+// processes returns configured processes and fails when
+// several processes would be run by the same unit
+func (s *systemdJobSupervisor) processes() ([]jobProcess, error) {
+	processes, err := readJobProcesses(s.fs, s.dirProvider.MonitJobsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	unitProcesses := map[string]string{}
+
+	for _, process := range processes {
+		unit := s.unitName(process.Name)
+
+		if otherName, found := unitProcesses[unit]; found {
+			return nil, bosherr.Errorf("Processes '%s' and '%s' map to the same unit %s", otherName, process.Name, unit)
+		}
+
+		unitProcesses[unit] = process.Name
+	}
+
+	return processes, nil
+}
+
+// unitConfig returns digest of arguments that unit is created with
+func (s *systemdJobSupervisor) unitConfig(unit string, process jobProcess) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(s.runArgs(unit, process), "\x00"))))
+}
+
+func (s *systemdJobSupervisor) readUnitConfigs() (map[string]string, error) {
+	unitConfigs := map[string]string{}
+
+	if !s.fs.FileExists(s.unitConfigsPath()) {
+		return unitConfigs, nil
+	}
+
+	bytes, err := s.fs.ReadFile(s.unitConfigsPath())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading unit configs")
+	}
+
+	err = json.Unmarshal(bytes, &unitConfigs)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling unit configs")
+	}
+
+	return unitConfigs, nil
+}
+
+func (s *systemdJobSupervisor) writeUnitConfigs(unitConfigs map[string]string) error {
+	bytes, err := json.Marshal(unitConfigs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling unit configs")
+	}
+
+	err = s.fs.WriteFile(s.unitConfigsPath(), bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing unit configs")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) loadedUnits() ([]systemdLoadedUnit, error) {
+	stdout, _, _, err := s.runner.RunCommand(
+		"systemctl", "list-units", "--all", "--plain", "--no-legend", "--no-pager", systemdUnitPrefix+"*.service",
+	)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing units")
+	}
+
+	units := []systemdLoadedUnit{}
+
+	// Each line lists unit name, load, active and sub states and description
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			units = append(units, systemdLoadedUnit{Name: fields[0], ActiveState: fields[2]})
+		}
+	}
+
+	return units, nil
+}
+
+func (s *systemdJobSupervisor) unitStatus(unit string) (systemdUnitStatus, error) {
+	var status systemdUnitStatus
+
+	stdout, _, _, err := s.runner.RunCommand(
+		"systemctl", "show", unit, "--property="+strings.Join(systemdShowProperties, ","),
+	)
+	if err != nil {
+		return status, bosherr.WrapErrorf(err, "Showing unit %s", unit)
+	}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key, value := parts[0], parts[1]
+
+		switch key {
+		case "ActiveState":
+			status.ActiveState = value
+		case "SubState":
+			status.SubState = value
+		case "MainPID":
+			status.MainPID, _ = strconv.Atoi(value)
+		case "NRestarts":
+			status.NRestarts, _ = strconv.Atoi(value)
+		case "MemoryCurrent":
+			status.MemoryBytes = s.parseUint(value)
+		case "CPUUsageNSec":
+			status.CPUUsageNSec = s.parseUint(value)
+		case "ActiveEnterTimestamp":
+			status.ActiveEntered, _ = time.Parse(systemdTimestampFormat, value)
+		}
+	}
+
+	return status, nil
+}
+
+func (s *systemdJobSupervisor) parseUint(value string) uint64 {
+	if value == systemdUnsetValue {
+		return 0
+	}
+
+	result, _ := strconv.ParseUint(value, 10, 64)
+	return result
+}
+
+func (s *systemdJobSupervisor) processState(status systemdUnitStatus) string {
+	switch status.ActiveState {
+	case "active", "reloading":
+		return "running"
+	case "activating":
+		return "starting"
+	case "deactivating":
+		return "stopping"
+	case "failed":
+		return "failing"
+	default:
+		return "stopped"
+	}
+}
+
+func (s *systemdJobSupervisor) unitName(processName string) string {
+	return systemdUnitPrefix + systemdUnitNameInvalidChars.ReplaceAllString(processName, "_") + ".service"
+}
+
+func (s *systemdJobSupervisor) stoppedFilePath() string {
+	return path.Join(s.dirProvider.MonitDir(), "stopped")
+}
+
+func (s *systemdJobSupervisor) unitConfigsPath() string {
+	return path.Join(s.dirProvider.MonitDir(), "systemd_units.json")
+}
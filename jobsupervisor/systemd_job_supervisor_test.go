@@ -0,0 +1,675 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+const (
+	systemdShowArgs = "--property=ActiveState,SubState,MainPID,NRestarts,MemoryCurrent,CPUUsageNSec,ActiveEnterTimestamp"
+
+	fakeMonitConfig = `
+check process fake-process
+  with pidfile /var/vcap/sys/run/fake-job/fake-process.pid
+  start program "/var/vcap/jobs/fake-job/bin/ctl start"
+  stop program "/var/vcap/jobs/fake-job/bin/ctl stop"
+  group vcap
+
+check file fake-file with path /var/vcap/fake-file
+  if changed checksum then alert
+
+check process other-process
+  matching "other"
+  start program = "/var/vcap/jobs/fake-job/bin/other_ctl"
+  group vcap
+`
+)
+
+var _ = Describe("systemdJobSupervisor", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		runner      *fakesys.FakeCmdRunner
+		timeService *fakeclock.FakeClock
+		supervisor  JobSupervisor
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		timeService = fakeclock.NewFakeClock(time.Date(2016, time.January, 4, 10, 0, 0, 0, time.UTC))
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		dirProvider := boshdir.NewProvider("/var/vcap")
+
+		supervisor = NewSystemdJobSupervisor(fs, runner, logger, dirProvider, timeService)
+	})
+
+	addConfig := func(config string) {
+		fs.WriteFileString("/var/vcap/monit/job/0000_fake-job.monitrc", config)
+		fs.SetGlob("/var/vcap/monit/job/*.monitrc", []string{"/var/vcap/monit/job/0000_fake-job.monitrc"})
+	}
+
+	addShowResult := func(unit, output string) {
+		runner.AddCmdResult("systemctl show "+unit+" "+systemdShowArgs, fakesys.FakeCmdResult{Stdout: output})
+	}
+
+	addStickyShowResult := func(unit, output string) {
+		runner.AddCmdResult("systemctl show "+unit+" "+systemdShowArgs, fakesys.FakeCmdResult{Stdout: output, Sticky: true})
+	}
+
+	Describe("AddJob", func() {
+		It("copies job config into monit jobs dir", func() {
+			fs.WriteFileString("/fake-config", fakeMonitConfig)
+
+			err := supervisor.AddJob("fake-job", 1, "/fake-config")
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := fs.ReadFileString("/var/vcap/monit/job/0001_fake-job.monitrc")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal(fakeMonitConfig))
+		})
+
+		It("returns error when process does not have start program", func() {
+			fs.WriteFileString("/fake-config", "check process fake-process\n  group vcap\n")
+
+			err := supervisor.AddJob("fake-job", 1, "/fake-config")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process 'fake-process' is missing start program"))
+			Expect(fs.FileExists("/var/vcap/monit/job/0001_fake-job.monitrc")).To(BeFalse())
+		})
+
+		It("returns error when job config cannot be read", func() {
+			err := supervisor.AddJob("fake-job", 1, "/missing-config")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading job config from file"))
+		})
+	})
+
+	Describe("AddProcessManifest", func() {
+		It("stores processes from manifest in monit jobs dir", func() {
+			fs.WriteFileString("/fake-manifest", `{"processes": [{"name": "fake-process", "executable": "/fake-exe"}]}`)
+
+			err := supervisor.AddProcessManifest("fake-job", 1, "/fake-manifest")
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := fs.ReadFileString("/var/vcap/monit/job/0001_fake-job.processes.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(MatchJSON(`{"job_name": "fake-job", "processes": [{"name": "fake-process", "executable": "/fake-exe", "limits": {}}]}`))
+		})
+
+		It("returns error when manifest is not valid", func() {
+			fs.WriteFileString("/fake-manifest", `{"processes": [{"name": "fake-process"}]}`)
+
+			err := supervisor.AddProcessManifest("fake-job", 1, "/fake-manifest")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must specify executable"))
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("removes monit jobs dir", func() {
+			fs.WriteFileString("/var/vcap/monit/job/0000_fake-job.monitrc", fakeMonitConfig)
+
+			err := supervisor.RemoveAllJobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/var/vcap/monit/job/0000_fake-job.monitrc")).To(BeFalse())
+		})
+	})
+
+	Describe("Start", func() {
+		BeforeEach(func() {
+			addConfig(fakeMonitConfig)
+			fs.WriteFileString("/var/vcap/monit/stopped", "")
+		})
+
+		It("creates transient units for every process and removes stopped file", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=inactive\n")
+			addShowResult("vcap-other-process.service", "ActiveState=inactive\n")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(ContainElement([]string{
+				"systemd-run",
+				"--unit=vcap-fake-process.service",
+				"--slice=vcap",
+				"--description=fake-process",
+				"--property=Restart=on-failure",
+				"--property=Type=forking",
+				"--property=PIDFile=/var/vcap/sys/run/fake-job/fake-process.pid",
+				`--property=ExecStop=/bin/sh -c "/var/vcap/jobs/fake-job/bin/ctl stop"`,
+				"/bin/sh", "-c", "/var/vcap/jobs/fake-job/bin/ctl start",
+			}))
+			Expect(runner.RunCommands).To(ContainElement([]string{
+				"systemd-run",
+				"--unit=vcap-other-process.service",
+				"--slice=vcap",
+				"--description=other-process",
+				"--property=Restart=on-failure",
+				"/bin/sh", "-c", "/var/vcap/jobs/fake-job/bin/other_ctl",
+			}))
+			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeFalse())
+		})
+
+		It("escapes stop command for systemd", func() {
+			addConfig(`
+check process fake-process
+  start program "/var/vcap/jobs/fake-job/bin/ctl start"
+  stop program "/bin/sh -c 'kill $(cat /var/vcap/sys/run/fake.pid) && date +%s > C:\stopped'"
+`)
+			addShowResult("vcap-fake-process.service", "ActiveState=inactive\n")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(ContainElement([]string{
+				"systemd-run",
+				"--unit=vcap-fake-process.service",
+				"--slice=vcap",
+				"--description=fake-process",
+				"--property=Restart=on-failure",
+				`--property=ExecStop=/bin/sh -c "/bin/sh -c 'kill $$(cat /var/vcap/sys/run/fake.pid) && date +%%s > C:\\stopped'"`,
+				"/bin/sh", "-c", "/var/vcap/jobs/fake-job/bin/ctl start",
+			}))
+		})
+
+		It("creates transient units for processes declared in process manifest", func() {
+			fs.WriteFileString("/var/vcap/monit/job/0001_manifest-job.processes.json", `{
+  "job_name": "manifest-job",
+  "processes": [
+    {
+      "name": "manifest-process",
+      "executable": "/fake-exe",
+      "args": ["--fake-arg", "fake value"],
+      "env": {"FAKE_B": "b", "FAKE_A": "a"},
+      "working_dir": "/fake-dir",
+      "limits": {"memory_mb": 512, "open_files": 1024, "processes": 100},
+      "shutdown_grace_period": 30
+    }
+  ]
+}`)
+			fs.SetGlob("/var/vcap/monit/job/*.processes.json", []string{"/var/vcap/monit/job/0001_manifest-job.processes.json"})
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands[len(runner.RunCommands)-1]).To(Equal([]string{
+				"systemd-run",
+				"--unit=vcap-manifest-process.service",
+				"--slice=vcap",
+				"--description=manifest-process",
+				"--property=Restart=on-failure",
+				"--property=TimeoutStopSec=30",
+				"--property=StandardOutput=append:/var/vcap/sys/log/manifest-job/manifest-process.stdout.log",
+				"--property=StandardError=append:/var/vcap/sys/log/manifest-job/manifest-process.stderr.log",
+				"--property=WorkingDirectory=/fake-dir",
+				"--property=MemoryMax=512M",
+				"--property=LimitNOFILE=1024",
+				"--property=TasksMax=100",
+				"--setenv=FAKE_A=a",
+				"--setenv=FAKE_B=b",
+				"/fake-exe", "--fake-arg", "fake value",
+			}))
+			Expect(fs.FileExists("/var/vcap/sys/log/manifest-job")).To(BeTrue())
+		})
+
+		It("does not recreate units that are already active", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=active\n")
+			addShowResult("vcap-other-process.service", "ActiveState=activating\n")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, cmd := range runner.RunCommands {
+				Expect(cmd[0]).ToNot(Equal("systemd-run"))
+			}
+		})
+
+		It("resets failed units before recreating them", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=failed\n")
+			addShowResult("vcap-other-process.service", "ActiveState=active\n")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands[1]).To(Equal([]string{"systemctl", "reset-failed", "vcap-fake-process.service"}))
+			Expect(runner.RunCommands[2][0]).To(Equal("systemd-run"))
+		})
+
+		It("returns error when unit cannot be created", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=inactive\n")
+			runner.AddCmdResult(
+				"systemd-run --unit=vcap-fake-process.service --slice=vcap --description=fake-process --property=Restart=on-failure --property=Type=forking --property=PIDFile=/var/vcap/sys/run/fake-job/fake-process.pid --property=ExecStop=/bin/sh -c \"/var/vcap/jobs/fake-job/bin/ctl stop\" /bin/sh -c /var/vcap/jobs/fake-job/bin/ctl start",
+				fakesys.FakeCmdResult{Error: errors.New("fake-run-err")},
+			)
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-run-err"))
+			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeTrue())
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops all units without waiting and writes stopped file", func() {
+			addConfig(fakeMonitConfig)
+
+			err := supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "stop", "--no-block", "vcap-fake-process.service", "vcap-other-process.service"},
+			}))
+			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeTrue())
+		})
+
+		It("only writes stopped file when there are no processes", func() {
+			err := supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(BeEmpty())
+			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeTrue())
+		})
+	})
+
+	Describe("StopAndWait", func() {
+		BeforeEach(func() {
+			addConfig(fakeMonitConfig)
+		})
+
+		It("stops all units and waits for them to stop", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=inactive\n")
+			addShowResult("vcap-other-process.service", "ActiveState=failed\n")
+
+			result, err := supervisor.StopAndWait(StopOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(StopResult{
+				Stopped: []string{"fake-process", "other-process"},
+				Killed:  []string{},
+			}))
+			Expect(runner.RunCommands[0]).To(Equal([]string{
+				"systemctl", "stop", "--no-block", "vcap-fake-process.service", "vcap-other-process.service",
+			}))
+			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeTrue())
+		})
+
+		It("kills units that are still running after timeout", func() {
+			addStickyShowResult("vcap-fake-process.service", "ActiveState=deactivating\n")
+			addStickyShowResult("vcap-other-process.service", "ActiveState=inactive\n")
+
+			resultchan := make(chan StopResult)
+			go func() {
+				defer GinkgoRecover()
+				result, err := supervisor.StopAndWait(StopOptions{DefaultTimeout: time.Minute})
+				Expect(err).ToNot(HaveOccurred())
+				resultchan <- result
+			}()
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(30 * time.Second)
+			Consistently(resultchan).ShouldNot(Receive())
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(31 * time.Second)
+
+			var result StopResult
+			Eventually(resultchan).Should(Receive(&result))
+			Expect(result).To(Equal(StopResult{
+				Stopped: []string{"other-process"},
+				Killed:  []string{"fake-process"},
+			}))
+			Expect(runner.RunCommands).To(ContainElement([]string{"systemctl", "kill", "--signal=SIGKILL", "vcap-fake-process.service"}))
+			Expect(fs.FileExists("/var/vcap/monit/stopped")).To(BeTrue())
+		})
+
+		It("stops units of listed jobs in order with their timeouts", func() {
+			fs.WriteFileString("/var/vcap/monit/job/0001_other-job.monitrc", "check process third-process\n  start program \"/fake-start\"\n")
+			fs.SetGlob("/var/vcap/monit/job/*.monitrc", []string{
+				"/var/vcap/monit/job/0000_fake-job.monitrc",
+				"/var/vcap/monit/job/0001_other-job.monitrc",
+			})
+
+			addShowResult("vcap-fake-process.service", "ActiveState=inactive\n")
+			addShowResult("vcap-other-process.service", "ActiveState=inactive\n")
+			addStickyShowResult("vcap-third-process.service", "ActiveState=active\n")
+
+			resultchan := make(chan StopResult)
+			go func() {
+				defer GinkgoRecover()
+				result, err := supervisor.StopAndWait(StopOptions{
+					Jobs: []JobStopOptions{{Name: "other-job", Timeout: 10 * time.Second}},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				resultchan <- result
+			}()
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(11 * time.Second)
+
+			var result StopResult
+			Eventually(resultchan).Should(Receive(&result))
+			Expect(result).To(Equal(StopResult{
+				Stopped: []string{"fake-process", "other-process"},
+				Killed:  []string{"third-process"},
+			}))
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "stop", "--no-block", "vcap-third-process.service"},
+				{"systemctl", "show", "vcap-third-process.service", systemdShowArgs},
+				{"systemctl", "show", "vcap-third-process.service", systemdShowArgs},
+				{"systemctl", "kill", "--signal=SIGKILL", "vcap-third-process.service"},
+				{"systemctl", "stop", "--no-block", "vcap-fake-process.service", "vcap-other-process.service"},
+				{"systemctl", "show", "vcap-fake-process.service", systemdShowArgs},
+				{"systemctl", "show", "vcap-other-process.service", systemdShowArgs},
+			}))
+		})
+
+		It("returns error when stopping units fails", func() {
+			runner.AddCmdResult(
+				"systemctl stop --no-block vcap-fake-process.service vcap-other-process.service",
+				fakesys.FakeCmdResult{Error: errors.New("fake-stop-err")},
+			)
+
+			_, err := supervisor.StopAndWait(StopOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-stop-err"))
+		})
+	})
+
+	Describe("Reload", func() {
+		const listUnitsCmd = "systemctl list-units --all --plain --no-legend --no-pager vcap-*.service"
+
+		BeforeEach(func() {
+			addConfig(fakeMonitConfig)
+			addShowResult("vcap-fake-process.service", "ActiveState=inactive\n")
+			addShowResult("vcap-other-process.service", "ActiveState=inactive\n")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			runner.RunCommands = [][]string{}
+		})
+
+		It("stops units that are no longer configured", func() {
+			runner.AddCmdResult(listUnitsCmd, fakesys.FakeCmdResult{
+				Stdout: "vcap-fake-process.service loaded active running fake-process\nvcap-old-process.service loaded active running old-process\n",
+			})
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "list-units", "--all", "--plain", "--no-legend", "--no-pager", "vcap-*.service"},
+				{"systemctl", "stop", "vcap-old-process.service"},
+			}))
+		})
+
+		It("restarts running units whose configuration changed", func() {
+			addConfig(`
+check process fake-process
+  start program "/var/vcap/jobs/fake-job/bin/ctl start --new"
+`)
+			runner.AddCmdResult(listUnitsCmd, fakesys.FakeCmdResult{
+				Stdout: "vcap-fake-process.service loaded active running fake-process\n",
+			})
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "list-units", "--all", "--plain", "--no-legend", "--no-pager", "vcap-*.service"},
+				{"systemctl", "stop", "vcap-fake-process.service"},
+				{
+					"systemd-run",
+					"--unit=vcap-fake-process.service",
+					"--slice=vcap",
+					"--description=fake-process",
+					"--property=Restart=on-failure",
+					"/bin/sh", "-c", "/var/vcap/jobs/fake-job/bin/ctl start --new",
+				},
+			}))
+
+			runner.RunCommands = [][]string{}
+			runner.AddCmdResult(listUnitsCmd, fakesys.FakeCmdResult{
+				Stdout: "vcap-fake-process.service loaded active running fake-process\n",
+			})
+
+			err = supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(HaveLen(1))
+		})
+
+		It("only stops units whose configuration changed when they are not running", func() {
+			addConfig(`
+check process fake-process
+  start program "/var/vcap/jobs/fake-job/bin/ctl start --new"
+`)
+			runner.AddCmdResult(listUnitsCmd, fakesys.FakeCmdResult{
+				Stdout: "vcap-fake-process.service loaded failed failed fake-process\n",
+			})
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "list-units", "--all", "--plain", "--no-legend", "--no-pager", "vcap-*.service"},
+				{"systemctl", "stop", "vcap-fake-process.service"},
+			}))
+		})
+
+		It("returns error when several processes map to the same unit", func() {
+			addConfig(`
+check process fake@process
+  start program "/var/vcap/jobs/fake-job/bin/ctl start"
+
+check process fake_process
+  start program "/var/vcap/jobs/fake-job/bin/ctl start"
+`)
+
+			err := supervisor.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Processes 'fake@process' and 'fake_process' map to the same unit vcap-fake_process.service"))
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("Processes", func() {
+		BeforeEach(func() {
+			addConfig(fakeMonitConfig)
+		})
+
+		It("maps unit state to processes", func() {
+			addShowResult("vcap-fake-process.service", `ActiveState=active
+SubState=running
+MainPID=123
+NRestarts=0
+MemoryCurrent=2097152
+CPUUsageNSec=30000000000
+ActiveEnterTimestamp=Mon 2016-01-04 09:59:00 UTC
+`)
+			addShowResult("vcap-other-process.service", `ActiveState=failed
+SubState=failed
+MainPID=0
+NRestarts=3
+MemoryCurrent=18446744073709551615
+CPUUsageNSec=18446744073709551615
+ActiveEnterTimestamp=
+`)
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{
+					Name:   "fake-process",
+					State:  "running",
+					Uptime: UptimeVitals{Secs: 60},
+					Memory: MemoryVitals{Kb: 2048},
+					CPU:    CPUVitals{Total: 50},
+				},
+				{
+					Name:  "other-process",
+					State: "failing",
+				},
+			}))
+		})
+
+		It("returns error when unit status cannot be retrieved", func() {
+			runner.AddCmdResult(
+				"systemctl show vcap-fake-process.service "+systemdShowArgs,
+				fakesys.FakeCmdResult{Error: errors.New("fake-show-err")},
+			)
+
+			_, err := supervisor.Processes()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-show-err"))
+		})
+	})
+
+	Describe("Status", func() {
+		BeforeEach(func() {
+			addConfig(fakeMonitConfig)
+		})
+
+		It("returns stopped when stopped file exists", func() {
+			fs.WriteFileString("/var/vcap/monit/stopped", "")
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+
+		It("returns running when all units are active", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=active\n")
+			addShowResult("vcap-other-process.service", "ActiveState=active\n")
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("returns starting when any unit is activating", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=failed\n")
+			addShowResult("vcap-other-process.service", "ActiveState=activating\n")
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		It("returns failing when any unit is not active", func() {
+			addShowResult("vcap-fake-process.service", "ActiveState=active\n")
+			addShowResult("vcap-other-process.service", "ActiveState=inactive\n")
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("returns unknown when unit status cannot be retrieved", func() {
+			runner.AddCmdResult(
+				"systemctl show vcap-fake-process.service "+systemdShowArgs,
+				fakesys.FakeCmdResult{Error: errors.New("fake-show-err")},
+			)
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		var (
+			alerts chan boshalert.MonitAlert
+		)
+
+		BeforeEach(func() {
+			addConfig("check process fake-process\n  start program \"/fake-start\"\n")
+			alerts = make(chan boshalert.MonitAlert, 10)
+
+			go supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+				alerts <- alert
+				return nil
+			})
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+		})
+
+		poll := func(output string) {
+			addShowResult("vcap-fake-process.service", output)
+			timeService.Increment(10 * time.Second)
+		}
+
+		It("reports units that failed", func() {
+			poll("ActiveState=failed\nNRestarts=0\n")
+
+			var alert boshalert.MonitAlert
+			Eventually(alerts).Should(Receive(&alert))
+			Expect(alert.Service).To(Equal("fake-process"))
+			Expect(alert.Event).To(Equal("pid failed"))
+			Expect(alert.Action).To(Equal("alert"))
+			Expect(alert.Description).To(Equal("unit vcap-fake-process.service failed"))
+			Expect(alert.Date).To(Equal(timeService.Now().Format(time.RFC1123Z)))
+		})
+
+		It("reports units that were restarted by systemd", func() {
+			poll("ActiveState=active\nNRestarts=1\n")
+			Consistently(alerts).ShouldNot(Receive())
+
+			poll("ActiveState=active\nNRestarts=3\n")
+
+			var alert boshalert.MonitAlert
+			Eventually(alerts).Should(Receive(&alert))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Description).To(Equal("unit vcap-fake-process.service was restarted 2 time(s)"))
+		})
+
+		It("does not report the same failure twice", func() {
+			poll("ActiveState=failed\n")
+			Eventually(alerts).Should(Receive())
+
+			poll("ActiveState=failed\n")
+			Consistently(alerts).ShouldNot(Receive())
+		})
+
+		Context("when process declares health check", func() {
+			var (
+				listener net.Listener
+			)
+
+			BeforeEach(func() {
+				var err error
+
+				listener, err = net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).ToNot(HaveOccurred())
+
+				_, port, err := net.SplitHostPort(listener.Addr().String())
+				Expect(err).ToNot(HaveOccurred())
+
+				manifest := `{"job_name": "fake-job", "processes": [{"name": "fake-process", "executable": "/fake-exe", "health_check": {"port": ` + port + `}}]}`
+
+				fs.WriteFileString("/var/vcap/monit/job/0000_fake-job.processes.json", manifest)
+				fs.SetGlob("/var/vcap/monit/job/*.monitrc", []string{})
+				fs.SetGlob("/var/vcap/monit/job/*.processes.json", []string{"/var/vcap/monit/job/0000_fake-job.processes.json"})
+			})
+
+			AfterEach(func() {
+				listener.Close()
+			})
+
+			It("does not report healthy processes", func() {
+				poll("ActiveState=active\n")
+				Consistently(alerts).ShouldNot(Receive())
+			})
+
+			It("restarts and reports processes that fail health check", func() {
+				listener.Close()
+
+				poll("ActiveState=active\n")
+
+				var alert boshalert.MonitAlert
+				Eventually(alerts).Should(Receive(&alert))
+				Expect(alert.Event).To(Equal("connection failed"))
+				Expect(alert.Action).To(Equal("restart"))
+				Expect(alert.Description).To(ContainSubstring("health check failed"))
+				Expect(runner.RunCommands).To(ContainElement([]string{"systemctl", "restart", "vcap-fake-process.service"}))
+			})
+		})
+
+		It("does not report failures when unmonitored", func() {
+			err := supervisor.Unmonitor()
+			Expect(err).ToNot(HaveOccurred())
+
+			poll("ActiveState=failed\n")
+			Consistently(alerts).ShouldNot(Receive())
+		})
+	})
+})
@@ -262,17 +262,21 @@ func (w *windowsJobSupervisor) Stop() error {
 	return nil
 }
 
-func (w *windowsJobSupervisor) StopAndWait() error {
+// StopAndWait stops all services at once; Windows services are stopped
+// by service wrapper so stop options are not applied.
+func (w *windowsJobSupervisor) StopAndWait(options StopOptions) (StopResult, error) {
 	const Timeout = time.Second * 3 // match
 
+	result := NewStopResult()
+
 	stdout, _, _, err := w.cmdRunner.RunCommand("-Command", listAllServiceNames)
 	if err != nil {
-		return bosherr.WrapError(err, "Disabling services")
+		return result, bosherr.WrapError(err, "Disabling services")
 	}
 	names := strings.Split(strings.TrimSpace(stdout), "\r\n")
 	m, err := mgr.Connect()
 	if err != nil {
-		return err // TODO: Wrap
+		return result, err // TODO: Wrap
 	}
 	defer m.Disconnect()
 	var svcs []*mgr.Service
@@ -319,16 +323,23 @@ func (w *windowsJobSupervisor) StopAndWait() error {
 
 	err = w.Stop()
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	select {
 	case <-doneCh:
 		// Ok
 	case <-time.After(Timeout):
-		return fmt.Errorf("StopAndWait: timed after: %s", Timeout)
+		return result, fmt.Errorf("StopAndWait: timed after: %s", Timeout)
 	}
-	return nil
+
+	for _, name := range names {
+		if name != "" {
+			result.Stopped = append(result.Stopped, name)
+		}
+	}
+
+	return result, nil
 }
 
 func (w *windowsJobSupervisor) Unmonitor() error {
@@ -438,8 +449,33 @@ func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath s
 		return err
 	}
 
+	return w.addProcesses(jobName, configPath, processConfig.Processes)
+}
+
+// AddProcessManifest installs manifest processes as services;
+// limits and health checks are not supported on Windows.
+func (w *windowsJobSupervisor) AddProcessManifest(jobName string, jobIndex int, manifestPath string) error {
+	manifest, err := LoadProcessManifest(w.fs, manifestPath)
+	if err != nil {
+		return err
+	}
+
+	var processes []WindowsProcess
+	for _, process := range manifest.Processes {
+		processes = append(processes, WindowsProcess{
+			Name:       process.Name,
+			Executable: process.Executable,
+			Args:       process.Args,
+			Env:        process.Env,
+		})
+	}
+
+	return w.addProcesses(jobName, manifestPath, processes)
+}
+
+func (w *windowsJobSupervisor) addProcesses(jobName string, configPath string, processes []WindowsProcess) error {
 	var buf bytes.Buffer
-	for _, process := range processConfig.Processes {
+	for _, process := range processes {
 		logPath := path.Join(w.dirProvider.LogsDir(), jobName, process.Name)
 		err := w.fs.MkdirAll(logPath, os.FileMode(0750))
 		if err != nil {
@@ -446,7 +446,8 @@ var _ = Describe("WindowsJobSupervisor", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(jobSupervisor.Start()).To(Succeed())
-				Expect(jobSupervisor.StopAndWait()).To(Succeed())
+				_, err = jobSupervisor.StopAndWait(StopOptions{})
+				Expect(err).To(Succeed())
 
 				for _, proc := range conf.Processes {
 					st, err := GetServiceState(proc.Name)
@@ -468,7 +469,8 @@ var _ = Describe("WindowsJobSupervisor", func() {
 					return SvcStateString(st), err
 				}, time.Second*6).Should(Equal(SvcStateString(svc.Stopped)))
 
-				Expect(jobSupervisor.StopAndWait()).To(Succeed())
+				_, err = jobSupervisor.StopAndWait(StopOptions{})
+				Expect(err).To(Succeed())
 
 				Consistently(func() bool {
 					stopped := true
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -30,10 +31,21 @@ type Manager interface {
 	// The certs argument should contain zero or more X.509 certificates in PEM format
 	// concatenated together. Any text that is not between `-----BEGIN CERTIFICATE-----`
 	// and `-----END CERTIFICATE-----` lines is ignored.
+	//
+	// Each certificate is validated before any of them are installed.
 	UpdateCertificates(certs string) error
+
+	// UpdateBundles replaces the set of named certificate bundles that jobs can
+	// reference as <name>.pem files under the instance certs directory.
+	// Bundles installed by a previous call that are not given are removed.
+	UpdateBundles(bundles map[string]string) error
+
+	// Certificates describes trusted certificates and bundles installed by the agent.
+	Certificates() ([]CertificateInfo, error)
 }
 
 type certManager struct {
+	store         certStore
 	fs            boshsys.FileSystem
 	runner        boshsys.CmdRunner
 	path          string
@@ -46,8 +58,9 @@ type certManager struct {
 	updateTimeout time.Duration
 }
 
-func NewUbuntuCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeout time.Duration, logger logger.Logger) Manager {
+func NewUbuntuCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, dirProvider boshdir.Provider, timeout time.Duration, logger logger.Logger) Manager {
 	return &certManager{
+		store:         newCertStore(fs, dirProvider),
 		fs:            fs,
 		runner:        runner,
 		path:          "/usr/local/share/ca-certificates/",
@@ -59,8 +72,9 @@ func NewUbuntuCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeo
 	}
 }
 
-func NewCentOSCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeout time.Duration, logger logger.Logger) Manager {
+func NewCentOSCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, dirProvider boshdir.Provider, timeout time.Duration, logger logger.Logger) Manager {
 	return &certManager{
+		store:         newCertStore(fs, dirProvider),
 		fs:            fs,
 		runner:        runner,
 		path:          "/etc/pki/ca-trust/source/anchors/",
@@ -71,8 +85,9 @@ func NewCentOSCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeo
 	}
 }
 
-func NewDummyCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, timeout time.Duration, logger logger.Logger) Manager {
+func NewDummyCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, dirProvider boshdir.Provider, timeout time.Duration, logger logger.Logger) Manager {
 	return &certManager{
+		store:         newCertStore(fs, dirProvider),
 		fs:            fs,
 		runner:        runner,
 		path:          "dummy",
@@ -90,6 +105,32 @@ func (c *certManager) UpdateCertificates(certs string) error {
 		return nil
 	}
 
+	_, err := ParseCertificates(certs)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating trusted certificates")
+	}
+
+	err = c.updateOSCertificates(certs)
+	if err != nil {
+		return err
+	}
+
+	return c.store.saveTrustedCerts(certs)
+}
+
+func (c *certManager) UpdateBundles(bundles map[string]string) error {
+	if c.updateCmdPath == "dummy" {
+		return nil
+	}
+
+	return c.store.updateBundles(bundles)
+}
+
+func (c *certManager) Certificates() ([]CertificateInfo, error) {
+	return c.store.certificates()
+}
+
+func (c *certManager) updateOSCertificates(certs string) error {
 	deletedFilesCount, err := deleteFiles(c.fs, c.path, "bosh-trusted-cert-")
 	c.logger.Debug(c.logTag, "Deleted %d existing certificate files", deletedFilesCount)
 	if err != nil {
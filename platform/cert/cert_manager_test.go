@@ -28,6 +28,27 @@ qokoSBXzJCJTt2P681gyqBDr/hUYzqpoXUsOTRisScbEbaSv8hTiTeFJUMyNQAqn
 DtmvI8bXKxU=
 -----END CERTIFICATE-----`
 
+const validCert string = `-----BEGIN CERTIFICATE-----
+MIIDSTCCAjGgAwIBAgIUG68zRMc3MXyBCUWQ1giyp4jYkMwwDQYJKoZIhvcNAQEL
+BQAwMzELMAkGA1UEBhMCVVMxDTALBgNVBAoMBEJPU0gxFTATBgNVBAMMDGJvc2gt
+dGVzdC1jYTAgFw0yNjEwMTkwODUxNTdaGA8yMTI2MDkyNTA4NTE1N1owMzELMAkG
+A1UEBhMCVVMxDTALBgNVBAoMBEJPU0gxFTATBgNVBAMMDGJvc2gtdGVzdC1jYTCC
+ASIwDQYJKoZIhvcNAQEBBQADggEPADCCAQoCggEBAMse6XIVzl3SfOGHHyPdceVx
+Hl+KjFAEcNyvPRGSJGTYWl5GlQr4FIJqDcZTEfW3tT4UZqVTM82uAJo4z7r+/kPM
++v+E/DjZ9L8kddndBLix8vtA9KURIXAiqkrw3Q7P39ADajQBJvRFSEdOZDpZlKok
+9eLMu5Zv/VE4ZkMyOHKtFPxhZB115xo/6jFZyoyqkB3i4ofdx3xHUMc6kygXFM7B
+ADHe7fUEoWQsUeN6S04qkXXOAwYDpb0QqpiL3lOyJaHsGzNfrVT5Af7XM4nx7xJS
+aXkgt/2c5q3ZXPHjvSIuk2YqEA0/DSBai4Q3ZhFtR0hZ7364f0JJPoxpTj5oPskC
+AwEAAaNTMFEwHQYDVR0OBBYEFOC0pm300b3uBHBUnGaVPhlzbj07MB8GA1UdIwQY
+MBaAFOC0pm300b3uBHBUnGaVPhlzbj07MA8GA1UdEwEB/wQFMAMBAf8wDQYJKoZI
+hvcNAQELBQADggEBALLkCwm0sUpqceh1C34+7PQl4D6oyfTbI24pUYLeS4FME6Ew
+Dfzo+evG9qfdlhoYyHrrOvukqmtFec/L7tGKkskbds7C0GiLVMu6wPVXF/ujhu7F
+euybbZ3Ez6sTICqqIrkh1AsofYkCgMUXj62NeZ5TLlerztWnO3e5eJjzJYp6Gznn
+MilW+ET9gJyx8ZlEWbrq/iHmktzTZ/2Jq55YWO6Q4NqfuZpP6YNb/X1jhGLlM/3k
+FCN1gmTbJPsiURksa8Rg9Vy4LvipPHmemY4Hg8K64ElvawmfY8gen6JvLxlDMu7p
+ap/+jNS51/Og+ct/+rcBCJb9Np+noVSJtCIv3h8=
+-----END CERTIFICATE-----`
+
 var _ = Describe("Certificate Management", func() {
 	var log logger.Logger
 	BeforeEach(func() {
@@ -168,22 +189,44 @@ var _ = Describe("Certificate Management", func() {
 		})
 	})
 
+	Describe("ParseCertificates", func() {
+		It("parses each certificate", func() {
+			certs, err := cert.ParseCertificates(fmt.Sprintf("%s\n%s\n", validCert, validCert))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(certs).To(HaveLen(2))
+			Expect(certs[0].Subject.CommonName).To(Equal("bosh-test-ca"))
+		})
+
+		It("returns no certificates for an empty string", func() {
+			certs, err := cert.ParseCertificates("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(certs).To(BeEmpty())
+		})
+
+		It("returns an error when a certificate cannot be parsed", func() {
+			_, err := cert.ParseCertificates(fmt.Sprintf("%s\n%s\n", validCert, cert1))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing certificate 2"))
+		})
+	})
+
 	Describe("cert.Manager implementations", func() {
 		var (
 			fakeFs        *fakesys.FakeFileSystem
 			fakeCmdRunner *fakesys.FakeCmdRunner
+			dirProvider   boshdir.Provider
 			certManager   cert.Manager
 		)
 
 		SharedLinuxCertManagerExamples := func(certBasePath, certUpdateProgram string) {
 			It("writes 1 cert to a file", func() {
-				err := certManager.UpdateCertificates(cert1)
+				err := certManager.UpdateCertificates(validCert)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fakeFs.FileExists(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(BeTrue())
 			})
 
 			It("writes each cert to its own file", func() {
-				certs := fmt.Sprintf("%s\n%s\n", cert1, cert1)
+				certs := fmt.Sprintf("%s\n%s\n", validCert, validCert)
 
 				err := certManager.UpdateCertificates(certs)
 				Expect(err).NotTo(HaveOccurred())
@@ -203,7 +246,7 @@ var _ = Describe("Certificate Management", func() {
 			})
 
 			It("deletes exisitng cert files before writing new ones", func() {
-				certs := fmt.Sprintf("%s\n%s\n", cert1, cert1)
+				certs := fmt.Sprintf("%s\n%s\n", validCert, validCert)
 				err := certManager.UpdateCertificates(certs)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fakeFs.FileExists(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(BeTrue())
@@ -214,14 +257,109 @@ var _ = Describe("Certificate Management", func() {
 					fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath),
 					fmt.Sprintf("%s/bosh-trusted-cert-2.crt", certBasePath),
 				})
-				certManager.UpdateCertificates(cert1)
+				certManager.UpdateCertificates(validCert)
 				Expect(fakeFs.FileExists(fmt.Sprintf("%s/bosh-trusted-cert-1.crt", certBasePath))).To(BeTrue())
 				Expect(countFiles(fakeFs, certBasePath)).To(Equal(1))
 			})
 
+			It("returns an error without replacing existing certs when passed an invalid cert", func() {
+				err := certManager.UpdateCertificates(validCert)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = certManager.UpdateCertificates(fmt.Sprintf("%s\n%s\n", validCert, cert1))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Parsing certificate 2"))
+				Expect(fakeFs.FileExists(fmt.Sprintf("%s/bosh-trusted-cert-2.crt", certBasePath))).To(BeFalse())
+
+				infos, err := certManager.Certificates()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(infos).To(HaveLen(1))
+			})
+
+			It("describes installed trusted certs", func() {
+				infos, err := certManager.Certificates()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(infos).To(BeEmpty())
+
+				err = certManager.UpdateCertificates(validCert)
+				Expect(err).NotTo(HaveOccurred())
+
+				infos, err = certManager.Certificates()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(infos).To(Equal([]cert.CertificateInfo{validCertInfo("")}))
+			})
+
+			Describe("UpdateBundles", func() {
+				It("writes each bundle to the instance certs dir", func() {
+					err := certManager.UpdateBundles(map[string]string{
+						"uaa":     validCert,
+						"routing": fmt.Sprintf("%s\n%s\n", validCert, validCert),
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := fakeFs.ReadFileString("/var/vcap/instance/certs/uaa.pem")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(contents).To(Equal(validCert))
+					Expect(fakeFs.FileExists("/var/vcap/instance/certs/routing.pem")).To(BeTrue())
+
+					infos, err := certManager.Certificates()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(infos).To(Equal([]cert.CertificateInfo{
+						validCertInfo("routing"),
+						validCertInfo("routing"),
+						validCertInfo("uaa"),
+					}))
+				})
+
+				It("removes previously installed bundles that are no longer given", func() {
+					err := certManager.UpdateBundles(map[string]string{"uaa": validCert, "routing": validCert})
+					Expect(err).NotTo(HaveOccurred())
+
+					err = certManager.UpdateBundles(map[string]string{"uaa": validCert})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeFs.FileExists("/var/vcap/instance/certs/uaa.pem")).To(BeTrue())
+					Expect(fakeFs.FileExists("/var/vcap/instance/certs/routing.pem")).To(BeFalse())
+				})
+
+				It("keeps trusted certs when updating bundles", func() {
+					err := certManager.UpdateCertificates(validCert)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = certManager.UpdateBundles(map[string]string{"uaa": validCert})
+					Expect(err).NotTo(HaveOccurred())
+
+					infos, err := certManager.Certificates()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(infos).To(Equal([]cert.CertificateInfo{validCertInfo(""), validCertInfo("uaa")}))
+				})
+
+				It("returns an error without writing any bundle when a bundle name is invalid", func() {
+					err := certManager.UpdateBundles(map[string]string{"../uaa": validCert})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Invalid certificate bundle name '../uaa'"))
+					Expect(fakeFs.FileExists("/var/vcap/instance/uaa.pem")).To(BeFalse())
+				})
+
+				It("returns an error without writing any bundle when a bundle contains an invalid cert", func() {
+					err := certManager.UpdateBundles(map[string]string{"uaa": cert1})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Validating certificate bundle 'uaa'"))
+					Expect(fakeFs.FileExists("/var/vcap/instance/certs/uaa.pem")).To(BeFalse())
+				})
+
+				It("returns an error when writing a bundle fails", func() {
+					fakeFs.WriteFileError = errors.New("fake-write-err")
+
+					err := certManager.UpdateBundles(map[string]string{"uaa": validCert})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+				})
+			})
+
 			It("returns an error when writing new cert files fails", func() {
 				fakeFs.WriteFileError = errors.New("NOT ALLOW")
-				err := certManager.UpdateCertificates(cert1)
+				err := certManager.UpdateCertificates(validCert)
 				Expect(err).To(HaveOccurred())
 			})
 
@@ -249,13 +387,14 @@ var _ = Describe("Certificate Management", func() {
 			BeforeEach(func() {
 				fakeFs = fakesys.NewFakeFileSystem()
 				fakeCmdRunner = fakesys.NewFakeCmdRunner()
+				dirProvider = boshdir.NewProvider("/var/vcap")
 				fakeCmdRunner.AddCmdResult("/usr/sbin/update-ca-certificates", fakesys.FakeCmdResult{
 					Stdout:     "",
 					Stderr:     "",
 					ExitStatus: 0,
 					Sticky:     true,
 				})
-				certManager = cert.NewUbuntuCertManager(fakeFs, fakeCmdRunner, 1, log)
+				certManager = cert.NewUbuntuCertManager(fakeFs, fakeCmdRunner, dirProvider, 1, log)
 				fakeResult = boshsys.Result{
 					Stdout:     "",
 					Stderr:     "",
@@ -275,7 +414,7 @@ var _ = Describe("Certificate Management", func() {
 			SharedLinuxCertManagerExamples("/usr/local/share/ca-certificates", "/usr/sbin/update-ca-certificates")
 
 			It("updates certs", func() {
-				err := certManager.UpdateCertificates(cert1)
+				err := certManager.UpdateCertificates(validCert)
 
 				Expect(fakeProcess1.Waited).To(BeTrue())
 				Expect(fakeProcess1.TerminatedNicely).To(BeFalse())
@@ -289,7 +428,7 @@ var _ = Describe("Certificate Management", func() {
 
 				fakeProcess1.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {}
 
-				err := certManager.UpdateCertificates(cert1)
+				err := certManager.UpdateCertificates(validCert)
 
 				Expect(fakeProcess1.Waited).To(BeTrue())
 				Expect(fakeProcess1.TerminatedNicely).To(BeTrue())
@@ -309,7 +448,7 @@ var _ = Describe("Certificate Management", func() {
 				fakeProcess2.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {}
 				fakeProcess3.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {}
 
-				err := certManager.UpdateCertificates(cert1)
+				err := certManager.UpdateCertificates(validCert)
 
 				Expect(fakeProcess1.Waited).To(BeTrue())
 				Expect(fakeProcess1.TerminatedNicely).To(BeTrue())
@@ -329,13 +468,14 @@ var _ = Describe("Certificate Management", func() {
 			BeforeEach(func() {
 				fakeFs = fakesys.NewFakeFileSystem()
 				fakeCmdRunner = fakesys.NewFakeCmdRunner()
+				dirProvider = boshdir.NewProvider("/var/vcap")
 				fakeCmdRunner.AddCmdResult("/usr/bin/update-ca-trust", fakesys.FakeCmdResult{
 					Stdout:     "",
 					Stderr:     "",
 					ExitStatus: 0,
 					Sticky:     true,
 				})
-				certManager = cert.NewCentOSCertManager(fakeFs, fakeCmdRunner, 0, log)
+				certManager = cert.NewCentOSCertManager(fakeFs, fakeCmdRunner, dirProvider, 0, log)
 			})
 
 			SharedLinuxCertManagerExamples("/etc/pki/ca-trust/source/anchors", "/usr/bin/update-ca-trust")
@@ -348,9 +488,9 @@ var _ = Describe("Certificate Management", func() {
 					ExitStatus: 2,
 					Error:      errors.New("command failed"),
 				})
-				certManager = cert.NewCentOSCertManager(fakeFs, fakeCmdRunner, 0, log)
+				certManager = cert.NewCentOSCertManager(fakeFs, fakeCmdRunner, dirProvider, 0, log)
 
-				err := certManager.UpdateCertificates(cert1)
+				err := certManager.UpdateCertificates(validCert)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -493,3 +633,11 @@ func countFiles(fs system.FileSystem, dir string) (count int) {
 	})
 	return
 }
+
+func validCertInfo(bundle string) cert.CertificateInfo {
+	return cert.CertificateInfo{
+		Bundle:   bundle,
+		Subject:  "CN=bosh-test-ca,O=BOSH,C=US",
+		NotAfter: time.Date(2126, time.September, 25, 8, 51, 57, 0, time.UTC),
+	}
+}
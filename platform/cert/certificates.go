@@ -0,0 +1,206 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CertificateInfo describes a single certificate installed by the agent.
+// Bundle is empty for certificates trusted by the operating system.
+type CertificateInfo struct {
+	Bundle   string    `json:"bundle,omitempty"`
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+var bundleNameRegexp = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9._-]*\z`)
+
+// ParseCertificates returns each X.509 certificate found in PEM encoded certs.
+// An error is returned if any of the certificates cannot be parsed.
+func ParseCertificates(certs string) ([]*x509.Certificate, error) {
+	var result []*x509.Certificate
+
+	for i, cert := range splitCerts(certs) {
+		block, _ := pem.Decode([]byte(cert))
+		if block == nil {
+			return nil, bosherr.Errorf("Decoding certificate %d", i+1)
+		}
+
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing certificate %d", i+1)
+		}
+
+		result = append(result, parsed)
+	}
+
+	return result, nil
+}
+
+// DescribeCertificates returns subject and expiry of each certificate in PEM encoded certs
+func DescribeCertificates(bundle, certs string) ([]CertificateInfo, error) {
+	parsed, err := ParseCertificates(certs)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []CertificateInfo
+
+	for _, cert := range parsed {
+		infos = append(infos, CertificateInfo{
+			Bundle:   bundle,
+			Subject:  cert.Subject.String(),
+			NotAfter: cert.NotAfter.UTC(),
+		})
+	}
+
+	return infos, nil
+}
+
+type installedCerts struct {
+	TrustedCerts string            `json:"trusted_certs"`
+	Bundles      map[string]string `json:"bundles,omitempty"`
+}
+
+// certStore keeps track of certificates installed by a Manager
+// and writes named bundles that jobs can reference
+type certStore struct {
+	fs         boshsys.FileSystem
+	statePath  string
+	bundlesDir string
+}
+
+func newCertStore(fs boshsys.FileSystem, dirProvider boshdir.Provider) certStore {
+	return certStore{
+		fs:         fs,
+		statePath:  filepath.Join(dirProvider.BoshDir(), "installed_certs.json"),
+		bundlesDir: dirProvider.InstanceCertsDir(),
+	}
+}
+
+func (s certStore) load() (installedCerts, error) {
+	var state installedCerts
+
+	if !s.fs.FileExists(s.statePath) {
+		return state, nil
+	}
+
+	bytes, err := s.fs.ReadFile(s.statePath)
+	if err != nil {
+		return state, bosherr.WrapError(err, "Reading installed certificates")
+	}
+
+	err = json.Unmarshal(bytes, &state)
+	if err != nil {
+		return state, bosherr.WrapError(err, "Unmarshalling installed certificates")
+	}
+
+	return state, nil
+}
+
+func (s certStore) save(state installedCerts) error {
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling installed certificates")
+	}
+
+	err = s.fs.WriteFile(s.statePath, bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing installed certificates")
+	}
+
+	return nil
+}
+
+func (s certStore) saveTrustedCerts(certs string) error {
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	state.TrustedCerts = certs
+
+	return s.save(state)
+}
+
+func (s certStore) updateBundles(bundles map[string]string) error {
+	for name, certs := range bundles {
+		if !bundleNameRegexp.MatchString(name) {
+			return bosherr.Errorf("Invalid certificate bundle name '%s'", name)
+		}
+
+		_, err := ParseCertificates(certs)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating certificate bundle '%s'", name)
+		}
+	}
+
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for name, certs := range bundles {
+		err = s.fs.WriteFileString(s.bundlePath(name), certs)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing certificate bundle '%s'", name)
+		}
+	}
+
+	for name := range state.Bundles {
+		if _, found := bundles[name]; found {
+			continue
+		}
+
+		err = s.fs.RemoveAll(s.bundlePath(name))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing certificate bundle '%s'", name)
+		}
+	}
+
+	state.Bundles = bundles
+
+	return s.save(state)
+}
+
+func (s certStore) bundlePath(name string) string {
+	return filepath.Join(s.bundlesDir, name+".pem")
+}
+
+func (s certStore) certificates() ([]CertificateInfo, error) {
+	state, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := DescribeCertificates("", state.TrustedCerts)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Describing trusted certificates")
+	}
+
+	var names []string
+	for name := range state.Bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		bundleInfos, err := DescribeCertificates(name, state.Bundles[name])
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Describing certificate bundle '%s'", name)
+		}
+
+		infos = append(infos, bundleInfos...)
+	}
+
+	return infos, nil
+}
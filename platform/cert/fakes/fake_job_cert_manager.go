@@ -0,0 +1,97 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/cert"
+)
+
+type FakeJobCertManager struct {
+	RequestCertificateStub        func(policy cert.CertificatePolicy) (string, error)
+	requestCertificateMutex       sync.RWMutex
+	requestCertificateArgsForCall []struct {
+		policy cert.CertificatePolicy
+	}
+	requestCertificateReturns struct {
+		result1 string
+		result2 error
+	}
+	InstallCertificateStub        func(signed cert.SignedCertificate) (cert.CertificatePolicy, error)
+	installCertificateMutex       sync.RWMutex
+	installCertificateArgsForCall []struct {
+		signed cert.SignedCertificate
+	}
+	installCertificateReturns struct {
+		result1 cert.CertificatePolicy
+		result2 error
+	}
+}
+
+func (fake *FakeJobCertManager) RequestCertificate(policy cert.CertificatePolicy) (string, error) {
+	fake.requestCertificateMutex.Lock()
+	fake.requestCertificateArgsForCall = append(fake.requestCertificateArgsForCall, struct {
+		policy cert.CertificatePolicy
+	}{policy})
+	fake.requestCertificateMutex.Unlock()
+	if fake.RequestCertificateStub != nil {
+		return fake.RequestCertificateStub(policy)
+	} else {
+		return fake.requestCertificateReturns.result1, fake.requestCertificateReturns.result2
+	}
+}
+
+func (fake *FakeJobCertManager) RequestCertificateCallCount() int {
+	fake.requestCertificateMutex.RLock()
+	defer fake.requestCertificateMutex.RUnlock()
+	return len(fake.requestCertificateArgsForCall)
+}
+
+func (fake *FakeJobCertManager) RequestCertificateArgsForCall(i int) cert.CertificatePolicy {
+	fake.requestCertificateMutex.RLock()
+	defer fake.requestCertificateMutex.RUnlock()
+	return fake.requestCertificateArgsForCall[i].policy
+}
+
+func (fake *FakeJobCertManager) RequestCertificateReturns(result1 string, result2 error) {
+	fake.RequestCertificateStub = nil
+	fake.requestCertificateReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeJobCertManager) InstallCertificate(signed cert.SignedCertificate) (cert.CertificatePolicy, error) {
+	fake.installCertificateMutex.Lock()
+	fake.installCertificateArgsForCall = append(fake.installCertificateArgsForCall, struct {
+		signed cert.SignedCertificate
+	}{signed})
+	fake.installCertificateMutex.Unlock()
+	if fake.InstallCertificateStub != nil {
+		return fake.InstallCertificateStub(signed)
+	} else {
+		return fake.installCertificateReturns.result1, fake.installCertificateReturns.result2
+	}
+}
+
+func (fake *FakeJobCertManager) InstallCertificateCallCount() int {
+	fake.installCertificateMutex.RLock()
+	defer fake.installCertificateMutex.RUnlock()
+	return len(fake.installCertificateArgsForCall)
+}
+
+func (fake *FakeJobCertManager) InstallCertificateArgsForCall(i int) cert.SignedCertificate {
+	fake.installCertificateMutex.RLock()
+	defer fake.installCertificateMutex.RUnlock()
+	return fake.installCertificateArgsForCall[i].signed
+}
+
+func (fake *FakeJobCertManager) InstallCertificateReturns(result1 cert.CertificatePolicy, result2 error) {
+	fake.InstallCertificateStub = nil
+	fake.installCertificateReturns = struct {
+		result1 cert.CertificatePolicy
+		result2 error
+	}{result1, result2}
+}
+
+var _ cert.JobCertManager = new(FakeJobCertManager)
@@ -16,6 +16,21 @@ type FakeManager struct {
 	updateCertificatesReturns struct {
 		result1 error
 	}
+	UpdateBundlesStub        func(bundles map[string]string) error
+	updateBundlesMutex       sync.RWMutex
+	updateBundlesArgsForCall []struct {
+		bundles map[string]string
+	}
+	updateBundlesReturns struct {
+		result1 error
+	}
+	CertificatesStub        func() ([]cert.CertificateInfo, error)
+	certificatesMutex       sync.RWMutex
+	certificatesArgsForCall []struct{}
+	certificatesReturns     struct {
+		result1 []cert.CertificateInfo
+		result2 error
+	}
 }
 
 func (fake *FakeManager) UpdateCertificates(certs string) error {
@@ -50,4 +65,61 @@ func (fake *FakeManager) UpdateCertificatesReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeManager) UpdateBundles(bundles map[string]string) error {
+	fake.updateBundlesMutex.Lock()
+	fake.updateBundlesArgsForCall = append(fake.updateBundlesArgsForCall, struct {
+		bundles map[string]string
+	}{bundles})
+	fake.updateBundlesMutex.Unlock()
+	if fake.UpdateBundlesStub != nil {
+		return fake.UpdateBundlesStub(bundles)
+	} else {
+		return fake.updateBundlesReturns.result1
+	}
+}
+
+func (fake *FakeManager) UpdateBundlesCallCount() int {
+	fake.updateBundlesMutex.RLock()
+	defer fake.updateBundlesMutex.RUnlock()
+	return len(fake.updateBundlesArgsForCall)
+}
+
+func (fake *FakeManager) UpdateBundlesArgsForCall(i int) map[string]string {
+	fake.updateBundlesMutex.RLock()
+	defer fake.updateBundlesMutex.RUnlock()
+	return fake.updateBundlesArgsForCall[i].bundles
+}
+
+func (fake *FakeManager) UpdateBundlesReturns(result1 error) {
+	fake.UpdateBundlesStub = nil
+	fake.updateBundlesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) Certificates() ([]cert.CertificateInfo, error) {
+	fake.certificatesMutex.Lock()
+	fake.certificatesArgsForCall = append(fake.certificatesArgsForCall, struct{}{})
+	fake.certificatesMutex.Unlock()
+	if fake.CertificatesStub != nil {
+		return fake.CertificatesStub()
+	} else {
+		return fake.certificatesReturns.result1, fake.certificatesReturns.result2
+	}
+}
+
+func (fake *FakeManager) CertificatesCallCount() int {
+	fake.certificatesMutex.RLock()
+	defer fake.certificatesMutex.RUnlock()
+	return len(fake.certificatesArgsForCall)
+}
+
+func (fake *FakeManager) CertificatesReturns(result1 []cert.CertificateInfo, result2 error) {
+	fake.CertificatesStub = nil
+	fake.certificatesReturns = struct {
+		result1 []cert.CertificateInfo
+		result2 error
+	}{result1, result2}
+}
+
 var _ cert.Manager = new(FakeManager)
@@ -0,0 +1,378 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	KeyTypeRSA   = "rsa"
+	KeyTypeECDSA = "ecdsa"
+
+	defaultRSAKeyBits = 2048
+
+	jobCertPendingDirMode  = os.FileMode(0700)
+	jobCertPendingFileMode = os.FileMode(0600)
+	jobCertDirMode         = os.FileMode(0755)
+	jobCertKeyFileMode     = os.FileMode(0640)
+	jobCertKeyOwner        = "root:vcap"
+)
+
+// CertificatePolicy describes a certificate that director wants issued for a job
+type CertificatePolicy struct {
+	Job  string `json:"job"`
+	Name string `json:"name"`
+
+	CommonName       string   `json:"common_name"`
+	AlternativeNames []string `json:"alternative_names,omitempty"`
+
+	// Defaults to RSA with 2048 bits
+	KeyType string `json:"key_type,omitempty"`
+	KeyBits int    `json:"key_bits,omitempty"`
+
+	// Job script (e.g. "reload-certs") run after certificate is installed
+	ReloadScript string `json:"reload_script,omitempty"`
+}
+
+// SignedCertificate is a certificate signed by director for a previously requested policy
+type SignedCertificate struct {
+	Job  string `json:"job"`
+	Name string `json:"name"`
+
+	Certificate string `json:"certificate"`
+	CA          string `json:"ca,omitempty"`
+}
+
+//go:generate counterfeiter . JobCertManager
+
+// JobCertManager issues certificates for jobs. Private keys are generated
+// on the VM and only certificate signing requests are handed out.
+type JobCertManager interface {
+	// RequestCertificate generates a new private key for policy
+	// and returns PEM encoded certificate signing request
+	RequestCertificate(policy CertificatePolicy) (string, error)
+
+	// InstallCertificate writes signed certificate and its pending private key
+	// into job's certs directory (e.g. /var/vcap/instance/job_certs/<job>)
+	// and returns policy it was requested with
+	InstallCertificate(signed SignedCertificate) (CertificatePolicy, error)
+}
+
+type pendingCertificate struct {
+	Policy     CertificatePolicy `json:"policy"`
+	PrivateKey string            `json:"private_key"`
+}
+
+type jobCertManager struct {
+	fs          boshsys.FileSystem
+	dirProvider boshdir.Provider
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewJobCertManager(fs boshsys.FileSystem, dirProvider boshdir.Provider, logger boshlog.Logger) JobCertManager {
+	return jobCertManager{
+		fs:          fs,
+		dirProvider: dirProvider,
+
+		logTag: "jobCertManager",
+		logger: logger,
+	}
+}
+
+func (m jobCertManager) RequestCertificate(policy CertificatePolicy) (string, error) {
+	err := m.validateNames(policy.Job, policy.Name)
+	if err != nil {
+		return "", err
+	}
+
+	if policy.CommonName == "" {
+		return "", bosherr.Errorf("Missing common name for certificate '%s' of job '%s'", policy.Name, policy.Job)
+	}
+
+	key, keyPEM, err := m.generateKey(policy)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Generating private key for certificate '%s' of job '%s'", policy.Name, policy.Job)
+	}
+
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: policy.CommonName},
+	}
+
+	for _, name := range policy.AlternativeNames {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating certificate request '%s' of job '%s'", policy.Name, policy.Job)
+	}
+
+	pendingBytes, err := json.Marshal(pendingCertificate{Policy: policy, PrivateKey: keyPEM})
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling pending certificate")
+	}
+
+	pendingPath := m.pendingPath(policy.Job, policy.Name)
+
+	err = m.fs.MkdirAll(filepath.Dir(pendingPath), jobCertPendingDirMode)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating pending certificates directory")
+	}
+
+	// Newer request replaces previous one so that only latest private key can be installed
+	err = m.writePrivateFile(pendingPath, pendingBytes, jobCertPendingFileMode)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Saving pending certificate")
+	}
+
+	m.logger.Info(m.logTag, "Requested certificate '%s' of job '%s'", policy.Name, policy.Job)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})), nil
+}
+
+func (m jobCertManager) InstallCertificate(signed SignedCertificate) (CertificatePolicy, error) {
+	err := m.validateNames(signed.Job, signed.Name)
+	if err != nil {
+		return CertificatePolicy{}, err
+	}
+
+	pendingPath := m.pendingPath(signed.Job, signed.Name)
+
+	if !m.fs.FileExists(pendingPath) {
+		return CertificatePolicy{}, bosherr.Errorf("No pending request for certificate '%s' of job '%s'", signed.Name, signed.Job)
+	}
+
+	pendingBytes, err := m.fs.ReadFile(pendingPath)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Reading pending certificate")
+	}
+
+	var pending pendingCertificate
+
+	err = json.Unmarshal(pendingBytes, &pending)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Unmarshalling pending certificate")
+	}
+
+	err = m.verifyCertificate(signed, pending.PrivateKey)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapErrorf(err, "Verifying certificate '%s' of job '%s'", signed.Name, signed.Job)
+	}
+
+	certsDir := m.dirProvider.InstanceJobCertsDir(signed.Job)
+
+	err = m.fs.MkdirAll(certsDir, jobCertDirMode)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Creating job certificates directory")
+	}
+
+	err = m.fs.WriteFileString(filepath.Join(certsDir, signed.Name+".crt"), signed.Certificate)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Writing certificate")
+	}
+
+	keyPath := filepath.Join(certsDir, signed.Name+".key")
+
+	err = m.writePrivateFile(keyPath, []byte(pending.PrivateKey), jobCertKeyFileMode)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Writing private key")
+	}
+
+	// Jobs run as vcap user and need to read their keys
+	err = m.fs.Chown(keyPath, jobCertKeyOwner)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Chowning private key")
+	}
+
+	if signed.CA != "" {
+		err = m.fs.WriteFileString(filepath.Join(certsDir, signed.Name+"-ca.crt"), signed.CA)
+		if err != nil {
+			return CertificatePolicy{}, bosherr.WrapError(err, "Writing CA certificate")
+		}
+	}
+
+	err = m.fs.RemoveAll(pendingPath)
+	if err != nil {
+		return CertificatePolicy{}, bosherr.WrapError(err, "Removing pending certificate")
+	}
+
+	m.logger.Info(m.logTag, "Installed certificate '%s' of job '%s'", signed.Name, signed.Job)
+
+	return pending.Policy, nil
+}
+
+func (m jobCertManager) generateKey(policy CertificatePolicy) (crypto.Signer, string, error) {
+	var key crypto.Signer
+	var block *pem.Block
+
+	switch policy.KeyType {
+	case "", KeyTypeRSA:
+		bits := policy.KeyBits
+		if bits == 0 {
+			bits = defaultRSAKeyBits
+		}
+
+		if bits < defaultRSAKeyBits {
+			return nil, "", bosherr.Errorf("RSA keys must have at least %d bits, got %d", defaultRSAKeyBits, bits)
+		}
+
+		rsaKey, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, "", err
+		}
+
+		key = rsaKey
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}
+
+	case KeyTypeECDSA:
+		ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, "", err
+		}
+
+		der, err := x509.MarshalECPrivateKey(ecdsaKey)
+		if err != nil {
+			return nil, "", err
+		}
+
+		key = ecdsaKey
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+
+	default:
+		return nil, "", bosherr.Errorf("Unknown key type '%s'", policy.KeyType)
+	}
+
+	return key, string(pem.EncodeToMemory(block)), nil
+}
+
+func (m jobCertManager) verifyCertificate(signed SignedCertificate, keyPEM string) error {
+	certs, err := ParseCertificates(signed.Certificate)
+	if err != nil {
+		return err
+	}
+
+	if len(certs) == 0 {
+		return bosherr.Error("Missing certificate")
+	}
+
+	if signed.CA != "" {
+		_, err = ParseCertificates(signed.CA)
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing CA certificate")
+		}
+	}
+
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return bosherr.Error("Decoding pending private key")
+	}
+
+	var publicKey interface{}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing pending private key")
+		}
+		publicKey = key.Public()
+
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing pending private key")
+		}
+		publicKey = key.Public()
+
+	default:
+		return bosherr.Errorf("Unknown pending private key type '%s'", block.Type)
+	}
+
+	expected, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling pending public key")
+	}
+
+	actual, err := x509.MarshalPKIXPublicKey(certs[0].PublicKey)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling certificate public key")
+	}
+
+	if !bytes.Equal(expected, actual) {
+		return bosherr.Error("Certificate does not match pending private key")
+	}
+
+	return nil
+}
+
+// writePrivateFile creates a new file with restrictive mode and renames it into place
+// so that private key contents are never readable by others, not even temporarily
+func (m jobCertManager) writePrivateFile(path string, contents []byte, mode os.FileMode) error {
+	stagedPath := path + ".tmp"
+
+	err := m.fs.RemoveAll(stagedPath)
+	if err != nil {
+		return err
+	}
+
+	file, err := m.fs.OpenFile(stagedPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(contents)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = m.fs.RemoveAll(stagedPath)
+		return err
+	}
+
+	// Mode passed to OpenFile is subject to umask
+	err = m.fs.Chmod(stagedPath, mode)
+	if err != nil {
+		return err
+	}
+
+	return m.fs.Rename(stagedPath, path)
+}
+
+func (m jobCertManager) validateNames(job, name string) error {
+	if !bundleNameRegexp.MatchString(job) {
+		return bosherr.Errorf("Invalid job name '%s'", job)
+	}
+
+	if !bundleNameRegexp.MatchString(name) {
+		return bosherr.Errorf("Invalid certificate name '%s'", name)
+	}
+
+	return nil
+}
+
+func (m jobCertManager) pendingPath(job, name string) string {
+	return filepath.Join(m.dirProvider.BoshDir(), "job_certs", job, name+".json")
+}
@@ -0,0 +1,317 @@
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	"github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("jobCertManager", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		manager cert.JobCertManager
+
+		caKey  *ecdsa.PrivateKey
+		caCert *x509.Certificate
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		manager = cert.NewJobCertManager(fs, boshdir.NewProvider("/var/vcap"), logger.NewLogger(logger.LevelNone))
+
+		var err error
+
+		caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		caTemplate := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "fake-ca"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+
+		caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		caCert, err = x509.ParseCertificate(caDER)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	encodeCert := func(der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	parseCSR := func(csrPEM string) *x509.CertificateRequest {
+		block, _ := pem.Decode([]byte(csrPEM))
+		Expect(block).ToNot(BeNil())
+		Expect(block.Type).To(Equal("CERTIFICATE REQUEST"))
+
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(csr.CheckSignature()).To(Succeed())
+
+		return csr
+	}
+
+	sign := func(csrPEM string) string {
+		csr := parseCSR(csrPEM)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			IPAddresses:  csr.IPAddresses,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, caKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		return encodeCert(der)
+	}
+
+	Describe("RequestCertificate", func() {
+		It("returns certificate request for policy and keeps private key pending on the VM", func() {
+			csrPEM, err := manager.RequestCertificate(cert.CertificatePolicy{
+				Job:              "fake-job",
+				Name:             "server",
+				CommonName:       "fake-job.internal",
+				AlternativeNames: []string{"fake-job.service", "10.0.0.5"},
+				KeyType:          cert.KeyTypeECDSA,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(csrPEM).ToNot(ContainSubstring("PRIVATE KEY"))
+
+			csr := parseCSR(csrPEM)
+			Expect(csr.Subject.CommonName).To(Equal("fake-job.internal"))
+			Expect(csr.DNSNames).To(Equal([]string{"fake-job.service"}))
+			Expect(csr.IPAddresses).To(HaveLen(1))
+			Expect(csr.IPAddresses[0].Equal(net.ParseIP("10.0.0.5"))).To(BeTrue())
+
+			pendingStats := fs.GetFileTestStat("/var/vcap/bosh/job_certs/fake-job/server.json")
+			Expect(pendingStats).ToNot(BeNil())
+			Expect(pendingStats.FileMode).To(Equal(os.FileMode(0600)))
+			Expect(pendingStats.Flags).To(Equal(os.O_WRONLY | os.O_CREATE | os.O_EXCL))
+			Expect(fs.FileExists("/var/vcap/bosh/job_certs/fake-job/server.json.tmp")).To(BeFalse())
+
+			pendingDirStats := fs.GetFileTestStat("/var/vcap/bosh/job_certs/fake-job")
+			Expect(pendingDirStats).ToNot(BeNil())
+			Expect(pendingDirStats.FileMode).To(Equal(os.FileMode(0700)))
+			Expect(pendingStats.StringContents()).To(ContainSubstring("EC PRIVATE KEY"))
+		})
+
+		It("generates 2048 bit RSA keys by default", func() {
+			csrPEM, err := manager.RequestCertificate(cert.CertificatePolicy{
+				Job:        "fake-job",
+				Name:       "server",
+				CommonName: "fake-job.internal",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			publicKey, ok := parseCSR(csrPEM).PublicKey.(*rsa.PublicKey)
+			Expect(ok).To(BeTrue())
+			Expect(publicKey.N.BitLen()).To(Equal(2048))
+		})
+
+		It("rejects weak RSA keys", func() {
+			_, err := manager.RequestCertificate(cert.CertificatePolicy{
+				Job:        "fake-job",
+				Name:       "server",
+				CommonName: "fake-job.internal",
+				KeyBits:    1024,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("RSA keys must have at least 2048 bits, got 1024"))
+		})
+
+		It("rejects unknown key types", func() {
+			_, err := manager.RequestCertificate(cert.CertificatePolicy{
+				Job:        "fake-job",
+				Name:       "server",
+				CommonName: "fake-job.internal",
+				KeyType:    "dsa",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown key type 'dsa'"))
+		})
+
+		It("rejects job and certificate names that escape job directory", func() {
+			_, err := manager.RequestCertificate(cert.CertificatePolicy{Job: "../fake-job", Name: "server", CommonName: "fake"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid job name '../fake-job'"))
+
+			_, err = manager.RequestCertificate(cert.CertificatePolicy{Job: "fake-job", Name: "a/b", CommonName: "fake"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid certificate name 'a/b'"))
+		})
+
+		It("requires common name", func() {
+			_, err := manager.RequestCertificate(cert.CertificatePolicy{Job: "fake-job", Name: "server"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Missing common name for certificate 'server' of job 'fake-job'"))
+		})
+
+		It("returns error when pending certificate cannot be saved", func() {
+			fs.OpenFileErr = errors.New("fake-write-err")
+
+			_, err := manager.RequestCertificate(cert.CertificatePolicy{
+				Job:        "fake-job",
+				Name:       "server",
+				CommonName: "fake-job.internal",
+				KeyType:    cert.KeyTypeECDSA,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+		})
+	})
+
+	Describe("InstallCertificate", func() {
+		var (
+			policy cert.CertificatePolicy
+			csrPEM string
+		)
+
+		BeforeEach(func() {
+			policy = cert.CertificatePolicy{
+				Job:          "fake-job",
+				Name:         "server",
+				CommonName:   "fake-job.internal",
+				KeyType:      cert.KeyTypeECDSA,
+				ReloadScript: "reload-certs",
+			}
+
+			var err error
+
+			csrPEM, err = manager.RequestCertificate(policy)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("installs certificate, pending private key and CA into job certs directory", func() {
+			signedPEM := sign(csrPEM)
+			caPEM := encodeCert(caCert.Raw)
+
+			pendingContents, err := fs.ReadFile("/var/vcap/bosh/job_certs/fake-job/server.json")
+			Expect(err).ToNot(HaveOccurred())
+
+			var pending map[string]interface{}
+			Expect(json.Unmarshal(pendingContents, &pending)).To(Succeed())
+
+			installedPolicy, err := manager.InstallCertificate(cert.SignedCertificate{
+				Job:         "fake-job",
+				Name:        "server",
+				Certificate: signedPEM,
+				CA:          caPEM,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(installedPolicy).To(Equal(policy))
+
+			contents, err := fs.ReadFileString("/var/vcap/instance/job_certs/fake-job/server.crt")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(signedPEM))
+
+			keyStats := fs.GetFileTestStat("/var/vcap/instance/job_certs/fake-job/server.key")
+			Expect(keyStats).ToNot(BeNil())
+			Expect(keyStats.StringContents()).To(Equal(pending["private_key"]))
+			Expect(keyStats.FileMode).To(Equal(os.FileMode(0640)))
+			Expect(keyStats.Flags).To(Equal(os.O_WRONLY | os.O_CREATE | os.O_EXCL))
+			Expect(keyStats.Username).To(Equal("root"))
+			Expect(keyStats.Groupname).To(Equal("vcap"))
+
+			contents, err = fs.ReadFileString("/var/vcap/instance/job_certs/fake-job/server-ca.crt")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(caPEM))
+
+			Expect(fs.FileExists("/var/vcap/bosh/job_certs/fake-job/server.json")).To(BeFalse())
+		})
+
+		It("rejects certificate that does not match pending private key", func() {
+			otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(3),
+				Subject:      pkix.Name{CommonName: "fake-job.internal"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+
+			der, err := x509.CreateCertificate(rand.Reader, template, caCert, otherKey.Public(), caKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = manager.InstallCertificate(cert.SignedCertificate{
+				Job:         "fake-job",
+				Name:        "server",
+				Certificate: encodeCert(der),
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Certificate does not match pending private key"))
+
+			Expect(fs.FileExists("/var/vcap/instance/job_certs/fake-job/server.key")).To(BeFalse())
+			Expect(fs.FileExists("/var/vcap/bosh/job_certs/fake-job/server.json")).To(BeTrue())
+		})
+
+		It("rejects certificates that cannot be parsed", func() {
+			_, err := manager.InstallCertificate(cert.SignedCertificate{
+				Job:         "fake-job",
+				Name:        "server",
+				Certificate: cert1,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying certificate 'server' of job 'fake-job'"))
+
+			_, err = manager.InstallCertificate(cert.SignedCertificate{
+				Job:         "fake-job",
+				Name:        "server",
+				Certificate: "",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Missing certificate"))
+		})
+
+		It("rejects certificates without pending request", func() {
+			_, err := manager.InstallCertificate(cert.SignedCertificate{
+				Job:         "fake-job",
+				Name:        "client",
+				Certificate: sign(csrPEM),
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No pending request for certificate 'client' of job 'fake-job'"))
+		})
+
+		It("returns error when certificate cannot be written", func() {
+			signedPEM := sign(csrPEM)
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			_, err := manager.InstallCertificate(cert.SignedCertificate{
+				Job:         "fake-job",
+				Name:        "server",
+				Certificate: signedPEM,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+		})
+	})
+})
@@ -7,11 +7,13 @@ import (
 	"strconv"
 
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type windowsCertManager struct {
+	store       certStore
 	fs          boshsys.FileSystem
 	runner      boshsys.CmdRunner
 	dirProvider boshdir.Provider
@@ -23,6 +25,7 @@ const rootCertStore string = `Cert:\LocalMachine\Root`
 
 func NewWindowsCertManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, dirProvider boshdir.Provider, logger logger.Logger) Manager {
 	return &windowsCertManager{
+		store:       newCertStore(fs, dirProvider),
 		fs:          fs,
 		runner:      runner,
 		dirProvider: dirProvider,
@@ -61,7 +64,12 @@ func (c *windowsCertManager) resetCerts() error {
 }
 
 func (c *windowsCertManager) UpdateCertificates(rawCerts string) error {
-	err := c.createBackup()
+	_, err := ParseCertificates(rawCerts)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating trusted certificates")
+	}
+
+	err = c.createBackup()
 	if err != nil {
 		return err
 	}
@@ -90,5 +98,13 @@ func (c *windowsCertManager) UpdateCertificates(rawCerts string) error {
 			return err
 		}
 	}
-	return nil
+	return c.store.saveTrustedCerts(rawCerts)
+}
+
+func (c *windowsCertManager) UpdateBundles(bundles map[string]string) error {
+	return c.store.updateBundles(bundles)
+}
+
+func (c *windowsCertManager) Certificates() ([]CertificateInfo, error) {
+	return c.store.certificates()
 }
@@ -9,7 +9,9 @@ import (
 type FakeDiskManager struct {
 	FakePartitioner           *FakePartitioner
 	FakeFormatter             *FakeFormatter
+	FakeFilesystemChecker     *FakeFilesystemChecker
 	FakeMounter               *FakeMounter
+	FakeFreezer               *FakeFreezer
 	FakeMountsSearcher        *FakeMountsSearcher
 	FakeRootDevicePartitioner *FakePartitioner
 	FakeDiskUtil              *fakedevutil.FakeDeviceUtil
@@ -22,7 +24,9 @@ func NewFakeDiskManager() *FakeDiskManager {
 	return &FakeDiskManager{
 		FakePartitioner:           NewFakePartitioner(),
 		FakeFormatter:             &FakeFormatter{},
+		FakeFilesystemChecker:     &FakeFilesystemChecker{},
 		FakeMounter:               &FakeMounter{},
+		FakeFreezer:               &FakeFreezer{},
 		FakeMountsSearcher:        &FakeMountsSearcher{},
 		FakeRootDevicePartitioner: NewFakePartitioner(),
 		FakeDiskUtil:              fakedevutil.NewFakeDeviceUtil(),
@@ -49,10 +53,18 @@ func (m *FakeDiskManager) GetFormatter() boshdisk.Formatter {
 	return m.FakeFormatter
 }
 
+func (m *FakeDiskManager) GetFilesystemChecker() boshdisk.FilesystemChecker {
+	return m.FakeFilesystemChecker
+}
+
 func (m *FakeDiskManager) GetMounter() boshdisk.Mounter {
 	return m.FakeMounter
 }
 
+func (m *FakeDiskManager) GetFreezer() boshdisk.Freezer {
+	return m.FakeFreezer
+}
+
 func (m *FakeDiskManager) GetMountsSearcher() boshdisk.MountsSearcher {
 	return m.FakeMountsSearcher
 }
@@ -0,0 +1,18 @@
+package fakes
+
+import (
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeFilesystemChecker struct {
+	CheckCalled         bool
+	CheckPartitionPaths []string
+	CheckResult         boshdisk.FilesystemCheckResult
+	CheckErr            error
+}
+
+func (c *FakeFilesystemChecker) Check(partitionPath string) (boshdisk.FilesystemCheckResult, error) {
+	c.CheckCalled = true
+	c.CheckPartitionPaths = append(c.CheckPartitionPaths, partitionPath)
+	return c.CheckResult, c.CheckErr
+}
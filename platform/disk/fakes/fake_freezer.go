@@ -0,0 +1,19 @@
+package fakes
+
+type FakeFreezer struct {
+	FreezeMountPoints []string
+	FreezeErr         error
+
+	ThawMountPoints []string
+	ThawErr         error
+}
+
+func (f *FakeFreezer) Freeze(mountPoint string) error {
+	f.FreezeMountPoints = append(f.FreezeMountPoints, mountPoint)
+	return f.FreezeErr
+}
+
+func (f *FakeFreezer) Thaw(mountPoint string) error {
+	f.ThawMountPoints = append(f.ThawMountPoints, mountPoint)
+	return f.ThawErr
+}
@@ -0,0 +1,43 @@
+package disk
+
+import (
+	"time"
+)
+
+type FilesystemCheckStatus string
+
+const (
+	// FilesystemCheckClean means no problems were found on the filesystem
+	FilesystemCheckClean FilesystemCheckStatus = "clean"
+
+	// FilesystemCheckRepaired means problems were found and fixed
+	FilesystemCheckRepaired FilesystemCheckStatus = "repaired"
+
+	// FilesystemCheckUnrepaired means problems were found but were left in place;
+	// filesystem should not be mounted until an operator intervenes
+	FilesystemCheckUnrepaired FilesystemCheckStatus = "unrepaired"
+
+	// FilesystemCheckSkipped means filesystem type is not supported by the checker
+	FilesystemCheckSkipped FilesystemCheckStatus = "skipped"
+)
+
+type FilesystemCheckResult struct {
+	PartitionPath  string                `json:"partition_path"`
+	FileSystemType FileSystemType        `json:"file_system_type"`
+	Status         FilesystemCheckStatus `json:"status"`
+	ExitStatus     int                   `json:"exit_status"`
+	Output         string                `json:"output,omitempty"`
+	CheckedAt      time.Time             `json:"checked_at"`
+
+	// Alerted is set once health monitor was notified about this result
+	Alerted bool `json:"alerted"`
+}
+
+// NeedsAlert returns true if the result should be reported to the health monitor
+func (r FilesystemCheckResult) NeedsAlert() bool {
+	return r.Status == FilesystemCheckRepaired || r.Status == FilesystemCheckUnrepaired
+}
+
+type FilesystemChecker interface {
+	Check(partitionPath string) (FilesystemCheckResult, error)
+}
@@ -0,0 +1,8 @@
+package disk
+
+type Freezer interface {
+	// Freeze suspends new writes to the filesystem mounted at mountPoint
+	// and flushes pending ones so that a consistent snapshot can be taken
+	Freeze(mountPoint string) (err error)
+	Thaw(mountPoint string) (err error)
+}
@@ -0,0 +1,58 @@
+package disk
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// HealthState keeps last filesystem check result for each persistent disk
+// keyed by disk CID.
+type HealthState struct {
+	Disks map[string]FilesystemCheckResult `json:"disks"`
+	path  string
+	fs    boshsys.FileSystem
+}
+
+func NewHealthState(fs boshsys.FileSystem, path string) (*HealthState, error) {
+	state := HealthState{
+		Disks: map[string]FilesystemCheckResult{},
+		fs:    fs,
+		path:  path,
+	}
+
+	if !fs.FileExists(path) {
+		return &state, nil
+	}
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading disk health state file")
+	}
+
+	err = json.Unmarshal(bytes, &state)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling disk health state")
+	}
+
+	if state.Disks == nil {
+		state.Disks = map[string]FilesystemCheckResult{}
+	}
+
+	return &state, nil
+}
+
+func (s *HealthState) SaveState() error {
+	jsonState, err := json.Marshal(*s)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling disk health state")
+	}
+
+	err = s.fs.WriteFile(s.path, jsonState)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing disk health state to file")
+	}
+
+	return nil
+}
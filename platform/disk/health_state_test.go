@@ -0,0 +1,64 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("HealthState", func() {
+	var (
+		fs *fakesys.FakeFileSystem
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	It("starts with no disks when state file does not exist", func() {
+		state, err := NewHealthState(fs, "/disk_health.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Disks).To(Equal(map[string]FilesystemCheckResult{}))
+	})
+
+	It("loads previously saved results", func() {
+		state, err := NewHealthState(fs, "/disk_health.json")
+		Expect(err).ToNot(HaveOccurred())
+
+		state.Disks["fake-disk-cid"] = FilesystemCheckResult{
+			PartitionPath: "/dev/sdb1",
+			Status:        FilesystemCheckRepaired,
+			Alerted:       true,
+		}
+
+		err = state.SaveState()
+		Expect(err).ToNot(HaveOccurred())
+
+		reloaded, err := NewHealthState(fs, "/disk_health.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.Disks).To(Equal(state.Disks))
+	})
+
+	It("returns error when state file cannot be parsed", func() {
+		fs.WriteFileString("/disk_health.json", "{")
+
+		_, err := NewHealthState(fs, "/disk_health.json")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling disk health state"))
+	})
+
+	It("returns error when state cannot be written", func() {
+		state, err := NewHealthState(fs, "/disk_health.json")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.WriteFileError = errors.New("fake-write-err")
+
+		err = state.SaveState()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+	})
+})
@@ -0,0 +1,14 @@
+package disk
+
+type linuxBindFreezer struct{}
+
+// NewLinuxBindFreezer returns a freezer that does nothing since freezing
+// a bind mounted directory would freeze the whole backing filesystem
+// shared with other containers.
+func NewLinuxBindFreezer() Freezer {
+	return linuxBindFreezer{}
+}
+
+func (f linuxBindFreezer) Freeze(mountPoint string) error { return nil }
+
+func (f linuxBindFreezer) Thaw(mountPoint string) error { return nil }
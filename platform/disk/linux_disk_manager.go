@@ -16,7 +16,9 @@ type linuxDiskManager struct {
 	rootDevicePartitioner Partitioner
 	partedPartitioner     Partitioner
 	formatter             Formatter
+	filesystemChecker     FilesystemChecker
 	mounter               Mounter
+	freezer               Freezer
 	mountsSearcher        MountsSearcher
 	fs                    boshsys.FileSystem
 	logger                boshlog.Logger
@@ -51,8 +53,11 @@ func NewLinuxDiskManager(
 
 	mounter = NewLinuxMounter(runner, mountsSearcher, 1*time.Second)
 
+	freezer := NewLinuxFreezer(runner)
+
 	if opts.BindMount {
 		mounter = NewLinuxBindMounter(mounter)
+		freezer = NewLinuxBindFreezer()
 	}
 
 	var partitioner Partitioner
@@ -71,7 +76,9 @@ func NewLinuxDiskManager(
 		rootDevicePartitioner: NewRootDevicePartitioner(logger, runner, uint64(20*1024*1024)),
 		partedPartitioner:     NewPartedPartitioner(logger, runner, clock.NewClock()),
 		formatter:             NewLinuxFormatter(runner, fs),
+		filesystemChecker:     NewLinuxFilesystemChecker(runner, clock.NewClock(), logger),
 		mounter:               mounter,
+		freezer:               freezer,
 		mountsSearcher:        mountsSearcher,
 		fs:                    fs,
 		logger:                logger,
@@ -83,9 +90,11 @@ func (m linuxDiskManager) GetPartitioner() Partitioner           { return m.part
 func (m linuxDiskManager) GetPartedPartitioner() Partitioner     { return m.partedPartitioner }
 func (m linuxDiskManager) GetRootDevicePartitioner() Partitioner { return m.rootDevicePartitioner }
 
-func (m linuxDiskManager) GetFormatter() Formatter           { return m.formatter }
-func (m linuxDiskManager) GetMounter() Mounter               { return m.mounter }
-func (m linuxDiskManager) GetMountsSearcher() MountsSearcher { return m.mountsSearcher }
+func (m linuxDiskManager) GetFormatter() Formatter                 { return m.formatter }
+func (m linuxDiskManager) GetFilesystemChecker() FilesystemChecker { return m.filesystemChecker }
+func (m linuxDiskManager) GetMounter() Mounter                     { return m.mounter }
+func (m linuxDiskManager) GetFreezer() Freezer                     { return m.freezer }
+func (m linuxDiskManager) GetMountsSearcher() MountsSearcher       { return m.mountsSearcher }
 
 func (m linuxDiskManager) GetDiskUtil(diskPath string) boshdevutil.DeviceUtil {
 	return NewDiskUtil(diskPath, m.runner, m.mounter, m.fs, m.logger)
@@ -33,6 +33,11 @@ var _ = Describe("NewLinuxDiskManager", func() {
 			diskManager := NewLinuxDiskManager(logger, runner, fs, LinuxDiskManagerOpts{})
 			Expect(diskManager.GetMounter()).To(Equal(expectedMounter))
 		})
+
+		It("returns disk manager configured to freeze filesystems", func() {
+			diskManager := NewLinuxDiskManager(logger, runner, fs, LinuxDiskManagerOpts{})
+			Expect(diskManager.GetFreezer()).To(Equal(NewLinuxFreezer(runner)))
+		})
 	})
 
 	Context("when bindMount is set to true", func() {
@@ -44,6 +49,12 @@ var _ = Describe("NewLinuxDiskManager", func() {
 			diskManager := NewLinuxDiskManager(logger, runner, fs, opts)
 			Expect(diskManager.GetMounter()).To(Equal(expectedMounter))
 		})
+
+		It("returns disk manager configured not to freeze filesystems", func() {
+			opts := LinuxDiskManagerOpts{BindMount: true}
+			diskManager := NewLinuxDiskManager(logger, runner, fs, opts)
+			Expect(diskManager.GetFreezer()).To(Equal(NewLinuxBindFreezer()))
+		})
 	})
 
 	Context("when partitioner type is not set", func() {
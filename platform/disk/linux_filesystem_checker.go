@@ -0,0 +1,112 @@
+package disk
+
+import (
+	"regexp"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	// fsck exit codes (see fsck(8))
+	fsckErrorsCorrected       = 1
+	fsckErrorsCorrectedReboot = 2
+	fsckErrorsUncorrected     = 4
+
+	// xfs_repair -n exits with 1 when corruption was detected
+	xfsRepairCorruptionDetected = 1
+)
+
+type linuxFilesystemChecker struct {
+	runner boshsys.CmdRunner
+	clock  clock.Clock
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewLinuxFilesystemChecker(runner boshsys.CmdRunner, clock clock.Clock, logger boshlog.Logger) FilesystemChecker {
+	return linuxFilesystemChecker{
+		runner: runner,
+		clock:  clock,
+		logTag: "linuxFilesystemChecker",
+		logger: logger,
+	}
+}
+
+func (c linuxFilesystemChecker) Check(partitionPath string) (FilesystemCheckResult, error) {
+	result := FilesystemCheckResult{
+		PartitionPath: partitionPath,
+		Status:        FilesystemCheckSkipped,
+	}
+
+	fsType, err := c.getPartitionFormatType(partitionPath)
+	if err != nil {
+		return result, bosherr.WrapError(err, "Checking filesystem format of partition")
+	}
+
+	result.FileSystemType = fsType
+	result.CheckedAt = c.clock.Now()
+
+	switch fsType {
+	case FileSystemExt4:
+		c.logger.Info(c.logTag, "Running fsck on %s", partitionPath)
+
+		stdout, stderr, exitStatus, err := c.runner.RunCommand("fsck", "-p", partitionPath)
+		result.ExitStatus = exitStatus
+		result.Output = stdout + stderr
+
+		switch {
+		case err == nil:
+			result.Status = FilesystemCheckClean
+		case exitStatus == fsckErrorsCorrected || exitStatus == fsckErrorsCorrectedReboot:
+			result.Status = FilesystemCheckRepaired
+		case exitStatus&fsckErrorsUncorrected != 0:
+			result.Status = FilesystemCheckUnrepaired
+		default:
+			return result, bosherr.WrapError(err, "Shelling out to fsck")
+		}
+
+	case FileSystemXFS:
+		c.logger.Info(c.logTag, "Running xfs_repair in no-modify mode on %s", partitionPath)
+
+		stdout, stderr, exitStatus, err := c.runner.RunCommand("xfs_repair", "-n", partitionPath)
+		result.ExitStatus = exitStatus
+		result.Output = stdout + stderr
+
+		switch {
+		case err == nil:
+			result.Status = FilesystemCheckClean
+		case exitStatus == xfsRepairCorruptionDetected:
+			result.Status = FilesystemCheckUnrepaired
+		default:
+			return result, bosherr.WrapError(err, "Shelling out to xfs_repair")
+		}
+
+	default:
+		c.logger.Info(c.logTag, "Skipping filesystem check of %s with unsupported type '%s'", partitionPath, fsType)
+	}
+
+	c.logger.Debug(c.logTag, "Filesystem check of %s finished with status '%s'", partitionPath, result.Status)
+
+	return result, nil
+}
+
+func (c linuxFilesystemChecker) getPartitionFormatType(partitionPath string) (FileSystemType, error) {
+	stdout, stderr, exitStatus, err := c.runner.RunCommand("blkid", "-p", partitionPath)
+	if err != nil {
+		if exitStatus == 2 && stderr == "" {
+			// in that case we expect the device not to have any file system
+			return "", nil
+		}
+		return "", err
+	}
+
+	match := regexp.MustCompile(" TYPE=\"([^\"]+)\"").FindStringSubmatch(stdout)
+	if nil == match {
+		return "", nil
+	}
+
+	return FileSystemType(match[1]), nil
+}
@@ -0,0 +1,150 @@
+package disk_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("linuxFilesystemChecker", func() {
+	var (
+		runner  *fakesys.FakeCmdRunner
+		now     time.Time
+		checker FilesystemChecker
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		now = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		checker = NewLinuxFilesystemChecker(runner, fakeclock.NewFakeClock(now), logger)
+	})
+
+	Context("when partition is formatted with ext4", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext4" yyyy zzzz`})
+		})
+
+		It("runs fsck in preen mode and reports clean filesystem", func() {
+			runner.AddCmdResult("fsck -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: "clean"})
+
+			result, err := checker.Check("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"blkid", "-p", "/dev/sdb1"},
+				{"fsck", "-p", "/dev/sdb1"},
+			}))
+			Expect(result).To(Equal(FilesystemCheckResult{
+				PartitionPath:  "/dev/sdb1",
+				FileSystemType: FileSystemExt4,
+				Status:         FilesystemCheckClean,
+				ExitStatus:     0,
+				Output:         "clean",
+				CheckedAt:      now,
+			}))
+		})
+
+		It("reports repaired filesystem when fsck corrected errors", func() {
+			runner.AddCmdResult("fsck -p /dev/sdb1", fakesys.FakeCmdResult{
+				Stdout:     "fixed",
+				ExitStatus: 1,
+				Error:      errors.New("fake-exit-1"),
+			})
+
+			result, err := checker.Check("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Status).To(Equal(FilesystemCheckRepaired))
+			Expect(result.ExitStatus).To(Equal(1))
+		})
+
+		It("reports unrepaired filesystem when fsck left errors uncorrected", func() {
+			runner.AddCmdResult("fsck -p /dev/sdb1", fakesys.FakeCmdResult{
+				Stderr:     "UNEXPECTED INCONSISTENCY; RUN fsck MANUALLY.",
+				ExitStatus: 4,
+				Error:      errors.New("fake-exit-4"),
+			})
+
+			result, err := checker.Check("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Status).To(Equal(FilesystemCheckUnrepaired))
+			Expect(result.Output).To(Equal("UNEXPECTED INCONSISTENCY; RUN fsck MANUALLY."))
+		})
+
+		It("returns error when fsck fails to operate", func() {
+			runner.AddCmdResult("fsck -p /dev/sdb1", fakesys.FakeCmdResult{
+				ExitStatus: 8,
+				Error:      errors.New("fake-exit-8"),
+			})
+
+			_, err := checker.Check("/dev/sdb1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-exit-8"))
+		})
+	})
+
+	Context("when partition is formatted with xfs", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="xfs" yyyy zzzz`})
+		})
+
+		It("runs xfs_repair in no-modify mode and reports clean filesystem", func() {
+			result, err := checker.Check("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands[1]).To(Equal([]string{"xfs_repair", "-n", "/dev/sdb1"}))
+			Expect(result.FileSystemType).To(Equal(FileSystemXFS))
+			Expect(result.Status).To(Equal(FilesystemCheckClean))
+		})
+
+		It("reports unrepaired filesystem when corruption was detected", func() {
+			runner.AddCmdResult("xfs_repair -n /dev/sdb1", fakesys.FakeCmdResult{
+				ExitStatus: 1,
+				Error:      errors.New("fake-exit-1"),
+			})
+
+			result, err := checker.Check("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Status).To(Equal(FilesystemCheckUnrepaired))
+		})
+
+		It("returns error when xfs_repair fails to operate", func() {
+			runner.AddCmdResult("xfs_repair -n /dev/sdb1", fakesys.FakeCmdResult{
+				ExitStatus: 2,
+				Error:      errors.New("fake-exit-2"),
+			})
+
+			_, err := checker.Check("/dev/sdb1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-exit-2"))
+		})
+	})
+
+	Context("when partition is not formatted with a supported filesystem", func() {
+		It("skips the check", func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext2" yyyy zzzz`})
+
+			result, err := checker.Check("/dev/sdb1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Status).To(Equal(FilesystemCheckSkipped))
+			Expect(runner.RunCommands).To(HaveLen(1))
+		})
+	})
+
+	It("returns error when filesystem type cannot be determined", func() {
+		runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{
+			Stderr:     "fake-stderr",
+			ExitStatus: 4,
+			Error:      errors.New("fake-blkid-err"),
+		})
+
+		_, err := checker.Check("/dev/sdb1")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-blkid-err"))
+	})
+})
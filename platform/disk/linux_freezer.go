@@ -0,0 +1,32 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type linuxFreezer struct {
+	runner boshsys.CmdRunner
+}
+
+func NewLinuxFreezer(runner boshsys.CmdRunner) Freezer {
+	return linuxFreezer{runner: runner}
+}
+
+func (f linuxFreezer) Freeze(mountPoint string) error {
+	_, _, _, err := f.runner.RunCommand("fsfreeze", "-f", mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Shelling out to fsfreeze to freeze %s", mountPoint)
+	}
+
+	return nil
+}
+
+func (f linuxFreezer) Thaw(mountPoint string) error {
+	_, _, _, err := f.runner.RunCommand("fsfreeze", "-u", mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Shelling out to fsfreeze to thaw %s", mountPoint)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("linuxFreezer", func() {
+	var (
+		runner  *fakesys.FakeCmdRunner
+		freezer Freezer
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		freezer = NewLinuxFreezer(runner)
+	})
+
+	Describe("Freeze", func() {
+		It("freezes filesystem with fsfreeze", func() {
+			err := freezer.Freeze("/var/vcap/store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{{"fsfreeze", "-f", "/var/vcap/store"}}))
+		})
+
+		It("returns error if fsfreeze fails", func() {
+			runner.AddCmdResult("fsfreeze -f /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-freeze-err")})
+
+			err := freezer.Freeze("/var/vcap/store")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-freeze-err"))
+		})
+	})
+
+	Describe("Thaw", func() {
+		It("thaws filesystem with fsfreeze", func() {
+			err := freezer.Thaw("/var/vcap/store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{{"fsfreeze", "-u", "/var/vcap/store"}}))
+		})
+
+		It("returns error if fsfreeze fails", func() {
+			runner.AddCmdResult("fsfreeze -u /var/vcap/store", fakesys.FakeCmdResult{Error: errors.New("fake-thaw-err")})
+
+			err := freezer.Thaw("/var/vcap/store")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+		})
+	})
+})
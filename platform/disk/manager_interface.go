@@ -9,7 +9,9 @@ type Manager interface {
 	GetRootDevicePartitioner() Partitioner
 	GetPartedPartitioner() Partitioner
 	GetFormatter() Formatter
+	GetFilesystemChecker() FilesystemChecker
 	GetMounter() Mounter
+	GetFreezer() Freezer
 	GetMountsSearcher() MountsSearcher
 	GetDiskUtil(diskPath string) boshdevutil.DeviceUtil
 }
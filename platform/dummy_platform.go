@@ -9,6 +9,8 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -69,7 +71,7 @@ func NewDummyPlatform(
 		dirProvider:        dirProvider,
 		devicePathResolver: devicePathResolver,
 		vitalsService:      boshvitals.NewService(collector, dirProvider),
-		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, 0, logger),
+		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, dirProvider, 0, logger),
 		logger:             logger,
 		auditLogger:        auditLogger,
 	}
@@ -103,6 +105,10 @@ func (p dummyPlatform) GetVitalsService() (service boshvitals.Service) {
 	return p.vitalsService
 }
 
+func (p dummyPlatform) GetNTPService() boshntp.Service {
+	return boshntp.NewConcreteService(p.fs, p.dirProvider)
+}
+
 func (p dummyPlatform) GetDevicePathResolver() (devicePathResolver boshdpresolv.DevicePathResolver) {
 	return p.devicePathResolver
 }
@@ -274,6 +280,10 @@ func (p dummyPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetting
 	return p.fs.WriteFile(p.mountsPath(), mountsJSON)
 }
 
+func (p dummyPlatform) GetDiskHealthState() (*boshdisk.HealthState, error) {
+	return boshdisk.NewHealthState(p.fs, filepath.Join(p.dirProvider.BoshDir(), "disk_health.json"))
+}
+
 func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	mounts, err := p.existingMounts()
 	if err != nil {
@@ -300,6 +310,14 @@ func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSetti
 	return true, nil
 }
 
+func (p dummyPlatform) FreezePersistentDisk(mountPoint string) error {
+	return nil
+}
+
+func (p dummyPlatform) ThawPersistentDisk(mountPoint string) error {
+	return nil
+}
+
 func (p dummyPlatform) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) string {
 	return "/dev/sdb"
 }
@@ -8,6 +8,9 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	fakentp "github.com/cloudfoundry/bosh-agent/platform/ntp/fakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	fakevitals "github.com/cloudfoundry/bosh-agent/platform/vitals/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -25,6 +28,7 @@ type FakePlatform struct {
 	FakeCompressor    *fakecmd.FakeCompressor
 	FakeCopier        *fakecmd.FakeCopier
 	FakeVitalsService *fakevitals.FakeService
+	FakeNTPService    *fakentp.FakeService
 	fsType            string
 	logger            boshlog.Logger
 	auditLogger       platform.AuditLogger
@@ -92,6 +96,8 @@ type FakePlatform struct {
 	MountPersistentDiskMountPoint string
 	MountPersistentDiskErr        error
 
+	GetDiskHealthStateErr error
+
 	UnmountPersistentDiskDidUnmount bool
 	UnmountPersistentDiskSettings   boshsettings.DiskSettings
 
@@ -115,6 +121,12 @@ type FakePlatform struct {
 	MigratePersistentDiskFromMountPoint string
 	MigratePersistentDiskToMountPoint   string
 
+	FreezePersistentDiskMountPoints []string
+	FreezePersistentDiskErr         error
+
+	ThawPersistentDiskMountPoints []string
+	ThawPersistentDiskErr         error
+
 	IsPersistentDiskMountableResult bool
 	IsPersistentDiskMountableErr    error
 
@@ -174,6 +186,7 @@ func NewFakePlatform() (platform *FakePlatform) {
 	platform.FakeCompressor = fakecmd.NewFakeCompressor()
 	platform.FakeCopier = fakecmd.NewFakeCopier()
 	platform.FakeVitalsService = fakevitals.NewFakeService()
+	platform.FakeNTPService = &fakentp.FakeService{}
 	platform.DevicePathResolver = fakedpresolv.NewFakeDevicePathResolver()
 	platform.AddUserToGroupsGroups = make(map[string][]string)
 	platform.SetupSSHPublicKeys = make(map[string][]string)
@@ -223,6 +236,10 @@ func (p *FakePlatform) GetVitalsService() (service boshvitals.Service) {
 	return p.FakeVitalsService
 }
 
+func (p *FakePlatform) GetNTPService() boshntp.Service {
+	return p.FakeNTPService
+}
+
 func (p *FakePlatform) GetDevicePathResolver() (devicePathResolver boshdpresolv.DevicePathResolver) {
 	return p.DevicePathResolver
 }
@@ -361,6 +378,13 @@ func (p *FakePlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetting
 	return p.MountPersistentDiskErr
 }
 
+func (p *FakePlatform) GetDiskHealthState() (*boshdisk.HealthState, error) {
+	if p.GetDiskHealthStateErr != nil {
+		return nil, p.GetDiskHealthStateErr
+	}
+	return boshdisk.NewHealthState(p.Fs, "/var/vcap/bosh/disk_health.json")
+}
+
 func (p *FakePlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	p.UnmountPersistentDiskSettings = diskSettings
 	didUnmount = p.UnmountPersistentDiskDidUnmount
@@ -410,6 +434,16 @@ func (p *FakePlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string
 	return
 }
 
+func (p *FakePlatform) FreezePersistentDisk(mountPoint string) error {
+	p.FreezePersistentDiskMountPoints = append(p.FreezePersistentDiskMountPoints, mountPoint)
+	return p.FreezePersistentDiskErr
+}
+
+func (p *FakePlatform) ThawPersistentDisk(mountPoint string) error {
+	p.ThawPersistentDiskMountPoints = append(p.ThawPersistentDiskMountPoints, mountPoint)
+	return p.ThawPersistentDiskErr
+}
+
 func (p *FakePlatform) IsMountPoint(path string) (string, bool, error) {
 	p.IsMountPointPath = path
 	return p.IsMountPointPartitionPath, p.IsMountPointResult, p.IsMountPointErr
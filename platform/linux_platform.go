@@ -2,6 +2,7 @@ package platform
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -16,6 +17,8 @@ import (
 	boshdevutil "github.com/cloudfoundry/bosh-agent/platform/deviceutil"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshdns "github.com/cloudfoundry/bosh-agent/platform/net/dns"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -80,6 +83,36 @@ type LinuxOptions struct {
 	// Strategy for resolving ephemeral & persistent disk partitioners;
 	// possible values: parted, "" (default is sfdisk if disk < 2TB, parted otherwise)
 	PartitionerType string
+
+	// Backend used to configure network interfaces;
+	// possible values: netplan, networkd, "" (default is distribution specific)
+	NetManagerType string
+
+	// IP addresses that are not expected to be assigned to network interfaces
+	// when validating network configuration (e.g. portal IPs routed by the IaaS)
+	NetworkValidationExcludedIPs []string
+
+	// How long to wait for configured IP addresses to show up on network
+	// interfaces before reporting validation failure; 0 validates once
+	NetworkValidationTimeoutSeconds int
+
+	// When set to true persistent disk filesystem will be checked
+	// (fsck -p for ext4, xfs_repair -n for xfs) right before mounting;
+	// disk will not be mounted if errors could not be repaired
+	CheckPersistentDiskFilesystem bool
+
+	// When set to true the agent serves DNS records synced by the director
+	// from an embedded resolver added to /etc/resolv.conf instead of /etc/hosts;
+	// other queries are forwarded to DNS servers of the default DNS network
+	UseLocalDNSResolver bool
+
+	// Link-local address the embedded resolver listens on;
+	// default is 169.254.0.2
+	LocalDNSResolverAddress string
+
+	// Daemon that keeps system time synchronized with NTP servers from settings;
+	// possible values: chrony, timesyncd, "" (default runs ntpdate once)
+	TimeSyncDaemon string
 }
 
 type linux struct {
@@ -102,6 +135,8 @@ type linux struct {
 	defaultNetworkResolver boshsettings.DefaultNetworkResolver
 	uuidGenerator          boshuuid.Generator
 	auditLogger            AuditLogger
+	localDNSServer         boshdns.Server
+	timeSyncDaemon         boshntp.Daemon
 }
 
 func NewLinuxPlatform(
@@ -124,6 +159,8 @@ func NewLinuxPlatform(
 	defaultNetworkResolver boshsettings.DefaultNetworkResolver,
 	uuidGenerator boshuuid.Generator,
 	auditLogger AuditLogger,
+	localDNSServer boshdns.Server,
+	timeSyncDaemon boshntp.Daemon,
 ) Platform {
 	return &linux{
 		fs:                     fs,
@@ -145,6 +182,8 @@ func NewLinuxPlatform(
 		defaultNetworkResolver: defaultNetworkResolver,
 		uuidGenerator:          uuidGenerator,
 		auditLogger:            auditLogger,
+		localDNSServer:         localDNSServer,
+		timeSyncDaemon:         timeSyncDaemon,
 	}
 }
 
@@ -191,6 +230,14 @@ func (p linux) GetVitalsService() (service boshvitals.Service) {
 	return p.vitalsService
 }
 
+func (p linux) GetNTPService() boshntp.Service {
+	if p.timeSyncDaemon != nil {
+		return p.timeSyncDaemon
+	}
+
+	return boshntp.NewConcreteService(p.fs, p.dirProvider)
+}
+
 func (p linux) GetFileContentsFromCDROM(fileName string) (content []byte, err error) {
 	contents, err := p.cdutil.GetFilesContents([]string{fileName})
 	if err != nil {
@@ -213,9 +260,105 @@ func (p linux) GetAuditLogger() AuditLogger {
 }
 
 func (p linux) SetupNetworking(networks boshsettings.Networks) (err error) {
+	if p.localDNSServer != nil && !networks.IsPreconfigured() {
+		networks, err = p.setupLocalDNSServer(networks)
+		if err != nil {
+			return bosherr.WrapError(err, "Setting up local DNS resolver")
+		}
+	}
+
 	return p.netManager.SetupNetworking(networks, nil)
 }
 
+// setupLocalDNSServer starts resolver forwarding to DNS servers of the default DNS network
+// and returns networks with the resolver in front of those DNS servers
+func (p linux) setupLocalDNSServer(networks boshsettings.Networks) (boshsettings.Networks, error) {
+	address := p.localDNSServer.Address()
+
+	dnsNetwork, found := networks.DefaultNetworkFor("dns")
+
+	upstreams := []string{}
+	for _, server := range dnsNetwork.DNS {
+		if server != address {
+			upstreams = append(upstreams, server)
+		}
+	}
+
+	p.localDNSServer.SetUpstreams(upstreams)
+
+	err := p.addLocalDNSAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.localDNSServer.Start()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Starting local DNS resolver")
+	}
+
+	err = p.loadSyncedDNSRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return networks, nil
+	}
+
+	// Every network that DefaultNetworkFor may pick gets the resolver
+	// so that net managers use it regardless of which one they choose
+	resolvedNetworks := boshsettings.Networks{}
+	for name, network := range networks {
+		if len(networks) == 1 || network.IsDefaultFor("dns") {
+			network.DNS = append([]string{address}, upstreams...)
+		}
+		resolvedNetworks[name] = network
+	}
+
+	return resolvedNetworks, nil
+}
+
+func (p linux) addLocalDNSAddress(address string) error {
+	stdout, _, _, err := p.cmdRunner.RunCommand("ip", "addr", "show", "dev", "lo")
+	if err != nil {
+		return bosherr.WrapError(err, "Listing loopback addresses")
+	}
+
+	if strings.Contains(stdout, " "+address+"/") {
+		return nil
+	}
+
+	_, _, _, err = p.cmdRunner.RunCommand("ip", "addr", "add", address+"/32", "dev", "lo")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding '%s' to loopback device", address)
+	}
+
+	return nil
+}
+
+// loadSyncedDNSRecords serves records from the last sync_dns
+// until the director sends a newer version
+func (p linux) loadSyncedDNSRecords() error {
+	recordsPath := filepath.Join(p.dirProvider.InstanceDNSDir(), "records.json")
+	if !p.fs.FileExists(recordsPath) {
+		return nil
+	}
+
+	contents, err := p.fs.ReadFile(recordsPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading '%s'", recordsPath)
+	}
+
+	dnsRecords := boshsettings.DNSRecords{}
+
+	err = json.Unmarshal(contents, &dnsRecords)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling '%s'", recordsPath)
+	}
+
+	return p.localDNSServer.UpdateRecords(dnsRecords)
+}
+
 func (p linux) GetConfiguredNetworkInterfaces() ([]string, error) {
 	return p.netManager.GetConfiguredNetworkInterfaces()
 }
@@ -442,8 +585,16 @@ func (p linux) SaveDNSRecords(dnsRecords boshsettings.DNSRecords, hostname strin
 		return bosherr.WrapError(err, "Generating default /etc/hosts")
 	}
 
-	for _, dnsRecord := range dnsRecords.Records {
-		dnsRecordsContents.WriteString(fmt.Sprintf("%s %s\n", dnsRecord[0], dnsRecord[1]))
+	if p.localDNSServer != nil {
+		// Records in /etc/hosts would shadow reloaded records since it is consulted first
+		err = p.localDNSServer.UpdateRecords(dnsRecords)
+		if err != nil {
+			return bosherr.WrapError(err, "Updating local DNS resolver records")
+		}
+	} else {
+		for _, dnsRecord := range dnsRecords.Records {
+			dnsRecordsContents.WriteString(fmt.Sprintf("%s %s\n", dnsRecord[0], dnsRecord[1]))
+		}
 	}
 
 	uuid, err := p.uuidGenerator.Generate()
@@ -538,6 +689,14 @@ const etcLogrotateDTemplate = `# Generated by bosh-agent
 `
 
 func (p linux) SetTimeWithNtpServers(servers []string) (err error) {
+	if p.timeSyncDaemon != nil {
+		err = p.timeSyncDaemon.SetServers(servers)
+		if err != nil {
+			err = bosherr.WrapError(err, "Configuring time sync daemon")
+		}
+		return
+	}
+
 	serversFilePath := path.Join(p.dirProvider.BaseDir(), "/bosh/etc/ntpserver")
 	if len(servers) == 0 {
 		return
@@ -1093,6 +1252,13 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 		realPath = partitionPath
 	}
 
+	if p.options.CheckPersistentDiskFilesystem {
+		err = p.checkPersistentDiskFilesystem(diskSetting.ID, realPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Checking persistent disk filesystem")
+		}
+	}
+
 	err = p.diskManager.GetMounter().Mount(realPath, mountPoint)
 
 	if err != nil {
@@ -1110,6 +1276,35 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	return nil
 }
 
+func (p linux) checkPersistentDiskFilesystem(diskCID, partitionPath string) error {
+	result, err := p.diskManager.GetFilesystemChecker().Check(partitionPath)
+	if err != nil {
+		return err
+	}
+
+	state, err := p.GetDiskHealthState()
+	if err != nil {
+		return err
+	}
+
+	state.Disks[diskCID] = result
+
+	err = state.SaveState()
+	if err != nil {
+		return err
+	}
+
+	if result.Status == boshdisk.FilesystemCheckUnrepaired {
+		return bosherr.Errorf("Filesystem on %s has errors that could not be repaired", partitionPath)
+	}
+
+	return nil
+}
+
+func (p linux) GetDiskHealthState() (*boshdisk.HealthState, error) {
+	return boshdisk.NewHealthState(p.fs, filepath.Join(p.dirProvider.BoshDir(), "disk_health.json"))
+}
+
 func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bool, error) {
 	p.logger.Debug(logTag, "Unmounting persistent disk %+v", diskSettings)
 
@@ -1132,6 +1327,38 @@ func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bo
 	return p.diskManager.GetMounter().Unmount(realPath)
 }
 
+func (p linux) FreezePersistentDisk(mountPoint string) error {
+	_, isMountPoint, err := p.IsMountPoint(mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking mount point")
+	}
+
+	if !isMountPoint {
+		p.logger.Info(logTag, "Skipping freezing %s since it is not a mount point", mountPoint)
+		return nil
+	}
+
+	p.logger.Info(logTag, "Freezing persistent disk mounted at %s", mountPoint)
+
+	return p.diskManager.GetFreezer().Freeze(mountPoint)
+}
+
+func (p linux) ThawPersistentDisk(mountPoint string) error {
+	_, isMountPoint, err := p.IsMountPoint(mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking mount point")
+	}
+
+	if !isMountPoint {
+		p.logger.Info(logTag, "Skipping thawing %s since it is not a mount point", mountPoint)
+		return nil
+	}
+
+	p.logger.Info(logTag, "Thawing persistent disk mounted at %s", mountPoint)
+
+	return p.diskManager.GetFreezer().Thaw(mountPoint)
+}
+
 func (p linux) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) string {
 	realPath, _, err := p.devicePathResolver.GetRealDevicePath(diskSettings)
 	if err != nil {
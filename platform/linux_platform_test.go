@@ -19,13 +19,17 @@ import (
 	fakedevutil "github.com/cloudfoundry/bosh-agent/platform/deviceutil/fakes"
 	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	fakedns "github.com/cloudfoundry/bosh-agent/platform/net/dns/fakes"
 	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	fakentp "github.com/cloudfoundry/bosh-agent/platform/ntp/fakes"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	fakeretry "github.com/cloudfoundry/bosh-utils/retrystrategy/fakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuidgen "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdns "github.com/cloudfoundry/bosh-agent/platform/net/dns"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -53,6 +57,8 @@ func describeLinuxPlatform() {
 		monitRetryStrategy         *fakeretry.FakeRetryStrategy
 		fakeDefaultNetworkResolver *fakenet.FakeDefaultNetworkResolver
 		fakeAuditLogger            *fakeplat.FakeAuditLogger
+		localDNSServer             boshdns.Server
+		timeSyncDaemon             boshntp.Daemon
 
 		fakeUUIDGenerator *fakeuuidgen.FakeGenerator
 
@@ -83,6 +89,8 @@ func describeLinuxPlatform() {
 
 		fakeUUIDGenerator = fakeuuidgen.NewFakeGenerator()
 		fakeAuditLogger = fakeplat.NewFakeAuditLogger()
+		localDNSServer = nil
+		timeSyncDaemon = nil
 
 		state, stateErr = NewBootstrapState(fs, "/agent-state.json")
 		Expect(stateErr).NotTo(HaveOccurred())
@@ -121,6 +129,8 @@ func describeLinuxPlatform() {
 			fakeDefaultNetworkResolver,
 			fakeUUIDGenerator,
 			fakeAuditLogger,
+			localDNSServer,
+			timeSyncDaemon,
 		)
 	})
 
@@ -329,6 +339,8 @@ bosh_foobar:...`
 					fakeDefaultNetworkResolver,
 					fakeUUIDGenerator,
 					fakeAuditLogger,
+					localDNSServer,
+					timeSyncDaemon,
 				)
 				err := platformWithNoEphemeralDisk.SetupRootDisk("")
 
@@ -560,6 +572,36 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
 			Expect(ntpConfig).To(BeNil())
 		})
+
+		Context("with time sync daemon", func() {
+			var fakeDaemon *fakentp.FakeDaemon
+
+			BeforeEach(func() {
+				fakeDaemon = &fakentp.FakeDaemon{}
+				timeSyncDaemon = fakeDaemon
+			})
+
+			It("configures the daemon instead of running ntpdate", func() {
+				err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDaemon.SetServersServers).To(Equal([]string{"0.north-america.pool.ntp.org"}))
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+				Expect(fs.FileExists("/fake-dir/bosh/etc/ntpserver")).To(BeFalse())
+			})
+
+			It("returns error when configuring the daemon fails", func() {
+				fakeDaemon.SetServersErr = errors.New("fake-set-servers-err")
+
+				err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Configuring time sync daemon: fake-set-servers-err"))
+			})
+
+			It("reports time sync info from the daemon", func() {
+				Expect(platform.GetNTPService()).To(Equal(fakeDaemon))
+			})
+		})
 	})
 
 	Describe("SetupEphemeralDiskWithPath", func() {
@@ -2449,6 +2491,69 @@ Number  Start   End     Size    File system  Name             Flags
 					Expect(formatter.FormatCalled).To(BeFalse())
 				})
 			})
+
+			Context("when CheckPersistentDiskFilesystem set to false", func() {
+				It("does not check the filesystem", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(diskManager.FakeFilesystemChecker.CheckCalled).To(BeFalse())
+				})
+			})
+
+			Context("when CheckPersistentDiskFilesystem set to true", func() {
+				var checker *fakedisk.FakeFilesystemChecker
+
+				BeforeEach(func() {
+					options.CheckPersistentDiskFilesystem = true
+					checker = diskManager.FakeFilesystemChecker
+					checker.CheckResult = boshdisk.FilesystemCheckResult{
+						PartitionPath:  "fake-real-device-path1",
+						FileSystemType: boshdisk.FileSystemExt4,
+						Status:         boshdisk.FilesystemCheckRepaired,
+						ExitStatus:     1,
+					}
+				})
+
+				It("checks the partition before mounting it", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(checker.CheckPartitionPaths).To(Equal([]string{"fake-real-device-path1"}))
+					Expect(mounter.MountPartitionPaths).To(Equal([]string{"fake-real-device-path1"}))
+				})
+
+				It("records check result in disk health state", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					state, err := platform.GetDiskHealthState()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.Disks).To(Equal(map[string]boshdisk.FilesystemCheckResult{
+						"fake-unique-id": checker.CheckResult,
+					}))
+				})
+
+				It("does not mount the disk if errors were left unrepaired", func() {
+					checker.CheckResult.Status = boshdisk.FilesystemCheckUnrepaired
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("could not be repaired"))
+					Expect(mounter.MountCalled).To(BeFalse())
+
+					state, err := platform.GetDiskHealthState()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.Disks["fake-unique-id"].Status).To(Equal(boshdisk.FilesystemCheckUnrepaired))
+				})
+
+				It("returns error when checking fails", func() {
+					checker.CheckErr = errors.New("fake-check-err")
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-check-err"))
+					Expect(mounter.MountCalled).To(BeFalse())
+				})
+			})
 		})
 
 		Context("when device path is not successfully resolved", func() {
@@ -2674,6 +2779,89 @@ Number  Start   End     Size    File system  Name             Flags
 		})
 	})
 
+	Describe("FreezePersistentDisk", func() {
+		var (
+			mounter *fakedisk.FakeMounter
+			freezer *fakedisk.FakeFreezer
+		)
+
+		BeforeEach(func() {
+			mounter = diskManager.FakeMounter
+			freezer = diskManager.FakeFreezer
+		})
+
+		It("freezes filesystem mounted at mount point", func() {
+			mounter.IsMountPointResult = true
+
+			err := platform.FreezePersistentDisk("/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(freezer.FreezeMountPoints).To(Equal([]string{"/mnt/point"}))
+		})
+
+		It("does not freeze anything if nothing is mounted at mount point", func() {
+			mounter.IsMountPointResult = false
+
+			err := platform.FreezePersistentDisk("/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(freezer.FreezeMountPoints).To(BeEmpty())
+		})
+
+		It("returns error if freezing fails", func() {
+			mounter.IsMountPointResult = true
+			freezer.FreezeErr = errors.New("fake-freeze-err")
+
+			err := platform.FreezePersistentDisk("/mnt/point")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-freeze-err"))
+		})
+
+		It("returns error if checking mount point fails", func() {
+			mounter.IsMountPointErr = errors.New("fake-is-mount-point-err")
+
+			err := platform.FreezePersistentDisk("/mnt/point")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-is-mount-point-err"))
+			Expect(freezer.FreezeMountPoints).To(BeEmpty())
+		})
+	})
+
+	Describe("ThawPersistentDisk", func() {
+		var (
+			mounter *fakedisk.FakeMounter
+			freezer *fakedisk.FakeFreezer
+		)
+
+		BeforeEach(func() {
+			mounter = diskManager.FakeMounter
+			freezer = diskManager.FakeFreezer
+		})
+
+		It("thaws filesystem mounted at mount point", func() {
+			mounter.IsMountPointResult = true
+
+			err := platform.ThawPersistentDisk("/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(freezer.ThawMountPoints).To(Equal([]string{"/mnt/point"}))
+		})
+
+		It("does not thaw anything if nothing is mounted at mount point", func() {
+			mounter.IsMountPointResult = false
+
+			err := platform.ThawPersistentDisk("/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(freezer.ThawMountPoints).To(BeEmpty())
+		})
+
+		It("returns error if thawing fails", func() {
+			mounter.IsMountPointResult = true
+			freezer.ThawErr = errors.New("fake-thaw-err")
+
+			err := platform.ThawPersistentDisk("/mnt/point")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-thaw-err"))
+		})
+	})
+
 	Describe("GetEphemeralDiskPath", func() {
 		Context("when real device path was resolved without an error", func() {
 			It("returns real device path and true", func() {
@@ -3028,6 +3216,119 @@ unit: sectors
 
 			Expect(netManager.SetupNetworkingNetworks).To(Equal(networks))
 		})
+
+		Context("with local DNS resolver", func() {
+			var (
+				fakeDNSServer *fakedns.FakeServer
+				networks      boshsettings.Networks
+			)
+
+			BeforeEach(func() {
+				fakeDNSServer = &fakedns.FakeServer{AddressIP: "169.254.0.2"}
+				localDNSServer = fakeDNSServer
+
+				networks = boshsettings.Networks{
+					"net1": boshsettings.Network{
+						IP:      "10.0.0.5",
+						DNS:     []string{"169.254.0.2", "8.8.8.8", "8.8.4.4"},
+						Default: []string{"dns", "gateway"},
+					},
+					"net2": boshsettings.Network{
+						IP:  "10.1.0.5",
+						DNS: []string{"1.1.1.1"},
+					},
+				}
+			})
+
+			It("starts resolver forwarding to DNS servers of the default DNS network", func() {
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDNSServer.Started).To(BeTrue())
+				Expect(fakeDNSServer.Upstreams).To(Equal([]string{"8.8.8.8", "8.8.4.4"}))
+				Expect(cmdRunner.RunCommands).To(ContainElement([]string{"ip", "addr", "add", "169.254.0.2/32", "dev", "lo"}))
+			})
+
+			It("puts resolver in front of DNS servers of the default DNS network", func() {
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(netManager.SetupNetworkingNetworks["net1"].DNS).To(Equal([]string{"169.254.0.2", "8.8.8.8", "8.8.4.4"}))
+				Expect(netManager.SetupNetworkingNetworks["net2"].DNS).To(Equal([]string{"1.1.1.1"}))
+				Expect(networks["net1"].DNS).To(Equal([]string{"169.254.0.2", "8.8.8.8", "8.8.4.4"}))
+			})
+
+			It("uses the only network when it is not marked as default for DNS", func() {
+				networks = boshsettings.Networks{
+					"net1": boshsettings.Network{IP: "10.0.0.5", DNS: []string{"8.8.8.8"}},
+				}
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDNSServer.Upstreams).To(Equal([]string{"8.8.8.8"}))
+				Expect(netManager.SetupNetworkingNetworks["net1"].DNS).To(Equal([]string{"169.254.0.2", "8.8.8.8"}))
+			})
+
+			It("does not change networks when none of them is default for DNS", func() {
+				networks = boshsettings.Networks{
+					"net1": boshsettings.Network{IP: "10.0.0.5", DNS: []string{"8.8.8.8"}},
+					"net2": boshsettings.Network{IP: "10.1.0.5", DNS: []string{"1.1.1.1"}},
+				}
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDNSServer.Started).To(BeTrue())
+				Expect(fakeDNSServer.Upstreams).To(BeEmpty())
+				Expect(netManager.SetupNetworkingNetworks).To(Equal(networks))
+			})
+
+			It("does not add resolver address to loopback device again", func() {
+				cmdRunner.AddCmdResult("ip addr show dev lo", fakesys.FakeCmdResult{
+					Stdout: "    inet 169.254.0.2/32 scope global lo\n",
+				})
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{{"ip", "addr", "show", "dev", "lo"}}))
+			})
+
+			It("loads records from the last DNS sync", func() {
+				err := fs.WriteFileString("/fake-dir/instance/dns/records.json", `{"Version":7,"records":[["10.0.0.10","web.bosh"]]}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDNSServer.UpdateRecordsDNSRecords).To(Equal([]boshsettings.DNSRecords{
+					{Version: 7, Records: [][2]string{{"10.0.0.10", "web.bosh"}}},
+				}))
+			})
+
+			It("returns error when resolver fails to start", func() {
+				fakeDNSServer.StartErr = errors.New("fake-start-err")
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+
+				Expect(netManager.SetupNetworkingNetworks).To(BeNil())
+			})
+
+			It("does not start resolver for preconfigured networks", func() {
+				networks = boshsettings.Networks{
+					"net1": boshsettings.Network{DNS: []string{"8.8.8.8"}, Preconfigured: true},
+				}
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDNSServer.Started).To(BeFalse())
+				Expect(netManager.SetupNetworkingNetworks).To(Equal(networks))
+			})
+		})
 	})
 
 	Describe("GetConfiguredNetworkInterfaces", func() {
@@ -3210,6 +3511,34 @@ unit: sectors
 			Expect(hostsFileContents).Should(MatchRegexp("fake-ip0\\s+fake-name0\\n"))
 			Expect(hostsFileContents).Should(MatchRegexp("fake-ip1\\s+fake-name1\\n"))
 		})
+
+		Context("with local DNS resolver", func() {
+			var fakeDNSServer *fakedns.FakeServer
+
+			BeforeEach(func() {
+				fakeDNSServer = &fakedns.FakeServer{}
+				localDNSServer = fakeDNSServer
+			})
+
+			It("reloads resolver records instead of writing them to '/etc/hosts'", func() {
+				err := platform.SaveDNSRecords(dnsRecords, "fake-hostname")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeDNSServer.UpdateRecordsDNSRecords).To(Equal([]boshsettings.DNSRecords{dnsRecords}))
+
+				hostsFileContents, err := fs.ReadFileString("/etc/hosts")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hostsFileContents).To(Equal(defaultEtcHosts))
+			})
+
+			It("returns error when resolver rejects records", func() {
+				fakeDNSServer.UpdateRecordsErr = errors.New("fake-update-err")
+
+				err := platform.SaveDNSRecords(dnsRecords, "fake-hostname")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-update-err"))
+			})
+		})
 	})
 
 	Describe("SetupDNSRecordFile", func() {
@@ -3,6 +3,7 @@ package net
 import (
 	"bytes"
 	"path"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -61,7 +62,7 @@ func (net centosNetManager) SetupNetworking(networks boshsettings.Networks, errC
 		nonVipNetworks[networkName] = networkSettings
 	}
 
-	staticInterfaceConfigurations, dhcpInterfaceConfigurations, err := net.buildInterfaces(nonVipNetworks)
+	staticInterfaceConfigurations, dhcpInterfaceConfigurations, links, err := net.buildInterfaces(nonVipNetworks)
 	if err != nil {
 		return err
 	}
@@ -69,7 +70,7 @@ func (net centosNetManager) SetupNetworking(networks boshsettings.Networks, errC
 	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
 	dnsServers := dnsNetwork.DNS
 
-	interfacesChanged, err := net.writeNetworkInterfaces(dhcpInterfaceConfigurations, staticInterfaceConfigurations, dnsServers)
+	interfacesChanged, err := net.writeNetworkInterfaces(dhcpInterfaceConfigurations, staticInterfaceConfigurations, links, dnsServers)
 	if err != nil {
 		return bosherr.WrapError(err, "Writing network configuration")
 	}
@@ -123,7 +124,14 @@ func (net centosNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 const centosDHCPIfcfgTemplate = `DEVICE={{ .Name }}
 BOOTPROTO=dhcp
 ONBOOT=yes
-PEERDNS=yes
+PEERDNS=yes{{ range .LinkOptions }}
+{{ . }}{{ end }}
+`
+
+const centosLinkIfcfgTemplate = `DEVICE={{ .Name }}
+BOOTPROTO=none
+ONBOOT=yes{{ range .LinkOptions }}
+{{ . }}{{ end }}
 `
 
 const centosStaticIfcfgTemplate = `DEVICE={{ .Name }}
@@ -134,12 +142,56 @@ BROADCAST={{ .Broadcast }}{{if .IsDefaultForGateway}}
 GATEWAY={{ .Gateway }}{{end}}
 ONBOOT=yes
 PEERDNS=no{{ range .DNSServers }}
-DNS{{ .Index }}={{ .Address }}{{ end }}
+DNS{{ .Index }}={{ .Address }}{{ end }}{{ if .IPv6CIDR }}
+IPV6INIT=yes
+IPV6ADDR={{ .IPv6CIDR }}{{ if and .IsDefaultForGateway .IPv6Gateway }}
+IPV6_DEFAULTGW={{ .IPv6Gateway }}{{ end }}{{ end }}{{ range .LinkOptions }}
+{{ . }}{{ end }}
 `
 
 type centosStaticIfcfg struct {
 	*StaticInterfaceConfiguration
-	DNSServers []dnsConfig
+	DNSServers  []dnsConfig
+	IPv6CIDR    string
+	LinkOptions []string
+}
+
+type centosDHCPIfcfg struct {
+	*DHCPInterfaceConfiguration
+	LinkOptions []string
+}
+
+type centosLinkIfcfg struct {
+	Name        string
+	LinkOptions []string
+}
+
+// centosLinkOptions returns ifcfg variables that make network-scripts
+// create bonds and VLANs before addresses are configured on them
+func centosLinkOptions(config renderedInterfaceConfiguration) []string {
+	options := []string{}
+
+	if bond := config.Bond; bond != nil {
+		bondingOpts := "mode=" + bond.Mode
+		if bond.MIIMon != 0 {
+			bondingOpts += " miimon=" + strconv.Itoa(bond.MIIMon)
+		}
+		options = append(options, "TYPE=Bond", "BONDING_MASTER=yes", `BONDING_OPTS="`+bondingOpts+`"`)
+	}
+
+	if config.BondMaster != "" {
+		options = append(options, "MASTER="+config.BondMaster, "SLAVE=yes")
+	}
+
+	if config.VLAN != nil {
+		options = append(options, "VLAN=yes", "PHYSDEV="+config.VLAN.Link)
+	}
+
+	if config.MTU != 0 {
+		options = append(options, "MTU="+strconv.Itoa(config.MTU))
+	}
+
+	return options
 }
 
 type dnsConfig struct {
@@ -176,15 +228,51 @@ func (net centosNetManager) writeIfcfgFile(name string, t *template.Template, co
 	return changed, nil
 }
 
-func (net centosNetManager) writeNetworkInterfaces(dhcpInterfaceConfigurations []DHCPInterfaceConfiguration, staticInterfaceConfigurations []StaticInterfaceConfiguration, dnsServers []string) (bool, error) {
+func (net centosNetManager) writeNetworkInterfaces(dhcpInterfaceConfigurations []DHCPInterfaceConfiguration, staticInterfaceConfigurations []StaticInterfaceConfiguration, links LinkConfigurations, dnsServers []string) (bool, error) {
 	anyInterfaceChanged := false
 
+	linkOptions := map[string][]string{}
+	addressed := map[string]bool{}
+	for _, config := range staticInterfaceConfigurations {
+		addressed[config.Name] = true
+	}
+	for _, config := range dhcpInterfaceConfigurations {
+		addressed[config.Name] = true
+	}
+
+	linkTemplate := template.Must(template.New("ifcfg").Parse(centosLinkIfcfgTemplate))
+
+	for _, config := range newRenderedLinkConfigurations(staticInterfaceConfigurations, dhcpInterfaceConfigurations, links) {
+		linkOptions[config.Name] = centosLinkOptions(config)
+
+		if addressed[config.Name] {
+			continue
+		}
+
+		changed, err := net.writeIfcfgFile(config.Name, linkTemplate, centosLinkIfcfg{Name: config.Name, LinkOptions: linkOptions[config.Name]})
+		if err != nil {
+			return false, bosherr.WrapError(err, "Writing link config")
+		}
+
+		anyInterfaceChanged = anyInterfaceChanged || changed
+	}
+
 	staticConfig := centosStaticIfcfg{}
 	staticConfig.DNSServers = newDNSConfigs(dnsServers)
 	staticTemplate := template.Must(template.New("ifcfg").Parse(centosStaticIfcfgTemplate))
 
 	for i := range staticInterfaceConfigurations {
 		staticConfig.StaticInterfaceConfiguration = &staticInterfaceConfigurations[i]
+		staticConfig.IPv6CIDR = ""
+		staticConfig.LinkOptions = linkOptions[staticConfig.StaticInterfaceConfiguration.Name]
+
+		if staticConfig.IPv6Address != "" {
+			ipv6CIDR, err := cidrNotation(staticConfig.IPv6Address, staticConfig.IPv6Netmask)
+			if err != nil {
+				return false, bosherr.WrapError(err, "Building IPv6 address")
+			}
+			staticConfig.IPv6CIDR = ipv6CIDR
+		}
 
 		changed, err := net.writeIfcfgFile(staticConfig.StaticInterfaceConfiguration.Name, staticTemplate, staticConfig)
 		if err != nil {
@@ -192,12 +280,22 @@ func (net centosNetManager) writeNetworkInterfaces(dhcpInterfaceConfigurations [
 		}
 
 		anyInterfaceChanged = anyInterfaceChanged || changed
+
+		changed, err = net.writePolicyRoutingFiles(*staticConfig.StaticInterfaceConfiguration)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Writing policy routing config")
+		}
+
+		anyInterfaceChanged = anyInterfaceChanged || changed
 	}
 
 	dhcpTemplate := template.Must(template.New("ifcfg").Parse(centosDHCPIfcfgTemplate))
 
 	for i := range dhcpInterfaceConfigurations {
-		config := &dhcpInterfaceConfigurations[i]
+		config := centosDHCPIfcfg{
+			DHCPInterfaceConfiguration: &dhcpInterfaceConfigurations[i],
+			LinkOptions:                linkOptions[dhcpInterfaceConfigurations[i].Name],
+		}
 
 		changed, err := net.writeIfcfgFile(config.Name, dhcpTemplate, config)
 		if err != nil {
@@ -210,19 +308,82 @@ func (net centosNetManager) writeNetworkInterfaces(dhcpInterfaceConfigurations [
 	return anyInterfaceChanged, nil
 }
 
-func (net centosNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+// writePolicyRoutingFiles writes route-, rule-, route6- and rule6- files
+// which network-scripts pass to 'ip route add' and 'ip rule add' when bringing interface up
+func (net centosNetManager) writePolicyRoutingFiles(config StaticInterfaceConfiguration) (bool, error) {
+	rendered, err := newStaticRenderedInterfaceConfiguration(config, nil)
+	if err != nil {
+		return false, err
+	}
+
+	contents := map[string]string{}
+
+	for _, route := range rendered.Routes {
+		if route.Table == 0 {
+			continue
+		}
+
+		prefix := "route-"
+		if isIPv6CIDR(route.Destination) {
+			prefix = "route6-"
+		}
+		contents[prefix] += route.ipRouteSpec(config.Name) + "\n"
+	}
+
+	for _, rule := range rendered.RoutingPolicyRules {
+		prefix := "rule-"
+		if isIPv6CIDR(rule.From) {
+			prefix = "rule6-"
+		}
+		contents[prefix] += rule.ipRuleSpec() + "\n"
+	}
+
+	anyFileChanged := false
+
+	for _, prefix := range []string{"route-", "rule-", "route6-", "rule6-"} {
+		filePath := path.Join("/etc/sysconfig/network-scripts", prefix+config.Name)
+
+		content, found := contents[prefix]
+		if !found {
+			if net.fs.FileExists(filePath) {
+				err = net.fs.RemoveAll(filePath)
+				if err != nil {
+					return false, bosherr.WrapErrorf(err, "Removing '%s'", filePath)
+				}
+				anyFileChanged = true
+			}
+			continue
+		}
+
+		changed, err := net.fs.ConvergeFileContents(filePath, []byte(content))
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Writing config to '%s'", filePath)
+		}
+
+		anyFileChanged = anyFileChanged || changed
+	}
+
+	return anyFileChanged, nil
+}
+
+func (net centosNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, LinkConfigurations, error) {
 	interfacesByMacAddress, err := net.detectMacAddresses()
 	if err != nil {
-		return nil, nil, bosherr.WrapError(err, "Getting network interfaces")
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Getting network interfaces")
 	}
 
 	staticInterfaceConfigurations, dhcpInterfaceConfigurations, err := net.interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMacAddress)
 
 	if err != nil {
-		return nil, nil, bosherr.WrapError(err, "Creating interface configurations")
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating interface configurations")
+	}
+
+	links, err := net.interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating link configurations")
 	}
 
-	return staticInterfaceConfigurations, dhcpInterfaceConfigurations, nil
+	return staticInterfaceConfigurations, dhcpInterfaceConfigurations, links, nil
 }
 
 func (net centosNetManager) broadcastIps(addresses []boship.InterfaceAddress, errCh chan error) {
@@ -161,6 +161,118 @@ prepend domain-name-servers 8.8.8.8, 9.9.9.9;
 			Expect(dhcpConfig.StringContents()).To(Equal(expectedNetworkConfigurationForDHCP))
 		})
 
+		It("writes IPv6 settings and policy routing files for static interfaces", func() {
+			staticNetwork.IPv6Address = "fd00::4"
+			staticNetwork.IPv6Netmask = "64"
+			staticNetwork.IPv6Gateway = "fd00::1"
+			staticNetwork.Default = []string{"gateway"}
+			staticNetwork.RoutingTable = 100
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-ethstatic")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`DEVICE=ethstatic
+BOOTPROTO=static
+IPADDR=1.2.3.4
+NETMASK=255.255.255.0
+BROADCAST=1.2.3.255
+GATEWAY=3.4.5.6
+ONBOOT=yes
+PEERDNS=no
+DNS1=8.8.8.8
+DNS2=9.9.9.9
+IPV6INIT=yes
+IPV6ADDR=fd00::4/64
+IPV6_DEFAULTGW=fd00::1
+`))
+
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/route-ethstatic")).To(Equal(`1.2.3.0/24 dev ethstatic scope link table 100
+0.0.0.0/0 via 3.4.5.6 dev ethstatic table 100
+`))
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/rule-ethstatic")).To(Equal("from 1.2.3.4/32 table 100\n"))
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/route6-ethstatic")).To(Equal(`fd00::/64 dev ethstatic scope link table 100
+::/0 via fd00::1 dev ethstatic table 100
+`))
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/rule6-ethstatic")).To(Equal("from fd00::4/128 table 100\n"))
+		})
+
+		It("writes network scripts for bonds, VLANs and their member interfaces", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "aa:01"},
+				"eth1": {Mac: "aa:02"},
+				"eth2": {Mac: "aa:03"},
+			})
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0", "1.2.3.4"),
+			}
+
+			bondedNetwork := staticNetwork
+			bondedNetwork.Mac = ""
+			bondedNetwork.Bond = &boshsettings.Bond{Name: "bond0", Interfaces: []string{"aa:01", "aa:02"}, MIIMon: 100}
+			bondedNetwork.MTU = 9000
+
+			taggedNetwork := dhcpNetwork
+			taggedNetwork.Default = nil
+			taggedNetwork.Mac = "aa:03"
+			taggedNetwork.VLAN = 100
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"bonded": bondedNetwork, "tagged": taggedNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/ifcfg-bond0")).To(Equal(`DEVICE=bond0
+BOOTPROTO=static
+IPADDR=1.2.3.4
+NETMASK=255.255.255.0
+BROADCAST=1.2.3.255
+ONBOOT=yes
+PEERDNS=no
+TYPE=Bond
+BONDING_MASTER=yes
+BONDING_OPTS="mode=active-backup miimon=100"
+MTU=9000
+`))
+
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/ifcfg-eth0")).To(Equal(`DEVICE=eth0
+BOOTPROTO=none
+ONBOOT=yes
+MASTER=bond0
+SLAVE=yes
+MTU=9000
+`))
+
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/ifcfg-eth2")).To(Equal(`DEVICE=eth2
+BOOTPROTO=none
+ONBOOT=yes
+`))
+
+			Expect(fs.ReadFileString("/etc/sysconfig/network-scripts/ifcfg-eth2.100")).To(Equal(`DEVICE=eth2.100
+BOOTPROTO=dhcp
+ONBOOT=yes
+PEERDNS=yes
+VLAN=yes
+PHYSDEV=eth2
+`))
+		})
+
+		It("removes policy routing files when routing table is no longer set", func() {
+			fs.WriteFileString("/etc/sysconfig/network-scripts/rule-ethstatic", "from 1.2.3.4/32 table 100\n")
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/sysconfig/network-scripts/rule-ethstatic")).To(BeFalse())
+		})
+
 		It("returns errors from glob /sys/class/net/", func() {
 			fs.GlobErr = errors.New("fs-glob-error")
 			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
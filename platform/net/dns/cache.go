@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	maxCacheEntries = 4096
+	maxCacheTTL     = 5 * time.Minute
+)
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type cacheEntry struct {
+	response  []byte
+	expiresAt time.Time
+}
+
+// responseCache keeps upstream responses for as long as their smallest TTL allows
+type responseCache struct {
+	entries     map[cacheKey]cacheEntry
+	timeService clock.Clock
+	lock        sync.Mutex
+}
+
+func newResponseCache(timeService clock.Clock) *responseCache {
+	return &responseCache{
+		entries:     map[cacheKey]cacheEntry{},
+		timeService: timeService,
+	}
+}
+
+// Get returns a copy of the cached response so that callers can rewrite its ID
+func (c *responseCache) Get(key cacheKey) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	if !c.timeService.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	response := make([]byte, len(entry.response))
+	copy(response, entry.response)
+
+	return response, true
+}
+
+func (c *responseCache) Set(key cacheKey, response []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	if ttl > maxCacheTTL {
+		ttl = maxCacheTTL
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.timeService.Now()
+
+	if len(c.entries) >= maxCacheEntries {
+		for existingKey, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, existingKey)
+			}
+		}
+	}
+
+	// Evict arbitrary entries when nothing has expired yet
+	for existingKey := range c.entries {
+		if len(c.entries) < maxCacheEntries {
+			break
+		}
+		delete(c.entries, existingKey)
+	}
+
+	stored := make([]byte, len(response))
+	copy(stored, response)
+
+	c.entries[key] = cacheEntry{response: stored, expiresAt: now.Add(ttl)}
+}
@@ -0,0 +1,13 @@
+package dns_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDNS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DNS Suite")
+}
@@ -0,0 +1,11 @@
+package dns
+
+import (
+	"github.com/pivotal-golang/clock"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+func NewServerWithMaxUDPQueries(ip string, port uint16, maxUDPQueries int, timeService clock.Clock, logger boshlog.Logger) Server {
+	return newServer(ip, port, maxUDPQueries, timeService, logger)
+}
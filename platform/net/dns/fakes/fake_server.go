@@ -0,0 +1,43 @@
+package fakes
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type FakeServer struct {
+	AddressIP string
+
+	Started  bool
+	StartErr error
+
+	Stopped bool
+	StopErr error
+
+	Upstreams []string
+
+	UpdateRecordsDNSRecords []boshsettings.DNSRecords
+	UpdateRecordsErr        error
+}
+
+func (s *FakeServer) Start() error {
+	s.Started = true
+	return s.StartErr
+}
+
+func (s *FakeServer) Stop() error {
+	s.Stopped = true
+	return s.StopErr
+}
+
+func (s *FakeServer) Address() string {
+	return s.AddressIP
+}
+
+func (s *FakeServer) SetUpstreams(upstreams []string) {
+	s.Upstreams = upstreams
+}
+
+func (s *FakeServer) UpdateRecords(dnsRecords boshsettings.DNSRecords) error {
+	s.UpdateRecordsDNSRecords = append(s.UpdateRecordsDNSRecords, dnsRecords)
+	return s.UpdateRecordsErr
+}
@@ -0,0 +1,314 @@
+package dns
+
+import (
+	"encoding/binary"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Only the subset of RFC 1035 needed to answer questions
+// about local records and to inspect upstream responses is implemented
+
+const (
+	typeA    uint16 = 1
+	typeAAAA uint16 = 28
+	typeSRV  uint16 = 33
+	typeANY  uint16 = 255
+
+	classINET uint16 = 1
+
+	rcodeSuccess        = 0
+	rcodeFormatError    = 1
+	rcodeServerFailure  = 2
+	rcodeNameError      = 3
+	rcodeNotImplemented = 4
+
+	headerLen = 12
+
+	flagResponse           = 1 << 15
+	flagAuthoritative      = 1 << 10
+	flagTruncated          = 1 << 9
+	flagRecursionDesired   = 1 << 8
+	flagRecursionAvailable = 1 << 7
+
+	maxUDPMessageLen = 512
+	maxNameLen       = 255
+	maxLabelLen      = 63
+
+	// Pointer to the question name which always follows the header
+	questionNamePointer = 0xC000 | headerLen
+)
+
+type header struct {
+	ID      uint16
+	Flags   uint16
+	QDCount uint16
+	ANCount uint16
+	NSCount uint16
+	ARCount uint16
+}
+
+type question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+
+	// Offset right after the question section
+	end int
+}
+
+type resourceRecord struct {
+	Type uint16
+	TTL  uint32
+	Data []byte
+}
+
+func parseHeader(msg []byte) (header, error) {
+	if len(msg) < headerLen {
+		return header{}, bosherr.Error("Message is shorter than DNS header")
+	}
+
+	return header{
+		ID:      binary.BigEndian.Uint16(msg[0:]),
+		Flags:   binary.BigEndian.Uint16(msg[2:]),
+		QDCount: binary.BigEndian.Uint16(msg[4:]),
+		ANCount: binary.BigEndian.Uint16(msg[6:]),
+		NSCount: binary.BigEndian.Uint16(msg[8:]),
+		ARCount: binary.BigEndian.Uint16(msg[10:]),
+	}, nil
+}
+
+func (h header) opcode() uint16 { return (h.Flags >> 11) & 0xF }
+
+func (h header) rcode() int { return int(h.Flags & 0xF) }
+
+func (h header) isResponse() bool { return h.Flags&flagResponse != 0 }
+
+// parseQuery returns the single question of a standard query
+func parseQuery(msg []byte) (header, question, error) {
+	h, err := parseHeader(msg)
+	if err != nil {
+		return h, question{}, err
+	}
+
+	if h.isResponse() || h.QDCount != 1 {
+		return h, question{}, bosherr.Error("Expected query with a single question")
+	}
+
+	q, err := parseQuestion(msg, headerLen)
+	if err != nil {
+		return h, question{}, bosherr.WrapError(err, "Parsing question")
+	}
+
+	return h, q, nil
+}
+
+func parseQuestion(msg []byte, offset int) (question, error) {
+	name, offset, err := readName(msg, offset)
+	if err != nil {
+		return question{}, err
+	}
+
+	if offset+4 > len(msg) {
+		return question{}, bosherr.Error("Question is truncated")
+	}
+
+	return question{
+		Name:  name,
+		Type:  binary.BigEndian.Uint16(msg[offset:]),
+		Class: binary.BigEndian.Uint16(msg[offset+2:]),
+		end:   offset + 4,
+	}, nil
+}
+
+// readName returns lower cased name without trailing dot
+// and the offset right after the name
+func readName(msg []byte, offset int) (string, int, error) {
+	labels := []string{}
+	nameLen := 0
+	end := -1
+
+	// Each pointer must point backwards which bounds the number of jumps
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, bosherr.Error("Name is truncated")
+		}
+
+		labelLen := int(msg[offset])
+
+		switch labelLen & 0xC0 {
+		case 0x00:
+			if labelLen == 0 {
+				if end < 0 {
+					end = offset + 1
+				}
+				return strings.ToLower(strings.Join(labels, ".")), end, nil
+			}
+
+			if offset+1+labelLen > len(msg) {
+				return "", 0, bosherr.Error("Label is truncated")
+			}
+
+			nameLen += labelLen + 1
+			if nameLen > maxNameLen {
+				return "", 0, bosherr.Error("Name is too long")
+			}
+
+			labels = append(labels, string(msg[offset+1:offset+1+labelLen]))
+			offset += 1 + labelLen
+
+		case 0xC0:
+			if offset+2 > len(msg) {
+				return "", 0, bosherr.Error("Name pointer is truncated")
+			}
+
+			if end < 0 {
+				end = offset + 2
+			}
+
+			pointer := int(binary.BigEndian.Uint16(msg[offset:]) & 0x3FFF)
+			if pointer >= offset || jumps > maxNameLen {
+				return "", 0, bosherr.Error("Invalid name pointer")
+			}
+
+			offset = pointer
+			jumps++
+
+		default:
+			return "", 0, bosherr.Error("Unsupported label type")
+		}
+	}
+}
+
+func packName(name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+
+	packed := []byte{}
+
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > maxLabelLen {
+				return nil, bosherr.Errorf("Invalid label in name '%s'", name)
+			}
+
+			packed = append(packed, byte(len(label)))
+			packed = append(packed, label...)
+		}
+	}
+
+	packed = append(packed, 0)
+
+	if len(packed) > maxNameLen {
+		return nil, bosherr.Errorf("Name '%s' is too long", name)
+	}
+
+	return packed, nil
+}
+
+func packSRV(record boshsettings.SRVRecord) ([]byte, error) {
+	target, err := packName(record.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 6, 6+len(target))
+	binary.BigEndian.PutUint16(data[0:], record.Priority)
+	binary.BigEndian.PutUint16(data[2:], record.Weight)
+	binary.BigEndian.PutUint16(data[4:], record.Port)
+
+	return append(data, target...), nil
+}
+
+// buildResponse echoes the question of the query
+// so that the client sees the name in the same case it asked for;
+// all answers refer to the question name
+func buildResponse(query []byte, h header, q question, rcode int, answers []resourceRecord, maxLen int) []byte {
+	flags := uint16(flagResponse|flagRecursionAvailable) | h.Flags&(0xF<<11|flagRecursionDesired) | uint16(rcode)
+	if rcode == rcodeSuccess || rcode == rcodeNameError {
+		flags |= flagAuthoritative
+	}
+
+	response := make([]byte, headerLen, maxUDPMessageLen)
+	binary.BigEndian.PutUint16(response[0:], h.ID)
+	binary.BigEndian.PutUint16(response[4:], 1)
+	response = append(response, query[headerLen:q.end]...)
+
+	for _, answer := range answers {
+		rr := make([]byte, 12, 12+len(answer.Data))
+		binary.BigEndian.PutUint16(rr[0:], questionNamePointer)
+		binary.BigEndian.PutUint16(rr[2:], answer.Type)
+		binary.BigEndian.PutUint16(rr[4:], classINET)
+		binary.BigEndian.PutUint32(rr[6:], answer.TTL)
+		binary.BigEndian.PutUint16(rr[10:], uint16(len(answer.Data)))
+		rr = append(rr, answer.Data...)
+
+		if maxLen > 0 && len(response)+len(rr) > maxLen {
+			flags |= flagTruncated
+			break
+		}
+
+		response = append(response, rr...)
+		binary.BigEndian.PutUint16(response[6:], binary.BigEndian.Uint16(response[6:])+1)
+	}
+
+	binary.BigEndian.PutUint16(response[2:], flags)
+
+	return response
+}
+
+// buildErrorResponse is used when the question could not be parsed
+func buildErrorResponse(h header, rcode int) []byte {
+	response := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(response[0:], h.ID)
+	binary.BigEndian.PutUint16(response[2:], flagResponse|flagRecursionAvailable|h.Flags&(0xF<<11|flagRecursionDesired)|uint16(rcode))
+	return response
+}
+
+// minTTL returns the smallest TTL of answer and authority records
+// which is how long a response may be cached
+func minTTL(msg []byte) (uint32, bool, error) {
+	h, err := parseHeader(msg)
+	if err != nil {
+		return 0, false, err
+	}
+
+	offset := headerLen
+
+	for i := 0; i < int(h.QDCount); i++ {
+		q, err := parseQuestion(msg, offset)
+		if err != nil {
+			return 0, false, err
+		}
+		offset = q.end
+	}
+
+	var ttl uint32
+	found := false
+
+	for i := 0; i < int(h.ANCount)+int(h.NSCount); i++ {
+		_, offset, err = readName(msg, offset)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if offset+10 > len(msg) {
+			return 0, false, bosherr.Error("Resource record is truncated")
+		}
+
+		rrTTL := binary.BigEndian.Uint32(msg[offset+4:])
+		if !found || rrTTL < ttl {
+			ttl = rrTTL
+			found = true
+		}
+
+		offset += 10 + int(binary.BigEndian.Uint16(msg[offset+8:]))
+	}
+
+	return ttl, found, nil
+}
+
+func setID(msg []byte, id uint16) {
+	binary.BigEndian.PutUint16(msg[0:], id)
+}
@@ -0,0 +1,96 @@
+package dns
+
+import (
+	"net"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Short TTL lets clients pick up reloaded records quickly
+const localRecordTTL = 5
+
+// records is never modified after creation so that
+// it can be swapped atomically while queries are being answered
+type records struct {
+	version uint64
+	a       map[string][]net.IP
+	aaaa    map[string][]net.IP
+	srv     map[string][][]byte
+}
+
+func newRecords(dnsRecords boshsettings.DNSRecords) (*records, error) {
+	r := &records{
+		version: dnsRecords.Version,
+		a:       map[string][]net.IP{},
+		aaaa:    map[string][]net.IP{},
+		srv:     map[string][][]byte{},
+	}
+
+	for _, record := range dnsRecords.Records {
+		ip := net.ParseIP(record[0])
+		if ip == nil {
+			return nil, bosherr.Errorf("Invalid IP address '%s' for '%s'", record[0], record[1])
+		}
+
+		name := canonicalName(record[1])
+		if name == "" {
+			return nil, bosherr.Errorf("Missing name for IP address '%s'", record[0])
+		}
+
+		if ipv4 := ip.To4(); ipv4 != nil {
+			r.a[name] = append(r.a[name], ipv4)
+		} else {
+			r.aaaa[name] = append(r.aaaa[name], ip)
+		}
+	}
+
+	for _, record := range dnsRecords.SRVRecords {
+		name := canonicalName(record.Name)
+		if name == "" || canonicalName(record.Target) == "" {
+			return nil, bosherr.Errorf("SRV record '%s' requires both name and target", record.Name)
+		}
+
+		data, err := packSRV(record)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Packing SRV record '%s'", record.Name)
+		}
+
+		r.srv[name] = append(r.srv[name], data)
+	}
+
+	return r, nil
+}
+
+func (r *records) has(name string) bool {
+	return len(r.a[name]) > 0 || len(r.aaaa[name]) > 0 || len(r.srv[name]) > 0
+}
+
+func (r *records) answers(name string, qtype uint16) []resourceRecord {
+	var answers []resourceRecord
+
+	if qtype == typeA || qtype == typeANY {
+		for _, ip := range r.a[name] {
+			answers = append(answers, resourceRecord{Type: typeA, TTL: localRecordTTL, Data: []byte(ip)})
+		}
+	}
+
+	if qtype == typeAAAA || qtype == typeANY {
+		for _, ip := range r.aaaa[name] {
+			answers = append(answers, resourceRecord{Type: typeAAAA, TTL: localRecordTTL, Data: []byte(ip)})
+		}
+	}
+
+	if qtype == typeSRV || qtype == typeANY {
+		for _, data := range r.srv[name] {
+			answers = append(answers, resourceRecord{Type: typeSRV, TTL: localRecordTTL, Data: data})
+		}
+	}
+
+	return answers
+}
+
+func canonicalName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,388 @@
+package dns
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const concreteServerLogTag = "dnsServer"
+
+const (
+	upstreamPort   = "53"
+	forwardTimeout = 2 * time.Second
+	tcpIdleTimeout = 10 * time.Second
+	maxMessageLen  = 65535
+
+	// Queries that arrive while this many udp queries are being handled
+	// wait in the socket buffer and are dropped by the kernel when it fills up
+	maxConcurrentUDPQueries = 64
+)
+
+type concreteServer struct {
+	ip     string
+	port   uint16
+	cache  *responseCache
+	logger boshlog.Logger
+
+	records   atomic.Value // *records
+	upstreams atomic.Value // []string
+
+	// Serializes record updates so that older versions never replace newer ones
+	updateLock sync.Mutex
+
+	// Limits number of udp queries handled at the same time
+	udpQueries chan struct{}
+
+	lock        sync.Mutex
+	udpConn     net.PacketConn
+	tcpListener net.Listener
+}
+
+func NewServer(ip string, port uint16, timeService clock.Clock, logger boshlog.Logger) Server {
+	return newServer(ip, port, maxConcurrentUDPQueries, timeService, logger)
+}
+
+func newServer(ip string, port uint16, maxUDPQueries int, timeService clock.Clock, logger boshlog.Logger) Server {
+	s := &concreteServer{
+		ip:         ip,
+		port:       port,
+		cache:      newResponseCache(timeService),
+		logger:     logger,
+		udpQueries: make(chan struct{}, maxUDPQueries),
+	}
+
+	s.upstreams.Store([]string{})
+
+	return s
+}
+
+func (s *concreteServer) Address() string {
+	return s.ip
+}
+
+// Start listens on both UDP and TCP and serves queries in the background;
+// starting already started server does nothing
+func (s *concreteServer) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.udpConn != nil {
+		return nil
+	}
+
+	address := net.JoinHostPort(s.ip, strconv.Itoa(int(s.port)))
+
+	udpConn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on udp %s", address)
+	}
+
+	tcpListener, err := net.Listen("tcp", address)
+	if err != nil {
+		s.closeConn(udpConn)
+		return bosherr.WrapErrorf(err, "Listening on tcp %s", address)
+	}
+
+	s.udpConn = udpConn
+	s.tcpListener = tcpListener
+
+	go s.serveUDP(udpConn)
+	go s.serveTCP(tcpListener)
+
+	return nil
+}
+
+func (s *concreteServer) Stop() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.udpConn == nil {
+		return nil
+	}
+
+	udpErr := s.udpConn.Close()
+	tcpErr := s.tcpListener.Close()
+
+	s.udpConn = nil
+	s.tcpListener = nil
+
+	if udpErr != nil {
+		return bosherr.WrapError(udpErr, "Closing udp listener")
+	}
+
+	if tcpErr != nil {
+		return bosherr.WrapError(tcpErr, "Closing tcp listener")
+	}
+
+	return nil
+}
+
+func (s *concreteServer) SetUpstreams(upstreams []string) {
+	s.upstreams.Store(append([]string{}, upstreams...))
+}
+
+// UpdateRecords replaces all records at once;
+// queries never observe a partially loaded set of records
+func (s *concreteServer) UpdateRecords(dnsRecords boshsettings.DNSRecords) error {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+
+	current := s.currentRecords()
+	if current != nil && dnsRecords.Version < current.version {
+		s.logger.Debug(concreteServerLogTag, "Ignoring DNS records version %d older than loaded version %d", dnsRecords.Version, current.version)
+		return nil
+	}
+
+	newRecords, err := newRecords(dnsRecords)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Loading DNS records version %d", dnsRecords.Version)
+	}
+
+	s.records.Store(newRecords)
+
+	return nil
+}
+
+func (s *concreteServer) currentRecords() *records {
+	current, _ := s.records.Load().(*records)
+	return current
+}
+
+func (s *concreteServer) currentUpstreams() []string {
+	return s.upstreams.Load().([]string)
+}
+
+func (s *concreteServer) serveUDP(conn net.PacketConn) {
+	buffer := make([]byte, maxMessageLen)
+
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			s.logger.Debug(concreteServerLogTag, "Stopped serving udp: %s", err.Error())
+			return
+		}
+
+		query := make([]byte, n)
+		copy(query, buffer[:n])
+
+		s.udpQueries <- struct{}{}
+
+		go func() {
+			defer func() { <-s.udpQueries }()
+
+			response := s.handle(query, "udp")
+			if response == nil {
+				return
+			}
+
+			_, err := conn.WriteTo(response, addr)
+			if err != nil {
+				s.logger.Error(concreteServerLogTag, "Failed to write udp response: %s", err.Error())
+			}
+		}()
+	}
+}
+
+func (s *concreteServer) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.logger.Debug(concreteServerLogTag, "Stopped serving tcp: %s", err.Error())
+			return
+		}
+
+		go s.handleTCPConnection(conn)
+	}
+}
+
+func (s *concreteServer) handleTCPConnection(conn net.Conn) {
+	defer s.closeConn(conn)
+
+	for {
+		err := conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		if err != nil {
+			return
+		}
+
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+
+		response := s.handle(query, "tcp")
+		if response == nil {
+			return
+		}
+
+		err = writeTCPMessage(conn, response)
+		if err != nil {
+			s.logger.Error(concreteServerLogTag, "Failed to write tcp response: %s", err.Error())
+			return
+		}
+	}
+}
+
+func (s *concreteServer) handle(query []byte, network string) []byte {
+	h, q, err := parseQuery(query)
+	if err != nil {
+		if len(query) < headerLen {
+			return nil
+		}
+		return buildErrorResponse(h, rcodeFormatError)
+	}
+
+	if h.opcode() != 0 {
+		return buildErrorResponse(h, rcodeNotImplemented)
+	}
+
+	maxLen := 0
+	if network == "udp" {
+		maxLen = maxUDPMessageLen
+	}
+
+	if q.Class == classINET {
+		current := s.currentRecords()
+		if current != nil && current.has(q.Name) {
+			return buildResponse(query, h, q, rcodeSuccess, current.answers(q.Name, q.Type), maxLen)
+		}
+	}
+
+	return s.forward(query, h, q, network)
+}
+
+func (s *concreteServer) forward(query []byte, h header, q question, network string) []byte {
+	key := cacheKey{name: q.Name, qtype: q.Type, qclass: q.Class}
+
+	if response, found := s.cache.Get(key); found {
+		setID(response, h.ID)
+		return response
+	}
+
+	for _, upstream := range s.currentUpstreams() {
+		response, err := exchange(upstream, network, query, h.ID)
+		if err != nil {
+			s.logger.Debug(concreteServerLogTag, "Forwarding '%s' to %s: %s", q.Name, upstream, err.Error())
+			continue
+		}
+
+		s.cacheResponse(key, response)
+
+		return response
+	}
+
+	return buildResponse(query, h, q, rcodeServerFailure, nil, 0)
+}
+
+func (s *concreteServer) cacheResponse(key cacheKey, response []byte) {
+	h, err := parseHeader(response)
+	if err != nil || h.Flags&flagTruncated != 0 {
+		return
+	}
+
+	if h.rcode() != rcodeSuccess && h.rcode() != rcodeNameError {
+		return
+	}
+
+	ttl, found, err := minTTL(response)
+	if err != nil || !found {
+		return
+	}
+
+	s.cache.Set(key, response, time.Duration(ttl)*time.Second)
+}
+
+func (s *concreteServer) closeConn(conn io.Closer) {
+	err := conn.Close()
+	if err != nil {
+		s.logger.Error(concreteServerLogTag, "Failed to close connection: %s", err.Error())
+	}
+}
+
+func exchange(upstream, network string, query []byte, id uint16) ([]byte, error) {
+	address := upstream
+	if _, _, err := net.SplitHostPort(upstream); err != nil {
+		address = net.JoinHostPort(upstream, upstreamPort)
+	}
+
+	conn, err := net.DialTimeout(network, address, forwardTimeout)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Connecting to upstream")
+	}
+
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(forwardTimeout))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Setting upstream deadline")
+	}
+
+	if network == "tcp" {
+		err = writeTCPMessage(conn, query)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Writing query to upstream")
+		}
+
+		return readTCPMessage(conn)
+	}
+
+	_, err = conn.Write(query)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Writing query to upstream")
+	}
+
+	buffer := make([]byte, maxMessageLen)
+
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Reading response from upstream")
+		}
+
+		h, err := parseHeader(buffer[:n])
+		if err == nil && h.isResponse() && h.ID == id {
+			return buffer[:n], nil
+		}
+	}
+}
+
+func readTCPMessage(conn io.Reader) ([]byte, error) {
+	length := make([]byte, 2)
+
+	_, err := io.ReadFull(conn, length)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint16(length))
+
+	_, err = io.ReadFull(conn, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func writeTCPMessage(conn io.Writer, msg []byte) error {
+	if len(msg) > maxMessageLen {
+		return bosherr.Error("Message is too long")
+	}
+
+	framed := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(framed, uint16(len(msg)))
+
+	_, err := conn.Write(append(framed, msg...))
+
+	return err
+}
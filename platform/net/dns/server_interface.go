@@ -0,0 +1,18 @@
+package dns
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+// Server answers DNS queries from records synced by the director
+// and forwards all other queries to upstream DNS servers
+type Server interface {
+	Start() error
+	Stop() error
+
+	// Address returns the IP the server is listening on
+	Address() string
+
+	SetUpstreams(upstreams []string)
+	UpdateRecords(dnsRecords boshsettings.DNSRecords) error
+}
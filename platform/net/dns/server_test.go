@@ -0,0 +1,265 @@
+package dns_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net/dns"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+func freePort() uint16 {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	defer conn.Close()
+
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func resolverFor(port uint16, network string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: time.Second}
+			return dialer.DialContext(ctx, network, net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+		},
+	}
+}
+
+func lookupIPs(resolver *net.Resolver, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, err := resolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+
+	return ips, nil
+}
+
+var _ = Describe("concreteServer", func() {
+	var (
+		timeService *fakeclock.FakeClock
+		logger      boshlog.Logger
+		port        uint16
+		server      Server
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		port = freePort()
+		server = NewServer("127.0.0.1", port, timeService, logger)
+
+		err := server.UpdateRecords(boshsettings.DNSRecords{
+			Version: 2,
+			Records: [][2]string{
+				{"10.0.0.1", "web.bosh"},
+				{"10.0.0.2", "WEB.bosh."},
+				{"2001:db8::1", "web.bosh"},
+				{"10.0.0.3", "db.bosh"},
+			},
+			SRVRecords: []boshsettings.SRVRecord{
+				{Name: "_http._tcp.web.bosh", Target: "web.bosh", Port: 8080, Priority: 1, Weight: 10},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(server.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(server.Stop()).To(Succeed())
+	})
+
+	It("returns the address it listens on", func() {
+		Expect(server.Address()).To(Equal("127.0.0.1"))
+	})
+
+	It("does nothing when started again", func() {
+		Expect(server.Start()).To(Succeed())
+	})
+
+	for _, network := range []string{"udp", "tcp"} {
+		network := network
+
+		Context("over "+network, func() {
+			It("answers A and AAAA queries from records", func() {
+				ips, err := lookupIPs(resolverFor(port, network), "web.bosh.")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ips).To(ConsistOf("10.0.0.1", "10.0.0.2", "2001:db8::1"))
+			})
+
+			It("answers SRV queries from records", func() {
+				_, srvs, err := resolverFor(port, network).LookupSRV(context.Background(), "", "", "_http._tcp.web.bosh.")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(srvs).To(Equal([]*net.SRV{
+					{Target: "web.bosh.", Port: 8080, Priority: 1, Weight: 10},
+				}))
+			})
+		})
+	}
+
+	It("fails queries for unknown names when there are no upstreams", func() {
+		_, err := lookupIPs(resolverFor(port, "udp"), "unknown.bosh.")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("UpdateRecords", func() {
+		It("replaces all records", func() {
+			err := server.UpdateRecords(boshsettings.DNSRecords{
+				Version: 3,
+				Records: [][2]string{{"10.0.0.4", "web.bosh"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			ips, err := lookupIPs(resolverFor(port, "udp"), "web.bosh.")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ips).To(ConsistOf("10.0.0.4"))
+
+			_, err = lookupIPs(resolverFor(port, "udp"), "db.bosh.")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("ignores records older than the loaded version", func() {
+			err := server.UpdateRecords(boshsettings.DNSRecords{
+				Version: 1,
+				Records: [][2]string{{"10.0.0.4", "web.bosh"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			ips, err := lookupIPs(resolverFor(port, "udp"), "db.bosh.")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ips).To(ConsistOf("10.0.0.3"))
+		})
+
+		It("keeps previous records when new records are invalid", func() {
+			err := server.UpdateRecords(boshsettings.DNSRecords{
+				Version: 3,
+				Records: [][2]string{{"not-an-ip", "web.bosh"}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid IP address 'not-an-ip' for 'web.bosh'"))
+
+			ips, err := lookupIPs(resolverFor(port, "udp"), "db.bosh.")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ips).To(ConsistOf("10.0.0.3"))
+		})
+
+		It("returns error when SRV record has no target", func() {
+			err := server.UpdateRecords(boshsettings.DNSRecords{
+				Version:    3,
+				SRVRecords: []boshsettings.SRVRecord{{Name: "_http._tcp.web.bosh"}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SRV record '_http._tcp.web.bosh' requires both name and target"))
+		})
+	})
+
+	Context("with upstreams", func() {
+		var (
+			upstreamPort uint16
+			upstream     Server
+		)
+
+		BeforeEach(func() {
+			upstreamPort = freePort()
+			upstream = NewServer("127.0.0.1", upstreamPort, fakeclock.NewFakeClock(time.Now()), logger)
+
+			err := upstream.UpdateRecords(boshsettings.DNSRecords{
+				Records: [][2]string{{"192.168.0.1", "example.com"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(upstream.Start()).To(Succeed())
+
+			server.SetUpstreams([]string{
+				"127.0.0.1:" + strconv.Itoa(int(freePort())),
+				"127.0.0.1:" + strconv.Itoa(int(upstreamPort)),
+			})
+		})
+
+		AfterEach(func() {
+			Expect(upstream.Stop()).To(Succeed())
+		})
+
+		for _, network := range []string{"udp", "tcp"} {
+			network := network
+
+			It("forwards queries for unknown names to the first responding upstream over "+network, func() {
+				ips, err := lookupIPs(resolverFor(port, network), "example.com.")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ips).To(ConsistOf("192.168.0.1"))
+			})
+		}
+
+		It("answers from records before forwarding", func() {
+			ips, err := lookupIPs(resolverFor(port, "udp"), "db.bosh.")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ips).To(ConsistOf("10.0.0.3"))
+		})
+
+		It("does not handle more udp queries at the same time than allowed", func() {
+			unresponsiveUpstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer unresponsiveUpstream.Close()
+
+			limitedPort := freePort()
+			limitedServer := NewServerWithMaxUDPQueries("127.0.0.1", limitedPort, 1, timeService, logger)
+			Expect(limitedServer.UpdateRecords(boshsettings.DNSRecords{
+				Records: [][2]string{{"10.0.0.3", "db.bosh"}},
+			})).To(Succeed())
+			limitedServer.SetUpstreams([]string{unresponsiveUpstream.LocalAddr().String()})
+			Expect(limitedServer.Start()).To(Succeed())
+			defer limitedServer.Stop()
+
+			go func() {
+				_, _ = lookupIPs(resolverFor(limitedPort, "udp"), "example.com.")
+			}()
+
+			// Wait until the only query slot is taken by forwarded query
+			Expect(unresponsiveUpstream.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			_, _, err = unresponsiveUpstream.ReadFrom(make([]byte, 512))
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			_, err = resolverFor(limitedPort, "udp").LookupIPAddr(ctx, "db.bosh.")
+			Expect(err).To(HaveOccurred())
+
+			ips, err := lookupIPs(resolverFor(port, "udp"), "db.bosh.")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ips).To(ConsistOf("10.0.0.3"))
+		})
+
+		It("caches upstream responses until their TTL expires", func() {
+			_, err := lookupIPs(resolverFor(port, "udp"), "example.com.")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(upstream.Stop()).To(Succeed())
+
+			ips, err := lookupIPs(resolverFor(port, "udp"), "example.com.")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ips).To(ConsistOf("192.168.0.1"))
+
+			timeService.Increment(6 * time.Second)
+
+			_, err = lookupIPs(resolverFor(port, "udp"), "example.com.")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
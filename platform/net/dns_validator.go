@@ -1,6 +1,7 @@
 package net
 
 import (
+	"fmt"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -11,6 +12,15 @@ type DNSValidator interface {
 	Validate([]string) error
 }
 
+// DNSValidationError is returned when none of the desired DNS servers is configured
+type DNSValidationError struct {
+	DNSServers []string
+}
+
+func (e DNSValidationError) Error() string {
+	return fmt.Sprintf("None of the DNS servers that were specified in the manifest were found in /etc/resolv.conf. Expected one of: '%s'", strings.Join(e.DNSServers, ", "))
+}
+
 type dnsValidator struct {
 	fs boshsys.FileSystem
 }
@@ -37,5 +47,5 @@ func (d *dnsValidator) Validate(dnsServers []string) error {
 		}
 	}
 
-	return bosherr.WrapError(err, "None of the DNS servers that were specified in the manifest were found in /etc/resolv.conf.")
+	return DNSValidationError{DNSServers: dnsServers}
 }
@@ -51,5 +51,10 @@ var _ = Describe("DNSValidator", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("None of the DNS servers that were specified in the manifest were found in /etc/resolv.conf."))
 		})
+
+		It("returns structured error with expected dns servers", func() {
+			err := dnsValidator.Validate([]string{"8.8.8.8", "9.9.9.9"})
+			Expect(err).To(Equal(DNSValidationError{DNSServers: []string{"8.8.8.8", "9.9.9.9"}}))
+		})
 	})
 })
@@ -1,6 +1,9 @@
 package net
 
 import (
+	"sort"
+	"strconv"
+
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -17,6 +20,11 @@ type StaticInterfaceConfiguration struct {
 	Mac                 string
 	Gateway             string
 	PostUpRoutes        boshsettings.Routes
+	IPv6Address         string
+	IPv6Netmask         string
+	IPv6Gateway         string
+	RoutingTable        int
+	MTU                 int
 }
 
 type StaticInterfaceConfigurations []StaticInterfaceConfiguration
@@ -36,6 +44,7 @@ func (configs StaticInterfaceConfigurations) Swap(i, j int) {
 type DHCPInterfaceConfiguration struct {
 	Name         string
 	PostUpRoutes boshsettings.Routes
+	MTU          int
 }
 
 type DHCPInterfaceConfigurations []DHCPInterfaceConfiguration
@@ -52,8 +61,49 @@ func (configs DHCPInterfaceConfigurations) Swap(i, j int) {
 	configs[i], configs[j] = configs[j], configs[i]
 }
 
+// LinkInterfaceConfiguration is a physical interface that is enslaved
+// to a bond or carries VLANs; it is not addressed unless
+// a network is also configured on it directly
+type LinkInterfaceConfiguration struct {
+	Name string
+	Mac  string
+	Bond string
+	MTU  int
+}
+
+// Active-backup does not require any switch configuration
+const defaultBondMode = "active-backup"
+
+type BondConfiguration struct {
+	Name       string
+	Mode       string
+	MIIMon     int
+	Interfaces []string
+	MTU        int
+}
+
+type VLANConfiguration struct {
+	Name string
+	Link string
+	ID   int
+	MTU  int
+}
+
+// LinkConfigurations describe links that have to be created
+// before addresses of static and DHCP configurations are applied
+type LinkConfigurations struct {
+	Interfaces []LinkInterfaceConfiguration
+	Bonds      []BondConfiguration
+	VLANs      []VLANConfiguration
+}
+
+func (links LinkConfigurations) IsEmpty() bool {
+	return len(links.Interfaces) == 0 && len(links.Bonds) == 0 && len(links.VLANs) == 0
+}
+
 type InterfaceConfigurationCreator interface {
 	CreateInterfaceConfigurations(boshsettings.Networks, map[string]string) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error)
+	CreateLinkConfigurations(boshsettings.Networks, map[string]string) (LinkConfigurations, error)
 }
 
 type interfaceConfigurationCreator struct {
@@ -71,11 +121,20 @@ func NewInterfaceConfigurationCreator(logger boshlog.Logger) InterfaceConfigurat
 func (creator interfaceConfigurationCreator) createInterfaceConfiguration(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration, ifaceName string, networkSettings boshsettings.Network) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
 	creator.logger.Debug(creator.logTag, "Creating network configuration with settings: %s", networkSettings)
 
-	if (networkSettings.IsDHCP() || networkSettings.Mac == "") && networkSettings.Alias == "" {
+	useDHCP := (networkSettings.IsDHCP() || networkSettings.Mac == "") && networkSettings.Alias == ""
+	if networkSettings.HasLink() {
+		// Bonds and VLANs are matched by name and
+		// have no MAC address of their own in settings
+		useDHCP = networkSettings.IsDHCP()
+		networkSettings.Mac = ""
+	}
+
+	if useDHCP {
 		creator.logger.Debug(creator.logTag, "Using dhcp networking")
 		dhcpConfigs = append(dhcpConfigs, DHCPInterfaceConfiguration{
 			Name:         ifaceName,
 			PostUpRoutes: networkSettings.Routes,
+			MTU:          networkSettings.MTU,
 		})
 	} else {
 		creator.logger.Debug(creator.logTag, "Using static networking")
@@ -94,17 +153,27 @@ func (creator interfaceConfigurationCreator) createInterfaceConfiguration(static
 			Mac:                 networkSettings.Mac,
 			Gateway:             networkSettings.Gateway,
 			PostUpRoutes:        networkSettings.Routes,
+			IPv6Address:         networkSettings.IPv6Address,
+			IPv6Netmask:         networkSettings.IPv6Netmask,
+			IPv6Gateway:         networkSettings.IPv6Gateway,
+			RoutingTable:        networkSettings.RoutingTable,
+			MTU:                 networkSettings.MTU,
 		})
 	}
 	return staticConfigs, dhcpConfigs, nil
 }
 
 func (creator interfaceConfigurationCreator) CreateInterfaceConfigurations(networks boshsettings.Networks, interfacesByMAC map[string]string) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+	networks, err := creator.resolveLinkSettings(networks)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// In cases where we only have one network and it has no MAC address (either because the IAAS doesn't give us one or
 	// it's an old CPI), if we only have one interface, we should map them
 	if len(networks) == 1 && len(interfacesByMAC) == 1 {
 		networkSettings := creator.getFirstNetwork(networks)
-		if networkSettings.Mac == "" {
+		if networkSettings.Mac == "" && !networkSettings.HasLink() {
 			var ifaceName string
 			networkSettings.Mac, ifaceName = creator.getFirstInterface(interfacesByMAC)
 			return creator.createInterfaceConfiguration([]StaticInterfaceConfiguration{}, []DHCPInterfaceConfiguration{}, ifaceName, networkSettings)
@@ -115,27 +184,38 @@ func (creator interfaceConfigurationCreator) CreateInterfaceConfigurations(netwo
 }
 
 func (creator interfaceConfigurationCreator) createMultipleInterfaceConfigurations(networks boshsettings.Networks, interfacesByMAC map[string]string) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
-	if !networks.HasInterfaceAlias() && len(interfacesByMAC) < len(networks) {
-		return nil, nil, bosherr.Errorf("Number of network settings '%d' is greater than the number of network devices '%d'", len(networks), len(interfacesByMAC))
+	physicalNetworks := boshsettings.Networks{}
+	for name, networkSettings := range networks {
+		if !networkSettings.HasLink() {
+			physicalNetworks[name] = networkSettings
+		}
 	}
 
-	for name := range networks {
-		if mac := networks[name].Mac; mac != "" {
-			if _, ok := interfacesByMAC[mac]; !ok {
-				return nil, nil, bosherr.Errorf("No device found for network '%s' with MAC address '%s'", name, mac)
-			}
-		}
+	if !networks.HasInterfaceAlias() && len(interfacesByMAC) < len(physicalNetworks) {
+		return nil, nil, bosherr.Errorf("Number of network settings '%d' is greater than the number of network devices '%d'", len(physicalNetworks), len(interfacesByMAC))
+	}
+
+	err := creator.validateMacAddresses(networks, interfacesByMAC)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	linkInterfaces := creator.linkInterfaceMacAddresses(networks)
+
 	// Configure interfaces with network settings matching MAC address.
 	// If we cannot find a network setting with a matching MAC address, configure that interface as DHCP
+	// unless it is only used as a bond member or a VLAN parent
 	var networkSettings boshsettings.Network
-	var err error
 	staticConfigs := []StaticInterfaceConfiguration{}
 	dhcpConfigs := []DHCPInterfaceConfiguration{}
 
 	for mac, ifaceName := range interfacesByMAC {
-		networkSettings, _ = networks.NetworkForMac(mac)
+		var found bool
+		networkSettings, found = physicalNetworks.NetworkForMac(mac)
+		if !found && linkInterfaces[mac] {
+			continue
+		}
+
 		staticConfigs, dhcpConfigs, err = creator.createInterfaceConfiguration(staticConfigs, dhcpConfigs, ifaceName, networkSettings)
 		if err != nil {
 			return nil, nil, bosherr.WrapError(err, "Creating interface configuration")
@@ -143,6 +223,13 @@ func (creator interfaceConfigurationCreator) createMultipleInterfaceConfiguratio
 	}
 
 	for _, networkSettings = range networks {
+		if networkSettings.HasLink() {
+			staticConfigs, dhcpConfigs, err = creator.createInterfaceConfiguration(staticConfigs, dhcpConfigs, linkInterfaceName(networkSettings, interfacesByMAC), networkSettings)
+			if err != nil {
+				return nil, nil, bosherr.WrapError(err, "Creating interface configuration using link")
+			}
+			continue
+		}
 		if networkSettings.Mac != "" {
 			continue
 		}
@@ -157,6 +244,195 @@ func (creator interfaceConfigurationCreator) createMultipleInterfaceConfiguratio
 	return staticConfigs, dhcpConfigs, nil
 }
 
+func (creator interfaceConfigurationCreator) CreateLinkConfigurations(networks boshsettings.Networks, interfacesByMAC map[string]string) (LinkConfigurations, error) {
+	links := LinkConfigurations{}
+
+	networks, err := creator.resolveLinkSettings(networks)
+	if err != nil {
+		return links, err
+	}
+
+	err = creator.validateMacAddresses(networks, interfacesByMAC)
+	if err != nil {
+		return links, err
+	}
+
+	networkNames := []string{}
+	for name := range networks {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+
+	bonds := map[string]*BondConfiguration{}
+	bondNames := []string{}
+	bondMacs := map[string][]string{}
+	vlanParentMTUs := map[string]int{}
+	vlanParentMacs := map[string]string{}
+
+	for _, name := range networkNames {
+		networkSettings := networks[name]
+
+		if bond := networkSettings.Bond; bond != nil {
+			bondConfig, found := bonds[bond.Name]
+			if !found {
+				bondConfig = &BondConfiguration{Name: bond.Name, Mode: bond.Mode, MIIMon: bond.MIIMon}
+				if bondConfig.Mode == "" {
+					bondConfig.Mode = defaultBondMode
+				}
+				for _, mac := range bond.Interfaces {
+					bondConfig.Interfaces = append(bondConfig.Interfaces, interfacesByMAC[mac])
+				}
+				bonds[bond.Name] = bondConfig
+				bondNames = append(bondNames, bond.Name)
+				bondMacs[bond.Name] = bond.Interfaces
+			}
+
+			bondConfig.MTU = maxMTU(bondConfig.MTU, networkSettings.MTU)
+		}
+
+		if networkSettings.VLAN == 0 {
+			continue
+		}
+
+		ifaceName := linkInterfaceName(networkSettings, interfacesByMAC)
+		parentName := linkParentName(networkSettings, interfacesByMAC)
+
+		links.VLANs = append(links.VLANs, VLANConfiguration{
+			Name: ifaceName,
+			Link: parentName,
+			ID:   networkSettings.VLAN,
+			MTU:  networkSettings.MTU,
+		})
+
+		// Parent has to be able to carry frames of all its VLANs
+		vlanParentMTUs[parentName] = maxMTU(vlanParentMTUs[parentName], networkSettings.MTU)
+		if networkSettings.Bond == nil {
+			vlanParentMacs[parentName] = networkSettings.Mac
+		}
+	}
+
+	for _, bondName := range bondNames {
+		bondConfig := bonds[bondName]
+		bondConfig.MTU = maxMTU(bondConfig.MTU, vlanParentMTUs[bondName])
+		links.Bonds = append(links.Bonds, *bondConfig)
+
+		for _, mac := range bondMacs[bondName] {
+			links.Interfaces = append(links.Interfaces, LinkInterfaceConfiguration{
+				Name: interfacesByMAC[mac],
+				Mac:  mac,
+				Bond: bondName,
+				MTU:  bondConfig.MTU,
+			})
+		}
+	}
+
+	parentNames := []string{}
+	for parentName := range vlanParentMacs {
+		parentNames = append(parentNames, parentName)
+	}
+	sort.Strings(parentNames)
+
+	for _, parentName := range parentNames {
+		links.Interfaces = append(links.Interfaces, LinkInterfaceConfiguration{
+			Name: parentName,
+			Mac:  vlanParentMacs[parentName],
+			MTU:  vlanParentMTUs[parentName],
+		})
+	}
+
+	return links, nil
+}
+
+func (creator interfaceConfigurationCreator) resolveLinkSettings(networks boshsettings.Networks) (boshsettings.Networks, error) {
+	resolvedNetworks := boshsettings.Networks{}
+
+	for name, networkSettings := range networks {
+		resolvedNetwork, err := networkSettings.LinkSettings()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Resolving link settings of network '%s'", name)
+		}
+
+		if resolvedNetwork.Bond != nil && resolvedNetwork.Bond.Name == "" {
+			return nil, bosherr.Errorf("Bond of network '%s' must have a name", name)
+		}
+
+		if resolvedNetwork.VLAN != 0 && resolvedNetwork.Bond == nil && resolvedNetwork.Mac == "" {
+			return nil, bosherr.Errorf("VLAN %d of network '%s' requires either a MAC address or a bond", resolvedNetwork.VLAN, name)
+		}
+
+		resolvedNetworks[name] = resolvedNetwork
+	}
+
+	return resolvedNetworks, nil
+}
+
+func (creator interfaceConfigurationCreator) validateMacAddresses(networks boshsettings.Networks, interfacesByMAC map[string]string) error {
+	for name := range networks {
+		if mac := networks[name].Mac; mac != "" {
+			if _, ok := interfacesByMAC[mac]; !ok {
+				return bosherr.Errorf("No device found for network '%s' with MAC address '%s'", name, mac)
+			}
+		}
+
+		if bond := networks[name].Bond; bond != nil {
+			if len(bond.Interfaces) == 0 {
+				return bosherr.Errorf("Bond '%s' of network '%s' has no interfaces", bond.Name, name)
+			}
+
+			for _, mac := range bond.Interfaces {
+				if _, ok := interfacesByMAC[mac]; !ok {
+					return bosherr.Errorf("No device found for bond '%s' of network '%s' with MAC address '%s'", bond.Name, name, mac)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// linkInterfaceMacAddresses returns MAC addresses of bond members and VLAN parents
+func (creator interfaceConfigurationCreator) linkInterfaceMacAddresses(networks boshsettings.Networks) map[string]bool {
+	macs := map[string]bool{}
+
+	for _, networkSettings := range networks {
+		if networkSettings.Bond != nil {
+			for _, mac := range networkSettings.Bond.Interfaces {
+				macs[mac] = true
+			}
+		} else if networkSettings.VLAN != 0 {
+			macs[networkSettings.Mac] = true
+		}
+	}
+
+	return macs
+}
+
+func linkParentName(networkSettings boshsettings.Network, interfacesByMAC map[string]string) string {
+	if networkSettings.Bond != nil {
+		return networkSettings.Bond.Name
+	}
+
+	return interfacesByMAC[networkSettings.Mac]
+}
+
+// linkInterfaceName follows the <parent>.<vlan id> naming used by ifupdown and network-scripts
+func linkInterfaceName(networkSettings boshsettings.Network, interfacesByMAC map[string]string) string {
+	name := linkParentName(networkSettings, interfacesByMAC)
+
+	if networkSettings.VLAN != 0 {
+		name += "." + strconv.Itoa(networkSettings.VLAN)
+	}
+
+	return name
+}
+
+func maxMTU(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func (creator interfaceConfigurationCreator) getFirstNetwork(networks boshsettings.Networks) boshsettings.Network {
 	for networkName := range networks {
 		return networks[networkName]
@@ -231,6 +231,27 @@ func describeInterfaceConfigurationCreator() {
 					Expect(len(dhcpInterfaceConfigurations)).To(Equal(0))
 				})
 			})
+			Context("And the network has IPv6 and routing table settings", func() {
+				BeforeEach(func() {
+					staticNetwork.IPv6Address = "fd00::4"
+					staticNetwork.IPv6Netmask = "64"
+					staticNetwork.IPv6Gateway = "fd00::1"
+					staticNetwork.RoutingTable = 100
+					networks["foo"] = staticNetwork
+					interfacesByMAC["fake-static-mac-address"] = "static-interface-name"
+				})
+
+				It("carries them over to the interface configuration", func() {
+					staticInterfaceConfigurations, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMAC)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(staticInterfaceConfigurations).To(HaveLen(1))
+					Expect(staticInterfaceConfigurations[0].IPv6Address).To(Equal("fd00::4"))
+					Expect(staticInterfaceConfigurations[0].IPv6Netmask).To(Equal("64"))
+					Expect(staticInterfaceConfigurations[0].IPv6Gateway).To(Equal("fd00::1"))
+					Expect(staticInterfaceConfigurations[0].RoutingTable).To(Equal(100))
+				})
+			})
 		})
 
 		Context("Multiple networks", func() {
@@ -468,6 +489,160 @@ func describeInterfaceConfigurationCreator() {
 		})
 	})
 
+	Context("with bonded and VLAN networks", func() {
+		var networks boshsettings.Networks
+		var interfacesByMAC map[string]string
+
+		BeforeEach(func() {
+			interfacesByMAC = map[string]string{
+				"aa:01": "eth0",
+				"aa:02": "eth1",
+				"aa:03": "eth2",
+			}
+
+			bond := &boshsettings.Bond{
+				Name:       "bond0",
+				Mode:       "802.3ad",
+				Interfaces: []string{"aa:01", "aa:02"},
+				MIIMon:     100,
+			}
+
+			networks = boshsettings.Networks{
+				"bonded": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.0.0.5",
+					Netmask: "255.255.255.0",
+					Bond:    bond,
+					MTU:     9000,
+				},
+				"tagged": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.1.0.5",
+					Netmask: "255.255.255.0",
+					Bond:    bond,
+					VLAN:    100,
+					MTU:     1500,
+				},
+				"trunk-vlan": boshsettings.Network{
+					Type: "dynamic",
+					Mac:  "aa:03",
+					VLAN: 200,
+				},
+			}
+		})
+
+		Describe("CreateInterfaceConfigurations", func() {
+			It("configures addresses on bonds and VLANs instead of their physical interfaces", func() {
+				staticInterfaceConfigurations, dhcpInterfaceConfigurations, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMAC)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(staticInterfaceConfigurations).To(ConsistOf(
+					StaticInterfaceConfiguration{
+						Name:      "bond0",
+						Address:   "10.0.0.5",
+						Netmask:   "255.255.255.0",
+						Network:   "10.0.0.0",
+						Broadcast: "10.0.0.255",
+						MTU:       9000,
+					},
+					StaticInterfaceConfiguration{
+						Name:      "bond0.100",
+						Address:   "10.1.0.5",
+						Netmask:   "255.255.255.0",
+						Network:   "10.1.0.0",
+						Broadcast: "10.1.0.255",
+						MTU:       1500,
+					},
+				))
+				Expect(dhcpInterfaceConfigurations).To(Equal([]DHCPInterfaceConfiguration{{Name: "eth2.200"}}))
+			})
+
+			It("reads link settings from network cloud properties", func() {
+				networks = boshsettings.Networks{
+					"tagged": boshsettings.Network{
+						Type:            "dynamic",
+						Mac:             "aa:03",
+						CloudProperties: map[string]interface{}{"vlan": 300, "mtu": 9000},
+					},
+				}
+
+				_, dhcpInterfaceConfigurations, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMAC)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dhcpInterfaceConfigurations).To(ConsistOf(
+					DHCPInterfaceConfiguration{Name: "eth0"},
+					DHCPInterfaceConfiguration{Name: "eth1"},
+					DHCPInterfaceConfiguration{Name: "eth2.300", MTU: 9000},
+				))
+			})
+
+			It("returns an error when a bond interface cannot be found", func() {
+				networks["bonded"].Bond.Interfaces = []string{"aa:01", "aa:99"}
+
+				_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMAC)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No device found for bond 'bond0' of network"))
+			})
+
+			It("returns an error when a VLAN has neither a MAC address nor a bond", func() {
+				networks["trunk-vlan"] = boshsettings.Network{Type: "dynamic", VLAN: 200}
+
+				_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMAC)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("VLAN 200 of network 'trunk-vlan' requires either a MAC address or a bond"))
+			})
+		})
+
+		Describe("CreateLinkConfigurations", func() {
+			It("creates bonds, VLANs and their physical interfaces", func() {
+				links, err := interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMAC)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(links.Bonds).To(Equal([]BondConfiguration{{
+					Name:       "bond0",
+					Mode:       "802.3ad",
+					MIIMon:     100,
+					Interfaces: []string{"eth0", "eth1"},
+					MTU:        9000,
+				}}))
+
+				Expect(links.VLANs).To(Equal([]VLANConfiguration{
+					{Name: "bond0.100", Link: "bond0", ID: 100, MTU: 1500},
+					{Name: "eth2.200", Link: "eth2", ID: 200},
+				}))
+
+				Expect(links.Interfaces).To(Equal([]LinkInterfaceConfiguration{
+					{Name: "eth0", Mac: "aa:01", Bond: "bond0", MTU: 9000},
+					{Name: "eth1", Mac: "aa:02", Bond: "bond0", MTU: 9000},
+					{Name: "eth2", Mac: "aa:03"},
+				}))
+			})
+
+			It("defaults bond mode to active-backup", func() {
+				networks["bonded"].Bond.Mode = ""
+
+				links, err := interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMAC)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(links.Bonds[0].Mode).To(Equal("active-backup"))
+			})
+
+			It("raises MTU of VLAN parents to fit their VLANs", func() {
+				trunkNetwork := networks["trunk-vlan"]
+				trunkNetwork.MTU = 9000
+				networks["trunk-vlan"] = trunkNetwork
+
+				links, err := interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMAC)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(links.Interfaces).To(ContainElement(LinkInterfaceConfiguration{Name: "eth2", Mac: "aa:03", MTU: 9000}))
+			})
+
+			It("returns no links for networks configured directly on physical interfaces", func() {
+				links, err := interfaceConfigurationCreator.CreateLinkConfigurations(boshsettings.Networks{"foo": staticNetwork}, map[string]string{staticNetwork.Mac: "eth0"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(links.IsEmpty()).To(BeTrue())
+			})
+		})
+	})
+
 	It("wraps errors calculating Network and Broadcast addresses", func() {
 		invalidNetwork := boshsettings.Network{
 			Type:    "manual",
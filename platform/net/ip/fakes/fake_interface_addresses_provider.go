@@ -1,14 +1,28 @@
 package fakes
 
 import (
+	"sync"
+
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
 )
 
 type FakeInterfaceAddressesProvider struct {
 	GetInterfaceAddresses []boship.InterfaceAddress
 	GetErr                error
+
+	lock sync.Mutex
 }
 
 func (f *FakeInterfaceAddressesProvider) Get() ([]boship.InterfaceAddress, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
 	return f.GetInterfaceAddresses, f.GetErr
 }
+
+func (f *FakeInterfaceAddressesProvider) SetInterfaceAddresses(addresses []boship.InterfaceAddress) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.GetInterfaceAddresses = addresses
+}
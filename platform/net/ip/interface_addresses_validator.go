@@ -1,62 +1,185 @@
 package ip
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 )
 
+const interfaceAddressesValidationRetryDelay = 1 * time.Second
+
 type InterfaceAddressesValidator interface {
 	Validate(desiredInterfaceAddresses []InterfaceAddress) error
 }
 
+type InterfaceAddressesValidatorOptions struct {
+	// IPs that are not expected to be assigned to any interface
+	// (e.g. portal IPs that are routed to the VM by the IaaS)
+	ExcludedIPs []string
+
+	// How long to wait for desired IPs to show up on interfaces;
+	// validation is attempted once when zero
+	Timeout time.Duration
+}
+
+// InterfaceAddressMismatch describes a desired IP that is not assigned to its interface
+type InterfaceAddressMismatch struct {
+	InterfaceName string
+	ExpectedIP    string
+	ActualIPs     []string
+}
+
+func (m InterfaceAddressMismatch) String() string {
+	if m.ActualIPs == nil {
+		return fmt.Sprintf("Validating network interface '%s' IP addresses, no interface configured with that name", m.InterfaceName)
+	}
+
+	return fmt.Sprintf("Validating network interface '%s' IP addresses, expected: '%s', actual: '%s'",
+		m.InterfaceName, m.ExpectedIP, strings.Join(m.ActualIPs, ", "))
+}
+
+type InterfaceAddressesValidationError struct {
+	Mismatches []InterfaceAddressMismatch
+}
+
+func (e InterfaceAddressesValidationError) Error() string {
+	messages := []string{}
+	for _, mismatch := range e.Mismatches {
+		messages = append(messages, mismatch.String())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
 type interfaceAddressesValidator struct {
 	interfaceAddrsProvider InterfaceAddressesProvider
+	options                InterfaceAddressesValidatorOptions
+	timeService            boshretry.Clock
+	logger                 boshlog.Logger
 }
 
 func NewInterfaceAddressesValidator(interfaceAddrsProvider InterfaceAddressesProvider) InterfaceAddressesValidator {
+	return NewInterfaceAddressesValidatorWithOptions(
+		interfaceAddrsProvider,
+		InterfaceAddressesValidatorOptions{},
+		nil,
+		boshlog.NewLogger(boshlog.LevelNone),
+	)
+}
+
+func NewInterfaceAddressesValidatorWithOptions(
+	interfaceAddrsProvider InterfaceAddressesProvider,
+	options InterfaceAddressesValidatorOptions,
+	timeService boshretry.Clock,
+	logger boshlog.Logger,
+) InterfaceAddressesValidator {
 	return &interfaceAddressesValidator{
 		interfaceAddrsProvider: interfaceAddrsProvider,
+		options:                options,
+		timeService:            timeService,
+		logger:                 logger,
 	}
 }
 
 func (i *interfaceAddressesValidator) Validate(desiredInterfaceAddresses []InterfaceAddress) error {
-	//default via 159.8.154.33 dev eth1
-	//10.0.0.0/8 via 10.113.205.1 dev eth0
-	//10.112.166.128/26 dev eth0  proto kernel  scope link  src 10.112.166.152
-	//10.113.205.0/24 dev eth0  proto kernel  scope link  src 10.113.205.134
-	//159.8.154.32/27 dev eth1  proto kernel  scope link  src 159.8.154.62
-	//161.26.0.0/16 via 10.113.205.1 dev eth0
-
-	//{ip.simpleInterfaceAddress{interfaceName:"lo", ip:"127.0.0.1"},
-	// ip.simpleInterfaceAddress{interfaceName:"eth0", ip:"10.113.205.134"},
-	// ip.simpleInterfaceAddress{interfaceName:"eth0", ip:"10.112.166.152"},
-	// ip.simpleInterfaceAddress{interfaceName:"eth1", ip:"159.8.154.62"}}
+	if i.options.Timeout <= 0 || i.timeService == nil {
+		return i.validateOnce(desiredInterfaceAddresses)
+	}
+
+	retryable := boshretry.NewRetryable(func() (bool, error) {
+		return true, i.validateOnce(desiredInterfaceAddresses)
+	})
+
+	return boshretry.NewTimeoutRetryStrategy(
+		i.options.Timeout,
+		interfaceAddressesValidationRetryDelay,
+		retryable,
+		i.timeService,
+		i.logger,
+	).Try()
+}
+
+func (i *interfaceAddressesValidator) validateOnce(desiredInterfaceAddresses []InterfaceAddress) error {
 	systemInterfaceAddresses, err := i.interfaceAddrsProvider.Get()
 	if err != nil {
 		return bosherr.WrapError(err, "Getting network interface addresses")
 	}
 
+	mismatches := []InterfaceAddressMismatch{}
+
 	for _, desiredInterfaceAddress := range desiredInterfaceAddresses {
 		ifaceName := desiredInterfaceAddress.GetInterfaceName()
-		iface, found := i.findInterfaceByName(ifaceName, systemInterfaceAddresses)
-		if !found {
-			return bosherr.WrapErrorf(err, "Validating network interface '%s' IP addresses, no interface configured with that name", ifaceName)
+
+		desiredIP, err := desiredInterfaceAddress.GetIP()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Getting desired IP address of network interface '%s'", ifaceName)
 		}
-		desiredIP, _ := desiredInterfaceAddress.GetIP()
-		actualIP, _ := iface.GetIP()
-		if desiredIP != actualIP {
-			return bosherr.WrapErrorf(err, "Validating network interface '%s' IP addresses, expected: '%s', actual: '%s'", ifaceName, desiredIP, actualIP)
+
+		if i.isExcluded(desiredIP) {
+			continue
+		}
+
+		actualIPs := i.findIPsByInterfaceName(ifaceName, systemInterfaceAddresses)
+		if !containsIP(actualIPs, desiredIP) {
+			mismatches = append(mismatches, InterfaceAddressMismatch{
+				InterfaceName: ifaceName,
+				ExpectedIP:    desiredIP,
+				ActualIPs:     actualIPs,
+			})
 		}
 	}
 
+	if len(mismatches) > 0 {
+		return InterfaceAddressesValidationError{Mismatches: mismatches}
+	}
+
 	return nil
 }
 
-func (i *interfaceAddressesValidator) findInterfaceByName(ifaceName string, ifaces []InterfaceAddress) (InterfaceAddress, bool) {
+func (i *interfaceAddressesValidator) isExcluded(ip string) bool {
+	return containsIP(i.options.ExcludedIPs, ip)
+}
+
+// findIPsByInterfaceName returns nil when interface is not found
+// so that it could be distinguished from an interface without IPs.
+// Addresses of alias interfaces (e.g. eth0:1) may be reported
+// either under the alias or under the base interface name.
+func (i *interfaceAddressesValidator) findIPsByInterfaceName(ifaceName string, ifaces []InterfaceAddress) []string {
+	var ips []string
+
 	for _, iface := range ifaces {
-		if iface.GetInterfaceName() == ifaceName {
-			return iface, true
+		name := iface.GetInterfaceName()
+		if name != ifaceName && name != baseInterfaceName(ifaceName) {
+			continue
+		}
+
+		if ips == nil {
+			ips = []string{}
+		}
+
+		ip, err := iface.GetIP()
+		if err == nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+func baseInterfaceName(ifaceName string) string {
+	return strings.SplitN(ifaceName, ":", 2)[0]
+}
+
+func containsIP(ips []string, ip string) bool {
+	for _, candidate := range ips {
+		if candidate == ip {
+			return true
 		}
 	}
 
-	return nil, false
+	return false
 }
@@ -2,12 +2,15 @@ package ip_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
 
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
 	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 var _ = Describe("InterfaceAddressesValidator", func() {
@@ -94,4 +97,135 @@ var _ = Describe("InterfaceAddressesValidator", func() {
 	Context("when resolv.conf has invalid dns configurations", func() {
 
 	})
+
+	Context("when interface has several addresses (e.g. portal IPs)", func() {
+		BeforeEach(func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "10.0.0.10"),
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+			}
+		})
+
+		It("returns nil when desired address is one of them", func() {
+			err := interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when desired interface is an alias", func() {
+		BeforeEach(func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("eth0", "5.6.7.8"),
+			}
+		})
+
+		It("validates address against the base interface", func() {
+			err := interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0:1", "5.6.7.8"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when several desired addresses do not match", func() {
+		BeforeEach(func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.5"),
+			}
+		})
+
+		It("returns structured error listing all mismatches", func() {
+			err := interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("eth1", "5.6.7.8"),
+			})
+			Expect(err).To(Equal(boship.InterfaceAddressesValidationError{
+				Mismatches: []boship.InterfaceAddressMismatch{
+					{InterfaceName: "eth0", ExpectedIP: "1.2.3.4", ActualIPs: []string{"1.2.3.5"}},
+					{InterfaceName: "eth1", ExpectedIP: "5.6.7.8"},
+				},
+			}))
+		})
+	})
+
+	Context("with options", func() {
+		var (
+			options   boship.InterfaceAddressesValidatorOptions
+			fakeClock *fakeclock.FakeClock
+		)
+
+		BeforeEach(func() {
+			options = boship.InterfaceAddressesValidatorOptions{}
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+			}
+		})
+
+		JustBeforeEach(func() {
+			interfaceAddrsValidator = boship.NewInterfaceAddressesValidatorWithOptions(
+				interfaceAddrsProvider,
+				options,
+				fakeClock,
+				boshlog.NewLogger(boshlog.LevelNone),
+			)
+		})
+
+		Context("when desired address is excluded", func() {
+			BeforeEach(func() {
+				options.ExcludedIPs = []string{"10.0.0.10"}
+			})
+
+			It("skips its validation", func() {
+				err := interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+					boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+					boship.NewSimpleInterfaceAddress("eth0", "10.0.0.10"),
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when timeout is set", func() {
+			BeforeEach(func() {
+				options.Timeout = 5 * time.Second
+			})
+
+			It("retries until desired address shows up", func() {
+				errCh := make(chan error)
+				go func() {
+					errCh <- interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+						boship.NewSimpleInterfaceAddress("eth0", "5.6.7.8"),
+					})
+				}()
+
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				interfaceAddrsProvider.SetInterfaceAddresses([]boship.InterfaceAddress{
+					boship.NewSimpleInterfaceAddress("eth0", "5.6.7.8"),
+				})
+				fakeClock.Increment(time.Second)
+
+				Expect(<-errCh).ToNot(HaveOccurred())
+			})
+
+			It("returns validation error after timeout", func() {
+				errCh := make(chan error)
+				go func() {
+					errCh <- interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+						boship.NewSimpleInterfaceAddress("eth0", "5.6.7.8"),
+					})
+				}()
+
+				for i := 0; i < 5; i++ {
+					fakeClock.WaitForWatcherAndIncrement(time.Second)
+				}
+
+				err := <-errCh
+				Expect(err).To(BeAssignableToTypeOf(boship.InterfaceAddressesValidationError{}))
+				Expect(err.Error()).To(ContainSubstring("expected: '5.6.7.8', actual: '1.2.3.4'"))
+			})
+		})
+	})
 })
@@ -0,0 +1,73 @@
+package net
+
+import (
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// setupKernelIPv6 enables IPv6 in kernel when it is requested by the user.
+// It reboots the machine when IPv6 is disabled on kernel command line
+// and waits on stopCh for the reboot to happen.
+func setupKernelIPv6(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	logTag string,
+	config boshsettings.IPv6,
+	stopCh <-chan struct{},
+) error {
+	const (
+		grubConfPath       = "/boot/grub/grub.conf"
+		grubIPv6DisableOpt = "ipv6.disable=1"
+	)
+
+	if !config.Enable {
+		return nil
+	}
+
+	grubConf, err := fs.ReadFileString(grubConfPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading grub")
+	}
+
+	if strings.Contains(grubConf, grubIPv6DisableOpt) {
+		grubConf = strings.Replace(grubConf, grubIPv6DisableOpt, "", -1)
+
+		err = fs.WriteFileString(grubConfPath, grubConf)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing grub.conf")
+		}
+
+		logger.Info(logTag, "Rebooting to enable IPv6 in kernel")
+
+		_, _, _, err = cmdRunner.RunCommand("shutdown", "-r", "now")
+		if err != nil {
+			return bosherr.WrapError(err, "Rebooting for IPv6")
+		}
+
+		// Wait here for the OS to reboot the machine
+		<-stopCh
+
+		return nil
+	}
+
+	ipv6Sysctls := []string{
+		"net.ipv6.conf.all.accept_ra=1",
+		"net.ipv6.conf.default.accept_ra=1",
+		"net.ipv6.conf.all.disable_ipv6=0",
+		"net.ipv6.conf.default.disable_ipv6=0",
+	}
+
+	for _, sysctl := range ipv6Sysctls {
+		_, _, _, err := cmdRunner.RunCommand("sysctl", sysctl)
+		if err != nil {
+			return bosherr.WrapError(err, "Running IPv6 sysctl")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package net
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const netplanNetManagerLogTag = "netplanNetManager"
+
+const netplanConfigDir = "/etc/netplan"
+
+type netplanNetManager struct {
+	fs                            boshsys.FileSystem
+	cmdRunner                     boshsys.CmdRunner
+	ipResolver                    boship.Resolver
+	interfaceConfigurationCreator InterfaceConfigurationCreator
+	interfaceAddressesValidator   boship.InterfaceAddressesValidator
+	addressBroadcaster            bosharp.AddressBroadcaster
+	logger                        boshlog.Logger
+}
+
+// NewNetplanNetManager renders one netplan file per interface, bond and VLAN
+// and runs 'netplan apply' when any of them changed.
+// DNS servers are not validated against /etc/resolv.conf
+// since it usually points to the systemd-resolved stub resolver.
+func NewNetplanNetManager(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	ipResolver boship.Resolver,
+	interfaceConfigurationCreator InterfaceConfigurationCreator,
+	interfaceAddressesValidator boship.InterfaceAddressesValidator,
+	addressBroadcaster bosharp.AddressBroadcaster,
+	logger boshlog.Logger,
+) Manager {
+	return netplanNetManager{
+		fs:                            fs,
+		cmdRunner:                     cmdRunner,
+		ipResolver:                    ipResolver,
+		interfaceConfigurationCreator: interfaceConfigurationCreator,
+		interfaceAddressesValidator:   interfaceAddressesValidator,
+		addressBroadcaster:            addressBroadcaster,
+		logger:                        logger,
+	}
+}
+
+func (net netplanNetManager) SetupIPv6(config boshsettings.IPv6, stopCh <-chan struct{}) error {
+	return setupKernelIPv6(net.fs, net.cmdRunner, net.logger, netplanNetManagerLogTag, config, stopCh)
+}
+
+func (net netplanNetManager) SetupNetworking(networks boshsettings.Networks, errCh chan error) error {
+	if networks.IsPreconfigured() {
+		return nil
+	}
+
+	nonVipNetworks := boshsettings.Networks{}
+	for networkName, networkSettings := range networks {
+		if networkSettings.IsVIP() {
+			continue
+		}
+		nonVipNetworks[networkName] = networkSettings
+	}
+
+	staticConfigs, dhcpConfigs, links, err := net.buildInterfaces(nonVipNetworks)
+	if err != nil {
+		return err
+	}
+
+	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
+
+	configs, err := newRenderedInterfaceConfigurations(staticConfigs, dhcpConfigs, dnsNetwork.DNS)
+	if err != nil {
+		return bosherr.WrapError(err, "Computing network configuration")
+	}
+
+	configs = configs.withLinks(links)
+
+	changed, err := net.writeNetplanFiles(configs)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing network configuration")
+	}
+
+	if changed {
+		err = net.applyNetplan()
+		if err != nil {
+			return err
+		}
+	}
+
+	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
+
+	err = net.interfaceAddressesValidator.Validate(staticAddresses)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating static network configuration")
+	}
+
+	net.broadcastIps(append(staticAddresses, dynamicAddresses...), errCh)
+
+	return nil
+}
+
+func (net netplanNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
+	interfaces := []string{}
+
+	interfacesByMacAddress, err := net.detectMacAddresses()
+	if err != nil {
+		return interfaces, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	for _, iface := range interfacesByMacAddress {
+		if net.fs.FileExists(netplanFilePath(iface)) {
+			interfaces = append(interfaces, iface)
+		}
+	}
+
+	return interfaces, nil
+}
+
+// Sorted after cloud-init's 50-cloud-init.yaml so that
+// agent provided settings take precedence when netplan merges files
+func netplanFilePath(name string) string {
+	return path.Join(netplanConfigDir, "60-bosh-"+name+".yaml")
+}
+
+const netplanTemplate = `# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  {{ if .Bond }}bonds{{ else if .VLAN }}vlans{{ else }}ethernets{{ end }}:
+    {{ .Name }}:{{ if .MatchMac }}
+      match:
+        macaddress: "{{ .MatchMac }}"
+      set-name: {{ .Name }}{{ end }}{{ with .Bond }}
+      interfaces:{{ range .Interfaces }}
+      - {{ . }}{{ end }}
+      parameters:
+        mode: {{ .Mode }}{{ if .MIIMon }}
+        mii-monitor-interval: {{ .MIIMon }}{{ end }}{{ end }}{{ with .VLAN }}
+      id: {{ .ID }}
+      link: {{ .Link }}{{ end }}{{ if .MTU }}
+      mtu: {{ .MTU }}{{ end }}
+      dhcp4: {{ .DHCP }}{{ if .Addresses }}
+      addresses:{{ range .Addresses }}
+      - {{ . }}{{ end }}{{ end }}{{ if .Routes }}
+      routes:{{ range .Routes }}
+      - to: "{{ .Destination }}"{{ if .Gateway }}
+        via: "{{ .Gateway }}"{{ else }}
+        scope: link{{ end }}{{ if .Table }}
+        table: {{ .Table }}{{ end }}{{ end }}{{ end }}{{ if .RoutingPolicyRules }}
+      routing-policy:{{ range .RoutingPolicyRules }}
+      - from: "{{ .From }}"
+        table: {{ .Table }}{{ end }}{{ end }}{{ if .DNSServers }}
+      nameservers:
+        addresses:{{ range .DNSServers }}
+        - {{ . }}{{ end }}{{ end }}
+`
+
+func (net netplanNetManager) writeNetplanFiles(configs renderedInterfaceConfigurations) (bool, error) {
+	sort.Stable(configs)
+
+	anyFileChanged := false
+	t := template.Must(template.New("netplan").Parse(netplanTemplate))
+	writtenPaths := map[string]bool{}
+
+	for _, config := range configs {
+		buffer := bytes.NewBuffer([]byte{})
+
+		err := t.Execute(buffer, config)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Generating '%s' config from template", config.Name)
+		}
+
+		filePath := netplanFilePath(config.Name)
+		changed, err := net.fs.ConvergeFileContents(filePath, buffer.Bytes())
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Writing config to '%s'", filePath)
+		}
+
+		// netplan warns about world readable configuration files
+		err = net.fs.Chmod(filePath, 0600)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Chmoding '%s'", filePath)
+		}
+
+		writtenPaths[filePath] = true
+		anyFileChanged = anyFileChanged || changed
+	}
+
+	removed, err := removeStaleFiles(net.fs, path.Join(netplanConfigDir, "60-bosh-*.yaml"), writtenPaths)
+	if err != nil {
+		return false, err
+	}
+
+	return anyFileChanged || removed, nil
+}
+
+func (net netplanNetManager) applyNetplan() error {
+	net.logger.Debug(netplanNetManagerLogTag, "Applying netplan configuration")
+
+	_, _, _, err := net.cmdRunner.RunCommand("netplan", "apply")
+	if err != nil {
+		return bosherr.WrapError(err, "Applying netplan configuration")
+	}
+
+	return nil
+}
+
+func (net netplanNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, LinkConfigurations, error) {
+	interfacesByMacAddress, err := net.detectMacAddresses()
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	staticConfigs, dhcpConfigs, err := net.interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating interface configurations")
+	}
+
+	links, err := net.interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating link configurations")
+	}
+
+	return staticConfigs, dhcpConfigs, links, nil
+}
+
+func (net netplanNetManager) broadcastIps(addresses []boship.InterfaceAddress, errCh chan error) {
+	go func() {
+		net.addressBroadcaster.BroadcastMACAddresses(addresses)
+		if errCh != nil {
+			errCh <- nil
+		}
+	}()
+}
+
+func (net netplanNetManager) detectMacAddresses() (map[string]string, error) {
+	addresses := map[string]string{}
+
+	filePaths, err := net.fs.Glob("/sys/class/net/*")
+	if err != nil {
+		return addresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
+	}
+
+	var macAddress string
+	for _, filePath := range filePaths {
+		isPhysicalDevice := net.fs.FileExists(path.Join(filePath, "device"))
+
+		if isPhysicalDevice {
+			macAddress, err = net.fs.ReadFileString(path.Join(filePath, "address"))
+			if err != nil {
+				return addresses, bosherr.WrapError(err, "Reading mac address from file")
+			}
+
+			macAddress = strings.Trim(macAddress, "\n")
+
+			interfaceName := path.Base(filePath)
+			addresses[macAddress] = interfaceName
+		}
+	}
+
+	return addresses, nil
+}
+
+func (net netplanNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
+	staticAddresses := []boship.InterfaceAddress{}
+	for _, iface := range staticConfigs {
+		staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, iface.Address))
+	}
+	dynamicAddresses := []boship.InterfaceAddress{}
+	for _, iface := range dhcpConfigs {
+		dynamicAddresses = append(dynamicAddresses, boship.NewResolvingInterfaceAddress(iface.Name, net.ipResolver))
+	}
+
+	return staticAddresses, dynamicAddresses
+}
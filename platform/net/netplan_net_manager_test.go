@@ -0,0 +1,400 @@
+package net_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("netplanNetManager", describeNetplanNetManager)
+
+func describeNetplanNetManager() {
+	var (
+		fs                     *fakesys.FakeFileSystem
+		cmdRunner              *fakesys.FakeCmdRunner
+		interfaceAddrsProvider *fakeip.FakeInterfaceAddressesProvider
+		addressBroadcaster     *fakearp.FakeAddressBroadcaster
+		netManager             Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
+		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
+		netManager = NewNetplanNetManager(
+			fs,
+			cmdRunner,
+			&fakeip.FakeResolver{},
+			NewInterfaceConfigurationCreator(logger),
+			boship.NewInterfaceAddressesValidator(interfaceAddrsProvider),
+			addressBroadcaster,
+			logger,
+		)
+	})
+
+	writeNetworkDevice := func(iface string, macAddress string) string {
+		interfacePath := fmt.Sprintf("/sys/class/net/%s", iface)
+		fs.WriteFile(interfacePath, []byte{})
+		fs.WriteFile(fmt.Sprintf("/sys/class/net/%s/device", iface), []byte{})
+		fs.WriteFileString(fmt.Sprintf("/sys/class/net/%s/address", iface), fmt.Sprintf("%s\n", macAddress))
+		return interfacePath
+	}
+
+	stubInterfaces := func(physicalInterfaces map[string]boshsettings.Network) {
+		interfacePaths := []string{}
+		for iface, networkSettings := range physicalInterfaces {
+			interfacePaths = append(interfacePaths, writeNetworkDevice(iface, networkSettings.Mac))
+		}
+		fs.SetGlob("/sys/class/net/*", interfacePaths)
+	}
+
+	Describe("SetupIPv6", func() {
+		It("removes ipv6.disable=1 from grub.conf and reboots when IPv6 is enabled", func() {
+			err := fs.WriteFileString("/boot/grub/grub.conf", "before ipv6.disable=1 after")
+			Expect(err).ToNot(HaveOccurred())
+
+			stopCh := make(chan struct{}, 1)
+			stopCh <- struct{}{}
+
+			err = netManager.SetupIPv6(boshsettings.IPv6{Enable: true}, stopCh)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString("/boot/grub/grub.conf")).To(Equal("before  after"))
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"shutdown", "-r", "now"}}))
+		})
+
+		It("sets IPv6 sysctl when grub.conf allows IPv6", func() {
+			err := fs.WriteFileString("/boot/grub/grub.conf", "before after")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = netManager.SetupIPv6(boshsettings.IPv6{Enable: true}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"sysctl", "net.ipv6.conf.all.accept_ra=1"},
+				{"sysctl", "net.ipv6.conf.default.accept_ra=1"},
+				{"sysctl", "net.ipv6.conf.all.disable_ipv6=0"},
+				{"sysctl", "net.ipv6.conf.default.disable_ipv6=0"},
+			}))
+		})
+
+		It("does nothing when IPv6 is not enabled", func() {
+			err := netManager.SetupIPv6(boshsettings.IPv6{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("SetupNetworking", func() {
+		var (
+			dhcpNetwork   boshsettings.Network
+			staticNetwork boshsettings.Network
+			networks      boshsettings.Networks
+		)
+
+		BeforeEach(func() {
+			dhcpNetwork = boshsettings.Network{
+				Type:    "dynamic",
+				Default: []string{"dns"},
+				DNS:     []string{"8.8.8.8", "9.9.9.9"},
+				Mac:     "fake-dhcp-mac-address",
+				Routes: boshsettings.Routes{
+					{Destination: "10.0.0.0", NetMask: "255.0.0.0", Gateway: "192.168.1.1"},
+				},
+			}
+			staticNetwork = boshsettings.Network{
+				Type:    "manual",
+				IP:      "1.2.3.4",
+				Netmask: "255.255.255.0",
+				Gateway: "1.2.3.1",
+				Default: []string{"gateway"},
+				Mac:     "fake-static-mac-address",
+			}
+			networks = boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+			}
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+		})
+
+		It("writes a netplan file for each interface", func() {
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/netplan/60-bosh-ethstatic.yaml")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    ethstatic:
+      match:
+        macaddress: "fake-static-mac-address"
+      set-name: ethstatic
+      dhcp4: false
+      addresses:
+      - 1.2.3.4/24
+      routes:
+      - to: "0.0.0.0/0"
+        via: "1.2.3.1"
+      nameservers:
+        addresses:
+        - 8.8.8.8
+        - 9.9.9.9
+`))
+			Expect(staticConfig.FileMode).To(BeEquivalentTo(0600))
+
+			dhcpConfig := fs.GetFileTestStat("/etc/netplan/60-bosh-ethdhcp.yaml")
+			Expect(dhcpConfig).ToNot(BeNil())
+			Expect(dhcpConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    ethdhcp:
+      dhcp4: true
+      routes:
+      - to: "10.0.0.0/8"
+        via: "192.168.1.1"
+      nameservers:
+        addresses:
+        - 8.8.8.8
+        - 9.9.9.9
+`))
+		})
+
+		It("writes IPv6 addresses and policy routing for static interfaces", func() {
+			staticNetwork.IPv6Address = "fd00::4"
+			staticNetwork.IPv6Netmask = "64"
+			staticNetwork.IPv6Gateway = "fd00::1"
+			staticNetwork.RoutingTable = 100
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork, "dhcp-network": dhcpNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/netplan/60-bosh-ethstatic.yaml")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    ethstatic:
+      match:
+        macaddress: "fake-static-mac-address"
+      set-name: ethstatic
+      dhcp4: false
+      addresses:
+      - 1.2.3.4/24
+      - fd00::4/64
+      routes:
+      - to: "0.0.0.0/0"
+        via: "1.2.3.1"
+      - to: "1.2.3.0/24"
+        scope: link
+        table: 100
+      - to: "0.0.0.0/0"
+        via: "1.2.3.1"
+        table: 100
+      - to: "::/0"
+        via: "fd00::1"
+      - to: "fd00::/64"
+        scope: link
+        table: 100
+      - to: "::/0"
+        via: "fd00::1"
+        table: 100
+      routing-policy:
+      - from: "1.2.3.4/32"
+        table: 100
+      - from: "fd00::4/128"
+        table: 100
+      nameservers:
+        addresses:
+        - 8.8.8.8
+        - 9.9.9.9
+`))
+		})
+
+		It("writes bonds, VLANs and their member interfaces", func() {
+			bond := &boshsettings.Bond{
+				Name:       "bond0",
+				Mode:       "802.3ad",
+				Interfaces: []string{"aa:01", "aa:02"},
+				MIIMon:     100,
+			}
+			networks = boshsettings.Networks{
+				"bonded": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.0.0.5",
+					Netmask: "255.255.255.0",
+					Gateway: "10.0.0.1",
+					Default: []string{"gateway", "dns"},
+					Bond:    bond,
+					MTU:     9000,
+				},
+				"tagged": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.1.0.5",
+					Netmask: "255.255.255.0",
+					Bond:    bond,
+					VLAN:    100,
+				},
+			}
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0", "10.0.0.5"),
+				boship.NewSimpleInterfaceAddress("bond0.100", "10.1.0.5"),
+			}
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "aa:01"},
+				"eth1": {Mac: "aa:02"},
+			})
+
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			bondConfig := fs.GetFileTestStat("/etc/netplan/60-bosh-bond0.yaml")
+			Expect(bondConfig).ToNot(BeNil())
+			Expect(bondConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  bonds:
+    bond0:
+      interfaces:
+      - eth0
+      - eth1
+      parameters:
+        mode: 802.3ad
+        mii-monitor-interval: 100
+      mtu: 9000
+      dhcp4: false
+      addresses:
+      - 10.0.0.5/24
+      routes:
+      - to: "0.0.0.0/0"
+        via: "10.0.0.1"
+`))
+
+			vlanConfig := fs.GetFileTestStat("/etc/netplan/60-bosh-bond0.100.yaml")
+			Expect(vlanConfig).ToNot(BeNil())
+			Expect(vlanConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  vlans:
+    bond0.100:
+      id: 100
+      link: bond0
+      dhcp4: false
+      addresses:
+      - 10.1.0.5/24
+`))
+
+			memberConfig := fs.GetFileTestStat("/etc/netplan/60-bosh-eth0.yaml")
+			Expect(memberConfig).ToNot(BeNil())
+			Expect(memberConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      mtu: 9000
+      dhcp4: false
+`))
+			Expect(fs.FileExists("/etc/netplan/60-bosh-eth1.yaml")).To(BeTrue())
+		})
+
+		It("applies netplan configuration only when it changed", func() {
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"netplan", "apply"}}))
+
+			err = netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(HaveLen(1))
+		})
+
+		It("returns error when netplan apply fails", func() {
+			cmdRunner.AddCmdResult("netplan apply", fakesys.FakeCmdResult{Error: errors.New("fake-apply-err")})
+
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Applying netplan configuration: fake-apply-err"))
+		})
+
+		It("removes netplan files of interfaces that are no longer configured", func() {
+			fs.WriteFileString("/etc/netplan/60-bosh-ethold.yaml", "stale")
+			fs.SetGlob("/etc/netplan/60-bosh-*.yaml", []string{
+				"/etc/netplan/60-bosh-ethdhcp.yaml",
+				"/etc/netplan/60-bosh-ethold.yaml",
+				"/etc/netplan/60-bosh-ethstatic.yaml",
+			})
+
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/netplan/60-bosh-ethold.yaml")).To(BeFalse())
+			Expect(fs.FileExists("/etc/netplan/60-bosh-ethstatic.yaml")).To(BeTrue())
+		})
+
+		It("does not write anything for preconfigured networks", func() {
+			err := netManager.SetupNetworking(boshsettings.Networks{
+				"preconfigured": boshsettings.Network{Preconfigured: true},
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns errors from writing the network configuration", func() {
+			fs.WriteFileError = errors.New("fs-write-file-error")
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fs-write-file-error"))
+		})
+
+		It("returns an error when static addresses are not configured on the interfaces", func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{}
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating static network configuration"))
+		})
+
+		It("broadcasts addresses of configured interfaces", func() {
+			errCh := make(chan error)
+			err := netManager.SetupNetworking(networks, errCh)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(<-errCh).ToNot(HaveOccurred())
+			Expect(addressBroadcaster.BroadcastMACAddressesAddresses).To(HaveLen(2))
+		})
+	})
+
+	Describe("GetConfiguredNetworkInterfaces", func() {
+		It("returns interfaces that have a netplan file", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "aa:bb"},
+				"eth1": {Mac: "cc:dd"},
+			})
+			fs.WriteFileString("/etc/netplan/60-bosh-eth1.yaml", "")
+
+			interfaces, err := netManager.GetConfiguredNetworkInterfaces()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(interfaces).To(ConsistOf("eth1"))
+		})
+	})
+}
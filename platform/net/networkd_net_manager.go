@@ -0,0 +1,331 @@
+package net
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const networkdNetManagerLogTag = "networkdNetManager"
+
+const networkdConfigDir = "/etc/systemd/network"
+
+type networkdNetManager struct {
+	fs                            boshsys.FileSystem
+	cmdRunner                     boshsys.CmdRunner
+	ipResolver                    boship.Resolver
+	interfaceConfigurationCreator InterfaceConfigurationCreator
+	interfaceAddressesValidator   boship.InterfaceAddressesValidator
+	addressBroadcaster            bosharp.AddressBroadcaster
+	logger                        boshlog.Logger
+}
+
+// NewNetworkdNetManager renders systemd-networkd .network and .link units
+// per interface as well as .netdev units for bonds and VLANs,
+// and restarts systemd-networkd when any of them changed.
+// DNS servers are not validated against /etc/resolv.conf
+// since it usually points to the systemd-resolved stub resolver.
+func NewNetworkdNetManager(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	ipResolver boship.Resolver,
+	interfaceConfigurationCreator InterfaceConfigurationCreator,
+	interfaceAddressesValidator boship.InterfaceAddressesValidator,
+	addressBroadcaster bosharp.AddressBroadcaster,
+	logger boshlog.Logger,
+) Manager {
+	return networkdNetManager{
+		fs:                            fs,
+		cmdRunner:                     cmdRunner,
+		ipResolver:                    ipResolver,
+		interfaceConfigurationCreator: interfaceConfigurationCreator,
+		interfaceAddressesValidator:   interfaceAddressesValidator,
+		addressBroadcaster:            addressBroadcaster,
+		logger:                        logger,
+	}
+}
+
+func (net networkdNetManager) SetupIPv6(config boshsettings.IPv6, stopCh <-chan struct{}) error {
+	return setupKernelIPv6(net.fs, net.cmdRunner, net.logger, networkdNetManagerLogTag, config, stopCh)
+}
+
+func (net networkdNetManager) SetupNetworking(networks boshsettings.Networks, errCh chan error) error {
+	if networks.IsPreconfigured() {
+		return nil
+	}
+
+	nonVipNetworks := boshsettings.Networks{}
+	for networkName, networkSettings := range networks {
+		if networkSettings.IsVIP() {
+			continue
+		}
+		nonVipNetworks[networkName] = networkSettings
+	}
+
+	staticConfigs, dhcpConfigs, links, err := net.buildInterfaces(nonVipNetworks)
+	if err != nil {
+		return err
+	}
+
+	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
+
+	configs, err := newRenderedInterfaceConfigurations(staticConfigs, dhcpConfigs, dnsNetwork.DNS)
+	if err != nil {
+		return bosherr.WrapError(err, "Computing network configuration")
+	}
+
+	configs = configs.withLinks(links)
+
+	changed, err := net.writeNetworkdUnits(configs)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing network configuration")
+	}
+
+	if changed {
+		err = net.restartNetworkd()
+		if err != nil {
+			return err
+		}
+	}
+
+	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
+
+	err = net.interfaceAddressesValidator.Validate(staticAddresses)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating static network configuration")
+	}
+
+	net.broadcastIps(append(staticAddresses, dynamicAddresses...), errCh)
+
+	return nil
+}
+
+func (net networkdNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
+	interfaces := []string{}
+
+	interfacesByMacAddress, err := net.detectMacAddresses()
+	if err != nil {
+		return interfaces, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	for _, iface := range interfacesByMacAddress {
+		if net.fs.FileExists(networkdUnitPath(iface, ".network")) {
+			interfaces = append(interfaces, iface)
+		}
+	}
+
+	return interfaces, nil
+}
+
+// Sorted before distribution provided units (e.g. 99-default.link)
+// since networkd only applies the first matching unit
+func networkdUnitPath(name, extension string) string {
+	return path.Join(networkdConfigDir, "10-bosh-"+name+extension)
+}
+
+const networkdNetworkTemplate = `# Generated by bosh-agent
+[Match]
+{{ if .MatchMac }}MACAddress={{ .MatchMac }}{{ else }}Name={{ .Name }}{{ end }}
+{{ if .MTU }}
+[Link]
+MTUBytes={{ .MTU }}
+{{ end }}
+[Network]
+DHCP={{ if .DHCP }}ipv4{{ else }}no{{ end }}{{ if .BondMaster }}
+Bond={{ .BondMaster }}{{ end }}{{ range .VLANs }}
+VLAN={{ . }}{{ end }}{{ range .Addresses }}
+Address={{ . }}{{ end }}{{ range .DNSServers }}
+DNS={{ . }}{{ end }}
+{{ range .Routes }}
+[Route]
+Destination={{ .Destination }}{{ if .Gateway }}
+Gateway={{ .Gateway }}{{ else }}
+Scope=link{{ end }}{{ if .Table }}
+Table={{ .Table }}{{ end }}
+{{ end }}{{ range .RoutingPolicyRules }}
+[RoutingPolicyRule]
+From={{ .From }}
+Table={{ .Table }}
+{{ end }}`
+
+const networkdNetdevTemplate = `# Generated by bosh-agent
+[NetDev]
+Name={{ .Name }}
+Kind={{ if .Bond }}bond{{ else }}vlan{{ end }}{{ if .MTU }}
+MTUBytes={{ .MTU }}{{ end }}
+{{ with .Bond }}
+[Bond]
+Mode={{ .Mode }}{{ if .MIIMon }}
+MIIMonitorSec={{ .MIIMon }}ms{{ end }}
+{{ end }}{{ with .VLAN }}
+[VLAN]
+Id={{ .ID }}
+{{ end }}`
+
+const networkdLinkTemplate = `# Generated by bosh-agent
+[Match]
+MACAddress={{ .Mac }}
+
+[Link]
+Name={{ .Name }}
+`
+
+func (net networkdNetManager) writeNetworkdUnits(configs renderedInterfaceConfigurations) (bool, error) {
+	sort.Stable(configs)
+
+	anyUnitChanged := false
+	networkTemplate := template.Must(template.New("networkd-network").Parse(networkdNetworkTemplate))
+	netdevTemplate := template.Must(template.New("networkd-netdev").Parse(networkdNetdevTemplate))
+	linkTemplate := template.Must(template.New("networkd-link").Parse(networkdLinkTemplate))
+	writtenPaths := map[string]bool{}
+
+	for _, config := range configs {
+		filePath := networkdUnitPath(config.Name, ".network")
+
+		changed, err := net.writeUnit(filePath, networkTemplate, config)
+		if err != nil {
+			return false, err
+		}
+
+		writtenPaths[filePath] = true
+		anyUnitChanged = anyUnitChanged || changed
+
+		if config.Bond != nil || config.VLAN != nil {
+			filePath = networkdUnitPath(config.Name, ".netdev")
+
+			changed, err = net.writeUnit(filePath, netdevTemplate, config)
+			if err != nil {
+				return false, err
+			}
+
+			writtenPaths[filePath] = true
+			anyUnitChanged = anyUnitChanged || changed
+		}
+
+		if config.Mac == "" {
+			continue
+		}
+
+		filePath = networkdUnitPath(config.Name, ".link")
+
+		changed, err = net.writeUnit(filePath, linkTemplate, config)
+		if err != nil {
+			return false, err
+		}
+
+		writtenPaths[filePath] = true
+		anyUnitChanged = anyUnitChanged || changed
+	}
+
+	removed, err := removeStaleFiles(net.fs, path.Join(networkdConfigDir, "10-bosh-*"), writtenPaths)
+	if err != nil {
+		return false, err
+	}
+
+	return anyUnitChanged || removed, nil
+}
+
+func (net networkdNetManager) writeUnit(filePath string, t *template.Template, config renderedInterfaceConfiguration) (bool, error) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := t.Execute(buffer, config)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Generating '%s' config from template", config.Name)
+	}
+
+	changed, err := net.fs.ConvergeFileContents(filePath, buffer.Bytes())
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Writing config to '%s'", filePath)
+	}
+
+	return changed, nil
+}
+
+func (net networkdNetManager) restartNetworkd() error {
+	net.logger.Debug(networkdNetManagerLogTag, "Restarting systemd-networkd")
+
+	_, _, _, err := net.cmdRunner.RunCommand("systemctl", "restart", "systemd-networkd")
+	if err != nil {
+		return bosherr.WrapError(err, "Restarting systemd-networkd")
+	}
+
+	return nil
+}
+
+func (net networkdNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, LinkConfigurations, error) {
+	interfacesByMacAddress, err := net.detectMacAddresses()
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	staticConfigs, dhcpConfigs, err := net.interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating interface configurations")
+	}
+
+	links, err := net.interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating link configurations")
+	}
+
+	return staticConfigs, dhcpConfigs, links, nil
+}
+
+func (net networkdNetManager) broadcastIps(addresses []boship.InterfaceAddress, errCh chan error) {
+	go func() {
+		net.addressBroadcaster.BroadcastMACAddresses(addresses)
+		if errCh != nil {
+			errCh <- nil
+		}
+	}()
+}
+
+func (net networkdNetManager) detectMacAddresses() (map[string]string, error) {
+	addresses := map[string]string{}
+
+	filePaths, err := net.fs.Glob("/sys/class/net/*")
+	if err != nil {
+		return addresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
+	}
+
+	var macAddress string
+	for _, filePath := range filePaths {
+		isPhysicalDevice := net.fs.FileExists(path.Join(filePath, "device"))
+
+		if isPhysicalDevice {
+			macAddress, err = net.fs.ReadFileString(path.Join(filePath, "address"))
+			if err != nil {
+				return addresses, bosherr.WrapError(err, "Reading mac address from file")
+			}
+
+			macAddress = strings.Trim(macAddress, "\n")
+
+			interfaceName := path.Base(filePath)
+			addresses[macAddress] = interfaceName
+		}
+	}
+
+	return addresses, nil
+}
+
+func (net networkdNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
+	staticAddresses := []boship.InterfaceAddress{}
+	for _, iface := range staticConfigs {
+		staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, iface.Address))
+	}
+	dynamicAddresses := []boship.InterfaceAddress{}
+	for _, iface := range dhcpConfigs {
+		dynamicAddresses = append(dynamicAddresses, boship.NewResolvingInterfaceAddress(iface.Name, net.ipResolver))
+	}
+
+	return staticAddresses, dynamicAddresses
+}
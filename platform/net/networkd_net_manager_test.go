@@ -0,0 +1,407 @@
+package net_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("networkdNetManager", describeNetworkdNetManager)
+
+func describeNetworkdNetManager() {
+	var (
+		fs                     *fakesys.FakeFileSystem
+		cmdRunner              *fakesys.FakeCmdRunner
+		interfaceAddrsProvider *fakeip.FakeInterfaceAddressesProvider
+		addressBroadcaster     *fakearp.FakeAddressBroadcaster
+		netManager             Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
+		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
+		netManager = NewNetworkdNetManager(
+			fs,
+			cmdRunner,
+			&fakeip.FakeResolver{},
+			NewInterfaceConfigurationCreator(logger),
+			boship.NewInterfaceAddressesValidator(interfaceAddrsProvider),
+			addressBroadcaster,
+			logger,
+		)
+	})
+
+	writeNetworkDevice := func(iface string, macAddress string) string {
+		interfacePath := fmt.Sprintf("/sys/class/net/%s", iface)
+		fs.WriteFile(interfacePath, []byte{})
+		fs.WriteFile(fmt.Sprintf("/sys/class/net/%s/device", iface), []byte{})
+		fs.WriteFileString(fmt.Sprintf("/sys/class/net/%s/address", iface), fmt.Sprintf("%s\n", macAddress))
+		return interfacePath
+	}
+
+	stubInterfaces := func(physicalInterfaces map[string]boshsettings.Network) {
+		interfacePaths := []string{}
+		for iface, networkSettings := range physicalInterfaces {
+			interfacePaths = append(interfacePaths, writeNetworkDevice(iface, networkSettings.Mac))
+		}
+		fs.SetGlob("/sys/class/net/*", interfacePaths)
+	}
+
+	Describe("SetupIPv6", func() {
+		It("removes ipv6.disable=1 from grub.conf and reboots when IPv6 is enabled", func() {
+			err := fs.WriteFileString("/boot/grub/grub.conf", "before ipv6.disable=1 after")
+			Expect(err).ToNot(HaveOccurred())
+
+			stopCh := make(chan struct{}, 1)
+			stopCh <- struct{}{}
+
+			err = netManager.SetupIPv6(boshsettings.IPv6{Enable: true}, stopCh)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString("/boot/grub/grub.conf")).To(Equal("before  after"))
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"shutdown", "-r", "now"}}))
+		})
+
+		It("sets IPv6 sysctl when grub.conf allows IPv6", func() {
+			err := fs.WriteFileString("/boot/grub/grub.conf", "before after")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = netManager.SetupIPv6(boshsettings.IPv6{Enable: true}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"sysctl", "net.ipv6.conf.all.accept_ra=1"},
+				{"sysctl", "net.ipv6.conf.default.accept_ra=1"},
+				{"sysctl", "net.ipv6.conf.all.disable_ipv6=0"},
+				{"sysctl", "net.ipv6.conf.default.disable_ipv6=0"},
+			}))
+		})
+
+		It("does nothing when IPv6 is not enabled", func() {
+			err := netManager.SetupIPv6(boshsettings.IPv6{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("SetupNetworking", func() {
+		var (
+			dhcpNetwork   boshsettings.Network
+			staticNetwork boshsettings.Network
+			networks      boshsettings.Networks
+		)
+
+		BeforeEach(func() {
+			dhcpNetwork = boshsettings.Network{
+				Type:    "dynamic",
+				Default: []string{"dns"},
+				DNS:     []string{"8.8.8.8", "9.9.9.9"},
+				Mac:     "fake-dhcp-mac-address",
+			}
+			staticNetwork = boshsettings.Network{
+				Type:    "manual",
+				IP:      "1.2.3.4",
+				Netmask: "255.255.255.0",
+				Gateway: "1.2.3.1",
+				Default: []string{"gateway"},
+				Mac:     "fake-static-mac-address",
+				Routes: boshsettings.Routes{
+					{Destination: "10.0.0.0", NetMask: "255.0.0.0", Gateway: "1.2.3.254"},
+				},
+			}
+			networks = boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+			}
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+		})
+
+		It("writes .network units for each interface", func() {
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticUnit := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(staticUnit).ToNot(BeNil())
+			Expect(staticUnit.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+MACAddress=fake-static-mac-address
+
+[Network]
+DHCP=no
+Address=1.2.3.4/24
+DNS=8.8.8.8
+DNS=9.9.9.9
+
+[Route]
+Destination=0.0.0.0/0
+Gateway=1.2.3.1
+
+[Route]
+Destination=10.0.0.0/8
+Gateway=1.2.3.254
+`))
+
+			dhcpUnit := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethdhcp.network")
+			Expect(dhcpUnit).ToNot(BeNil())
+			Expect(dhcpUnit.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethdhcp
+
+[Network]
+DHCP=ipv4
+DNS=8.8.8.8
+DNS=9.9.9.9
+`))
+		})
+
+		It("writes IPv6 addresses and policy routing for static interfaces", func() {
+			staticNetwork.IPv6Address = "fd00::4"
+			staticNetwork.IPv6Netmask = "ffff:ffff:ffff:ffff::"
+			staticNetwork.IPv6Gateway = "fd00::1"
+			staticNetwork.RoutingTable = 100
+			staticNetwork.Routes = nil
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork, "dhcp-network": dhcpNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticUnit := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(staticUnit).ToNot(BeNil())
+			Expect(staticUnit.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+MACAddress=fake-static-mac-address
+
+[Network]
+DHCP=no
+Address=1.2.3.4/24
+Address=fd00::4/64
+DNS=8.8.8.8
+DNS=9.9.9.9
+
+[Route]
+Destination=0.0.0.0/0
+Gateway=1.2.3.1
+
+[Route]
+Destination=1.2.3.0/24
+Scope=link
+Table=100
+
+[Route]
+Destination=0.0.0.0/0
+Gateway=1.2.3.1
+Table=100
+
+[Route]
+Destination=::/0
+Gateway=fd00::1
+
+[Route]
+Destination=fd00::/64
+Scope=link
+Table=100
+
+[Route]
+Destination=::/0
+Gateway=fd00::1
+Table=100
+
+[RoutingPolicyRule]
+From=1.2.3.4/32
+Table=100
+
+[RoutingPolicyRule]
+From=fd00::4/128
+Table=100
+`))
+		})
+
+		It("writes .link units pinning names of interfaces with known MAC addresses", func() {
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			linkUnit := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.link")
+			Expect(linkUnit).ToNot(BeNil())
+			Expect(linkUnit.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+MACAddress=fake-static-mac-address
+
+[Link]
+Name=ethstatic
+`))
+
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethdhcp.link")).To(BeFalse())
+		})
+
+		It("writes .netdev units for bonds and VLANs and attaches member interfaces", func() {
+			networks = boshsettings.Networks{
+				"bonded": boshsettings.Network{
+					Type: "dynamic",
+					Bond: &boshsettings.Bond{
+						Name:       "bond0",
+						Interfaces: []string{"aa:01", "aa:02"},
+						MIIMon:     100,
+					},
+					MTU: 9000,
+				},
+				"tagged": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.1.0.5",
+					Netmask: "255.255.255.0",
+					Mac:     "aa:03",
+					VLAN:    100,
+				},
+			}
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth2.100", "10.1.0.5"),
+			}
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "aa:01"},
+				"eth1": {Mac: "aa:02"},
+				"eth2": {Mac: "aa:03"},
+			})
+
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			bondNetdev := fs.GetFileTestStat("/etc/systemd/network/10-bosh-bond0.netdev")
+			Expect(bondNetdev).ToNot(BeNil())
+			Expect(bondNetdev.StringContents()).To(Equal(`# Generated by bosh-agent
+[NetDev]
+Name=bond0
+Kind=bond
+MTUBytes=9000
+
+[Bond]
+Mode=active-backup
+MIIMonitorSec=100ms
+`))
+
+			memberUnit := fs.GetFileTestStat("/etc/systemd/network/10-bosh-eth0.network")
+			Expect(memberUnit).ToNot(BeNil())
+			Expect(memberUnit.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=eth0
+
+[Link]
+MTUBytes=9000
+
+[Network]
+DHCP=no
+Bond=bond0
+`))
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-eth0.link")).To(BeTrue())
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-bond0.network")).To(BeTrue())
+
+			vlanNetdev := fs.GetFileTestStat("/etc/systemd/network/10-bosh-eth2.100.netdev")
+			Expect(vlanNetdev).ToNot(BeNil())
+			Expect(vlanNetdev.StringContents()).To(Equal(`# Generated by bosh-agent
+[NetDev]
+Name=eth2.100
+Kind=vlan
+
+[VLAN]
+Id=100
+`))
+
+			parentUnit := fs.GetFileTestStat("/etc/systemd/network/10-bosh-eth2.network")
+			Expect(parentUnit).ToNot(BeNil())
+			Expect(parentUnit.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+MACAddress=aa:03
+
+[Network]
+DHCP=no
+VLAN=eth2.100
+`))
+		})
+
+		It("restarts systemd-networkd only when units changed", func() {
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "systemd-networkd"}}))
+
+			err = netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(HaveLen(1))
+		})
+
+		It("returns error when restarting systemd-networkd fails", func() {
+			cmdRunner.AddCmdResult("systemctl restart systemd-networkd", fakesys.FakeCmdResult{Error: errors.New("fake-apply-err")})
+
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Restarting systemd-networkd: fake-apply-err"))
+		})
+
+		It("removes units of interfaces that are no longer configured", func() {
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString("/etc/systemd/network/10-bosh-ethold.network", "stale")
+			fs.SetGlob("/etc/systemd/network/10-bosh-*", []string{
+				"/etc/systemd/network/10-bosh-ethdhcp.network",
+				"/etc/systemd/network/10-bosh-ethold.network",
+				"/etc/systemd/network/10-bosh-ethstatic.link",
+				"/etc/systemd/network/10-bosh-ethstatic.network",
+			})
+
+			err = netManager.SetupNetworking(networks, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethold.network")).To(BeFalse())
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethstatic.link")).To(BeTrue())
+			Expect(cmdRunner.RunCommands).To(HaveLen(2))
+		})
+
+		It("returns errors from writing the network configuration", func() {
+			fs.WriteFileError = errors.New("fs-write-file-error")
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fs-write-file-error"))
+		})
+
+		It("returns an error when a route netmask is invalid", func() {
+			staticNetwork.Routes[0].NetMask = "not-a-netmask"
+			networks["static-network"] = staticNetwork
+
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid netmask 'not-a-netmask'"))
+		})
+
+		It("returns an error when static addresses are not configured on the interfaces", func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{}
+			err := netManager.SetupNetworking(networks, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating static network configuration"))
+		})
+	})
+
+	Describe("GetConfiguredNetworkInterfaces", func() {
+		It("returns interfaces that have a .network unit", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "aa:bb"},
+				"eth1": {Mac: "cc:dd"},
+			})
+			fs.WriteFileString("/etc/systemd/network/10-bosh-eth0.network", "")
+
+			interfaces, err := netManager.GetConfiguredNetworkInterfaces()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(interfaces).To(ConsistOf("eth0"))
+		})
+	})
+}
@@ -0,0 +1,386 @@
+package net
+
+import (
+	gonet "net"
+	"strconv"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// renderedInterfaceConfiguration is the backend-neutral view of a single
+// interface used by the netplan and systemd-networkd templates
+type renderedInterfaceConfiguration struct {
+	Name               string
+	Mac                string
+	DHCP               bool
+	Addresses          []string
+	Routes             []renderedRoute
+	RoutingPolicyRules []renderedRoutingPolicyRule
+	DNSServers         []string
+
+	MTU        int
+	Bond       *renderedBond
+	BondMaster string
+	VLAN       *renderedVLAN
+	VLANs      []string
+}
+
+type renderedBond struct {
+	Mode       string
+	MIIMon     int
+	Interfaces []string
+}
+
+type renderedVLAN struct {
+	ID   int
+	Link string
+}
+
+// MatchMac is empty for bond members since bonding
+// may change their MAC address to the one of the bond
+func (c renderedInterfaceConfiguration) MatchMac() string {
+	if c.BondMaster != "" {
+		return ""
+	}
+
+	return c.Mac
+}
+
+// renderedRoute without a gateway is an on-link route;
+// Table 0 stands for the main routing table
+type renderedRoute struct {
+	Destination string
+	Gateway     string
+	Table       int
+}
+
+// ipRouteSpec renders route in 'ip route' syntax
+func (r renderedRoute) ipRouteSpec(device string) string {
+	spec := r.Destination
+	if r.Gateway != "" {
+		spec += " via " + r.Gateway
+	}
+
+	spec += " dev " + device
+
+	if r.Gateway == "" {
+		spec += " scope link"
+	}
+
+	if r.Table != 0 {
+		spec += " table " + strconv.Itoa(r.Table)
+	}
+
+	return spec
+}
+
+type renderedRoutingPolicyRule struct {
+	From  string
+	Table int
+}
+
+// ipRuleSpec renders rule in 'ip rule' syntax
+func (r renderedRoutingPolicyRule) ipRuleSpec() string {
+	return "from " + r.From + " table " + strconv.Itoa(r.Table)
+}
+
+type renderedInterfaceConfigurations []renderedInterfaceConfiguration
+
+func (configs renderedInterfaceConfigurations) Len() int {
+	return len(configs)
+}
+
+func (configs renderedInterfaceConfigurations) Less(i, j int) bool {
+	return configs[i].Name < configs[j].Name
+}
+
+func (configs renderedInterfaceConfigurations) Swap(i, j int) {
+	configs[i], configs[j] = configs[j], configs[i]
+}
+
+func newRenderedInterfaceConfigurations(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration, dnsServers []string) (renderedInterfaceConfigurations, error) {
+	configs := renderedInterfaceConfigurations{}
+
+	for _, dhcpConfig := range dhcpConfigs {
+		routes, err := newRenderedRoutes(dhcpConfig.PostUpRoutes)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Building routes for '%s'", dhcpConfig.Name)
+		}
+
+		configs = append(configs, renderedInterfaceConfiguration{
+			Name:       dhcpConfig.Name,
+			DHCP:       true,
+			Routes:     routes,
+			DNSServers: dnsServers,
+			MTU:        dhcpConfig.MTU,
+		})
+	}
+
+	for _, staticConfig := range staticConfigs {
+		config, err := newStaticRenderedInterfaceConfiguration(staticConfig, dnsServers)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Building configuration for '%s'", staticConfig.Name)
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// newRenderedLinkConfigurations renders only link settings of interfaces
+// for backends that render addresses by themselves
+func newRenderedLinkConfigurations(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration, links LinkConfigurations) renderedInterfaceConfigurations {
+	configs := renderedInterfaceConfigurations{}
+
+	for _, dhcpConfig := range dhcpConfigs {
+		configs = append(configs, renderedInterfaceConfiguration{Name: dhcpConfig.Name, DHCP: true, MTU: dhcpConfig.MTU})
+	}
+
+	for _, staticConfig := range staticConfigs {
+		configs = append(configs, renderedInterfaceConfiguration{Name: staticConfig.Name, Mac: staticConfig.Mac, MTU: staticConfig.MTU})
+	}
+
+	return configs.withLinks(links)
+}
+
+// withLinks merges link settings into configurations of the same name
+// and adds unaddressed configurations for bonds, VLANs and their members
+func (configs renderedInterfaceConfigurations) withLinks(links LinkConfigurations) renderedInterfaceConfigurations {
+	indexByName := map[string]int{}
+	for i, config := range configs {
+		indexByName[config.Name] = i
+	}
+
+	configFor := func(name string) *renderedInterfaceConfiguration {
+		i, found := indexByName[name]
+		if !found {
+			configs = append(configs, renderedInterfaceConfiguration{Name: name})
+			i = len(configs) - 1
+			indexByName[name] = i
+		}
+		return &configs[i]
+	}
+
+	for _, iface := range links.Interfaces {
+		config := configFor(iface.Name)
+		config.Mac = iface.Mac
+		config.BondMaster = iface.Bond
+		config.MTU = maxMTU(config.MTU, iface.MTU)
+	}
+
+	for _, bond := range links.Bonds {
+		config := configFor(bond.Name)
+		config.Bond = &renderedBond{Mode: bond.Mode, MIIMon: bond.MIIMon, Interfaces: bond.Interfaces}
+		config.MTU = maxMTU(config.MTU, bond.MTU)
+	}
+
+	for _, vlan := range links.VLANs {
+		config := configFor(vlan.Name)
+		config.VLAN = &renderedVLAN{ID: vlan.ID, Link: vlan.Link}
+		config.MTU = maxMTU(config.MTU, vlan.MTU)
+
+		parent := configFor(vlan.Link)
+		parent.VLANs = append(parent.VLANs, vlan.Name)
+	}
+
+	return configs
+}
+
+func newStaticRenderedInterfaceConfiguration(staticConfig StaticInterfaceConfiguration, dnsServers []string) (renderedInterfaceConfiguration, error) {
+	config := renderedInterfaceConfiguration{
+		Name:       staticConfig.Name,
+		Mac:        staticConfig.Mac,
+		DNSServers: dnsServers,
+		MTU:        staticConfig.MTU,
+	}
+
+	families := []struct {
+		address            string
+		netmask            string
+		gateway            string
+		defaultDestination string
+	}{
+		{staticConfig.Address, staticConfig.Netmask, staticConfig.Gateway, "0.0.0.0/0"},
+		{staticConfig.IPv6Address, staticConfig.IPv6Netmask, staticConfig.IPv6Gateway, "::/0"},
+	}
+
+	for _, family := range families {
+		if family.address == "" {
+			continue
+		}
+
+		address, err := cidrNotation(family.address, family.netmask)
+		if err != nil {
+			return config, bosherr.WrapError(err, "Building address")
+		}
+
+		config.Addresses = append(config.Addresses, address)
+
+		if staticConfig.IsDefaultForGateway && family.gateway != "" {
+			config.Routes = append(config.Routes, renderedRoute{
+				Destination: family.defaultDestination,
+				Gateway:     family.gateway,
+			})
+		}
+
+		if staticConfig.RoutingTable == 0 {
+			continue
+		}
+
+		subnet, err := subnetCIDRNotation(family.address, family.netmask)
+		if err != nil {
+			return config, bosherr.WrapError(err, "Building subnet route")
+		}
+
+		config.Routes = append(config.Routes, renderedRoute{
+			Destination: subnet,
+			Table:       staticConfig.RoutingTable,
+		})
+
+		if family.gateway != "" {
+			config.Routes = append(config.Routes, renderedRoute{
+				Destination: family.defaultDestination,
+				Gateway:     family.gateway,
+				Table:       staticConfig.RoutingTable,
+			})
+		}
+
+		config.RoutingPolicyRules = append(config.RoutingPolicyRules, renderedRoutingPolicyRule{
+			From:  hostCIDRNotation(family.address),
+			Table: staticConfig.RoutingTable,
+		})
+	}
+
+	routes, err := newRenderedRoutes(staticConfig.PostUpRoutes)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Building routes")
+	}
+
+	config.Routes = append(config.Routes, routes...)
+
+	return config, nil
+}
+
+func newRenderedRoutes(routes boshsettings.Routes) ([]renderedRoute, error) {
+	renderedRoutes := []renderedRoute{}
+
+	for _, route := range routes {
+		destination, err := cidrNotation(route.Destination, route.NetMask)
+		if err != nil {
+			return nil, err
+		}
+
+		renderedRoutes = append(renderedRoutes, renderedRoute{
+			Destination: destination,
+			Gateway:     route.Gateway,
+		})
+	}
+
+	return renderedRoutes, nil
+}
+
+func cidrNotation(address, netmask string) (string, error) {
+	ones, err := prefixLength(address, netmask)
+	if err != nil {
+		return "", err
+	}
+
+	return address + "/" + strconv.Itoa(ones), nil
+}
+
+func subnetCIDRNotation(address, netmask string) (string, error) {
+	cidr, err := cidrNotation(address, netmask)
+	if err != nil {
+		return "", err
+	}
+
+	_, subnet, err := gonet.ParseCIDR(cidr)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing '%s'", cidr)
+	}
+
+	return subnet.String(), nil
+}
+
+func hostCIDRNotation(address string) string {
+	if isIPv6(address) {
+		return address + "/128"
+	}
+
+	return address + "/32"
+}
+
+// prefixLength accepts netmasks either in dotted/colon notation
+// (e.g. 255.255.255.0, ffff:ffff:ffff:ffff::) or as a prefix length (e.g. 64)
+func prefixLength(address, netmask string) (int, error) {
+	if gonet.ParseIP(address) == nil {
+		return 0, bosherr.Errorf("Invalid IP '%s'", address)
+	}
+
+	maxLength := 32
+	if isIPv6(address) {
+		maxLength = 128
+	}
+
+	if length, err := strconv.Atoi(netmask); err == nil {
+		if length < 0 || length > maxLength {
+			return 0, bosherr.Errorf("Invalid prefix length '%s'", netmask)
+		}
+
+		return length, nil
+	}
+
+	mask := gonet.ParseIP(netmask)
+	if mask == nil {
+		return 0, bosherr.Errorf("Invalid netmask '%s'", netmask)
+	}
+
+	if ipv4Mask := mask.To4(); ipv4Mask != nil && !isIPv6(address) {
+		mask = ipv4Mask
+	}
+
+	ones, bits := gonet.IPMask(mask).Size()
+	if bits == 0 {
+		return 0, bosherr.Errorf("Non-canonical netmask '%s'", netmask)
+	}
+
+	return ones, nil
+}
+
+func isIPv6CIDR(cidr string) bool {
+	return strings.Contains(cidr, ":")
+}
+
+func isIPv6(address string) bool {
+	ip := gonet.ParseIP(address)
+	return ip != nil && ip.To4() == nil
+}
+
+// removeStaleFiles deletes previously generated files matching pattern
+// that were not written during the current convergence
+func removeStaleFiles(fs boshsys.FileSystem, pattern string, writtenPaths map[string]bool) (bool, error) {
+	filePaths, err := fs.Glob(pattern)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Listing '%s'", pattern)
+	}
+
+	removed := false
+	for _, filePath := range filePaths {
+		if writtenPaths[filePath] {
+			continue
+		}
+
+		err = fs.RemoveAll(filePath)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Removing stale config '%s'", filePath)
+		}
+
+		removed = true
+	}
+
+	return removed, nil
+}
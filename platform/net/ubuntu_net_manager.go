@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -67,6 +68,11 @@ prepend domain-name-servers {{ . }};{{ end }}
 `
 
 func (net UbuntuNetManager) ComputeNetworkConfig(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, []string, error) {
+	staticConfigs, dhcpConfigs, _, dnsServers, err := net.computeNetworkConfig(networks)
+	return staticConfigs, dhcpConfigs, dnsServers, err
+}
+
+func (net UbuntuNetManager) computeNetworkConfig(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, LinkConfigurations, []string, error) {
 	nonVipNetworks := boshsettings.Networks{}
 	for networkName, networkSettings := range networks {
 		if networkSettings.IsVIP() {
@@ -75,67 +81,18 @@ func (net UbuntuNetManager) ComputeNetworkConfig(networks boshsettings.Networks)
 		nonVipNetworks[networkName] = networkSettings
 	}
 
-	staticConfigs, dhcpConfigs, err := net.buildInterfaces(nonVipNetworks)
+	staticConfigs, dhcpConfigs, links, err := net.buildInterfaces(nonVipNetworks)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, LinkConfigurations{}, nil, err
 	}
 
 	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
 	dnsServers := dnsNetwork.DNS
-	return staticConfigs, dhcpConfigs, dnsServers, nil
+	return staticConfigs, dhcpConfigs, links, dnsServers, nil
 }
 
 func (net UbuntuNetManager) SetupIPv6(config boshsettings.IPv6, stopCh <-chan struct{}) error {
-	const (
-		grubConfPath       = "/boot/grub/grub.conf"
-		grubIPv6DisableOpt = "ipv6.disable=1"
-	)
-
-	if !config.Enable {
-		return nil
-	}
-
-	grubConf, err := net.fs.ReadFileString(grubConfPath)
-	if err != nil {
-		return bosherr.WrapError(err, "Reading grub")
-	}
-
-	if strings.Contains(grubConf, grubIPv6DisableOpt) {
-		grubConf = strings.Replace(grubConf, grubIPv6DisableOpt, "", -1)
-
-		err = net.fs.WriteFileString(grubConfPath, grubConf)
-		if err != nil {
-			return bosherr.WrapError(err, "Writing grub.conf")
-		}
-
-		net.logger.Info(UbuntuNetManagerLogTag, "Rebooting to enable IPv6 in kernel")
-
-		_, _, _, err = net.cmdRunner.RunCommand("shutdown", "-r", "now")
-		if err != nil {
-			return bosherr.WrapError(err, "Rebooting for IPv6")
-		}
-
-		// Wait here for the OS to reboot the machine
-		<-stopCh
-
-		return nil
-	}
-
-	ipv6Sysctls := []string{
-		"net.ipv6.conf.all.accept_ra=1",
-		"net.ipv6.conf.default.accept_ra=1",
-		"net.ipv6.conf.all.disable_ipv6=0",
-		"net.ipv6.conf.default.disable_ipv6=0",
-	}
-
-	for _, sysctl := range ipv6Sysctls {
-		_, _, _, err := net.cmdRunner.RunCommand("sysctl", sysctl)
-		if err != nil {
-			return bosherr.WrapError(err, "Running IPv6 sysctl")
-		}
-	}
-
-	return nil
+	return setupKernelIPv6(net.fs, net.cmdRunner, net.logger, UbuntuNetManagerLogTag, config, stopCh)
 }
 
 func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errCh chan error) error {
@@ -149,12 +106,12 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errC
 		net.writeResolvConf(networks)
 	}
 
-	staticConfigs, dhcpConfigs, dnsServers, err := net.ComputeNetworkConfig(networks)
+	staticConfigs, dhcpConfigs, links, dnsServers, err := net.computeNetworkConfig(networks)
 	if err != nil {
 		return bosherr.WrapError(err, "Computing network configuration")
 	}
 
-	interfacesChanged, err := net.writeNetworkInterfaces(dhcpConfigs, staticConfigs, dnsServers)
+	interfacesChanged, err := net.writeNetworkInterfaces(dhcpConfigs, staticConfigs, links, dnsServers)
 	if err != nil {
 		return bosherr.WrapError(err, "Writing network configuration")
 	}
@@ -173,24 +130,23 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errC
 			return err
 		}
 
-		net.restartNetworkingInterfaces(net.ifaceNames(dhcpConfigs, staticConfigs))
+		net.restartNetworkingInterfaces(net.ifaceNames(newIfupdownManualInterfaces(staticConfigs, dhcpConfigs, links), dhcpConfigs, staticConfigs, links))
 	}
 
-	//staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
-
-	//TODO: portal IP could not pass the validation
+	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
 
-	//err = net.interfaceAddressesValidator.Validate(staticAddresses)
-	//if err != nil {
-	//	return bosherr.WrapError(err, "Validating static network configuration")
-	//}
+	// Validation errors are returned as is so that callers can inspect mismatches
+	err = net.interfaceAddressesValidator.Validate(staticAddresses)
+	if err != nil {
+		return err
+	}
 
-	//err = net.dnsValidator.Validate(dnsServers)
-	//if err != nil {
-	//	return bosherr.WrapError(err, "Validating dns configuration")
-	//}
+	err = net.dnsValidator.Validate(dnsServers)
+	if err != nil {
+		return err
+	}
 
-	//net.broadcastIps(append(staticAddresses, dynamicAddresses...), errCh)
+	net.broadcastIps(append(staticAddresses, dynamicAddresses...), errCh)
 
 	return nil
 }
@@ -244,10 +200,10 @@ func (net UbuntuNetManager) removeDhcpDNSConfiguration() error {
 	return nil
 }
 
-func (net UbuntuNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+func (net UbuntuNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, LinkConfigurations, error) {
 	interfacesByMacAddress, err := net.detectMacAddresses()
 	if err != nil {
-		return nil, nil, bosherr.WrapError(err, "Getting network interfaces")
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Getting network interfaces")
 	}
 
 	// if len(interfacesByMacAddress) == 0 {
@@ -256,10 +212,15 @@ func (net UbuntuNetManager) buildInterfaces(networks boshsettings.Networks) ([]S
 
 	staticConfigs, dhcpConfigs, err := net.interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMacAddress)
 	if err != nil {
-		return nil, nil, bosherr.WrapError(err, "Creating interface configurations")
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating interface configurations")
+	}
+
+	links, err := net.interfaceConfigurationCreator.CreateLinkConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, LinkConfigurations{}, bosherr.WrapError(err, "Creating link configurations")
 	}
 
-	return staticConfigs, dhcpConfigs, nil
+	return staticConfigs, dhcpConfigs, links, nil
 }
 
 func (net UbuntuNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
@@ -323,23 +284,40 @@ type networkInterfaceConfig struct {
 	DNSServers        []string
 	StaticConfigs     []StaticInterfaceConfiguration
 	DHCPConfigs       []DHCPInterfaceConfiguration
+	ManualInterfaces  []ifupdownInterface
+	LinkOptions       map[string][]string
 	HasDNSNameServers bool
 }
 
-func (net UbuntuNetManager) writeNetworkInterfaces(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations, dnsServers []string) (bool, error) {
+// ifupdownInterface is brought up without addresses
+// to carry bonds or VLANs
+type ifupdownInterface struct {
+	Name    string
+	Options []string
+}
+
+func (net UbuntuNetManager) writeNetworkInterfaces(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations, links LinkConfigurations, dnsServers []string) (bool, error) {
 	sort.Stable(dhcpConfigs)
 	sort.Stable(staticConfigs)
 
 	networkInterfaceValues := networkInterfaceConfig{
 		DHCPConfigs:       dhcpConfigs,
 		StaticConfigs:     staticConfigs,
+		ManualInterfaces:  newIfupdownManualInterfaces(staticConfigs, dhcpConfigs, links),
+		LinkOptions:       map[string][]string{},
 		HasDNSNameServers: true,
 		DNSServers:        dnsServers,
 	}
 
+	for _, config := range newRenderedLinkConfigurations(staticConfigs, dhcpConfigs, links) {
+		if options := ifupdownLinkOptions(config); len(options) > 0 {
+			networkInterfaceValues.LinkOptions[config.Name] = options
+		}
+	}
+
 	buffer := bytes.NewBuffer([]byte{})
 
-	t := template.Must(template.New("network-interfaces").Parse(networkInterfacesTemplate))
+	t := template.Must(template.New("network-interfaces").Funcs(networkInterfacesTemplateFuncs).Parse(networkInterfacesTemplate))
 
 	err := t.Execute(buffer, networkInterfaceValues)
 	if err != nil {
@@ -357,22 +335,145 @@ func (net UbuntuNetManager) writeNetworkInterfaces(dhcpConfigs DHCPInterfaceConf
 const networkInterfacesTemplate = `# Generated by bosh-agent
 auto lo
 iface lo inet loopback
-{{ range .DHCPConfigs }}
+{{ range .ManualInterfaces }}
 auto {{ .Name }}
-iface {{ .Name }} inet dhcp{{ range .PostUpRoutes }}
+iface {{ .Name }} inet manual{{ range .Options }}
+    {{ . }}{{ end }}
+{{ end }}{{ range .DHCPConfigs }}
+auto {{ .Name }}
+iface {{ .Name }} inet dhcp{{ range index $.LinkOptions .Name }}
+{{ . }}{{ end }}{{ range .PostUpRoutes }}
 post-up route add -net {{ .Destination }} netmask {{ .NetMask }} gw {{ .Gateway }}{{ end }}
 {{ end }}{{ range .StaticConfigs }}
 auto {{ .Name }}
 iface {{ .Name }} inet static
     address {{ .Address }}
     network {{ .Network }}
-    netmask {{ .Netmask }}
+    netmask {{ .Netmask }}{{ range index $.LinkOptions .Name }}
+    {{ . }}{{ end }}
 {{ if .IsDefaultForGateway }}    broadcast {{ .Broadcast }}
-    gateway {{ .Gateway }}{{ end }}{{ range .PostUpRoutes }}
-    post-up route add -net {{ .Destination }} netmask {{ .NetMask }} gw {{ .Gateway }}{{ end }}{{ end }}
+    gateway {{ .Gateway }}{{ end }}{{ range .PostUpRoutes }}{{ if not (isIPv6 .Destination) }}
+    post-up route add -net {{ .Destination }} netmask {{ .NetMask }} gw {{ .Gateway }}{{ end }}{{ end }}{{ with ifupdownCommands . false }}{{ range .PostUp }}
+    post-up {{ . }}{{ end }}{{ range .PreDown }}
+    pre-down {{ . }}{{ end }}{{ end }}{{ if .IPv6Address }}
+
+iface {{ .Name }} inet6 static
+    address {{ .IPv6Address }}
+    netmask {{ prefixLength .IPv6Address .IPv6Netmask }}{{ if and .IsDefaultForGateway .IPv6Gateway }}
+    gateway {{ .IPv6Gateway }}{{ end }}{{ with ifupdownCommands . true }}{{ range .PostUp }}
+    post-up {{ . }}{{ end }}{{ range .PreDown }}
+    pre-down {{ . }}{{ end }}{{ end }}{{ end }}{{ end }}
 {{ if .DNSServers }}
 dns-nameservers{{ range .DNSServers }} {{ . }}{{ end }}{{ end }}`
 
+var networkInterfacesTemplateFuncs = template.FuncMap{
+	"isIPv6":           isIPv6,
+	"prefixLength":     prefixLength,
+	"ifupdownCommands": newIfupdownCommands,
+}
+
+// newIfupdownManualInterfaces returns bonds, bond members and VLAN parents
+// that do not have a network of their own
+func newIfupdownManualInterfaces(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration, links LinkConfigurations) []ifupdownInterface {
+	addressed := map[string]bool{}
+	for _, config := range staticConfigs {
+		addressed[config.Name] = true
+	}
+	for _, config := range dhcpConfigs {
+		addressed[config.Name] = true
+	}
+
+	configs := newRenderedLinkConfigurations(staticConfigs, dhcpConfigs, links)
+	sort.Stable(configs)
+
+	manualInterfaces := []ifupdownInterface{}
+	for _, config := range configs {
+		if !addressed[config.Name] {
+			manualInterfaces = append(manualInterfaces, ifupdownInterface{Name: config.Name, Options: ifupdownLinkOptions(config)})
+		}
+	}
+
+	return manualInterfaces
+}
+
+// ifupdownLinkOptions relies on ifenslave and vlan packages
+// to create bonds and VLANs when interfaces are brought up
+func ifupdownLinkOptions(config renderedInterfaceConfiguration) []string {
+	options := []string{}
+
+	if bond := config.Bond; bond != nil {
+		options = append(options, "bond-slaves "+strings.Join(bond.Interfaces, " "), "bond-mode "+bond.Mode)
+		if bond.MIIMon != 0 {
+			options = append(options, "bond-miimon "+strconv.Itoa(bond.MIIMon))
+		}
+	}
+
+	if config.BondMaster != "" {
+		options = append(options, "bond-master "+config.BondMaster)
+	}
+
+	if config.VLAN != nil {
+		options = append(options, "vlan-raw-device "+config.VLAN.Link)
+	}
+
+	if config.MTU != 0 {
+		options = append(options, "mtu "+strconv.Itoa(config.MTU))
+	}
+
+	return options
+}
+
+type ifupdownCommands struct {
+	PostUp  []string
+	PreDown []string
+}
+
+// newIfupdownCommands builds ip commands for policy routing of given address family
+// and, for IPv6, post-up routes which cannot be expressed with 'route add -net'
+func newIfupdownCommands(config StaticInterfaceConfiguration, ipv6 bool) (ifupdownCommands, error) {
+	commands := ifupdownCommands{}
+
+	rendered, err := newStaticRenderedInterfaceConfiguration(config, nil)
+	if err != nil {
+		return commands, err
+	}
+
+	routes := []renderedRoute{}
+	for _, route := range rendered.Routes {
+		if route.Table != 0 {
+			routes = append(routes, route)
+		}
+	}
+
+	if ipv6 {
+		postUpRoutes, err := newRenderedRoutes(config.PostUpRoutes)
+		if err != nil {
+			return commands, err
+		}
+		routes = append(routes, postUpRoutes...)
+	}
+
+	ipCommand := "ip"
+	if ipv6 {
+		ipCommand = "ip -6"
+	}
+
+	for _, route := range routes {
+		if isIPv6CIDR(route.Destination) == ipv6 {
+			commands.PostUp = append(commands.PostUp, ipCommand+" route add "+route.ipRouteSpec(config.Name))
+		}
+	}
+
+	for _, rule := range rendered.RoutingPolicyRules {
+		if isIPv6CIDR(rule.From) == ipv6 {
+			commands.PostUp = append(commands.PostUp, ipCommand+" rule add "+rule.ipRuleSpec())
+			commands.PreDown = append(commands.PreDown, ipCommand+" rule del "+rule.ipRuleSpec())
+		}
+	}
+
+	return commands, nil
+}
+
 func (net UbuntuNetManager) detectMacAddresses() (map[string]string, error) {
 	addresses := map[string]string{}
 
@@ -401,15 +502,35 @@ func (net UbuntuNetManager) detectMacAddresses() (map[string]string, error) {
 	return addresses, nil
 }
 
-func (net UbuntuNetManager) ifaceNames(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations) []string {
-	ifaceNames := []string{}
+// ifaceNames lists VLANs last so that their parent links are up by the time they are brought up
+func (net UbuntuNetManager) ifaceNames(manualInterfaces []ifupdownInterface, dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations, links LinkConfigurations) []string {
+	vlans := map[string]bool{}
+	for _, vlan := range links.VLANs {
+		vlans[vlan.Name] = true
+	}
+
+	names := []string{}
+	for _, iface := range manualInterfaces {
+		names = append(names, iface.Name)
+	}
 	for _, config := range dhcpConfigs {
-		ifaceNames = append(ifaceNames, config.Name)
+		names = append(names, config.Name)
 	}
 	for _, config := range staticConfigs {
-		ifaceNames = append(ifaceNames, config.Name)
+		names = append(names, config.Name)
+	}
+
+	ifaceNames := []string{}
+	vlanNames := []string{}
+	for _, name := range names {
+		if vlans[name] {
+			vlanNames = append(vlanNames, name)
+		} else {
+			ifaceNames = append(ifaceNames, name)
+		}
 	}
-	return ifaceNames
+
+	return append(ifaceNames, vlanNames...)
 }
 
 func (net UbuntuNetManager) writeResolvConf(networks boshsettings.Networks) error {
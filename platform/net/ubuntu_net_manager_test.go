@@ -492,6 +492,88 @@ dns-nameservers 8.8.8.8`))
 
 		})
 
+		It("configures IPv6 and per-interface routing tables for static networks", func() {
+			staticNetwork = boshsettings.Network{
+				Type:         "manual",
+				IP:           "1.2.3.4",
+				Netmask:      "255.255.255.0",
+				Gateway:      "1.2.3.1",
+				Mac:          "fake-static-mac-address",
+				IPv6Address:  "fd00::4",
+				IPv6Netmask:  "64",
+				IPv6Gateway:  "fd00::1",
+				RoutingTable: 101,
+				Routes: []boshsettings.Route{
+					{Destination: "fd01::", NetMask: "32", Gateway: "fd00::fe"},
+				},
+			}
+			secondStaticNetwork := boshsettings.Network{
+				Type:         "manual",
+				IP:           "5.6.7.8",
+				Netmask:      "255.255.255.0",
+				Gateway:      "5.6.7.1",
+				Mac:          "second-fake-static-mac-address",
+				DNS:          []string{"8.8.8.8"},
+				Default:      []string{"gateway", "dns"},
+				RoutingTable: 102,
+			}
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": staticNetwork,
+				"eth1": secondStaticNetwork,
+			})
+
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("eth1", "5.6.7.8"),
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{
+				"static-1": staticNetwork,
+				"static-2": secondStaticNetwork,
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+auto lo
+iface lo inet loopback
+
+auto eth0
+iface eth0 inet static
+    address 1.2.3.4
+    network 1.2.3.0
+    netmask 255.255.255.0
+
+    post-up ip route add 1.2.3.0/24 dev eth0 scope link table 101
+    post-up ip route add 0.0.0.0/0 via 1.2.3.1 dev eth0 table 101
+    post-up ip rule add from 1.2.3.4/32 table 101
+    pre-down ip rule del from 1.2.3.4/32 table 101
+
+iface eth0 inet6 static
+    address fd00::4
+    netmask 64
+    post-up ip -6 route add fd00::/64 dev eth0 scope link table 101
+    post-up ip -6 route add ::/0 via fd00::1 dev eth0 table 101
+    post-up ip -6 route add fd01::/32 via fd00::fe dev eth0
+    post-up ip -6 rule add from fd00::4/128 table 101
+    pre-down ip -6 rule del from fd00::4/128 table 101
+auto eth1
+iface eth1 inet static
+    address 5.6.7.8
+    network 5.6.7.0
+    netmask 255.255.255.0
+    broadcast 5.6.7.255
+    gateway 5.6.7.1
+    post-up ip route add 5.6.7.0/24 dev eth1 scope link table 102
+    post-up ip route add 0.0.0.0/0 via 5.6.7.1 dev eth1 table 102
+    post-up ip rule add from 5.6.7.8/32 table 102
+    pre-down ip rule del from 5.6.7.8/32 table 102
+
+dns-nameservers 8.8.8.8`))
+		})
+
 		It("configures postup routes for static network", func() {
 			staticNetwork = boshsettings.Network{
 				Type:    "manual",
@@ -615,6 +697,79 @@ dns-nameservers 8.8.8.8`))
 
 		})
 
+		It("configures bonds, VLANs and their member interfaces", func() {
+			bond := &boshsettings.Bond{
+				Name:       "bond0",
+				Mode:       "802.3ad",
+				Interfaces: []string{"aa:01", "aa:02"},
+				MIIMon:     100,
+			}
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "aa:01"},
+				"eth1": {Mac: "aa:02"},
+			})
+
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0", "10.0.0.5"),
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{
+				"bonded": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.0.0.5",
+					Netmask: "255.255.255.0",
+					Gateway: "10.0.0.1",
+					DNS:     []string{"8.8.8.8"},
+					Default: []string{"gateway", "dns"},
+					Bond:    bond,
+					MTU:     9000,
+				},
+				"tagged": boshsettings.Network{
+					Type: "dynamic",
+					Bond: bond,
+					VLAN: 100,
+				},
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+auto lo
+iface lo inet loopback
+
+auto eth0
+iface eth0 inet manual
+    bond-master bond0
+    mtu 9000
+
+auto eth1
+iface eth1 inet manual
+    bond-master bond0
+    mtu 9000
+
+auto bond0.100
+iface bond0.100 inet dhcp
+vlan-raw-device bond0
+
+auto bond0
+iface bond0 inet static
+    address 10.0.0.5
+    network 10.0.0.0
+    netmask 255.255.255.0
+    bond-slaves eth0 eth1
+    bond-mode 802.3ad
+    bond-miimon 100
+    mtu 9000
+    broadcast 10.0.0.255
+    gateway 10.0.0.1
+
+dns-nameservers 8.8.8.8`))
+
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"ifup", "--force", "eth0", "eth1", "bond0", "bond0.100"}))
+		})
+
 		It("writes /etc/network/interfaces without dns-namservers if there are no dns servers", func() {
 			staticNetworkWithoutDNS := boshsettings.Network{
 				Type:    "manual",
@@ -908,7 +1063,11 @@ prepend domain-name-servers 8.8.8.8, 9.9.9.9;
 				errCh := make(chan error)
 				err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, errCh)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("Validating static network configuration"))
+
+				validationErr, ok := err.(boship.InterfaceAddressesValidationError)
+				Expect(ok).To(BeTrue())
+				Expect(validationErr.Mismatches).To(HaveLen(1))
+				Expect(validationErr.Mismatches[0].InterfaceName).To(Equal("ethstatic"))
 			})
 		})
 
@@ -935,7 +1094,7 @@ prepend domain-name-servers 8.8.8.8, 9.9.9.9;
 				errCh := make(chan error)
 				err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, errCh)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("Validating dns configuration"))
+				Expect(err).To(Equal(DNSValidationError{DNSServers: []string{"8.8.8.8"}}))
 			})
 		})
 
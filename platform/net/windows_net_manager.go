@@ -87,6 +87,25 @@ foreach($interface in $interfaces) {
 	NicSettingsTemplate = `
 $connectionName=(get-wmiobject win32_networkadapter | where-object {$_.MacAddress -eq '%s'}).netconnectionid
 netsh interface ip set address $connectionName static %s %s %s
+`
+
+	NicMTUTemplate = `
+$connectionName=(get-wmiobject win32_networkadapter | where-object {$_.MacAddress -eq '%s'}).netconnectionid
+Set-NetIPInterface -InterfaceAlias $connectionName -NlMtuBytes %d
+`
+
+	NicIPv6SettingsTemplate = `
+$connectionName=(get-wmiobject win32_networkadapter | where-object {$_.MacAddress -eq '%[1]s'}).netconnectionid
+if (-not (Get-NetIPAddress -InterfaceAlias $connectionName -IPAddress '%[2]s' -ErrorAction SilentlyContinue)) {
+	New-NetIPAddress -InterfaceAlias $connectionName -IPAddress '%[2]s' -PrefixLength %[3]d
+}
+`
+
+	NicIPv6GatewayTemplate = `
+$connectionName=(get-wmiobject win32_networkadapter | where-object {$_.MacAddress -eq '%[1]s'}).netconnectionid
+if (-not (Get-NetRoute -InterfaceAlias $connectionName -DestinationPrefix '::/0' -NextHop '%[2]s' -ErrorAction SilentlyContinue)) {
+	New-NetRoute -InterfaceAlias $connectionName -DestinationPrefix '::/0' -NextHop '%[2]s'
+}
 `
 )
 
@@ -128,6 +147,15 @@ func (net WindowsNetManager) SetupNetworking(networks boshsettings.Networks, err
 			continue
 		}
 		nonVipNetworks[networkName] = networkSettings
+
+		linkSettings, err := networkSettings.LinkSettings()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Resolving link settings of network '%s'", networkName)
+		}
+
+		if linkSettings.HasLink() {
+			return bosherr.Errorf("Configuring network '%s': bonds and VLANs are not supported", networkName)
+		}
 	}
 	staticConfigs, _, dnsServers, err := net.ComputeNetworkConfig(networks)
 	if err != nil {
@@ -156,7 +184,48 @@ func (net WindowsNetManager) setupInterfaces(staticConfigs []StaticInterfaceConf
 		if err != nil {
 			return bosherr.WrapError(err, "Configuring interface")
 		}
+
+		err = net.setupIPv6Interface(conf)
+		if err != nil {
+			return err
+		}
+
+		if conf.MTU != 0 {
+			_, _, _, err = net.runner.RunCommand("-Command", fmt.Sprintf(NicMTUTemplate, conf.Mac, conf.MTU))
+			if err != nil {
+				return bosherr.WrapError(err, "Configuring interface MTU")
+			}
+		}
+
+		if conf.RoutingTable != 0 {
+			net.logger.Warn(net.logTag, "Ignoring routing table '%d' for '%s': policy routing is not supported", conf.RoutingTable, conf.Mac)
+		}
+	}
+	return nil
+}
+
+func (net WindowsNetManager) setupIPv6Interface(conf StaticInterfaceConfiguration) error {
+	if conf.IPv6Address == "" {
+		return nil
+	}
+
+	length, err := prefixLength(conf.IPv6Address, conf.IPv6Netmask)
+	if err != nil {
+		return bosherr.WrapError(err, "Building IPv6 address")
+	}
+
+	_, _, _, err = net.runner.RunCommand("-Command", fmt.Sprintf(NicIPv6SettingsTemplate, conf.Mac, conf.IPv6Address, length))
+	if err != nil {
+		return bosherr.WrapError(err, "Configuring interface IPv6 address")
+	}
+
+	if conf.IsDefaultForGateway && conf.IPv6Gateway != "" {
+		_, _, _, err = net.runner.RunCommand("-Command", fmt.Sprintf(NicIPv6GatewayTemplate, conf.Mac, conf.IPv6Gateway))
+		if err != nil {
+			return bosherr.WrapError(err, "Configuring interface IPv6 gateway")
+		}
 	}
+
 	return nil
 }
 
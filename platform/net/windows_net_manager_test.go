@@ -112,6 +112,55 @@ var _ = Describe("WindowsNetManager", func() {
 				ContainElement([]string{"-Command", fmt.Sprintf(NicSettingsTemplate, network2.Mac, network2.IP, network2.Netmask, "")}))
 		})
 
+		It("sets IPv6 address on interfaces and IPv6 gateway on the default gateway interface", func() {
+			ipv6Network1 := network1
+			ipv6Network1.IPv6Address = "fd00::50"
+			ipv6Network1.IPv6Netmask = "64"
+			ipv6Network1.IPv6Gateway = "fd00::1"
+
+			ipv6Network2 := network2
+			ipv6Network2.IPv6Address = "fd01::20"
+			ipv6Network2.IPv6Netmask = "64"
+			ipv6Network2.IPv6Gateway = "fd01::1"
+
+			setupMACs(ipv6Network1, ipv6Network2)
+			err := setupNetworking(boshsettings.Networks{"net1": ipv6Network1, "net2": ipv6Network2})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(
+				ContainElement([]string{"-Command", fmt.Sprintf(NicIPv6SettingsTemplate, network1.Mac, "fd00::50", 64)}))
+			Expect(runner.RunCommands).To(
+				ContainElement([]string{"-Command", fmt.Sprintf(NicIPv6GatewayTemplate, network1.Mac, "fd00::1")}))
+			Expect(runner.RunCommands).To(
+				ContainElement([]string{"-Command", fmt.Sprintf(NicIPv6SettingsTemplate, network2.Mac, "fd01::20", 64)}))
+			Expect(runner.RunCommands).ToNot(
+				ContainElement([]string{"-Command", fmt.Sprintf(NicIPv6GatewayTemplate, network2.Mac, "fd01::1")}))
+		})
+
+		It("sets MTU on interfaces that declare it", func() {
+			mtuNetwork := network1
+			mtuNetwork.MTU = 9000
+
+			setupMACs(mtuNetwork, network2)
+			err := setupNetworking(boshsettings.Networks{"net1": mtuNetwork, "net2": network2})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(
+				ContainElement([]string{"-Command", fmt.Sprintf(NicMTUTemplate, network1.Mac, 9000)}))
+			Expect(runner.RunCommands).ToNot(
+				ContainElement([]string{"-Command", fmt.Sprintf(NicMTUTemplate, network2.Mac, 0)}))
+		})
+
+		It("returns an error for bonded or VLAN networks", func() {
+			vlanNetwork := network1
+			vlanNetwork.CloudProperties = map[string]interface{}{"vlan": 100}
+
+			setupMACs(vlanNetwork)
+			err := setupNetworking(boshsettings.Networks{"net1": vlanNetwork})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bonds and VLANs are not supported"))
+		})
+
 		It("ignores VIP networks", func() {
 			err := setupNetworking(boshsettings.Networks{"vip": vip})
 			Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,121 @@
+package ntp
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const chronyDaemonLogTag = "chronyDaemon"
+
+type chronyDaemon struct {
+	configPath  string
+	serviceName string
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	logger      boshlog.Logger
+}
+
+// NewChronyDaemon manages chrony configuration at configPath;
+// config path and service name differ between distributions
+func NewChronyDaemon(
+	configPath string,
+	serviceName string,
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	logger boshlog.Logger,
+) Daemon {
+	return chronyDaemon{
+		configPath:  configPath,
+		serviceName: serviceName,
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		logger:      logger,
+	}
+}
+
+const chronyConfigTemplate = `# Generated by bosh-agent
+{{ range . }}server {{ . }} iburst
+{{ end }}driftfile /var/lib/chrony/chrony.drift
+makestep 1.0 3
+rtcsync
+`
+
+func (d chronyDaemon) SetServers(servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+
+	t := template.Must(template.New("chrony").Parse(chronyConfigTemplate))
+
+	err := t.Execute(buffer, servers)
+	if err != nil {
+		return bosherr.WrapError(err, "Generating chrony config from template")
+	}
+
+	return restartIfChanged(d.fs, d.cmdRunner, d.configPath, buffer.Bytes(), d.serviceName)
+}
+
+// GetInfo parses 'chronyc -c tracking' which prints single CSV line:
+// ref ID, ref name, stratum, ref time, system time offset, last offset, RMS offset,
+// frequency, residual frequency, skew, root delay, root dispersion, update interval, leap status
+func (d chronyDaemon) GetInfo() Info {
+	stdout, _, _, err := d.cmdRunner.RunCommand("chronyc", "-c", "tracking")
+	if err != nil {
+		d.logger.Debug(chronyDaemonLogTag, "Getting chrony tracking: %s", err.Error())
+		return Info{Message: "chrony not running"}
+	}
+
+	fields := strings.Split(strings.TrimSpace(stdout), ",")
+	if len(fields) < 14 {
+		return Info{Message: "bad chronyc output"}
+	}
+
+	stratum, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Info{Message: "bad chronyc output"}
+	}
+
+	info := Info{
+		Offset:  fields[4],
+		Stratum: stratum,
+		State:   StateSynchronized,
+	}
+
+	if fields[13] == "Not synchronised" || stratum == 0 {
+		info.State = StateUnsynchronized
+	}
+
+	refTime, err := strconv.ParseFloat(fields[3], 64)
+	if err == nil && refTime > 0 {
+		info.LastSync = time.Unix(int64(refTime), 0).UTC().Format(time.RFC3339)
+	}
+
+	return info
+}
+
+func restartIfChanged(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner, configPath string, config []byte, serviceName string) error {
+	changed, err := fs.ConvergeFileContents(configPath, config)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing to %s", configPath)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	_, _, _, err = cmdRunner.RunCommand("systemctl", "restart", serviceName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Restarting %s", serviceName)
+	}
+
+	return nil
+}
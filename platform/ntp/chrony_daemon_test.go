@@ -0,0 +1,110 @@
+package ntp_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("chronyDaemon", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		daemon    Daemon
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		daemon = NewChronyDaemon("/etc/chrony/chrony.conf", "chrony", fs, cmdRunner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("SetServers", func() {
+		It("renders chrony config and restarts chrony", func() {
+			err := daemon.SetServers([]string{"0.pool.ntp.org", "1.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := fs.ReadFileString("/etc/chrony/chrony.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(`# Generated by bosh-agent
+server 0.pool.ntp.org iburst
+server 1.pool.ntp.org iburst
+driftfile /var/lib/chrony/chrony.drift
+makestep 1.0 3
+rtcsync
+`))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "chrony"}}))
+		})
+
+		It("does not restart chrony when config did not change", func() {
+			err := daemon.SetServers([]string{"0.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = daemon.SetServers([]string{"0.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(HaveLen(1))
+		})
+
+		It("does nothing when there are no servers", func() {
+			err := daemon.SetServers([]string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/chrony/chrony.conf")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error when restarting chrony fails", func() {
+			cmdRunner.AddCmdResult("systemctl restart chrony", fakesys.FakeCmdResult{Error: errors.New("fake-restart-err")})
+
+			err := daemon.SetServers([]string{"0.pool.ntp.org"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Restarting chrony: fake-restart-err"))
+		})
+	})
+
+	Describe("GetInfo", func() {
+		It("reports offset, stratum, state and last sync time", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{
+				Stdout: "A9FEA97B,169.254.169.123,4,1588786020.283366151,-0.000002334,0.000001037,0.000012126,-8.542,-0.000,0.012,0.000371022,0.000058131,1024.6,Normal\n",
+			})
+
+			Expect(daemon.GetInfo()).To(Equal(Info{
+				Offset:   "-0.000002334",
+				Stratum:  4,
+				State:    StateSynchronized,
+				LastSync: "2020-05-06T17:27:00Z",
+			}))
+		})
+
+		It("reports unsynchronized state", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{
+				Stdout: "00000000,,0,0.000000000,0.000000000,0.000000000,0.000000000,0.000,0.000,0.000,1.000000000,1.000000000,0.0,Not synchronised\n",
+			})
+
+			Expect(daemon.GetInfo()).To(Equal(Info{
+				Offset:  "0.000000000",
+				State:   StateUnsynchronized,
+				Stratum: 0,
+			}))
+		})
+
+		It("reports message when chronyc fails", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+			Expect(daemon.GetInfo()).To(Equal(Info{Message: "chrony not running"}))
+		})
+
+		It("reports message when chronyc output is malformed", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Stdout: "garbage"})
+
+			Expect(daemon.GetInfo()).To(Equal(Info{Message: "bad chronyc output"}))
+		})
+	})
+})
@@ -0,0 +1,11 @@
+package ntp
+
+// Daemon keeps system time synchronized continuously
+// instead of stepping it once with ntpdate
+type Daemon interface {
+	Service
+
+	// SetServers renders daemon configuration and restarts daemon
+	// only when configuration changed
+	SetServers(servers []string) error
+}
@@ -0,0 +1,13 @@
+package fakes
+
+type FakeDaemon struct {
+	FakeService
+
+	SetServersServers []string
+	SetServersErr     error
+}
+
+func (d *FakeDaemon) SetServers(servers []string) error {
+	d.SetServersServers = servers
+	return d.SetServersErr
+}
@@ -3,7 +3,9 @@ package ntp
 import (
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -14,10 +16,35 @@ var (
 	badServerRegex = regexp.MustCompile(`no server suitable for synchronization found`)
 )
 
+const (
+	StateSynchronized   = "synchronized"
+	StateUnsynchronized = "unsynchronized"
+)
+
 type Info struct {
+	// Offset of system time from NTP time in seconds
 	Offset    string `json:"offset,omitempty"`
 	Timestamp string `json:"timestamp,omitempty"`
 	Message   string `json:"message,omitempty"`
+
+	// Only reported by time sync daemons
+	Stratum  int    `json:"stratum,omitempty"`
+	State    string `json:"state,omitempty"`
+	LastSync string `json:"last_sync,omitempty"`
+}
+
+// OffsetDuration returns false when offset is unknown
+func (i Info) OffsetDuration() (time.Duration, bool) {
+	if i.Offset == "" {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(i.Offset, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
 }
 
 type Service interface {
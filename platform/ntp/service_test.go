@@ -1,6 +1,8 @@
 package ntp_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -68,3 +70,18 @@ var _ = Describe("concreteService", func() {
 		})
 	})
 })
+
+var _ = Describe("Info", func() {
+	Describe("OffsetDuration", func() {
+		It("parses offset in seconds", func() {
+			offset, found := Info{Offset: "-0.081236"}.OffsetDuration()
+			Expect(found).To(BeTrue())
+			Expect(offset).To(Equal(-81236 * time.Microsecond))
+		})
+
+		It("returns false when offset is unknown", func() {
+			_, found := Info{Message: "file missing"}.OffsetDuration()
+			Expect(found).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,116 @@
+package ntp
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const timesyncdDaemonLogTag = "timesyncdDaemon"
+
+const timesyncdConfigPath = "/etc/systemd/timesyncd.conf.d/bosh.conf"
+
+var (
+	timesyncdOffsetRegex   = regexp.MustCompile(`(?m)^\s*Offset:\s*([+-]?[\d.]+)(us|ms|s|min)\s*$`)
+	timesyncdStratumRegex  = regexp.MustCompile(`(?m)^\s*Stratum:\s*(\d+)\s*$`)
+	timesyncdReceivedRegex = regexp.MustCompile(`ReceiveTimestamp=([^,}]+)`)
+)
+
+type timesyncdDaemon struct {
+	fs        boshsys.FileSystem
+	cmdRunner boshsys.CmdRunner
+	logger    boshlog.Logger
+}
+
+// NewTimesyncdDaemon configures systemd-timesyncd with a drop-in
+// so that distribution defaults in timesyncd.conf stay untouched
+func NewTimesyncdDaemon(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner, logger boshlog.Logger) Daemon {
+	return timesyncdDaemon{
+		fs:        fs,
+		cmdRunner: cmdRunner,
+		logger:    logger,
+	}
+}
+
+const timesyncdConfigTemplate = `# Generated by bosh-agent
+[Time]
+NTP={{ join . " " }}
+`
+
+func (d timesyncdDaemon) SetServers(servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+
+	funcs := template.FuncMap{"join": strings.Join}
+	t := template.Must(template.New("timesyncd").Funcs(funcs).Parse(timesyncdConfigTemplate))
+
+	err := t.Execute(buffer, servers)
+	if err != nil {
+		return bosherr.WrapError(err, "Generating timesyncd config from template")
+	}
+
+	return restartIfChanged(d.fs, d.cmdRunner, timesyncdConfigPath, buffer.Bytes(), "systemd-timesyncd")
+}
+
+func (d timesyncdDaemon) GetInfo() Info {
+	stdout, _, _, err := d.cmdRunner.RunCommand("timedatectl", "timesync-status")
+	if err != nil {
+		d.logger.Debug(timesyncdDaemonLogTag, "Getting timesync status: %s", err.Error())
+		return Info{Message: "timesyncd not running"}
+	}
+
+	offsetMatches := timesyncdOffsetRegex.FindStringSubmatch(stdout)
+	stratumMatches := timesyncdStratumRegex.FindStringSubmatch(stdout)
+	if offsetMatches == nil || stratumMatches == nil {
+		return Info{Message: "bad timedatectl output"}
+	}
+
+	offset, err := parseTimesyncdOffset(offsetMatches[1], offsetMatches[2])
+	if err != nil {
+		return Info{Message: "bad timedatectl output"}
+	}
+
+	stratum, err := strconv.Atoi(stratumMatches[1])
+	if err != nil {
+		return Info{Message: "bad timedatectl output"}
+	}
+
+	info := Info{
+		Offset:  strconv.FormatFloat(offset.Seconds(), 'f', 6, 64),
+		Stratum: stratum,
+		State:   StateUnsynchronized,
+	}
+
+	stdout, _, _, err = d.cmdRunner.RunCommand("timedatectl", "show", "-p", "NTPSynchronized", "--value")
+	if err == nil && strings.TrimSpace(stdout) == "yes" {
+		info.State = StateSynchronized
+	}
+
+	stdout, _, _, err = d.cmdRunner.RunCommand("timedatectl", "show-timesync", "-p", "NTPMessage", "--value")
+	if err == nil {
+		receivedMatches := timesyncdReceivedRegex.FindStringSubmatch(stdout)
+		if receivedMatches != nil {
+			info.LastSync = strings.TrimSpace(receivedMatches[1])
+		}
+	}
+
+	return info
+}
+
+func parseTimesyncdOffset(value, unit string) (time.Duration, error) {
+	if unit == "min" {
+		unit = "m"
+	}
+
+	return time.ParseDuration(value + unit)
+}
@@ -0,0 +1,116 @@
+package ntp_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("timesyncdDaemon", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		daemon    Daemon
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		daemon = NewTimesyncdDaemon(fs, cmdRunner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("SetServers", func() {
+		It("renders timesyncd drop-in and restarts timesyncd", func() {
+			err := daemon.SetServers([]string{"0.pool.ntp.org", "1.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := fs.ReadFileString("/etc/systemd/timesyncd.conf.d/bosh.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(`# Generated by bosh-agent
+[Time]
+NTP=0.pool.ntp.org 1.pool.ntp.org
+`))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "systemd-timesyncd"}}))
+		})
+
+		It("does not restart timesyncd when config did not change", func() {
+			err := daemon.SetServers([]string{"0.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = daemon.SetServers([]string{"0.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands).To(HaveLen(1))
+		})
+	})
+
+	Describe("GetInfo", func() {
+		var (
+			statusResult       fakesys.FakeCmdResult
+			synchronizedResult fakesys.FakeCmdResult
+		)
+
+		BeforeEach(func() {
+			statusResult = fakesys.FakeCmdResult{
+				Stdout: `       Server: 91.189.94.4 (ntp.ubuntu.com)
+Poll interval: 34min 8s (min: 32s; max 34min 8s)
+         Leap: normal
+      Version: 4
+      Stratum: 2
+    Reference: C0248F97
+    Precision: 1us (-24)
+Root distance: 14.153ms (max: 5s)
+       Offset: -1.208ms
+        Delay: 162.826ms
+       Jitter: 3.069ms
+ Packet count: 4
+    Frequency: -16.563ppm
+`,
+			}
+			synchronizedResult = fakesys.FakeCmdResult{Stdout: "yes\n"}
+		})
+
+		JustBeforeEach(func() {
+			cmdRunner.AddCmdResult("timedatectl timesync-status", statusResult)
+			cmdRunner.AddCmdResult("timedatectl show -p NTPSynchronized --value", synchronizedResult)
+			cmdRunner.AddCmdResult("timedatectl show-timesync -p NTPMessage --value", fakesys.FakeCmdResult{
+				Stdout: "{ Leap=0, Version=4, Mode=4, Stratum=2, Precision=-24, ReceiveTimestamp=Fri 2020-05-08 16:09:42 UTC, TransmitTimestamp=Fri 2020-05-08 16:09:42 UTC }\n",
+			})
+		})
+
+		It("reports offset, stratum, state and last sync time", func() {
+			Expect(daemon.GetInfo()).To(Equal(Info{
+				Offset:   "-0.001208",
+				Stratum:  2,
+				State:    StateSynchronized,
+				LastSync: "Fri 2020-05-08 16:09:42 UTC",
+			}))
+		})
+
+		Context("when timesyncd is not synchronized", func() {
+			BeforeEach(func() {
+				synchronizedResult = fakesys.FakeCmdResult{Stdout: "no\n"}
+			})
+
+			It("reports unsynchronized state", func() {
+				Expect(daemon.GetInfo().State).To(Equal(StateUnsynchronized))
+			})
+		})
+
+		Context("when timedatectl fails", func() {
+			BeforeEach(func() {
+				statusResult = fakesys.FakeCmdResult{Error: errors.New("fake-err")}
+			})
+
+			It("reports message", func() {
+				Expect(daemon.GetInfo()).To(Equal(Info{Message: "timesyncd not running"}))
+			})
+		})
+	})
+})
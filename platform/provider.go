@@ -11,7 +11,9 @@ import (
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boshdns "github.com/cloudfoundry/bosh-agent/platform/net/dns"
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshiscsi "github.com/cloudfoundry/bosh-agent/platform/openiscsi"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshudev "github.com/cloudfoundry/bosh-agent/platform/udevdevice"
@@ -35,6 +37,8 @@ const (
 	SigarStatsCollectionInterval = 10 * time.Second
 )
 
+const defaultLocalDNSResolverAddress = "169.254.0.2"
+
 type Provider interface {
 	Get(name string) (Platform, error)
 }
@@ -76,16 +80,29 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	interfaceConfigurationCreator := boshnet.NewInterfaceConfigurationCreator(logger)
 
 	interfaceAddressesProvider := boship.NewSystemInterfaceAddressesProvider()
-	interfaceAddressesValidator := boship.NewInterfaceAddressesValidator(interfaceAddressesProvider)
+	interfaceAddressesValidatorOptions := boship.InterfaceAddressesValidatorOptions{
+		ExcludedIPs: options.Linux.NetworkValidationExcludedIPs,
+		Timeout:     time.Duration(options.Linux.NetworkValidationTimeoutSeconds) * time.Second,
+	}
+	interfaceAddressesValidator := boship.NewInterfaceAddressesValidatorWithOptions(interfaceAddressesProvider, interfaceAddressesValidatorOptions, clock, logger)
 	dnsValidator := boshnet.NewDNSValidator(fs)
 
 	centosNetManager := boshnet.NewCentosNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, logger)
 	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, logger)
 
+	switch options.Linux.NetManagerType {
+	case "netplan":
+		netplanNetManager := boshnet.NewNetplanNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, arping, logger)
+		centosNetManager, ubuntuNetManager = netplanNetManager, netplanNetManager
+	case "networkd":
+		networkdNetManager := boshnet.NewNetworkdNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, arping, logger)
+		centosNetManager, ubuntuNetManager = networkdNetManager, networkdNetManager
+	}
+
 	windowsNetManager := boshnet.NewWindowsNetManager(runner, interfaceConfigurationCreator, boshnet.NewMACAddressDetector(), logger, clock)
 
-	centosCertManager := boshcert.NewCentOSCertManager(fs, runner, 0, logger)
-	ubuntuCertManager := boshcert.NewUbuntuCertManager(fs, runner, 60, logger)
+	centosCertManager := boshcert.NewCentOSCertManager(fs, runner, dirProvider, 0, logger)
+	ubuntuCertManager := boshcert.NewUbuntuCertManager(fs, runner, dirProvider, 60, logger)
 	windowsCertManager := boshcert.NewWindowsCertManager(fs, runner, dirProvider, logger)
 
 	routesSearcher := boshnet.NewRoutesSearcher(runner)
@@ -118,6 +135,25 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 
 	uuidGenerator := boshuuid.NewGenerator()
 
+	var localDNSServer boshdns.Server
+	if options.Linux.UseLocalDNSResolver {
+		localDNSResolverAddress := options.Linux.LocalDNSResolverAddress
+		if localDNSResolverAddress == "" {
+			localDNSResolverAddress = defaultLocalDNSResolverAddress
+		}
+		localDNSServer = boshdns.NewServer(localDNSResolverAddress, 53, clock, logger)
+	}
+
+	var centosTimeSyncDaemon, ubuntuTimeSyncDaemon boshntp.Daemon
+	switch options.Linux.TimeSyncDaemon {
+	case "chrony":
+		centosTimeSyncDaemon = boshntp.NewChronyDaemon("/etc/chrony.conf", "chronyd", fs, runner, logger)
+		ubuntuTimeSyncDaemon = boshntp.NewChronyDaemon("/etc/chrony/chrony.conf", "chrony", fs, runner, logger)
+	case "timesyncd":
+		timesyncdDaemon := boshntp.NewTimesyncdDaemon(fs, runner, logger)
+		centosTimeSyncDaemon, ubuntuTimeSyncDaemon = timesyncdDaemon, timesyncdDaemon
+	}
+
 	var centos = func() Platform {
 		return NewLinuxPlatform(
 			fs,
@@ -139,6 +175,8 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			defaultNetworkResolver,
 			uuidGenerator,
 			auditLogger,
+			localDNSServer,
+			centosTimeSyncDaemon,
 		)
 	}
 
@@ -163,6 +201,8 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			defaultNetworkResolver,
 			uuidGenerator,
 			auditLogger,
+			localDNSServer,
+			ubuntuTimeSyncDaemon,
 		)
 	}
 
@@ -10,7 +10,9 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -97,6 +99,10 @@ func (p WindowsPlatform) GetVitalsService() (service boshvitals.Service) {
 	return p.vitalsService
 }
 
+func (p WindowsPlatform) GetNTPService() boshntp.Service {
+	return boshntp.NewConcreteService(p.fs, p.dirProvider)
+}
+
 func (p WindowsPlatform) GetDevicePathResolver() (devicePathResolver boshdpresolv.DevicePathResolver) {
 	return p.devicePathResolver
 }
@@ -289,10 +295,22 @@ func (p WindowsPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetti
 	return
 }
 
+func (p WindowsPlatform) GetDiskHealthState() (*boshdisk.HealthState, error) {
+	return boshdisk.NewHealthState(p.fs, filepath.Join(p.dirProvider.BoshDir(), "disk_health.json"))
+}
+
 func (p WindowsPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	return
 }
 
+func (p WindowsPlatform) FreezePersistentDisk(mountPoint string) (err error) {
+	return
+}
+
+func (p WindowsPlatform) ThawPersistentDisk(mountPoint string) (err error) {
+	return
+}
+
 func (p WindowsPlatform) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) string {
 	return ""
 }
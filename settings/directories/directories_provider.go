@@ -78,6 +78,10 @@ func (p Provider) LogsDir() string {
 	return filepath.Join(p.BaseDir(), "sys", "log")
 }
 
+func (p Provider) RunDir() string {
+	return filepath.Join(p.BaseDir(), "sys", "run")
+}
+
 func (p Provider) AgentLogsDir() string {
 	return filepath.Join(p.BaseDir(), "bosh", "log")
 }
@@ -94,6 +98,16 @@ func (p Provider) InstanceDNSDir() string {
 	return filepath.Join(p.InstanceDir(), "dns")
 }
 
+func (p Provider) InstanceCertsDir() string {
+	return filepath.Join(p.InstanceDir(), "certs")
+}
+
+// InstanceJobCertsDir is kept outside of installed job bundles
+// so that rotated certificates survive job updates
+func (p Provider) InstanceJobCertsDir(jobName string) string {
+	return filepath.Join(p.InstanceDir(), "job_certs", jobName)
+}
+
 func (p Provider) BlobsDir() string {
 	return filepath.Join(p.DataDir(), "blobs")
 }
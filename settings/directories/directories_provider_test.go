@@ -33,11 +33,14 @@ var _ = Describe("Provider", func() {
 		Entry("SettingsDir()", p.SettingsDir(), "/some/dir/bosh/settings"),
 		Entry("TmpDir()", p.TmpDir(), "/some/dir/data/tmp"),
 		Entry("LogsDir()", p.LogsDir(), "/some/dir/sys/log"),
+		Entry("RunDir()", p.RunDir(), "/some/dir/sys/run"),
 		Entry("AgentLogsDir()", p.AgentLogsDir(), "/some/dir/bosh/log"),
 		Entry("InstanceDir()", p.InstanceDir(), "/some/dir/instance"),
 		Entry("DisksDir()", p.DisksDir(), "/some/dir/instance/disks"),
 		Entry("BlobsDir()", p.BlobsDir(), "/some/dir/data/blobs"),
 		Entry("InstanceDNSDir()", p.InstanceDNSDir(), "/some/dir/instance/dns"),
+		Entry("InstanceCertsDir()", p.InstanceCertsDir(), "/some/dir/instance/certs"),
+		Entry("InstanceJobCertsDir()", p.InstanceJobCertsDir("fake-job"), "/some/dir/instance/job_certs/fake-job"),
 	)
 
 	It("cleans the base dir", func() {
@@ -1,9 +1,11 @@
 package settings
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/cloudfoundry/bosh-agent/platform/disk"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type DiskAssociations struct {
@@ -38,6 +40,9 @@ type Settings struct {
 type UpdateSettings struct {
 	DiskAssociations []DiskAssociation `json:"disk_associations"`
 	TrustedCerts     string            `json:"trusted_certs"`
+
+	// Named PEM bundles written for jobs in addition to the OS trust store
+	CertBundles map[string]string `json:"cert_bundles,omitempty"`
 }
 
 type Source interface {
@@ -237,8 +242,19 @@ type IPv6 struct {
 }
 
 type DNSRecords struct {
-	Version uint64      `json:"Version"`
-	Records [][2]string `json:"records"`
+	Version    uint64      `json:"Version"`
+	Records    [][2]string `json:"records"`
+	SRVRecords []SRVRecord `json:"srv_records,omitempty"`
+}
+
+// SRVRecord is served by the local DNS resolver;
+// records with IPv6 addresses are served as AAAA records
+type SRVRecord struct {
+	Name     string `json:"name"`
+	Target   string `json:"target"`
+	Port     uint16 `json:"port"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
 }
 
 type NetworkType string
@@ -274,6 +290,35 @@ type Network struct {
 
 	Alias  string `json:"alias,omitempty"`
 	Routes Routes `json:"routes,omitempty"`
+
+	// Static IPv6 configuration applied alongside IPv4 on the same interface
+	IPv6Address string `json:"ipv6_address,omitempty"`
+	IPv6Netmask string `json:"ipv6_netmask,omitempty"`
+	IPv6Gateway string `json:"ipv6_gateway,omitempty"`
+
+	// When set, network's subnet and default routes are installed into
+	// the given routing table and traffic sourced from network's addresses
+	// is looked up in it so that replies leave through the same interface
+	RoutingTable int `json:"routing_table,omitempty"`
+
+	// Link settings; zero MTU keeps interface default.
+	// When VLAN is set network is configured on a tagged sub-interface
+	// of the interface with Mac address or of the Bond.
+	MTU  int   `json:"mtu,omitempty"`
+	VLAN int   `json:"vlan,omitempty"`
+	Bond *Bond `json:"bond,omitempty"`
+
+	// Link settings may also be declared by the director
+	// in network's cloud properties (see LinkSettings)
+	CloudProperties map[string]interface{} `json:"cloud_properties,omitempty"`
+}
+
+// Bond aggregates physical interfaces identified by their MAC addresses
+type Bond struct {
+	Name       string   `json:"name"`
+	Mode       string   `json:"mode"` // e.g. active-backup (default), 802.3ad
+	Interfaces []string `json:"interfaces"`
+	MIIMon     int      `json:"miimon,omitempty"`
 }
 
 type Networks map[string]Network
@@ -379,6 +424,50 @@ func (n Network) String() string {
 	)
 }
 
+// LinkSettings returns network with MTU, VLAN and Bond filled in
+// from cloud properties unless they are already explicitly set
+func (n Network) LinkSettings() (Network, error) {
+	if len(n.CloudProperties) == 0 {
+		return n, nil
+	}
+
+	cloudPropertiesJSON, err := json.Marshal(n.CloudProperties)
+	if err != nil {
+		return n, bosherr.WrapError(err, "Marshalling network cloud properties")
+	}
+
+	var linkSettings struct {
+		MTU  int   `json:"mtu"`
+		VLAN int   `json:"vlan"`
+		Bond *Bond `json:"bond"`
+	}
+
+	err = json.Unmarshal(cloudPropertiesJSON, &linkSettings)
+	if err != nil {
+		return n, bosherr.WrapError(err, "Unmarshalling link settings from network cloud properties")
+	}
+
+	if n.MTU == 0 {
+		n.MTU = linkSettings.MTU
+	}
+
+	if n.VLAN == 0 {
+		n.VLAN = linkSettings.VLAN
+	}
+
+	if n.Bond == nil {
+		n.Bond = linkSettings.Bond
+	}
+
+	return n, nil
+}
+
+// HasLink is true for networks configured on a bond or a VLAN
+// rather than directly on a physical interface
+func (n Network) HasLink() bool {
+	return n.Bond != nil || n.VLAN != 0
+}
+
 func (n Network) IsDHCP() bool {
 	if n.IsVIP() {
 		return false
@@ -568,6 +568,52 @@ var _ = Describe("Settings", func() {
 				})
 			})
 		})
+
+		Describe("LinkSettings", func() {
+			It("fills in link settings from cloud properties", func() {
+				network.CloudProperties = map[string]interface{}{
+					"subnet": "subnet-xxxxxx",
+					"mtu":    9000,
+					"vlan":   100,
+					"bond": map[string]interface{}{
+						"name":       "bond0",
+						"mode":       "802.3ad",
+						"interfaces": []interface{}{"aa:bb", "cc:dd"},
+						"miimon":     100,
+					},
+				}
+
+				network, err := network.LinkSettings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(network.MTU).To(Equal(9000))
+				Expect(network.VLAN).To(Equal(100))
+				Expect(network.Bond).To(Equal(&Bond{
+					Name:       "bond0",
+					Mode:       "802.3ad",
+					Interfaces: []string{"aa:bb", "cc:dd"},
+					MIIMon:     100,
+				}))
+				Expect(network.HasLink()).To(BeTrue())
+			})
+
+			It("prefers explicitly set link settings", func() {
+				network.MTU = 1500
+				network.CloudProperties = map[string]interface{}{"mtu": 9000}
+
+				network, err := network.LinkSettings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(network.MTU).To(Equal(1500))
+				Expect(network.HasLink()).To(BeFalse())
+			})
+
+			It("returns an error when cloud properties contain invalid link settings", func() {
+				network.CloudProperties = map[string]interface{}{"vlan": "not-a-number"}
+
+				_, err := network.LinkSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unmarshalling link settings"))
+			})
+		})
 	})
 
 	Describe("Networks", func() {
@@ -719,11 +765,12 @@ var _ = Describe("Settings", func() {
 	Describe("UpdateSettings", func() {
 		var updateSettingsJSON string
 		BeforeEach(func() {
-			updateSettingsJSON = `{"trusted_certs": "some_cert", "disk_associations": [{"name": "some_name", "cid": "some_cid"}]}`
+			updateSettingsJSON = `{"trusted_certs": "some_cert", "cert_bundles": {"uaa": "some_bundle"}, "disk_associations": [{"name": "some_name", "cid": "some_cid"}]}`
 		})
 		It("contains the correct keys", func() {
 			err := json.Unmarshal([]byte(updateSettingsJSON), &updateSettings)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(updateSettings.CertBundles).To(Equal(map[string]string{"uaa": "some_bundle"}))
 		})
 	})
 })